@@ -0,0 +1,47 @@
+package scraper
+
+import (
+	"math"
+	"net/url"
+
+	"github.com/docutag/scraper/models"
+)
+
+// scoreAdjustments maps normalized domains to a learned score adjustment
+type scoreAdjustments map[string]float64
+
+// SetScoreAdjustments replaces the per-domain score adjustments applied when scoring
+// A nil or empty map turns adjustments off
+func (s *Scraper) SetScoreAdjustments(adjustments map[string]float64) {
+	index := make(scoreAdjustments, len(adjustments))
+	for domain, adjustment := range adjustments {
+		index[NormalizeDomain(domain)] = adjustment
+	}
+	s.scoreAdjustments.Store(&index)
+}
+
+// scoreAdjustmentFor returns the learned adjustment for a URL's domain
+func (s *Scraper) scoreAdjustmentFor(rawURL string) float64 {
+	index := s.scoreAdjustments.Load()
+	if index == nil || len(*index) == 0 {
+		return 0
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	return (*index)[NormalizeDomain(parsed.Hostname())]
+}
+
+// applyScoreAdjustment shifts a score by its domain's learned adjustment and re-applies the threshold
+func (s *Scraper) applyScoreAdjustment(score *models.LinkScore) {
+	adjustment := s.scoreAdjustmentFor(score.URL)
+	if adjustment == 0 {
+		return
+	}
+	raw := score.Score
+	score.Score = math.Max(0, math.Min(1, raw+adjustment))
+	score.Adjustment = score.Score - raw // The shift actually applied, less than adjustment when clamped
+	score.IsRecommended = score.Score >= s.config.LinkScoreThreshold
+}
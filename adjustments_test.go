@@ -0,0 +1,51 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestApplyScoreAdjustment(t *testing.T) {
+	s := New(DefaultConfig(), nil, nil)
+
+	score := &models.LinkScore{URL: "https://example.com/a", Score: 0.45}
+	s.applyScoreAdjustment(score)
+	if score.Score != 0.45 || score.Adjustment != 0 {
+		t.Fatalf("expected no adjustment before any are set, got %+v", score)
+	}
+
+	s.SetScoreAdjustments(map[string]float64{"WWW.Example.com": 0.1, "spam.test": -0.3})
+
+	tests := []struct {
+		url         string
+		score       float64
+		want        float64
+		applied     float64
+		recommended bool
+	}{
+		{"https://www.example.com/a", 0.45, 0.55, 0.1, true},
+		{"https://example.com/b", 0.95, 1, 0.05, true},
+		{"https://spam.test/c", 0.6, 0.3, -0.3, false},
+		{"https://spam.test/d", 0.2, 0, -0.2, false},
+		{"https://other.test/e", 0.45, 0.45, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			score := &models.LinkScore{URL: tt.url, Score: tt.score, IsRecommended: tt.score >= 0.5}
+			s.applyScoreAdjustment(score)
+			if diff := score.Score - tt.want; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("score = %v, want %v", score.Score, tt.want)
+			}
+			if diff := score.Adjustment - tt.applied; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("adjustment = %v, want %v", score.Adjustment, tt.applied)
+			}
+			if raw := score.Score - score.Adjustment; raw-tt.score > 1e-9 || raw-tt.score < -1e-9 {
+				t.Errorf("unadjusted score = %v, want %v", raw, tt.score)
+			}
+			if score.IsRecommended != tt.recommended {
+				t.Errorf("is_recommended = %v, want %v", score.IsRecommended, tt.recommended)
+			}
+		})
+	}
+}
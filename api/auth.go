@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docutag/scraper/models"
+	"github.com/google/uuid"
+)
+
+// Scopes granted to API keys
+// Admin implies every other scope
+const (
+	ScopeRead   = "read"   // Read scraped data and images
+	ScopeScrape = "scrape" // Scrape, score and extract links; counts against the daily quota
+	ScopeAdmin  = "admin"  // Delete and tombstone data, manage API keys
+)
+
+// apiKeyPrefix marks scraper API keys so they are recognizable in configs and secret scanners
+const apiKeyPrefix = "dts_"
+
+// bootstrapKeyID identifies requests authenticated with the configured admin key
+const bootstrapKeyID = "bootstrap"
+
+// apiKeyStore is the persistence needed to authenticate requests
+type apiKeyStore interface {
+	GetAPIKeyByHash(keyHash string) (*models.APIKey, error)
+	IncrementScrapeUsage(keyID string, day time.Time, quota int) (bool, error)
+}
+
+// apiKeyContextKey is the context key for the authenticated API key
+type apiKeyContextKey struct{}
+
+// apiKeyFromContext returns the API key that authenticated the request, if any
+func apiKeyFromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*models.APIKey)
+	return key
+}
+
+// generateAPIKey returns a new random API key and the hash to store for it
+func generateAPIKey() (key, keyHash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, hashAPIKey(key), nil
+}
+
+// hashAPIKey hashes a key for storage and lookup
+// Keys carry 256 bits of entropy, so a fast unsalted hash is sufficient
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// validScope reports whether scope is a known scope
+func validScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeScrape || scope == ScopeAdmin
+}
+
+// hasScope reports whether the key grants scope
+func hasScope(key *models.APIKey, scope string) bool {
+	for _, s := range key.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredScope returns the scope a request needs, or "" for public endpoints
+func requiredScope(r *http.Request) string {
+	path := r.URL.Path
+
+	switch {
+	case r.Method == http.MethodOptions:
+		return ""
+	case path == "/health" || path == "/metrics":
+		return ""
+	case strings.HasPrefix(path, "/images/"):
+		return "" // Served to public SEO pages
+	case strings.HasPrefix(path, "/api/admin/"):
+		return ScopeAdmin
+	case r.Method == http.MethodDelete || r.Method == http.MethodPut:
+		return ScopeAdmin
+	}
+
+	switch path {
+	case "/api/scrape", "/api/scrape/stream", "/api/process-image", "/api/extract-links", "/api/score", "/api/feeds/discover":
+		return ScopeScrape
+	case "/api/feeds":
+		if r.Method == http.MethodPost {
+			return ScopeScrape // Subscribing schedules scrapes
+		}
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return ScopeScrape // Writes such as score labels retrain shared state
+	}
+	return ScopeRead
+}
+
+// requestAPIKey extracts the API key from the Authorization or X-API-Key header
+// EventSource cannot set headers, so the stream endpoint also accepts ?api_key=
+func requestAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if r.URL.Path == "/api/scrape/stream" {
+		return r.URL.Query().Get("api_key")
+	}
+	return ""
+}
+
+// authenticate enforces API key scopes, per-key rate limits and daily scrape quotas
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := requiredScope(r)
+		if scope == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := s.lookupAPIKey(requestAPIKey(r))
+		if err != nil {
+			slog.Error("failed to look up API key", "error", err)
+			respondError(w, http.StatusInternalServerError, "authentication failed")
+			return
+		}
+		if key == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scraper"`)
+			respondError(w, http.StatusUnauthorized, "valid API key required")
+			return
+		}
+		if !hasScope(key, scope) {
+			respondError(w, http.StatusForbidden, fmt.Sprintf("API key lacks %s scope", scope))
+			return
+		}
+
+		if !s.rateLimiter.allow(key.ID, key.RateLimitPerMinute) {
+			w.Header().Set("Retry-After", "60")
+			respondError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		if scope == ScopeScrape && key.ID != bootstrapKeyID {
+			allowed, err := s.keys.IncrementScrapeUsage(key.ID, time.Now(), key.DailyScrapeQuota)
+			if err != nil {
+				slog.Error("failed to record API key usage", "key_id", key.ID, "error", err)
+				respondError(w, http.StatusInternalServerError, "failed to record usage")
+				return
+			}
+			if !allowed {
+				respondError(w, http.StatusTooManyRequests, fmt.Sprintf("daily scrape quota of %d exceeded", key.DailyScrapeQuota))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+	})
+}
+
+// refuseAdmin rejects /api/admin endpoints when authentication is disabled, since they
+// expose API keys, site credentials and retained image metadata such as GPS coordinates
+func refuseAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/admin/") {
+			respondError(w, http.StatusForbidden, "admin endpoints require AUTH_ENABLED=true")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// lookupAPIKey resolves a presented key, returning nil for unknown or revoked keys
+func (s *Server) lookupAPIKey(presented string) (*models.APIKey, error) {
+	if presented == "" {
+		return nil, nil
+	}
+
+	if s.adminKey != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(s.adminKey)) == 1 {
+		return &models.APIKey{ID: bootstrapKeyID, Name: "bootstrap admin", Scopes: []string{ScopeAdmin}}, nil
+	}
+
+	key, err := s.keys.GetAPIKeyByHash(hashAPIKey(presented))
+	if err != nil || key == nil || key.RevokedAt != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// rateLimiter is a per-key token bucket refilled continuously at the key's per-minute rate
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// allow reports whether a request for keyID fits within perMinute (0 = unlimited)
+func (l *rateLimiter) allow(keyID string, perMinute int) bool {
+	if perMinute <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	capacity := float64(perMinute)
+	bucket, ok := l.buckets[keyID]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, last: now}
+		l.buckets[keyID] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Minutes() * capacity
+	if bucket.tokens > capacity {
+		bucket.tokens = capacity
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// CreateAPIKeyRequest represents a request to issue an API key
+type CreateAPIKeyRequest struct {
+	Name               string   `json:"name"`
+	Scopes             []string `json:"scopes"`
+	RateLimitPerMinute int      `json:"rate_limit_per_minute"`
+	DailyScrapeQuota   int      `json:"daily_scrape_quota"`
+}
+
+// CreateAPIKeyResponse returns a newly issued key
+// The plaintext key is only ever returned here
+type CreateAPIKeyResponse struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+// handleAPIKeys handles listing (GET) and issuing (POST) API keys
+func (s *Server) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := s.db.ListAPIKeys()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list API keys")
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"keys":  keys,
+			"count": len(keys),
+		})
+	case http.MethodPost:
+		s.handleCreateAPIKey(w, r)
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleCreateAPIKey issues a new API key
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		respondError(w, http.StatusBadRequest, "at least one scope is required")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !validScope(scope) {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid scope: %s", scope))
+			return
+		}
+	}
+	if req.RateLimitPerMinute < 0 || req.DailyScrapeQuota < 0 {
+		respondError(w, http.StatusBadRequest, "limits cannot be negative")
+		return
+	}
+
+	plaintext, keyHash, err := generateAPIKey()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to generate API key")
+		return
+	}
+
+	key := &models.APIKey{
+		ID:                 uuid.New().String(),
+		Name:               req.Name,
+		Prefix:             plaintext[:len(apiKeyPrefix)+6],
+		Scopes:             req.Scopes,
+		RateLimitPerMinute: req.RateLimitPerMinute,
+		DailyScrapeQuota:   req.DailyScrapeQuota,
+		CreatedAt:          time.Now(),
+	}
+
+	if err := s.db.CreateAPIKey(key, keyHash); err != nil {
+		slog.Error("failed to create API key", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to create API key")
+		return
+	}
+
+	slog.Info("issued API key", "key_id", key.ID, "name", key.Name, "scopes", key.Scopes)
+	respondJSON(w, http.StatusCreated, CreateAPIKeyResponse{Key: plaintext, APIKey: key})
+}
+
+// handleAPIKey handles revoking a single API key (DELETE /api/admin/keys/{id})
+func (s *Server) handleAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/admin/keys/")
+	if id == "" {
+		respondError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if err := s.db.RevokeAPIKey(id); err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	slog.Info("revoked API key", "key_id", id)
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "API key revoked",
+		"id":      id,
+	})
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+// fakeKeyStore is an in-memory apiKeyStore
+type fakeKeyStore struct {
+	keys  map[string]*models.APIKey // By hash
+	usage map[string]int            // Scrapes by key ID
+}
+
+func newFakeKeyStore() *fakeKeyStore {
+	return &fakeKeyStore{
+		keys:  make(map[string]*models.APIKey),
+		usage: make(map[string]int),
+	}
+}
+
+func (f *fakeKeyStore) add(t *testing.T, key *models.APIKey) string {
+	t.Helper()
+	plaintext, keyHash, err := generateAPIKey()
+	if err != nil {
+		t.Fatalf("generateAPIKey failed: %v", err)
+	}
+	f.keys[keyHash] = key
+	return plaintext
+}
+
+func (f *fakeKeyStore) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	return f.keys[keyHash], nil
+}
+
+func (f *fakeKeyStore) IncrementScrapeUsage(keyID string, day time.Time, quota int) (bool, error) {
+	if quota > 0 && f.usage[keyID] >= quota {
+		return false, nil
+	}
+	f.usage[keyID]++
+	return true, nil
+}
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/health", ""},
+		{http.MethodGet, "/metrics", ""},
+		{http.MethodGet, "/images/some-slug.jpg", ""},
+		{http.MethodOptions, "/api/scrape", ""},
+		{http.MethodGet, "/api/data", ScopeRead},
+		{http.MethodGet, "/api/images/search", ScopeRead},
+		{http.MethodPost, "/api/scrape", ScopeScrape},
+		{http.MethodGet, "/api/scrape/stream", ScopeScrape},
+		{http.MethodPost, "/api/score", ScopeScrape},
+		{http.MethodDelete, "/api/data/123", ScopeAdmin},
+		{http.MethodPut, "/api/images/123/tombstone", ScopeAdmin},
+		{http.MethodGet, "/api/admin/keys", ScopeAdmin},
+		{http.MethodGet, "/api/feeds", ScopeRead},
+		{http.MethodPost, "/api/feeds", ScopeScrape},
+		{http.MethodPost, "/api/feeds/discover", ScopeScrape},
+		{http.MethodDelete, "/api/feeds/123", ScopeAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if got := requiredScope(req); got != tt.want {
+				t.Errorf("requiredScope = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequiredScopeWrites(t *testing.T) {
+	// Requests that change state need scrape scope even on endpoints not listed by path;
+	// only GET and HEAD are reads
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/api/data/123/label", ScopeRead},
+		{http.MethodHead, "/api/data/123", ScopeRead},
+		{http.MethodPost, "/api/data/123/label", ScopeScrape},
+		{http.MethodPatch, "/api/data/123", ScopeScrape},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := requiredScope(req); got != tt.want {
+			t.Errorf("requiredScope(%s %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	store := newFakeKeyStore()
+	readKey := store.add(t, &models.APIKey{ID: "reader", Scopes: []string{ScopeRead}})
+	scrapeKey := store.add(t, &models.APIKey{ID: "scraper", Scopes: []string{ScopeRead, ScopeScrape}, DailyScrapeQuota: 1})
+	revoked := time.Now()
+	revokedKey := store.add(t, &models.APIKey{ID: "revoked", Scopes: []string{ScopeAdmin}, RevokedAt: &revoked})
+
+	s := &Server{
+		keys:        store,
+		adminKey:    "bootstrap-secret",
+		rateLimiter: newRateLimiter(),
+	}
+
+	var gotKeyID string
+	handler := s.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := apiKeyFromContext(r.Context()); key != nil {
+			gotKeyID = key.ID
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     string
+		key        string
+		wantStatus int
+		wantKeyID  string
+	}{
+		{"public endpoint", http.MethodGet, "/health", "", "", http.StatusOK, ""},
+		{"missing key", http.MethodGet, "/api/data", "", "", http.StatusUnauthorized, ""},
+		{"unknown key", http.MethodGet, "/api/data", "Authorization", "Bearer dts_nope", http.StatusUnauthorized, ""},
+		{"revoked key", http.MethodGet, "/api/data", "X-API-Key", revokedKey, http.StatusUnauthorized, ""},
+		{"read key reads", http.MethodGet, "/api/data", "Authorization", "Bearer " + readKey, http.StatusOK, "reader"},
+		{"read key cannot scrape", http.MethodPost, "/api/scrape", "X-API-Key", readKey, http.StatusForbidden, ""},
+		{"read key cannot delete", http.MethodDelete, "/api/data/1", "X-API-Key", readKey, http.StatusForbidden, ""},
+		{"scrape within quota", http.MethodPost, "/api/scrape", "X-API-Key", scrapeKey, http.StatusOK, "scraper"},
+		{"scrape over quota", http.MethodPost, "/api/scrape", "X-API-Key", scrapeKey, http.StatusTooManyRequests, ""},
+		{"bootstrap admin", http.MethodDelete, "/api/data/1", "X-API-Key", "bootstrap-secret", http.StatusOK, bootstrapKeyID},
+		{"stream query key", http.MethodGet, "/api/scrape/stream?api_key=bootstrap-secret", "", "", http.StatusOK, bootstrapKeyID},
+		{"query key ignored elsewhere", http.MethodGet, "/api/data?api_key=bootstrap-secret", "", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotKeyID = ""
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if gotKeyID != tt.wantKeyID {
+				t.Errorf("key in context = %q, want %q", gotKeyID, tt.wantKeyID)
+			}
+		})
+	}
+
+	if store.usage[bootstrapKeyID] != 0 {
+		t.Error("Bootstrap key should not be counted against a quota")
+	}
+}
+
+func TestRefuseAdminWithoutAuth(t *testing.T) {
+	handler := refuseAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/api/admin/images/img-1/exif", http.StatusForbidden},
+		{http.MethodPut, "/api/admin/images/img-1/safety", http.StatusForbidden},
+		{http.MethodGet, "/api/admin/keys", http.StatusForbidden},
+		{http.MethodGet, "/api/admin/credentials", http.StatusForbidden},
+		{http.MethodGet, "/api/images/img-1", http.StatusOK},
+		{http.MethodGet, "/health", http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if !l.allow("key", 3) {
+			t.Fatalf("request %d rejected within burst", i+1)
+		}
+	}
+	if l.allow("key", 3) {
+		t.Fatal("Expected request beyond the per-minute limit to be rejected")
+	}
+
+	// Other keys have their own bucket
+	if !l.allow("other", 3) {
+		t.Error("Expected a different key to be allowed")
+	}
+
+	// One token refills every 20s at 3/minute
+	now = now.Add(20 * time.Second)
+	if !l.allow("key", 3) {
+		t.Error("Expected request to be allowed after refill")
+	}
+	if l.allow("key", 3) {
+		t.Error("Expected only one token to have refilled")
+	}
+
+	if !l.allow("unlimited", 0) {
+		t.Error("Expected limit 0 to mean unlimited")
+	}
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, keyHash, err := generateAPIKey()
+	if err != nil {
+		t.Fatalf("generateAPIKey failed: %v", err)
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		t.Errorf("key %q missing prefix %q", key, apiKeyPrefix)
+	}
+	if keyHash != hashAPIKey(key) {
+		t.Error("Expected returned hash to match hashAPIKey")
+	}
+	if strings.Contains(keyHash, key) {
+		t.Error("Hash must not contain the plaintext key")
+	}
+
+	other, _, _ := generateAPIKey()
+	if other == key {
+		t.Error("Expected unique keys")
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/calibration"
+	"github.com/docutag/scraper/models"
+	"github.com/google/uuid"
+)
+
+// scoreLabelStore is the persistence for human score labels
+type scoreLabelStore interface {
+	GetByID(id string) (*models.ScrapedData, error)
+	SaveScoreLabel(label *models.ScoreLabel) error
+	GetScoreLabel(scrapeID string) (*models.ScoreLabel, error)
+	ListScoreLabels() ([]*models.ScoreLabel, error)
+}
+
+// ScoreLabelRequest represents a human accept/reject judgement of a scrape's score
+type ScoreLabelRequest struct {
+	Accepted *bool  `json:"accepted"`
+	Note     string `json:"note,omitempty"`
+}
+
+// CalibrationResponse is a calibration report with the learned per-domain adjustments
+type CalibrationResponse struct {
+	*calibration.Report
+	AdjustmentsEnabled bool               `json:"adjustments_enabled"`
+	DomainAdjustments  map[string]float64 `json:"domain_adjustments"` // Learned from the labels at the configured threshold
+}
+
+// RefreshScoreAdjustments learns per-domain score adjustments from the stored labels
+// and applies them to the scraper. Does nothing unless adjustments are enabled.
+func (s *Server) RefreshScoreAdjustments() error {
+	if !s.scoreAdjustments {
+		return nil
+	}
+
+	labels, err := s.labels.ListScoreLabels()
+	if err != nil {
+		return err
+	}
+	threshold := s.scraper.Config().LinkScoreThreshold
+	s.scraper.SetScoreAdjustments(calibration.DomainAdjustments(labels, threshold, calibration.DefaultAdjustmentOptions()))
+	return nil
+}
+
+// handleScoreLabel gets (GET) or records (POST) the label for a scrape's score
+func (s *Server) handleScoreLabel(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case http.MethodGet:
+		label, err := s.labels.GetScoreLabel(id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get label: %v", err))
+			return
+		}
+		if label == nil {
+			respondError(w, http.StatusNotFound, "label not found")
+			return
+		}
+		respondJSON(w, http.StatusOK, label)
+	case http.MethodPost:
+		var req ScoreLabelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if req.Accepted == nil {
+			respondError(w, http.StatusBadRequest, "accepted is required")
+			return
+		}
+
+		data, err := s.labels.GetByID(id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get scrape: %v", err))
+			return
+		}
+		if data == nil {
+			respondError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if data.Score == nil {
+			respondError(w, http.StatusBadRequest, "scrape has no score to label")
+			return
+		}
+
+		label := &models.ScoreLabel{
+			ID:         uuid.New().String(),
+			ScrapeID:   data.ID,
+			URL:        data.URL,
+			Accepted:   *req.Accepted,
+			Score:      data.Score.Score - data.Score.Adjustment, // Label the unadjusted score
+			AIUsed:     data.Score.AIUsed,
+			Categories: data.Score.Categories,
+			Note:       req.Note,
+		}
+		if parsed, err := url.Parse(data.URL); err == nil {
+			label.Domain = scraper.NormalizeDomain(parsed.Hostname())
+		}
+		if key := apiKeyFromContext(r.Context()); key != nil {
+			label.APIKeyID = key.ID
+		}
+
+		if err := s.labels.SaveScoreLabel(label); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save label: %v", err))
+			return
+		}
+		if err := s.RefreshScoreAdjustments(); err != nil {
+			slog.Warn("failed to refresh score adjustments", "error", err)
+		}
+
+		slog.Info("recorded score label", "scrape_id", data.ID, "domain", label.Domain, "accepted", label.Accepted, "score", label.Score)
+		respondJSON(w, http.StatusOK, label)
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleCalibration reports how well scores agree with the recorded labels
+// Query parameters: threshold (default: the configured link score threshold)
+func (s *Server) handleCalibration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	configured := s.scraper.Config().LinkScoreThreshold
+	threshold := configured
+	if raw := r.URL.Query().Get("threshold"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			respondError(w, http.StatusBadRequest, "threshold must be a number between 0 and 1")
+			return
+		}
+		threshold = parsed
+	}
+
+	labels, err := s.labels.ListScoreLabels()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list labels: %v", err))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, CalibrationResponse{
+		Report:             calibration.NewReport(labels, threshold),
+		AdjustmentsEnabled: s.scoreAdjustments,
+		DomainAdjustments:  calibration.DomainAdjustments(labels, configured, calibration.DefaultAdjustmentOptions()),
+	})
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/models"
+)
+
+// memoryLabelStore is an in-memory scoreLabelStore
+type memoryLabelStore struct {
+	scrapes map[string]*models.ScrapedData
+	labels  map[string]*models.ScoreLabel
+}
+
+func (m *memoryLabelStore) GetByID(id string) (*models.ScrapedData, error) {
+	return m.scrapes[id], nil
+}
+
+func (m *memoryLabelStore) SaveScoreLabel(label *models.ScoreLabel) error {
+	m.labels[label.ScrapeID] = label
+	return nil
+}
+
+func (m *memoryLabelStore) GetScoreLabel(scrapeID string) (*models.ScoreLabel, error) {
+	return m.labels[scrapeID], nil
+}
+
+func (m *memoryLabelStore) ListScoreLabels() ([]*models.ScoreLabel, error) {
+	labels := make([]*models.ScoreLabel, 0, len(m.labels))
+	for _, l := range m.labels {
+		labels = append(labels, l)
+	}
+	return labels, nil
+}
+
+func newLabelTestServer(adjustments bool) (*Server, *memoryLabelStore) {
+	store := &memoryLabelStore{
+		scrapes: map[string]*models.ScrapedData{
+			"unscored": {ID: "unscored", URL: "https://example.com/raw"},
+			// Raw 0.9 with a +0.3 adjustment, clamped to 1 so only 0.1 was applied
+			"clamped": {ID: "clamped", URL: "https://boost.test/a", Score: &models.LinkScore{Score: 1, Adjustment: 0.1}},
+		},
+		labels: map[string]*models.ScoreLabel{},
+	}
+	for i, id := range []string{"s0", "s1", "s2", "s3", "s4"} {
+		store.scrapes[id] = &models.ScrapedData{
+			ID:  id,
+			URL: "https://www.example.com/article-" + id,
+			Score: &models.LinkScore{
+				Score:      0.45 - float64(i)*0.01,
+				Adjustment: -0.05,
+				AIUsed:     true,
+				Categories: []string{"news"},
+			},
+		}
+	}
+
+	s := &Server{
+		scraper:          scraper.New(scraper.DefaultConfig(), nil, nil),
+		labels:           store,
+		scoreAdjustments: adjustments,
+	}
+	return s, store
+}
+
+func postLabel(s *Server, id, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.handleData(rec, httptest.NewRequest(http.MethodPost, "/api/data/"+id+"/label", strings.NewReader(body)))
+	return rec
+}
+
+func TestHandleScoreLabel(t *testing.T) {
+	s, store := newLabelTestServer(false)
+
+	t.Run("records unadjusted score", func(t *testing.T) {
+		rec := postLabel(s, "s0", `{"accepted": true, "note": "good read"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+		}
+		label := store.labels["s0"]
+		if label == nil || !label.Accepted || label.Domain != "example.com" || label.Score != 0.5 || !label.AIUsed {
+			t.Errorf("unexpected label: %+v", label)
+		}
+	})
+
+	t.Run("records raw score of clamped adjustment", func(t *testing.T) {
+		rec := postLabel(s, "clamped", `{"accepted": false}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+		}
+		if label := store.labels["clamped"]; label == nil || label.Score != 0.9 {
+			t.Errorf("unexpected label: %+v", label)
+		}
+	})
+
+	t.Run("get label", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.handleData(rec, httptest.NewRequest(http.MethodGet, "/api/data/s0/label", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", rec.Code)
+		}
+
+		rec = httptest.NewRecorder()
+		s.handleData(rec, httptest.NewRequest(http.MethodGet, "/api/data/s1/label", nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", rec.Code)
+		}
+	})
+
+	tests := []struct {
+		name string
+		id   string
+		body string
+		want int
+	}{
+		{"missing accepted", "s1", `{"note": "?"}`, http.StatusBadRequest},
+		{"unknown scrape", "missing", `{"accepted": false}`, http.StatusNotFound},
+		{"unscored scrape", "unscored", `{"accepted": false}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := postLabel(s, tt.id, tt.body); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreLabelsLearnAdjustments(t *testing.T) {
+	s, _ := newLabelTestServer(true)
+
+	for _, id := range []string{"s0", "s1", "s2", "s3", "s4"} {
+		if rec := postLabel(s, id, `{"accepted": true}`); rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleCalibration(rec, httptest.NewRequest(http.MethodGet, "/api/calibration?threshold=0.48", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var resp CalibrationResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Labels != 5 || resp.Threshold != 0.48 || !resp.AdjustmentsEnabled {
+		t.Errorf("unexpected report: %+v", resp.Report)
+	}
+	if resp.Current.TruePositives != 3 || resp.Current.FalseNegatives != 2 {
+		t.Errorf("unexpected confusion matrix at 0.48: %+v", resp.Current)
+	}
+	if resp.DomainAdjustments["example.com"] <= 0 {
+		t.Errorf("expected a positive adjustment for example.com, got %v", resp.DomainAdjustments)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleCalibration(rec, httptest.NewRequest(http.MethodGet, "/api/calibration?threshold=2", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/models"
+)
+
+// credentialStore is the persistence for site credentials
+type credentialStore interface {
+	CredentialsEnabled() bool
+	ListSiteCredentials() ([]*models.SiteCredential, error)
+	GetSiteCredential(domain string) (*models.SiteCredential, error)
+	UpsertSiteCredential(c *models.SiteCredential) error
+	DeleteSiteCredential(domain string) error
+}
+
+// SiteCredentialRequest represents a request to set the credential for a domain
+// Which secret fields are required depends on the kind.
+type SiteCredentialRequest struct {
+	Kind     string            `json:"kind"`
+	Note     string            `json:"note,omitempty"`
+	Cookies  map[string]string `json:"cookies,omitempty"`  // cookies
+	Token    string            `json:"token,omitempty"`    // bearer
+	Username string            `json:"username,omitempty"` // basic and form_login
+	Password string            `json:"password,omitempty"` // basic and form_login
+	Login    *models.FormLogin `json:"login,omitempty"`    // form_login
+}
+
+// validate checks that the request carries what its kind needs
+func (r *SiteCredentialRequest) validate() error {
+	switch r.Kind {
+	case models.CredentialCookies:
+		if len(r.Cookies) == 0 {
+			return fmt.Errorf("cookies credentials need at least one cookie")
+		}
+	case models.CredentialBearer:
+		if r.Token == "" {
+			return fmt.Errorf("bearer credentials need a token")
+		}
+	case models.CredentialBasic:
+		if r.Username == "" {
+			return fmt.Errorf("basic credentials need a username")
+		}
+	case models.CredentialFormLogin:
+		if r.Username == "" || r.Password == "" {
+			return fmt.Errorf("form_login credentials need a username and password")
+		}
+		if r.Login == nil || !isHTTPURL(r.Login.URL) {
+			return fmt.Errorf("form_login credentials need an absolute http(s) login.url")
+		}
+		if r.Login.SubmitURL != "" && !isHTTPURL(r.Login.SubmitURL) {
+			return fmt.Errorf("login.submit_url must be an absolute http(s) URL")
+		}
+		if r.Login.SubmitURL != "" {
+			login, _ := url.Parse(r.Login.URL)
+			submit, _ := url.Parse(r.Login.SubmitURL)
+			if login.Scheme == "https" && submit.Scheme != "https" {
+				return fmt.Errorf("login.submit_url must be https when login.url is")
+			}
+		}
+	default:
+		return fmt.Errorf("invalid kind %q: must be one of %s, %s, %s, %s", r.Kind,
+			models.CredentialCookies, models.CredentialBearer, models.CredentialBasic, models.CredentialFormLogin)
+	}
+	return nil
+}
+
+// isHTTPURL reports whether raw is an absolute http or https URL
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// RefreshSiteCredentials loads site credentials from the database into the scraper
+// It does nothing when no credentials key is configured.
+func (s *Server) RefreshSiteCredentials() error {
+	if s.credentials == nil || !s.credentials.CredentialsEnabled() {
+		return nil
+	}
+	credentials, err := s.credentials.ListSiteCredentials()
+	if err != nil {
+		return err
+	}
+	s.scraper.SetSiteCredentials(credentials)
+	return nil
+}
+
+// credentialsEnabled responds with 503 and returns false when the vault has no key
+func (s *Server) credentialsEnabled(w http.ResponseWriter) bool {
+	if s.credentials == nil || !s.credentials.CredentialsEnabled() {
+		respondError(w, http.StatusServiceUnavailable, "site credentials are disabled: set CREDENTIALS_KEY")
+		return false
+	}
+	return true
+}
+
+// handleSiteCredentials lists all site credentials, without their secrets
+func (s *Server) handleSiteCredentials(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.credentialsEnabled(w) {
+		return
+	}
+
+	credentials, err := s.credentials.ListSiteCredentials()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list site credentials: %v", err))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"credentials": credentials,
+		"count":       len(credentials),
+	})
+}
+
+// handleSiteCredential gets (GET), sets (PUT) or removes (DELETE) the credential for a domain
+// Secrets are write-only: responses never include them.
+func (s *Server) handleSiteCredential(w http.ResponseWriter, r *http.Request) {
+	domain := scraper.NormalizeDomain(strings.TrimPrefix(r.URL.Path, "/api/admin/credentials/"))
+	if domain == "" || strings.ContainsAny(domain, "/:?# \t") {
+		respondError(w, http.StatusBadRequest, "a valid domain is required")
+		return
+	}
+	if !s.credentialsEnabled(w) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		credential, err := s.credentials.GetSiteCredential(domain)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get site credential: %v", err))
+			return
+		}
+		if credential == nil {
+			respondError(w, http.StatusNotFound, "site credential not found")
+			return
+		}
+		respondJSON(w, http.StatusOK, credential)
+	case http.MethodPut:
+		var req SiteCredentialRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if err := req.validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		credential := &models.SiteCredential{
+			Domain: domain,
+			Kind:   req.Kind,
+			Note:   req.Note,
+			Secret: models.CredentialSecret{
+				Cookies:  req.Cookies,
+				Token:    req.Token,
+				Username: req.Username,
+				Password: req.Password,
+			},
+		}
+		if req.Kind == models.CredentialFormLogin {
+			credential.Login = req.Login
+		}
+		if err := s.credentials.UpsertSiteCredential(credential); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save site credential: %v", err))
+			return
+		}
+		s.refreshSiteCredentialsAfterChange()
+
+		slog.Info("set site credential", "domain", domain, "kind", req.Kind)
+		respondJSON(w, http.StatusOK, credential)
+	case http.MethodDelete:
+		if err := s.credentials.DeleteSiteCredential(domain); err != nil {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.refreshSiteCredentialsAfterChange()
+
+		slog.Info("deleted site credential", "domain", domain)
+		respondJSON(w, http.StatusOK, map[string]string{
+			"message": "site credential deleted",
+			"domain":  domain,
+		})
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// refreshSiteCredentialsAfterChange applies a credential change immediately
+// On failure the periodic refresh picks the change up later
+func (s *Server) refreshSiteCredentialsAfterChange() {
+	if err := s.RefreshSiteCredentials(); err != nil {
+		slog.Warn("failed to refresh site credentials", "error", err)
+	}
+}
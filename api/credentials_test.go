@@ -0,0 +1,124 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/models"
+)
+
+// memoryCredentialStore is an in-memory credentialStore
+type memoryCredentialStore struct {
+	enabled     bool
+	credentials map[string]*models.SiteCredential
+}
+
+func (m *memoryCredentialStore) CredentialsEnabled() bool { return m.enabled }
+
+func (m *memoryCredentialStore) ListSiteCredentials() ([]*models.SiteCredential, error) {
+	credentials := make([]*models.SiteCredential, 0, len(m.credentials))
+	for _, c := range m.credentials {
+		credentials = append(credentials, c)
+	}
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].Domain < credentials[j].Domain })
+	return credentials, nil
+}
+
+func (m *memoryCredentialStore) GetSiteCredential(domain string) (*models.SiteCredential, error) {
+	return m.credentials[domain], nil
+}
+
+func (m *memoryCredentialStore) UpsertSiteCredential(c *models.SiteCredential) error {
+	m.credentials[c.Domain] = c
+	return nil
+}
+
+func (m *memoryCredentialStore) DeleteSiteCredential(domain string) error {
+	if _, ok := m.credentials[domain]; !ok {
+		return fmt.Errorf("site credential not found: %s", domain)
+	}
+	delete(m.credentials, domain)
+	return nil
+}
+
+func TestHandleSiteCredential(t *testing.T) {
+	store := &memoryCredentialStore{enabled: true, credentials: map[string]*models.SiteCredential{}}
+	s := &Server{
+		scraper:     scraper.New(scraper.DefaultConfig(), nil, nil),
+		credentials: store,
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if path == "/api/admin/credentials" {
+			s.handleSiteCredentials(rec, req)
+		} else {
+			s.handleSiteCredential(rec, req)
+		}
+		return rec
+	}
+
+	t.Run("invalid requests", func(t *testing.T) {
+		bodies := []string{
+			`{"kind": "magic"}`,
+			`{"kind": "bearer"}`,
+			`{"kind": "cookies", "cookies": {}}`,
+			`{"kind": "form_login", "username": "u", "password": "p"}`,
+			`{"kind": "form_login", "username": "u", "password": "p", "login": {"url": "/login"}}`,
+			`{"kind": "form_login", "username": "u", "password": "p", "login": {"url": "https://wiki.example.com/login", "submit_url": "http://wiki.example.com/session"}}`,
+		}
+		for _, body := range bodies {
+			if rec := do(http.MethodPut, "/api/admin/credentials/wiki.example.com", body); rec.Code != http.StatusBadRequest {
+				t.Errorf("%s: status = %d, want 400", body, rec.Code)
+			}
+		}
+	})
+
+	t.Run("secrets are never returned", func(t *testing.T) {
+		rec := do(http.MethodPut, "/api/admin/credentials/WWW.Wiki.example.com",
+			`{"kind": "form_login", "username": "reader", "password": "hunter2", "login": {"url": "https://wiki.example.com/login"}}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+		}
+		stored := store.credentials["wiki.example.com"]
+		if stored == nil || stored.Secret.Password != "hunter2" {
+			t.Fatalf("stored credential = %+v", stored)
+		}
+
+		for _, rec := range []*httptest.ResponseRecorder{
+			rec,
+			do(http.MethodGet, "/api/admin/credentials/wiki.example.com", ""),
+			do(http.MethodGet, "/api/admin/credentials", ""),
+		} {
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want 200", rec.Code)
+			}
+			if body := rec.Body.String(); strings.Contains(body, "hunter2") || strings.Contains(body, "reader") {
+				t.Errorf("response leaks the secret: %s", body)
+			}
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if rec := do(http.MethodDelete, "/api/admin/credentials/wiki.example.com", ""); rec.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", rec.Code)
+		}
+		if rec := do(http.MethodGet, "/api/admin/credentials/wiki.example.com", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", rec.Code)
+		}
+	})
+
+	t.Run("disabled without a key", func(t *testing.T) {
+		store.enabled = false
+		defer func() { store.enabled = true }()
+		if rec := do(http.MethodGet, "/api/admin/credentials", ""); rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want 503", rec.Code)
+		}
+	})
+}
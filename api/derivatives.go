@@ -0,0 +1,186 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/docutag/scraper/imaging"
+	"github.com/docutag/scraper/models"
+)
+
+// defaultDerivativeWidths are the widths derivatives are generated at when the server
+// is not configured with its own
+var defaultDerivativeWidths = []int{320, 640, 1280}
+
+var imageDerivatives = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "scraper_image_derivatives_total",
+	Help: "Image derivative requests, by result (\"hit\", \"generated\" or \"error\")",
+}, []string{"result"})
+
+// derivativeStore is the persistence for generated image derivatives
+type derivativeStore interface {
+	GetImageDerivative(imageID string, width int, format string) (*models.ImageDerivative, error)
+	SaveImageDerivative(d *models.ImageDerivative) error
+	ListImageDerivatives(imageID string) ([]*models.ImageDerivative, error)
+}
+
+// derivativeRequest is a derivative asked for with the w and fmt query parameters
+type derivativeRequest struct {
+	Width  int    // One of the configured widths, 0 for the original width
+	Format string // imaging.FormatJPEG or imaging.FormatWebP
+}
+
+// derivativeWidths sorts and de-duplicates configured derivative widths, falling back to
+// defaultDerivativeWidths when none are positive
+func derivativeWidths(configured []int) []int {
+	var widths []int
+	for _, w := range configured {
+		if w > 0 && !slices.Contains(widths, w) {
+			widths = append(widths, w)
+		}
+	}
+	if len(widths) == 0 {
+		return defaultDerivativeWidths
+	}
+	slices.Sort(widths)
+	return widths
+}
+
+// variant names the derivative in its storage key
+func (d derivativeRequest) variant() string {
+	if d.Width == 0 {
+		return "full"
+	}
+	return fmt.Sprintf("w%d", d.Width)
+}
+
+// parseDerivativeRequest reads the w and fmt query parameters against the ascending
+// configured widths
+// ok is false when neither is set and the original should be served. A width is rounded
+// up to the next configured width, and capped at the largest, so that arbitrary widths
+// cannot fill storage with derivatives.
+func parseDerivativeRequest(q url.Values, widths []int) (req derivativeRequest, ok bool, err error) {
+	w, format := q.Get("w"), strings.ToLower(q.Get("fmt"))
+	if w == "" && format == "" {
+		return derivativeRequest{}, false, nil
+	}
+
+	switch format {
+	case "", imaging.FormatJPEG, "jpg":
+		req.Format = imaging.FormatJPEG
+	case imaging.FormatWebP:
+		req.Format = imaging.FormatWebP
+	default:
+		return derivativeRequest{}, false, fmt.Errorf("fmt must be %q or %q", imaging.FormatJPEG, imaging.FormatWebP)
+	}
+
+	if w != "" {
+		width, err := strconv.Atoi(w)
+		if err != nil || width <= 0 {
+			return derivativeRequest{}, false, fmt.Errorf("w must be a positive integer")
+		}
+		req.Width = widths[len(widths)-1]
+		for _, candidate := range widths {
+			if candidate >= width {
+				req.Width = candidate
+				break
+			}
+		}
+	}
+
+	return req, true, nil
+}
+
+// serveImageDerivative serves a derivative of image, generating and storing it on first
+// request
+func (s *Server) serveImageDerivative(w http.ResponseWriter, image *models.ImageInfo, req derivativeRequest) {
+	cached, err := s.derivatives.GetImageDerivative(image.ID, req.Width, req.Format)
+	if err != nil {
+		slog.Error("failed to look up image derivative", "image_id", image.ID, "error", err)
+	}
+	if cached != nil {
+		data, err := s.storage.ReadImage(cached.FilePath)
+		if err == nil {
+			imageDerivatives.WithLabelValues("hit").Inc()
+			writeImage(w, data, cached.ContentType)
+			return
+		}
+		// Regenerate below; the stored copy is overwritten
+		slog.Warn("failed to read image derivative", "file_path", cached.FilePath, "error", err)
+	}
+
+	original, err := s.storage.ReadImage(image.FilePath)
+	if err != nil {
+		imageDerivatives.WithLabelValues("error").Inc()
+		slog.Error("failed to read image file", "file_path", image.FilePath, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to read image file")
+		return
+	}
+
+	derived, err := imaging.Derive(original, req.Width, req.Format)
+	if err != nil {
+		imageDerivatives.WithLabelValues("error").Inc()
+		var unsupported *imaging.UnsupportedFormatError
+		if errors.As(err, &unsupported) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.Warn("failed to derive image", "image_id", image.ID, "error", err)
+		respondError(w, http.StatusUnprocessableEntity, "image cannot be resized")
+		return
+	}
+
+	filePath, err := s.storage.SaveImageDerivative(derived.Data, image.FilePath, req.variant(), derived.ContentType)
+	if err != nil {
+		// Still serve it; the next request tries to store it again
+		slog.Error("failed to store image derivative", "image_id", image.ID, "error", err)
+	} else {
+		err = s.derivatives.SaveImageDerivative(&models.ImageDerivative{
+			ImageID:       image.ID,
+			Width:         req.Width,
+			Format:        req.Format,
+			FilePath:      filePath,
+			ContentType:   derived.ContentType,
+			ActualWidth:   derived.Width,
+			ActualHeight:  derived.Height,
+			FileSizeBytes: int64(len(derived.Data)),
+		})
+		if err != nil {
+			slog.Error("failed to record image derivative", "image_id", image.ID, "error", err)
+		}
+	}
+
+	imageDerivatives.WithLabelValues("generated").Inc()
+	writeImage(w, derived.Data, derived.ContentType)
+}
+
+// handleListImageDerivatives lists the derivatives generated for an image
+func (s *Server) handleListImageDerivatives(w http.ResponseWriter, r *http.Request, id string) {
+	derivatives, err := s.derivatives.ListImageDerivatives(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"derivatives": derivatives,
+		"count":       len(derivatives),
+	})
+}
+
+// writeImage writes image bytes; imageNotModified sets the caching headers
+func writeImage(w http.ResponseWriter, data []byte, contentType string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/docutag/scraper/imaging"
+	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/storage"
+)
+
+// memoryStorage is an in-memory storage.StorageInterface for images
+type memoryStorage struct {
+	objects  map[string][]byte
+	saves    int
+	replaces int
+}
+
+func (m *memoryStorage) SaveImage(imageData []byte, slug, contentType string) (string, error) {
+	key := "images/" + slug
+	m.objects[key] = imageData
+	return key, nil
+}
+
+func (m *memoryStorage) SaveImageDerivative(imageData []byte, originalKey, variant, contentType string) (string, error) {
+	key := storage.DerivativeKey(originalKey, variant, contentType)
+	m.objects[key] = imageData
+	m.saves++
+	return key, nil
+}
+
+func (m *memoryStorage) ReplaceImage(key string, imageData []byte, contentType string) error {
+	m.objects[key] = imageData
+	m.replaces++
+	return nil
+}
+
+func (m *memoryStorage) SaveContent(content, slug string) (string, error) {
+	return "", fmt.Errorf("not supported")
+}
+
+func (m *memoryStorage) ReadImage(relPath string) ([]byte, error) {
+	data, ok := m.objects[relPath]
+	if !ok {
+		return nil, fmt.Errorf("no such key: %s", relPath)
+	}
+	return data, nil
+}
+
+func (m *memoryStorage) ReadContent(relPath string) (string, error) {
+	return "", fmt.Errorf("not supported")
+}
+
+func (m *memoryStorage) DeleteImage(relPath string) error {
+	delete(m.objects, relPath)
+	return nil
+}
+
+func (m *memoryStorage) DeleteContent(relPath string) error {
+	return nil
+}
+
+// memoryDerivativeStore is an in-memory derivativeStore
+type memoryDerivativeStore struct {
+	derivatives []*models.ImageDerivative
+}
+
+func (m *memoryDerivativeStore) GetImageDerivative(imageID string, width int, format string) (*models.ImageDerivative, error) {
+	for _, d := range m.derivatives {
+		if d.ImageID == imageID && d.Width == width && d.Format == format {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryDerivativeStore) SaveImageDerivative(d *models.ImageDerivative) error {
+	m.derivatives = append(m.derivatives, d)
+	return nil
+}
+
+func (m *memoryDerivativeStore) ListImageDerivatives(imageID string) ([]*models.ImageDerivative, error) {
+	var derivatives []*models.ImageDerivative
+	for _, d := range m.derivatives {
+		if d.ImageID == imageID {
+			derivatives = append(derivatives, d)
+		}
+	}
+	return derivatives, nil
+}
+
+func TestParseDerivativeRequest(t *testing.T) {
+	widths := []int{320, 640, 1280}
+	tests := []struct {
+		query   string
+		want    derivativeRequest
+		wantOK  bool
+		wantErr bool
+	}{
+		{"", derivativeRequest{}, false, false},
+		{"w=320", derivativeRequest{Width: 320, Format: imaging.FormatJPEG}, true, false},
+		{"w=400&fmt=webp", derivativeRequest{Width: 640, Format: imaging.FormatWebP}, true, false},
+		{"w=5000", derivativeRequest{Width: 1280, Format: imaging.FormatJPEG}, true, false},
+		{"fmt=WEBP", derivativeRequest{Width: 0, Format: imaging.FormatWebP}, true, false},
+		{"fmt=jpg", derivativeRequest{Width: 0, Format: imaging.FormatJPEG}, true, false},
+		{"w=0", derivativeRequest{}, false, true},
+		{"w=wide", derivativeRequest{}, false, true},
+		{"fmt=avif", derivativeRequest{}, false, true},
+	}
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt.query)
+		got, ok, err := parseDerivativeRequest(q, widths)
+		if (err != nil) != tt.wantErr || ok != tt.wantOK || got != tt.want {
+			t.Errorf("parseDerivativeRequest(%q) = %+v, %v, %v, want %+v, %v, error %v", tt.query, got, ok, err, tt.want, tt.wantOK, tt.wantErr)
+		}
+	}
+}
+
+func TestDerivativeWidths(t *testing.T) {
+	got := derivativeWidths([]int{1280, 320, 0, 320})
+	if len(got) != 2 || got[0] != 320 || got[1] != 1280 {
+		t.Errorf("derivativeWidths = %v, want [320 1280]", got)
+	}
+	if got := derivativeWidths(nil); len(got) != len(defaultDerivativeWidths) {
+		t.Errorf("derivativeWidths(nil) = %v, want the defaults", got)
+	}
+}
+
+func TestServeImageDerivative(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 800, 400))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i)
+	}
+	var original bytes.Buffer
+	if err := png.Encode(&original, src); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &memoryStorage{objects: map[string][]byte{"images/2024/03/cat.png": original.Bytes()}}
+	derivatives := &memoryDerivativeStore{}
+	s := &Server{storage: store, derivatives: derivatives, derivativeWidths: defaultDerivativeWidths}
+	img := &models.ImageInfo{ID: "img-1", FilePath: "images/2024/03/cat.png", ContentType: "image/png"}
+
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.serveImageDerivative(rec, img, derivativeRequest{Width: 320, Format: imaging.FormatWebP})
+		return rec
+	}
+
+	first := serve()
+	if first.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", first.Code, first.Body.String())
+	}
+	if ct := first.Header().Get("Content-Type"); ct != "image/webp" {
+		t.Errorf("Content-Type = %q, want image/webp", ct)
+	}
+	if len(derivatives.derivatives) != 1 {
+		t.Fatalf("recorded %d derivatives, want 1", len(derivatives.derivatives))
+	}
+	d := derivatives.derivatives[0]
+	if d.FilePath != "images/2024/03/cat_w320.webp" || d.ActualWidth != 320 || d.ActualHeight != 160 {
+		t.Errorf("derivative = %+v", d)
+	}
+	decoded, _, err := image.DecodeConfig(bytes.NewReader(first.Body.Bytes()))
+	if err != nil || decoded.Width != 320 {
+		t.Errorf("served derivative = %+v, %v", decoded, err)
+	}
+
+	// The second request is served from storage without generating again
+	second := serve()
+	if second.Code != http.StatusOK || !bytes.Equal(second.Body.Bytes(), first.Body.Bytes()) {
+		t.Errorf("cached derivative differs: status %d", second.Code)
+	}
+	if store.saves != 1 || len(derivatives.derivatives) != 1 {
+		t.Errorf("saves = %d, recorded = %d, want 1 each", store.saves, len(derivatives.derivatives))
+	}
+
+	// An original that cannot be decoded is unprocessable
+	store.objects["images/broken.png"] = []byte("not an image")
+	rec := httptest.NewRecorder()
+	s.serveImageDerivative(rec, &models.ImageInfo{ID: "img-2", FilePath: "images/broken.png"}, derivativeRequest{Width: 320, Format: imaging.FormatJPEG})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("broken image status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/feed"
+	"github.com/docutag/scraper/models"
+)
+
+// domainPolicyStore is the persistence for domain policies
+type domainPolicyStore interface {
+	ListDomainPolicies() ([]*models.DomainPolicy, error)
+	GetDomainPolicy(domain string) (*models.DomainPolicy, error)
+	UpsertDomainPolicy(p *models.DomainPolicy) error
+	DeleteDomainPolicy(domain string) error
+}
+
+// DomainPolicyRequest represents a request to set the policy for a domain
+type DomainPolicyRequest struct {
+	Policy string `json:"policy"`
+	Note   string `json:"note,omitempty"`
+}
+
+// RefreshDomainPolicies loads domain policies from the database into the scraper
+func (s *Server) RefreshDomainPolicies() error {
+	policies, err := s.policies.ListDomainPolicies()
+	if err != nil {
+		return err
+	}
+	s.scraper.SetDomainPolicies(policies)
+	return nil
+}
+
+// handleDomainPolicies lists all domain policies
+func (s *Server) handleDomainPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	policies, err := s.policies.ListDomainPolicies()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list domain policies: %v", err))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"policies": policies,
+		"count":    len(policies),
+	})
+}
+
+// handleDomainPolicy gets (GET), sets (PUT) or removes (DELETE) the policy for a domain
+func (s *Server) handleDomainPolicy(w http.ResponseWriter, r *http.Request) {
+	domain := scraper.NormalizeDomain(strings.TrimPrefix(r.URL.Path, "/api/domain-policies/"))
+	if domain == "" || strings.ContainsAny(domain, "/:?# \t") {
+		respondError(w, http.StatusBadRequest, "a valid domain is required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := s.policies.GetDomainPolicy(domain)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get domain policy: %v", err))
+			return
+		}
+		if policy == nil {
+			respondError(w, http.StatusNotFound, "domain policy not found")
+			return
+		}
+		respondJSON(w, http.StatusOK, policy)
+	case http.MethodPut:
+		var req DomainPolicyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if !models.ValidDomainPolicy(req.Policy) {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid policy %q: must be one of %s, %s, %s, %s", req.Policy,
+				models.DomainPolicyAllow, models.DomainPolicyBlock, models.DomainPolicyAlwaysRecommend, models.DomainPolicyNeverDownloadImages))
+			return
+		}
+
+		policy := &models.DomainPolicy{Domain: domain, Policy: req.Policy, Note: req.Note}
+		if err := s.policies.UpsertDomainPolicy(policy); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save domain policy: %v", err))
+			return
+		}
+		s.refreshDomainPoliciesAfterChange()
+
+		slog.Info("set domain policy", "domain", domain, "policy", req.Policy)
+		respondJSON(w, http.StatusOK, policy)
+	case http.MethodDelete:
+		if err := s.policies.DeleteDomainPolicy(domain); err != nil {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.refreshDomainPoliciesAfterChange()
+
+		slog.Info("deleted domain policy", "domain", domain)
+		respondJSON(w, http.StatusOK, map[string]string{
+			"message": "domain policy deleted",
+			"domain":  domain,
+		})
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// refreshDomainPoliciesAfterChange applies a policy change immediately
+// On failure the periodic refresh picks the change up later
+func (s *Server) refreshDomainPoliciesAfterChange() {
+	if err := s.RefreshDomainPolicies(); err != nil {
+		slog.Warn("failed to refresh domain policies", "error", err)
+	}
+}
+
+// scrapeErrorStatus maps a scrape error to an HTTP status code
+func scrapeErrorStatus(err error) int {
+	var blocked *scraper.DomainBlockedError
+	var blockedAddr *scraper.BlockedAddressError
+	if errors.As(err, &blocked) || errors.As(err, &blockedAddr) {
+		return http.StatusForbidden
+	}
+	// The target served something the scraper refuses to read, or is not a feed
+	var unsupported *scraper.UnsupportedContentTypeError
+	var bomb *scraper.DecompressionBombError
+	if errors.As(err, &unsupported) || errors.As(err, &bomb) || errors.Is(err, feed.ErrUnknownFormat) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
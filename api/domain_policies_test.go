@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/models"
+)
+
+// memoryPolicyStore is an in-memory domainPolicyStore
+type memoryPolicyStore map[string]*models.DomainPolicy
+
+func (m memoryPolicyStore) ListDomainPolicies() ([]*models.DomainPolicy, error) {
+	policies := make([]*models.DomainPolicy, 0, len(m))
+	for _, p := range m {
+		policies = append(policies, p)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Domain < policies[j].Domain })
+	return policies, nil
+}
+
+func (m memoryPolicyStore) GetDomainPolicy(domain string) (*models.DomainPolicy, error) {
+	return m[domain], nil
+}
+
+func (m memoryPolicyStore) UpsertDomainPolicy(p *models.DomainPolicy) error {
+	m[p.Domain] = p
+	return nil
+}
+
+func (m memoryPolicyStore) DeleteDomainPolicy(domain string) error {
+	if _, ok := m[domain]; !ok {
+		return fmt.Errorf("domain policy not found: %s", domain)
+	}
+	delete(m, domain)
+	return nil
+}
+
+func newPolicyTestServer() *Server {
+	return &Server{
+		scraper:  scraper.New(scraper.DefaultConfig(), nil, nil),
+		policies: memoryPolicyStore{},
+	}
+}
+
+func TestHandleDomainPolicy(t *testing.T) {
+	s := newPolicyTestServer()
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handleDomainPolicy(rec, httptest.NewRequest(http.MethodPut, path, strings.NewReader(body)))
+		return rec
+	}
+
+	t.Run("invalid policy", func(t *testing.T) {
+		rec := put("/api/domain-policies/example.com", `{"policy": "maybe"}`)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	})
+
+	t.Run("invalid domain", func(t *testing.T) {
+		for _, path := range []string{"/api/domain-policies/", "/api/domain-policies/example.com/page", "/api/domain-policies/example.com:8080"} {
+			if rec := put(path, `{"policy": "block"}`); rec.Code != http.StatusBadRequest {
+				t.Errorf("%s: status = %d, want 400", path, rec.Code)
+			}
+		}
+	})
+
+	t.Run("set policy", func(t *testing.T) {
+		rec := put("/api/domain-policies/WWW.Example.com", `{"policy": "block", "note": "spam"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+		}
+		var policy models.DomainPolicy
+		if err := json.NewDecoder(rec.Body).Decode(&policy); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if policy.Domain != "example.com" || policy.Policy != models.DomainPolicyBlock {
+			t.Errorf("unexpected policy: %+v", policy)
+		}
+		if p := s.scraper.DomainPolicyFor("https://blog.example.com/post"); p == nil || p.Policy != models.DomainPolicyBlock {
+			t.Errorf("expected policy to be enforced immediately, got %+v", p)
+		}
+	})
+
+	t.Run("get policy", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.handleDomainPolicy(rec, httptest.NewRequest(http.MethodGet, "/api/domain-policies/example.com", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", rec.Code)
+		}
+
+		rec = httptest.NewRecorder()
+		s.handleDomainPolicy(rec, httptest.NewRequest(http.MethodGet, "/api/domain-policies/other.com", nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", rec.Code)
+		}
+	})
+
+	t.Run("list policies", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.handleDomainPolicies(rec, httptest.NewRequest(http.MethodGet, "/api/domain-policies", nil))
+		var resp struct {
+			Policies []models.DomainPolicy `json:"policies"`
+			Count    int                   `json:"count"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Count != 1 || resp.Policies[0].Domain != "example.com" {
+			t.Errorf("unexpected list: %+v", resp)
+		}
+	})
+
+	t.Run("delete policy", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.handleDomainPolicy(rec, httptest.NewRequest(http.MethodDelete, "/api/domain-policies/example.com", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		if p := s.scraper.DomainPolicyFor("https://example.com/"); p != nil {
+			t.Errorf("expected policy to be removed, got %+v", p)
+		}
+
+		rec = httptest.NewRecorder()
+		s.handleDomainPolicy(rec, httptest.NewRequest(http.MethodDelete, "/api/domain-policies/example.com", nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", rec.Code)
+		}
+	})
+}
+
+func TestScrapeErrorStatus(t *testing.T) {
+	blocked := fmt.Errorf("wrapped: %w", &scraper.DomainBlockedError{URL: "https://example.com", Domain: "example.com"})
+	if got := scrapeErrorStatus(blocked); got != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", got)
+	}
+	private := fmt.Errorf("wrapped: %w", &scraper.BlockedAddressError{Host: "metadata", IP: "169.254.169.254"})
+	if got := scrapeErrorStatus(private); got != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", got)
+	}
+	unsupported := fmt.Errorf("wrapped: %w", &scraper.UnsupportedContentTypeError{URL: "https://example.com/a.mp4", ContentType: "video/mp4"})
+	if got := scrapeErrorStatus(unsupported); got != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want 422", got)
+	}
+	if got := scrapeErrorStatus(fmt.Errorf("boom")); got != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", got)
+	}
+}
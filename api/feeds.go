@@ -0,0 +1,392 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/db"
+	"github.com/docutag/scraper/feed"
+	"github.com/docutag/scraper/models"
+)
+
+const (
+	// defaultFeedInterval is how often a feed is polled when the subscription does not say
+	defaultFeedInterval = 15 * time.Minute
+
+	// minFeedInterval keeps subscriptions from hammering a site
+	minFeedInterval = time.Minute
+
+	// defaultFeedMaxEntries is how many new entries a poll queues when the subscription
+	// does not say; a large sitemap is worked through over several polls
+	defaultFeedMaxEntries = 20
+
+	// feedQueueSize is how many feed entries can wait for a worker
+	feedQueueSize = 1000
+
+	// feedPollTimeout bounds fetching one feed, including a sitemap index's children
+	feedPollTimeout = 2 * time.Minute
+)
+
+var (
+	feedPolls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scraper_feed_polls_total",
+		Help: "Feed subscription polls, by result (\"success\" or \"error\")",
+	}, []string{"result"})
+
+	feedEntriesQueued = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "scraper_feed_entries_queued_total",
+		Help: "New feed entries queued for scraping",
+	})
+)
+
+// feedStore is the persistence for feed subscriptions and the entries they have scraped
+type feedStore interface {
+	CreateFeedSubscription(f *models.FeedSubscription) error
+	ListFeedSubscriptions() ([]*models.FeedSubscription, error)
+	GetFeedSubscription(id string) (*models.FeedSubscription, error)
+	DeleteFeedSubscription(id string) error
+	RecordFeedPoll(id string, polledAt time.Time, pollErr string) error
+	SeenFeedEntries(id string, urls []string) (map[string]bool, error)
+	RecordFeedEntries(id string, urls []string) error
+	RecordFeedEntryFailure(id, entryURL, scrapeErr string) error
+}
+
+// feedJob is a feed entry waiting to be scraped
+type feedJob struct {
+	SubscriptionID string
+	Entry          feed.Entry
+}
+
+// key identifies the job's entry among the pending feed entries
+func (j feedJob) key() string {
+	return j.SubscriptionID + " " + j.Entry.URL
+}
+
+// DiscoverFeedsRequest represents a feed discovery request
+type DiscoverFeedsRequest struct {
+	URL string `json:"url"`
+}
+
+// DiscoverFeedsResponse lists the feeds and sitemaps found for a page
+type DiscoverFeedsResponse struct {
+	URL   string      `json:"url"`
+	Feeds []feed.Link `json:"feeds"`
+	Count int         `json:"count"`
+}
+
+// FeedSubscriptionRequest represents a request to subscribe to a feed or sitemap
+type FeedSubscriptionRequest struct {
+	FeedURL           string `json:"feed_url"`
+	IntervalSeconds   int    `json:"interval_seconds,omitempty"`     // Default 900, minimum 60
+	MaxEntriesPerPoll *int   `json:"max_entries_per_poll,omitempty"` // Default 20, 0 = all
+}
+
+// validate checks the request and fills in defaults
+func (r *FeedSubscriptionRequest) validate() error {
+	if !isHTTPURL(r.FeedURL) {
+		return fmt.Errorf("feed_url must be an absolute http(s) URL")
+	}
+	if r.IntervalSeconds == 0 {
+		r.IntervalSeconds = int(defaultFeedInterval / time.Second)
+	}
+	if r.IntervalSeconds < int(minFeedInterval/time.Second) {
+		return fmt.Errorf("interval_seconds must be at least %d", int(minFeedInterval/time.Second))
+	}
+	if r.MaxEntriesPerPoll == nil {
+		r.MaxEntriesPerPoll = scraper.Int(defaultFeedMaxEntries)
+	}
+	if *r.MaxEntriesPerPoll < 0 {
+		return fmt.Errorf("max_entries_per_poll cannot be negative")
+	}
+	return nil
+}
+
+// handleDiscoverFeeds finds the feeds a page advertises and the sitemaps of its site
+func (s *Server) handleDiscoverFeeds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req DiscoverFeedsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.URL == "" {
+		respondError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), feedPollTimeout)
+	defer cancel()
+
+	links, err := s.scraper.DiscoverFeeds(ctx, req.URL)
+	if err != nil {
+		respondError(w, scrapeErrorStatus(err), fmt.Sprintf("feed discovery failed: %v", err))
+		return
+	}
+	if links == nil {
+		links = []feed.Link{}
+	}
+
+	respondJSON(w, http.StatusOK, DiscoverFeedsResponse{URL: req.URL, Feeds: links, Count: len(links)})
+}
+
+// handleFeeds lists feed subscriptions (GET) or subscribes to a feed (POST)
+// Subscribing fetches the feed once to check it parses.
+func (s *Server) handleFeeds(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		subscriptions, err := s.feeds.ListFeedSubscriptions()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list feed subscriptions: %v", err))
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"feeds": subscriptions,
+			"count": len(subscriptions),
+		})
+	case http.MethodPost:
+		var req FeedSubscriptionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if err := req.validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), feedPollTimeout)
+		defer cancel()
+		f, err := s.scraper.FetchFeed(ctx, req.FeedURL)
+		if err != nil {
+			respondError(w, scrapeErrorStatus(err), fmt.Sprintf("failed to fetch feed: %v", err))
+			return
+		}
+
+		subscription := &models.FeedSubscription{
+			ID:                uuid.New().String(),
+			FeedURL:           req.FeedURL,
+			Kind:              f.Kind,
+			Title:             f.Title,
+			IntervalSeconds:   req.IntervalSeconds,
+			MaxEntriesPerPoll: *req.MaxEntriesPerPoll,
+		}
+		if err := s.feeds.CreateFeedSubscription(subscription); err != nil {
+			if errors.Is(err, db.ErrFeedSubscriptionExists) {
+				respondError(w, http.StatusConflict, err.Error())
+				return
+			}
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save feed subscription: %v", err))
+			return
+		}
+
+		slog.Info("subscribed to feed", "id", subscription.ID, "url", subscription.FeedURL, "kind", subscription.Kind,
+			"entries", len(f.Entries))
+		respondJSON(w, http.StatusCreated, subscription)
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleFeed gets (GET) or unsubscribes from (DELETE) a feed subscription
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/feeds/")
+	if id == "" || strings.Contains(id, "/") {
+		respondError(w, http.StatusBadRequest, "a feed subscription id is required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		subscription, err := s.feeds.GetFeedSubscription(id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get feed subscription: %v", err))
+			return
+		}
+		if subscription == nil {
+			respondError(w, http.StatusNotFound, "feed subscription not found")
+			return
+		}
+		respondJSON(w, http.StatusOK, subscription)
+	case http.MethodDelete:
+		if err := s.feeds.DeleteFeedSubscription(id); err != nil {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		slog.Info("unsubscribed from feed", "id", id)
+		respondJSON(w, http.StatusOK, map[string]string{
+			"message": "feed subscription deleted",
+			"id":      id,
+		})
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// PollFeeds fetches every subscription that is due and queues its new entries for the
+// feed workers, newest first and at most MaxEntriesPerPoll per subscription
+// Entries are only recorded once scraped, so entries that do not fit in the queue, are
+// still queued at shutdown or fail to scrape are queued again by a later poll.
+func (s *Server) PollFeeds(ctx context.Context) error {
+	subscriptions, err := s.feeds.ListFeedSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !subscription.Due(now) {
+			continue
+		}
+		if err := s.pollFeed(ctx, subscription); err != nil {
+			slog.Warn("feed poll failed", "id", subscription.ID, "url", subscription.FeedURL, "error", err)
+		}
+	}
+	return nil
+}
+
+// pollFeed fetches one subscription and queues its unseen entries that are not already queued
+func (s *Server) pollFeed(ctx context.Context, subscription *models.FeedSubscription) error {
+	fetchCtx, cancel := context.WithTimeout(ctx, feedPollTimeout)
+	defer cancel()
+
+	f, err := s.scraper.FetchFeed(fetchCtx, subscription.FeedURL)
+	if recordErr := s.feeds.RecordFeedPoll(subscription.ID, time.Now(), errorString(err)); recordErr != nil {
+		slog.Warn("failed to record feed poll", "id", subscription.ID, "error", recordErr)
+	}
+	if err != nil {
+		feedPolls.WithLabelValues("error").Inc()
+		return err
+	}
+	feedPolls.WithLabelValues("success").Inc()
+
+	urls := make([]string, len(f.Entries))
+	for i, e := range f.Entries {
+		urls[i] = e.URL
+	}
+	seen, err := s.feeds.SeenFeedEntries(subscription.ID, urls)
+	if err != nil {
+		return err
+	}
+
+	feed.SortNewestFirst(f.Entries)
+	queued := 0
+queue:
+	for _, e := range f.Entries {
+		if seen[e.URL] {
+			continue
+		}
+		if subscription.MaxEntriesPerPoll > 0 && queued >= subscription.MaxEntriesPerPoll {
+			break
+		}
+		job := feedJob{SubscriptionID: subscription.ID, Entry: e}
+		if _, pending := s.feedPending.LoadOrStore(job.key(), struct{}{}); pending {
+			continue // Queued by an earlier poll and not yet scraped
+		}
+		select {
+		case s.feedQueue <- job:
+		default:
+			s.feedPending.Delete(job.key())
+			slog.Warn("feed queue full, leaving entries for the next poll", "id", subscription.ID)
+			break queue
+		}
+		seen[e.URL] = true // Feeds sometimes list an entry twice
+		queued++
+	}
+	feedEntriesQueued.Add(float64(queued))
+
+	if queued > 0 {
+		slog.Info("queued feed entries", "id", subscription.ID, "url", subscription.FeedURL, "count", queued)
+	}
+	return nil
+}
+
+// StartFeedWorkers starts n workers that scrape queued feed entries until ctx is done
+// Entries whose URL has already been scraped are skipped.
+func (s *Server) StartFeedWorkers(ctx context.Context, n int) {
+	for i := 0; i < n; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.feedQueue:
+					s.scrapeFeedEntry(ctx, job)
+				}
+			}
+		}()
+	}
+}
+
+// scrapeFeedEntry scrapes and saves one feed entry
+func (s *Server) scrapeFeedEntry(ctx context.Context, job feedJob) {
+	existing, err := s.db.GetByURL(job.Entry.URL)
+	if err != nil {
+		slog.Warn("failed to check for an existing scrape", "url", job.Entry.URL, "error", err)
+		s.feedPending.Delete(job.key()) // Polled again without counting an attempt
+		return
+	}
+	if existing != nil {
+		slog.Debug("feed entry already scraped", "url", job.Entry.URL, "uuid", existing.ID)
+		s.finishFeedEntry(ctx, job, nil)
+		return
+	}
+
+	scrapeCtx, cancel := context.WithTimeout(ctx, scrapeTimeout)
+	defer cancel()
+
+	entry := job.Entry
+	result, err := s.scrapeAndSave(scrapeCtx, entry.URL, scraper.ScrapeOptions{FeedEntry: &entry})
+	if err != nil {
+		slog.Warn("failed to scrape feed entry", "id", job.SubscriptionID, "url", entry.URL, "error", err)
+	} else {
+		slog.Info("scraped feed entry", "id", job.SubscriptionID, "url", entry.URL, "uuid", result.ID)
+	}
+	s.finishFeedEntry(ctx, job, err)
+}
+
+// finishFeedEntry records the outcome of scraping a queued feed entry
+// A scraped entry is never queued again; a failed one is queued by later polls until it
+// has failed db.MaxFeedEntryAttempts times. A scrape cut short by shutdown is not counted.
+func (s *Server) finishFeedEntry(ctx context.Context, job feedJob, scrapeErr error) {
+	defer s.feedPending.Delete(job.key())
+
+	var err error
+	switch {
+	case scrapeErr == nil:
+		err = s.feeds.RecordFeedEntries(job.SubscriptionID, []string{job.Entry.URL})
+	case ctx.Err() != nil:
+		return
+	default:
+		err = s.feeds.RecordFeedEntryFailure(job.SubscriptionID, job.Entry.URL, scrapeErr.Error())
+	}
+	if err != nil {
+		slog.Warn("failed to record feed entry", "id", job.SubscriptionID, "url", job.Entry.URL, "error", err)
+	}
+}
+
+// errorString returns err's message, or "" for a nil error
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/db"
+	"github.com/docutag/scraper/feed"
+	"github.com/docutag/scraper/models"
+)
+
+// memoryFeedStore is an in-memory feedStore
+type memoryFeedStore struct {
+	subscriptions []*models.FeedSubscription
+	entries       map[string]map[string]bool
+	failures      map[string]int
+}
+
+func (m *memoryFeedStore) CreateFeedSubscription(f *models.FeedSubscription) error {
+	for _, existing := range m.subscriptions {
+		if existing.FeedURL == f.FeedURL {
+			return db.ErrFeedSubscriptionExists
+		}
+	}
+	f.CreatedAt = time.Now()
+	m.subscriptions = append(m.subscriptions, f)
+	return nil
+}
+
+func (m *memoryFeedStore) ListFeedSubscriptions() ([]*models.FeedSubscription, error) {
+	return m.subscriptions, nil
+}
+
+func (m *memoryFeedStore) GetFeedSubscription(id string) (*models.FeedSubscription, error) {
+	for _, f := range m.subscriptions {
+		if f.ID == id {
+			return f, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryFeedStore) DeleteFeedSubscription(id string) error {
+	for i, f := range m.subscriptions {
+		if f.ID == id {
+			m.subscriptions = append(m.subscriptions[:i], m.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("feed subscription not found: %s", id)
+}
+
+func (m *memoryFeedStore) RecordFeedPoll(id string, polledAt time.Time, pollErr string) error {
+	f, _ := m.GetFeedSubscription(id)
+	f.LastPolledAt = &polledAt
+	f.LastError = pollErr
+	return nil
+}
+
+func (m *memoryFeedStore) SeenFeedEntries(id string, urls []string) (map[string]bool, error) {
+	seen := map[string]bool{}
+	for _, u := range urls {
+		if m.entries[id][u] || m.failures[id+" "+u] >= db.MaxFeedEntryAttempts {
+			seen[u] = true
+		}
+	}
+	return seen, nil
+}
+
+func (m *memoryFeedStore) RecordFeedEntries(id string, urls []string) error {
+	if m.entries[id] == nil {
+		m.entries[id] = map[string]bool{}
+	}
+	for _, u := range urls {
+		m.entries[id][u] = true
+	}
+	return nil
+}
+
+func (m *memoryFeedStore) RecordFeedEntryFailure(id, entryURL, scrapeErr string) error {
+	if m.failures == nil {
+		m.failures = map[string]int{}
+	}
+	m.failures[id+" "+entryURL]++
+	return nil
+}
+
+// newFeedServer serves an RSS feed of three dated articles and an HTML page
+func newFeedServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, `<rss version="2.0"><channel><title>Example</title>
+<item><link>/old</link><pubDate>Mon, 01 Jan 2024 00:00:00 GMT</pubDate></item>
+<item><link>/newest</link><pubDate>Wed, 03 Jan 2024 00:00:00 GMT</pubDate></item>
+<item><link>/middle</link><pubDate>Tue, 02 Jan 2024 00:00:00 GMT</pubDate></item>
+</channel></rss>`)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Page</title></head><body>Not a feed</body></html>`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHandleFeeds(t *testing.T) {
+	site := newFeedServer(t)
+	store := &memoryFeedStore{entries: map[string]map[string]bool{}}
+	s := &Server{scraper: scraper.New(scraper.DefaultConfig(), nil, nil), feeds: store}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if path == "/api/feeds" {
+			s.handleFeeds(rec, req)
+		} else {
+			s.handleFeed(rec, req)
+		}
+		return rec
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"relative URL", `{"feed_url": "/feed.xml"}`, http.StatusBadRequest},
+		{"interval too short", fmt.Sprintf(`{"feed_url": "%s/feed.xml", "interval_seconds": 5}`, site.URL), http.StatusBadRequest},
+		{"negative limit", fmt.Sprintf(`{"feed_url": "%s/feed.xml", "max_entries_per_poll": -1}`, site.URL), http.StatusBadRequest},
+		{"not a feed", fmt.Sprintf(`{"feed_url": "%s/page"}`, site.URL), http.StatusUnprocessableEntity},
+		{"subscribe", fmt.Sprintf(`{"feed_url": "%s/feed.xml"}`, site.URL), http.StatusCreated},
+		{"duplicate", fmt.Sprintf(`{"feed_url": "%s/feed.xml"}`, site.URL), http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := do(http.MethodPost, "/api/feeds", tt.body); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body: %s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	if len(store.subscriptions) != 1 {
+		t.Fatalf("subscriptions = %d, want 1", len(store.subscriptions))
+	}
+	subscription := store.subscriptions[0]
+	if subscription.Kind != "rss" || subscription.Title != "Example" || subscription.IntervalSeconds != 900 || subscription.MaxEntriesPerPoll != 20 {
+		t.Errorf("subscription = %+v", subscription)
+	}
+
+	if rec := do(http.MethodGet, "/api/feeds/"+subscription.ID, ""); rec.Code != http.StatusOK {
+		t.Errorf("GET status = %d, want 200", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/feeds/"+subscription.ID, ""); rec.Code != http.StatusOK {
+		t.Errorf("DELETE status = %d, want 200", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/feeds/"+subscription.ID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET after delete status = %d, want 404", rec.Code)
+	}
+}
+
+func TestPollFeeds(t *testing.T) {
+	site := newFeedServer(t)
+	store := &memoryFeedStore{entries: map[string]map[string]bool{}}
+	s := &Server{
+		scraper:   scraper.New(scraper.DefaultConfig(), nil, nil),
+		feeds:     store,
+		feedQueue: make(chan feedJob, 10),
+	}
+	feedURL := site.URL + "/feed.xml"
+	subscription := &models.FeedSubscription{ID: "feed-1", FeedURL: feedURL, Kind: "rss", IntervalSeconds: 60, MaxEntriesPerPoll: 2}
+	broken := &models.FeedSubscription{ID: "feed-2", FeedURL: site.URL + "/missing.xml", Kind: "rss", IntervalSeconds: 60}
+	store.subscriptions = []*models.FeedSubscription{subscription, broken}
+
+	drain := func() []string {
+		var urls []string
+		for len(s.feedQueue) > 0 {
+			job := <-s.feedQueue
+			if job.Entry.FeedURL != feedURL {
+				t.Errorf("entry feed URL = %q, want %q", job.Entry.FeedURL, feedURL)
+			}
+			s.finishFeedEntry(context.Background(), job, nil)
+			urls = append(urls, strings.TrimPrefix(job.Entry.URL, site.URL))
+		}
+		return urls
+	}
+
+	if err := s.PollFeeds(context.Background()); err != nil {
+		t.Fatalf("PollFeeds failed: %v", err)
+	}
+	if got := drain(); strings.Join(got, ",") != "/newest,/middle" {
+		t.Errorf("first poll queued %v, want the two newest entries", got)
+	}
+	if broken.LastPolledAt == nil || !strings.Contains(broken.LastError, "404") {
+		t.Errorf("broken subscription = %+v, want the poll error recorded", broken)
+	}
+
+	// Not due again until the interval passes
+	if err := s.PollFeeds(context.Background()); err != nil {
+		t.Fatalf("PollFeeds failed: %v", err)
+	}
+	if got := drain(); len(got) != 0 {
+		t.Errorf("poll before the interval queued %v", got)
+	}
+
+	subscription.LastPolledAt = nil
+	if err := s.PollFeeds(context.Background()); err != nil {
+		t.Fatalf("PollFeeds failed: %v", err)
+	}
+	if got := drain(); strings.Join(got, ",") != "/old" {
+		t.Errorf("second poll queued %v, want only the remaining entry", got)
+	}
+}
+
+func TestPollFeedsRetriesFailedEntries(t *testing.T) {
+	site := newFeedServer(t)
+	store := &memoryFeedStore{entries: map[string]map[string]bool{}}
+	s := &Server{
+		scraper:   scraper.New(scraper.DefaultConfig(), nil, nil),
+		feeds:     store,
+		feedQueue: make(chan feedJob, 10),
+	}
+	subscription := &models.FeedSubscription{ID: "feed-1", FeedURL: site.URL + "/feed.xml", Kind: "rss", IntervalSeconds: 60, MaxEntriesPerPoll: 1}
+	store.subscriptions = []*models.FeedSubscription{subscription}
+
+	poll := func() []feedJob {
+		t.Helper()
+		subscription.LastPolledAt = nil
+		if err := s.PollFeeds(context.Background()); err != nil {
+			t.Fatalf("PollFeeds failed: %v", err)
+		}
+		var jobs []feedJob
+		for len(s.feedQueue) > 0 {
+			jobs = append(jobs, <-s.feedQueue)
+		}
+		return jobs
+	}
+
+	jobs := poll()
+	if len(jobs) != 1 || !strings.HasSuffix(jobs[0].Entry.URL, "/newest") {
+		t.Fatalf("first poll queued %v, want the newest entry", jobs)
+	}
+	newest := jobs[0]
+
+	// Still pending, e.g. waiting in the queue, so a poll must not queue it twice
+	if jobs := poll(); len(jobs) != 1 || jobs[0].Entry.URL == newest.Entry.URL {
+		t.Fatalf("poll while pending queued %v, want the next entry instead", jobs)
+	} else {
+		s.finishFeedEntry(context.Background(), jobs[0], nil)
+	}
+
+	// A failed scrape leaves the entry for later polls, until it has failed too often
+	for attempt := 1; attempt <= db.MaxFeedEntryAttempts; attempt++ {
+		s.finishFeedEntry(context.Background(), newest, fmt.Errorf("HTTP error: 503"))
+		jobs := poll()
+		if attempt < db.MaxFeedEntryAttempts {
+			if len(jobs) != 1 || jobs[0].Entry.URL != newest.Entry.URL {
+				t.Fatalf("poll after %d failed scrapes queued %v, want the entry retried", attempt, jobs)
+			}
+			continue
+		}
+		if len(jobs) != 1 || strings.HasSuffix(jobs[0].Entry.URL, "/newest") {
+			t.Errorf("poll after %d failed scrapes queued %v, want the entry given up on", attempt, jobs)
+		}
+	}
+
+	// A scrape cut short by shutdown is not counted as a failure
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	old := feedJob{SubscriptionID: subscription.ID, Entry: feed.Entry{URL: site.URL + "/old"}}
+	s.finishFeedEntry(ctx, old, context.Canceled)
+	if store.failures[subscription.ID+" "+old.Entry.URL] != 0 || store.entries[subscription.ID][old.Entry.URL] {
+		t.Error("Expected an interrupted scrape to be left for the next poll")
+	}
+}
@@ -0,0 +1,16 @@
+package api
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var fetchRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "scraper_fetch_retries_total",
+	Help: "Page and image fetches retried after transient failures, by status code or \"network\"",
+}, []string{"reason"})
+
+// observeFetchRetry counts a retried page or image fetch
+func observeFetchRetry(reason string) {
+	fetchRetries.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/imaging"
+	"github.com/docutag/scraper/models"
+)
+
+// imageMetadataBatchSize is how many images the metadata backfill loads at a time
+const imageMetadataBatchSize = 100
+
+var imageMetadataScrubs = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "scraper_image_metadata_scrubs_total",
+	Help: "Stored images checked by the metadata backfill, by result (\"scrubbed\", \"unchanged\" or \"error\")",
+}, []string{"result"})
+
+// imageMetadataStore is the persistence for image metadata policies and retained EXIF
+type imageMetadataStore interface {
+	ListImagesForMetadataScrub(satisfying []string, afterID string, limit int) ([]*models.ImageInfo, error)
+	UpdateImageMetadata(img *models.ImageInfo) error
+	GetImageEXIF(id string) (*models.ImageInfo, error)
+}
+
+// satisfyingPolicies returns the names of the metadata policies at least as strict as policy
+func satisfyingPolicies(policy imaging.MetadataPolicy) []string {
+	var names []string
+	for _, p := range []imaging.MetadataPolicy{imaging.MetadataKeep, imaging.MetadataStripGPS, imaging.MetadataStripAll} {
+		if p.Covers(policy) {
+			names = append(names, string(p))
+		}
+	}
+	return names
+}
+
+// BackfillImageMetadata applies the configured metadata policy to images stored under a
+// weaker one, rewriting their stored bytes and redacting their EXIF
+// Images that fail are logged and skipped. Returns how many images were rewritten.
+func (s *Server) BackfillImageMetadata(ctx context.Context) (int, error) {
+	policy := s.scraper.Config().ImageMetadataPolicy
+	if imaging.MetadataKeep.Covers(policy) {
+		return 0, nil
+	}
+	satisfying := satisfyingPolicies(policy)
+
+	scrubbed, after := 0, ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return scrubbed, err
+		}
+		images, err := s.imageMetadata.ListImagesForMetadataScrub(satisfying, after, imageMetadataBatchSize)
+		if err != nil {
+			return scrubbed, err
+		}
+		if len(images) == 0 {
+			return scrubbed, nil
+		}
+
+		for _, img := range images {
+			after = img.ID
+			changed, err := s.scrubStoredImage(img)
+			switch {
+			case err != nil:
+				imageMetadataScrubs.WithLabelValues("error").Inc()
+				slog.Warn("failed to scrub image metadata", "image_id", img.ID, "error", err)
+			case changed:
+				scrubbed++
+				imageMetadataScrubs.WithLabelValues("scrubbed").Inc()
+			default:
+				imageMetadataScrubs.WithLabelValues("unchanged").Inc()
+			}
+		}
+	}
+}
+
+// scrubStoredImage applies the metadata policy to one stored image, replacing its stored
+// bytes if anything was removed, and records the policy
+func (s *Server) scrubStoredImage(img *models.ImageInfo) (bool, error) {
+	var data []byte
+	var err error
+	if img.FilePath != "" {
+		data, err = s.storage.ReadImage(img.FilePath)
+	} else {
+		data, err = base64.StdEncoding.DecodeString(img.Base64Data)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	scrubbed, err := s.scraper.ScrubImageMetadata(img, data)
+	if err != nil {
+		return false, err
+	}
+
+	changed := !bytes.Equal(scrubbed, data)
+	if changed {
+		if img.FilePath != "" {
+			contentType := img.ContentType
+			if contentType == "" {
+				contentType = http.DetectContentType(scrubbed)
+			}
+			if err := s.storage.ReplaceImage(img.FilePath, scrubbed, contentType); err != nil {
+				return false, err
+			}
+		} else {
+			img.Base64Data = base64.StdEncoding.EncodeToString(scrubbed)
+		}
+	}
+
+	if err := s.imageMetadata.UpdateImageMetadata(img); err != nil {
+		return false, err
+	}
+	return changed, nil
+}
+
+// scrubServedImage removes metadata from an image stored under a weaker policy than the
+// configured one, so images the backfill has not reached are never served with it
+func (s *Server) scrubServedImage(image *models.ImageInfo, data []byte) ([]byte, error) {
+	policy := s.scraper.Config().ImageMetadataPolicy
+	if imaging.MetadataPolicy(image.MetadataPolicy).Covers(policy) {
+		return data, nil
+	}
+	return imaging.StripMetadata(data, policy)
+}
+
+// redactServedImage redacts the EXIF, and legacy base64 bytes, of an image stored under a
+// weaker metadata policy than the configured one, so images the backfill has not reached
+// never publish what the policy removes
+func (s *Server) redactServedImage(img *models.ImageInfo) {
+	policy := s.scraper.Config().ImageMetadataPolicy
+	if imaging.MetadataPolicy(img.MetadataPolicy).Covers(policy) {
+		return
+	}
+	img.EXIF = scraper.RedactEXIF(img.EXIF, policy)
+	if img.Base64Data == "" {
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(img.Base64Data)
+	if err == nil {
+		data, err = s.scrubServedImage(img, data)
+	}
+	if err != nil {
+		slog.Warn("failed to scrub served image metadata, omitting image data", "image_id", img.ID, "error", err)
+		img.Base64Data = ""
+		return
+	}
+	img.Base64Data = base64.StdEncoding.EncodeToString(data)
+}
+
+// redactServedImages applies redactServedImage to each image
+func (s *Server) redactServedImages(images []models.ImageInfo) {
+	for i := range images {
+		s.redactServedImage(&images[i])
+	}
+}
+
+// ImageEXIFResponse is an image's EXIF as served to admins
+type ImageEXIFResponse struct {
+	ImageID        string           `json:"image_id"`
+	MetadataPolicy string           `json:"metadata_policy,omitempty"`
+	EXIF           *models.EXIFData `json:"exif,omitempty"`          // What the stored image still carries
+	RetainedEXIF   *models.EXIFData `json:"retained_exif,omitempty"` // Full EXIF, when retained
+}
+
+// handleAdminImage handles /api/admin/images/{id}/exif and /api/admin/images/{id}/safety
+func (s *Server) handleAdminImage(w http.ResponseWriter, r *http.Request) {
+	id, resource, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/admin/images/"), "/")
+	switch {
+	case id == "":
+		respondError(w, http.StatusNotFound, "not found")
+	case resource == "exif":
+		s.handleAdminImageEXIF(w, r, id)
+	case resource == "safety":
+		s.handleAdminImageSafety(w, r, id)
+	default:
+		respondError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// handleAdminImageEXIF handles GET /api/admin/images/{id}/exif
+func (s *Server) handleAdminImageEXIF(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	img, err := s.imageMetadata.GetImageEXIF(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	if img == nil {
+		respondError(w, http.StatusNotFound, "image not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ImageEXIFResponse{
+		ImageID:        img.ID,
+		MetadataPolicy: img.MetadataPolicy,
+		EXIF:           img.EXIF,
+		RetainedEXIF:   img.PrivateEXIF,
+	})
+}
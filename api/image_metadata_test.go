@@ -0,0 +1,229 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/imaging"
+	"github.com/docutag/scraper/models"
+)
+
+// memoryImageMetadataStore is an in-memory imageMetadataStore
+type memoryImageMetadataStore struct {
+	images map[string]*models.ImageInfo
+}
+
+func (m *memoryImageMetadataStore) ListImagesForMetadataScrub(satisfying []string, afterID string, limit int) ([]*models.ImageInfo, error) {
+	var ids []string
+	for id, img := range m.images {
+		if id > afterID && !slices.Contains(satisfying, img.MetadataPolicy) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	var images []*models.ImageInfo
+	for _, id := range ids[:min(limit, len(ids))] {
+		img := *m.images[id]
+		images = append(images, &img)
+	}
+	return images, nil
+}
+
+func (m *memoryImageMetadataStore) UpdateImageMetadata(img *models.ImageInfo) error {
+	m.images[img.ID] = img
+	return nil
+}
+
+func (m *memoryImageMetadataStore) GetImageEXIF(id string) (*models.ImageInfo, error) {
+	return m.images[id], nil
+}
+
+// jpegWithXMP returns a small JPEG carrying an XMP packet
+func jpegWithXMP(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	xmp := []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>exif:GPSLatitude 51,30N</x:xmpmeta>")
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(xmp)+2))
+	data = append(data, xmp...)
+	return append(data, buf.Bytes()[2:]...)
+}
+
+func newMetadataTestServer(policy imaging.MetadataPolicy, retain bool) (*Server, *memoryStorage, *memoryImageMetadataStore) {
+	cfg := scraper.DefaultConfig()
+	cfg.ImageMetadataPolicy = policy
+	cfg.RetainImageMetadata = retain
+	store := &memoryStorage{objects: map[string][]byte{}}
+	images := &memoryImageMetadataStore{images: map[string]*models.ImageInfo{}}
+	return &Server{scraper: scraper.New(cfg, nil, nil), storage: store, imageMetadata: images}, store, images
+}
+
+func TestBackfillImageMetadata(t *testing.T) {
+	s, store, images := newMetadataTestServer(imaging.MetadataStripGPS, true)
+	withXMP := jpegWithXMP(t)
+	gps := &models.GPSData{Latitude: 51.5, Longitude: -0.12}
+
+	store.objects["images/a.jpg"] = withXMP
+	store.objects["images/b.jpg"] = withXMP
+	images.images["a"] = &models.ImageInfo{ID: "a", FilePath: "images/a.jpg", ContentType: "image/jpeg", EXIF: &models.EXIFData{Make: "Canon", GPS: gps}}
+	images.images["b"] = &models.ImageInfo{ID: "b", FilePath: "images/b.jpg", MetadataPolicy: "strip-all"}
+	images.images["c"] = &models.ImageInfo{ID: "c", Base64Data: base64.StdEncoding.EncodeToString(withXMP)}
+	images.images["d"] = &models.ImageInfo{ID: "d", FilePath: "images/missing.jpg"}
+
+	scrubbed, err := s.BackfillImageMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("BackfillImageMetadata failed: %v", err)
+	}
+	if scrubbed != 2 {
+		t.Errorf("scrubbed = %d, want 2", scrubbed)
+	}
+
+	if bytes.Contains(store.objects["images/a.jpg"], []byte("xmpmeta")) {
+		t.Error("stored image a still carries XMP")
+	}
+	if !bytes.Equal(store.objects["images/b.jpg"], withXMP) {
+		t.Error("image b was already stored under a stricter policy and should be untouched")
+	}
+	a := images.images["a"]
+	if a.MetadataPolicy != "strip-gps" || a.EXIF.GPS != nil || a.EXIF.Make != "Canon" || a.PrivateEXIF == nil || a.PrivateEXIF.GPS == nil {
+		t.Errorf("image a = %+v, want GPS moved to the retained EXIF", a)
+	}
+	if a.FileSizeBytes != int64(len(store.objects["images/a.jpg"])) {
+		t.Errorf("image a size = %d, stored %d", a.FileSizeBytes, len(store.objects["images/a.jpg"]))
+	}
+	if c, _ := base64.StdEncoding.DecodeString(images.images["c"].Base64Data); bytes.Contains(c, []byte("xmpmeta")) {
+		t.Error("base64 image c still carries XMP")
+	}
+	if images.images["d"].MetadataPolicy != "" {
+		t.Error("unreadable image d should be left for a later backfill")
+	}
+
+	// A second run finds only the unreadable image
+	if scrubbed, err := s.BackfillImageMetadata(context.Background()); err != nil || scrubbed != 0 {
+		t.Errorf("second run scrubbed %d, %v; want 0", scrubbed, err)
+	}
+	if store.replaces != 1 {
+		t.Errorf("replaced %d stored images, want 1", store.replaces)
+	}
+}
+
+// memoryScrapeStore is an in-memory scrapeStore
+type memoryScrapeStore map[string]*models.ScrapedData
+
+func (m memoryScrapeStore) GetByID(id string) (*models.ScrapedData, error) {
+	data, ok := m[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *data
+	copied.Images = slices.Clone(data.Images)
+	return &copied, nil
+}
+
+func TestGetDataAfterBackfillImageMetadata(t *testing.T) {
+	s, store, images := newMetadataTestServer(imaging.MetadataStripGPS, true)
+	withXMP := jpegWithXMP(t)
+	gps := &models.GPSData{Latitude: 51.5, Longitude: -0.12}
+
+	// A scrape saved before the metadata policy, its images embedded with their full EXIF
+	store.objects["images/a.jpg"] = withXMP
+	images.images["a"] = &models.ImageInfo{ID: "a", FilePath: "images/a.jpg", ContentType: "image/jpeg", EXIF: &models.EXIFData{Make: "Canon", GPS: gps}}
+	s.scrapes = memoryScrapeStore{"scrape-1": {
+		ID:  "scrape-1",
+		URL: "https://example.com/article",
+		Images: []models.ImageInfo{
+			{ID: "a", FilePath: "images/a.jpg", EXIF: &models.EXIFData{Make: "Canon", GPS: gps}},
+			{ID: "b", Base64Data: base64.StdEncoding.EncodeToString(withXMP), EXIF: &models.EXIFData{Make: "Nikon", GPS: gps}},
+		},
+	}}
+
+	if _, err := s.BackfillImageMetadata(context.Background()); err != nil {
+		t.Fatalf("BackfillImageMetadata failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleData(rec, httptest.NewRequest(http.MethodGet, "/api/data/scrape-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); strings.Contains(body, "latitude") || strings.Contains(body, `"gps"`) {
+		t.Errorf("GET /api/data/{id} still serves GPS: %s", body)
+	}
+	var data models.ScrapedData
+	if err := json.NewDecoder(rec.Body).Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Images) != 2 || data.Images[0].EXIF == nil || data.Images[0].EXIF.Make != "Canon" {
+		t.Fatalf("images = %+v, want the EXIF kept apart from GPS", data.Images)
+	}
+	if b, _ := base64.StdEncoding.DecodeString(data.Images[1].Base64Data); len(b) == 0 || bytes.Contains(b, []byte("xmpmeta")) {
+		t.Error("embedded base64 image was served with its XMP")
+	}
+}
+
+func TestScrubServedImage(t *testing.T) {
+	s, _, _ := newMetadataTestServer(imaging.MetadataStripGPS, false)
+	withXMP := jpegWithXMP(t)
+
+	served, err := s.scrubServedImage(&models.ImageInfo{}, withXMP)
+	if err != nil || bytes.Contains(served, []byte("xmpmeta")) {
+		t.Errorf("image stored before the policy was served with its XMP (err %v)", err)
+	}
+	served, err = s.scrubServedImage(&models.ImageInfo{MetadataPolicy: "strip-gps"}, withXMP)
+	if err != nil || !bytes.Equal(served, withXMP) {
+		t.Errorf("image already scrubbed was stripped again (err %v)", err)
+	}
+}
+
+func TestHandleAdminImageEXIF(t *testing.T) {
+	s, _, images := newMetadataTestServer(imaging.MetadataStripGPS, true)
+	images.images["a"] = &models.ImageInfo{
+		ID:             "a",
+		MetadataPolicy: "strip-gps",
+		EXIF:           &models.EXIFData{Make: "Canon"},
+		PrivateEXIF:    &models.EXIFData{Make: "Canon", GPS: &models.GPSData{Latitude: 51.5}},
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleAdminImage(rec, httptest.NewRequest(http.MethodGet, "/api/admin/images/a/exif", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	var resp ImageEXIFResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.RetainedEXIF == nil || resp.RetainedEXIF.GPS == nil || resp.EXIF.GPS != nil || resp.MetadataPolicy != "strip-gps" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	for path, want := range map[string]int{"/api/admin/images/missing/exif": http.StatusNotFound, "/api/admin/images/a": http.StatusNotFound} {
+		rec := httptest.NewRecorder()
+		s.handleAdminImage(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+
+	// Admin endpoints need the admin scope
+	if scope := requiredScope(httptest.NewRequest(http.MethodGet, "/api/admin/images/a/exif", nil)); scope != ScopeAdmin {
+		t.Errorf("required scope = %q, want %q", scope, ScopeAdmin)
+	}
+	if b, _ := json.Marshal(models.ImageInfo{PrivateEXIF: &models.EXIFData{Make: "Canon"}}); bytes.Contains(b, []byte("Canon")) {
+		t.Error("retained EXIF must never be serialized with an image")
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/imaging"
+	"github.com/docutag/scraper/models"
+)
+
+var blurredImages = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "scraper_images_blurred_total",
+	Help: "Flagged images served blurred, by result (\"served\" or \"error\")",
+}, []string{"result"})
+
+// imageSafetyStore is the persistence for image safety classifications
+type imageSafetyStore interface {
+	SetImageSafetyFlagged(id string, flagged bool) error
+}
+
+// blurFlagged reports whether image is served blurred: it was flagged as unsafe and the
+// safety action is scraper.SafetyBlur
+func (s *Server) blurFlagged(image *models.ImageInfo) bool {
+	return image.Safety != nil && image.Safety.Flagged && s.scraper.Config().ImageSafetyAction == scraper.SafetyBlur
+}
+
+// excludeFlagged reports whether images flagged as unsafe are left out of tag search
+func (s *Server) excludeFlagged() bool {
+	return s.scraper.Config().ImageSafetyAction == scraper.SafetyExclude
+}
+
+// imageNotModified sets the caching headers for an image response, and writes 304 Not
+// Modified if the client's copy is current
+// Without safety classification stored images never change and are cached for a year. With
+// it an admin can flag or clear an image at any time, changing whether it is blurred, so
+// caches revalidate every response against an ETag of the image's safety state.
+func (s *Server) imageNotModified(w http.ResponseWriter, r *http.Request, image *models.ImageInfo) bool {
+	action := s.scraper.Config().ImageSafetyAction
+	if !action.Enabled() {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		return false
+	}
+
+	state := "clear"
+	if image.Safety != nil && image.Safety.Flagged {
+		state = "flagged"
+	}
+	etag := fmt.Sprintf(`"%s-%s-%s"`, image.ID, action, state)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, no-cache")
+	if strings.Contains(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// serveBlurredImage serves a blurred copy of a flagged image at the requested width and
+// format, JPEG if none was asked for
+// Blurred copies are generated on every request rather than stored.
+func (s *Server) serveBlurredImage(w http.ResponseWriter, image *models.ImageInfo, req derivativeRequest) {
+	original, err := s.storage.ReadImage(image.FilePath)
+	if err != nil {
+		blurredImages.WithLabelValues("error").Inc()
+		slog.Error("failed to read image file", "file_path", image.FilePath, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to read image file")
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = imaging.FormatJPEG
+	}
+	blurred, err := imaging.Blur(original, req.Width, format)
+	if err != nil {
+		// Never fall back to the original
+		blurredImages.WithLabelValues("error").Inc()
+		slog.Warn("failed to blur image", "image_id", image.ID, "error", err)
+		respondError(w, http.StatusUnprocessableEntity, "image cannot be blurred")
+		return
+	}
+
+	blurredImages.WithLabelValues("served").Inc()
+	w.Header().Set("Content-Type", blurred.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(blurred.Data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(blurred.Data)
+}
+
+// ImageSafetyRequest overrides the safety classifier's verdict on an image
+type ImageSafetyRequest struct {
+	Flagged *bool `json:"flagged"`
+}
+
+// handleAdminImageSafety handles PUT /api/admin/images/{id}/safety, which flags or
+// clears an image whose classification was wrong
+// Clearing the flag does not untombstone an image tombstoned for it.
+func (s *Server) handleAdminImageSafety(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPut {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req ImageSafetyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Flagged == nil {
+		respondError(w, http.StatusBadRequest, "flagged is required")
+		return
+	}
+
+	if err := s.imageSafety.SetImageSafetyFlagged(id, *req.Flagged); err != nil {
+		if strings.Contains(err.Error(), "no image found") {
+			respondError(w, http.StatusNotFound, "image not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to update image safety")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"image_id": id,
+		"flagged":  *req.Flagged,
+	})
+}
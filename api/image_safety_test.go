@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/models"
+)
+
+// memoryImageSafetyStore is an in-memory imageSafetyStore
+type memoryImageSafetyStore struct {
+	flagged map[string]bool
+}
+
+func (m *memoryImageSafetyStore) SetImageSafetyFlagged(id string, flagged bool) error {
+	if _, ok := m.flagged[id]; !ok {
+		return fmt.Errorf("no image found with id: %s", id)
+	}
+	m.flagged[id] = flagged
+	return nil
+}
+
+func newSafetyTestServer(action scraper.SafetyAction) *Server {
+	cfg := scraper.DefaultConfig()
+	cfg.ImageSafetyAction = action
+	return &Server{scraper: scraper.New(cfg, nil, nil)}
+}
+
+func TestSafetyActions(t *testing.T) {
+	flagged := &models.ImageInfo{Safety: &models.ImageSafety{Flagged: true}}
+	unflagged := &models.ImageInfo{Safety: &models.ImageSafety{Categories: map[string]float64{"violence": 0.2}}}
+
+	blur := newSafetyTestServer(scraper.SafetyBlur)
+	if !blur.blurFlagged(flagged) || blur.blurFlagged(unflagged) || blur.blurFlagged(&models.ImageInfo{}) {
+		t.Error("blur action should blur only flagged images")
+	}
+	if blur.excludeFlagged() {
+		t.Error("blur action should not exclude flagged images from search")
+	}
+
+	exclude := newSafetyTestServer(scraper.SafetyExclude)
+	if !exclude.excludeFlagged() || exclude.blurFlagged(flagged) {
+		t.Error("exclude action should exclude flagged images from search without blurring them")
+	}
+}
+
+func TestServeBlurredImage(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 640, 320))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i)
+	}
+	var original bytes.Buffer
+	if err := png.Encode(&original, src); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newSafetyTestServer(scraper.SafetyBlur)
+	s.storage = &memoryStorage{objects: map[string][]byte{"images/a.png": original.Bytes(), "images/broken.png": []byte("not an image")}}
+
+	rec := httptest.NewRecorder()
+	s.serveBlurredImage(rec, &models.ImageInfo{ID: "a", FilePath: "images/a.png"}, derivativeRequest{Width: 320})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Content-Type = %q, want image/jpeg", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); strings.Contains(cc, "immutable") {
+		t.Errorf("Cache-Control = %q, blurred images must not be cached as immutable", cc)
+	}
+	if cfg, _, err := image.DecodeConfig(rec.Body); err != nil || cfg.Width != 320 {
+		t.Errorf("served image = %+v, %v; want 320 wide", cfg, err)
+	}
+
+	// The original is never served in place of a blurred copy
+	rec = httptest.NewRecorder()
+	s.serveBlurredImage(rec, &models.ImageInfo{ID: "b", FilePath: "images/broken.png"}, derivativeRequest{})
+	if rec.Code != http.StatusUnprocessableEntity || strings.Contains(rec.Body.String(), "not an image") {
+		t.Errorf("broken image status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestImageNotModified(t *testing.T) {
+	image := &models.ImageInfo{ID: "a", Safety: &models.ImageSafety{}}
+	request := func(s *Server, etag string) (*httptest.ResponseRecorder, bool) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/images/a", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		return rec, s.imageNotModified(rec, req, image)
+	}
+
+	// Without classification images never change
+	rec, _ := request(newSafetyTestServer(scraper.SafetyOff), "")
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=31536000, immutable" || rec.Header().Get("ETag") != "" {
+		t.Errorf("unclassified: Cache-Control = %q, ETag = %q", cc, rec.Header().Get("ETag"))
+	}
+
+	// With it, caches revalidate and an admin's verdict changes the ETag
+	s := newSafetyTestServer(scraper.SafetyBlur)
+	rec, notModified := request(s, "")
+	etag := rec.Header().Get("ETag")
+	if notModified || etag == "" || strings.Contains(rec.Header().Get("Cache-Control"), "max-age") {
+		t.Fatalf("classified: not modified = %v, ETag = %q, Cache-Control = %q", notModified, etag, rec.Header().Get("Cache-Control"))
+	}
+	if rec, notModified := request(s, etag); !notModified || rec.Code != http.StatusNotModified {
+		t.Errorf("revalidation: not modified = %v, status %d; want 304", notModified, rec.Code)
+	}
+	image.Safety.Flagged = true
+	if rec, notModified := request(s, etag); notModified || rec.Header().Get("ETag") == etag {
+		t.Error("flagging the image should invalidate cached copies")
+	}
+}
+
+func TestHandleAdminImageSafety(t *testing.T) {
+	store := &memoryImageSafetyStore{flagged: map[string]bool{"a": true}}
+	s := &Server{imageSafety: store}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"clear flag", http.MethodPut, "/api/admin/images/a/safety", `{"flagged": false}`, http.StatusOK},
+		{"missing flag", http.MethodPut, "/api/admin/images/a/safety", `{}`, http.StatusBadRequest},
+		{"missing image", http.MethodPut, "/api/admin/images/missing/safety", `{"flagged": true}`, http.StatusNotFound},
+		{"wrong method", http.MethodGet, "/api/admin/images/a/safety", "", http.StatusMethodNotAllowed},
+		{"unknown resource", http.MethodPut, "/api/admin/images/a/tags", `{"flagged": true}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleAdminImage(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	if store.flagged["a"] {
+		t.Error("flag was not cleared")
+	}
+}
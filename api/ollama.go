@@ -0,0 +1,93 @@
+package api
+
+import (
+	"time"
+
+	"github.com/docutag/scraper/ollama"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ollamaHealthTimeout bounds the Ollama check performed by /health
+const ollamaHealthTimeout = 3 * time.Second
+
+var (
+	ollamaCircuitState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "scraper_ollama_circuit_state",
+		Help: "Ollama circuit breaker state per endpoint (0=closed, 1=half-open, 2=open)",
+	}, []string{"endpoint"})
+	ollamaCircuitFailures = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "scraper_ollama_circuit_consecutive_failures",
+		Help: "Consecutive Ollama request failures counted by each endpoint's circuit breaker",
+	}, []string{"endpoint"})
+	ollamaCircuitRejected = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "scraper_ollama_circuit_rejected_requests",
+		Help: "Ollama requests skipped by each endpoint's circuit breaker since startup",
+	}, []string{"endpoint"})
+	ollamaInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "scraper_ollama_in_flight_requests",
+		Help: "Ollama requests currently in flight per endpoint",
+	}, []string{"endpoint"})
+	ollamaModelAvailable = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "scraper_ollama_model_available",
+		Help: "Whether a configured Ollama model is installed on an endpoint (1) or missing (0)",
+	}, []string{"endpoint", "model"})
+	ollamaRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scraper_ollama_request_duration_seconds",
+		Help:    "Duration of Ollama request attempts per pool and endpoint",
+		Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"pool", "endpoint"})
+	ollamaRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scraper_ollama_request_errors_total",
+		Help: "Failed Ollama request attempts (transport errors and 5xx) per pool and endpoint",
+	}, []string{"pool", "endpoint"})
+)
+
+// observeOllamaRequest records latency and errors for a single Ollama request attempt
+func observeOllamaRequest(pool, endpoint string, duration time.Duration, err error) {
+	ollamaRequestDuration.WithLabelValues(pool, endpoint).Observe(duration.Seconds())
+	if err != nil {
+		ollamaRequestErrors.WithLabelValues(pool, endpoint).Inc()
+	}
+}
+
+// UpdateOllamaMetrics updates Prometheus gauges for each Ollama endpoint
+func (s *Server) UpdateOllamaMetrics() {
+	for _, ep := range s.scraper.OllamaClient().Endpoints() {
+		ollamaCircuitState.WithLabelValues(ep.BaseURL).Set(float64(ep.Circuit.State))
+		ollamaCircuitFailures.WithLabelValues(ep.BaseURL).Set(float64(ep.Circuit.ConsecutiveFailures))
+		ollamaCircuitRejected.WithLabelValues(ep.BaseURL).Set(float64(ep.Circuit.Rejected))
+		ollamaInFlight.WithLabelValues(ep.BaseURL).Set(float64(ep.InFlight))
+	}
+}
+
+// recordOllamaHealth publishes per-endpoint model availability from a health or startup check
+func (s *Server) recordOllamaHealth(health ollama.HealthStatus) {
+	s.UpdateOllamaMetrics()
+	for _, ep := range health.Endpoints {
+		if !ep.Reachable {
+			continue
+		}
+		for _, pool := range ep.Pools {
+			model := health.TextModel
+			if pool == ollama.PoolVision {
+				model = health.VisionModel
+			}
+			missing := false
+			for _, m := range ep.MissingModels {
+				if m == model {
+					missing = true
+				}
+			}
+			ollamaModelAvailable.WithLabelValues(ep.BaseURL, model).Set(boolToFloat(!missing))
+		}
+	}
+}
+
+// boolToFloat converts a bool to a 0/1 gauge value
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/docutag/scraper/db"
+	"github.com/docutag/scraper/rules"
+)
+
+// ruleSetName is the name the active rule set is stored under in the database
+const ruleSetName = "default"
+
+// maxRuleSetSize limits the size of uploaded rule set documents
+const maxRuleSetSize = 1 << 20
+
+// dbRuleSource loads and stores the rule set in the database
+// Until a rule set is saved, the built-in rules are served
+type dbRuleSource struct {
+	db *db.DB
+}
+
+func (s dbRuleSource) Load() ([]byte, string, error) {
+	doc, updatedAt, err := s.db.GetRuleSetDocument(ruleSetName)
+	if err != nil {
+		return nil, "", err
+	}
+	if doc == "" {
+		return rules.DefaultDocument(), "builtin", nil
+	}
+	return []byte(doc), updatedAt.UTC().Format(time.RFC3339Nano), nil
+}
+
+func (s dbRuleSource) Save(doc []byte) error {
+	return s.db.SaveRuleSetDocument(ruleSetName, string(doc))
+}
+
+func (s dbRuleSource) String() string {
+	return "db"
+}
+
+// loadRules creates the rule engine for a source: "builtin", "db", or a YAML/JSON file path
+func loadRules(source string, database *db.DB) (*rules.Engine, error) {
+	switch source {
+	case "", "builtin":
+		return rules.NewEngine(rules.Default()), nil
+	case "db":
+		return rules.Load(dbRuleSource{db: database})
+	default:
+		return rules.Load(rules.FileSource{Path: source})
+	}
+}
+
+// RuleTestRequest represents a request to test a URL against the rules
+type RuleTestRequest struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+}
+
+// RuleTestResponse explains which rules fire for a URL on each target
+type RuleTestResponse struct {
+	URL     string         `json:"url"`
+	Version string         `json:"version"`
+	Results []rules.Result `json:"results"`
+}
+
+// handleRules handles reading (GET) and replacing (PUT) the active rule set
+func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
+	engine := s.scraper.Rules()
+
+	switch r.Method {
+	case http.MethodGet:
+		respondJSON(w, http.StatusOK, engine.Status())
+	case http.MethodPut:
+		doc, err := io.ReadAll(io.LimitReader(r.Body, maxRuleSetSize+1))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+		if len(doc) > maxRuleSetSize {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("rule set exceeds %d bytes", maxRuleSetSize))
+			return
+		}
+
+		if err := engine.Update(doc); err != nil {
+			if errors.Is(err, rules.ErrReadOnly) {
+				respondError(w, http.StatusConflict, fmt.Sprintf("rules are loaded from %s and cannot be changed through the API", engine.Status().Source))
+				return
+			}
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		status := engine.Status()
+		slog.Info("updated rules", "source", status.Source, "version", status.Version, "rules", len(status.Rules.Rules))
+		respondJSON(w, http.StatusOK, status)
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleRulesTest evaluates a URL against every rule target and explains the result
+func (s *Server) handleRulesTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req RuleTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	parsed, err := url.Parse(req.URL)
+	if req.URL == "" || err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		respondError(w, http.StatusBadRequest, "a valid http or https url is required")
+		return
+	}
+
+	status := s.scraper.Rules().Status()
+	resp := RuleTestResponse{
+		URL:     req.URL,
+		Version: status.Version,
+		Results: make([]rules.Result, 0, len(rules.Targets)),
+	}
+	for _, target := range rules.Targets {
+		resp.Results = append(resp.Results, status.Rules.Evaluate(target, req.URL, req.Title))
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/rules"
+)
+
+// memoryRuleSource is a writable in-memory rules.Source
+type memoryRuleSource struct {
+	doc     []byte
+	version int
+}
+
+func (m *memoryRuleSource) Load() ([]byte, string, error) {
+	return m.doc, strconv.Itoa(m.version), nil
+}
+
+func (m *memoryRuleSource) Save(doc []byte) error {
+	m.doc = doc
+	m.version++
+	return nil
+}
+
+func (m *memoryRuleSource) String() string { return "memory" }
+
+func newRulesTestServer(t *testing.T, engine *rules.Engine) *Server {
+	t.Helper()
+	config := scraper.DefaultConfig()
+	config.Rules = engine
+	return &Server{scraper: scraper.New(config, nil, nil)}
+}
+
+func TestHandleRulesGet(t *testing.T) {
+	s := newRulesTestServer(t, rules.NewEngine(rules.Default()))
+
+	rec := httptest.NewRecorder()
+	s.handleRules(rec, httptest.NewRequest(http.MethodGet, "/api/rules", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var status rules.Status
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if status.Source != "builtin" || status.Writable || len(status.Rules.Rules) == 0 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestHandleRulesPut(t *testing.T) {
+	t.Run("read-only source", func(t *testing.T) {
+		s := newRulesTestServer(t, rules.NewEngine(rules.Default()))
+		rec := httptest.NewRecorder()
+		s.handleRules(rec, httptest.NewRequest(http.MethodPut, "/api/rules", strings.NewReader("rules: []")))
+		if rec.Code != http.StatusConflict {
+			t.Errorf("status = %d, want 409", rec.Code)
+		}
+	})
+
+	engine, err := rules.Load(&memoryRuleSource{doc: rules.DefaultDocument()})
+	if err != nil {
+		t.Fatalf("rules.Load failed: %v", err)
+	}
+	s := newRulesTestServer(t, engine)
+
+	t.Run("invalid document", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		body := `{"rules": [{"name": "a", "targets": ["links"], "action": "explode", "match": {"keywords": ["x"]}}]}`
+		s.handleRules(rec, httptest.NewRequest(http.MethodPut, "/api/rules", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "invalid action") {
+			t.Errorf("expected validation error, got: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("valid document", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		body := "rules:\n  - {name: drafts, targets: [links], action: block, match: {paths: ['/drafts/**']}}\n"
+		s.handleRules(rec, httptest.NewRequest(http.MethodPut, "/api/rules", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+		}
+		if !engine.Rules().Blocked(rules.TargetLinks, "https://example.com/drafts/post") {
+			t.Error("expected updated rules to be active")
+		}
+	})
+}
+
+func TestHandleRulesTest(t *testing.T) {
+	s := newRulesTestServer(t, rules.NewEngine(rules.Default()))
+
+	t.Run("explains matches", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		body := `{"url": "https://example.com/about/team"}`
+		s.handleRulesTest(rec, httptest.NewRequest(http.MethodPost, "/api/rules/test", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+
+		var resp RuleTestResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Results) != len(rules.Targets) {
+			t.Fatalf("expected a result per target, got %d", len(resp.Results))
+		}
+
+		links := resp.Results[0]
+		if links.Target != rules.TargetLinks || !links.Blocked {
+			t.Errorf("expected links target to be blocked, got %+v", links)
+		}
+		if len(links.Hits) == 0 || links.Hits[0].Rule != "about-page" || links.Hits[0].Condition != "segment about" {
+			t.Errorf("unexpected explanation: %+v", links.Hits)
+		}
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.handleRulesTest(rec, httptest.NewRequest(http.MethodPost, "/api/rules/test", strings.NewReader(`{"url": "ftp://example.com"}`)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.handleRulesTest(rec, httptest.NewRequest(http.MethodGet, "/api/rules/test", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want 405", rec.Code)
+		}
+	})
+}
@@ -13,7 +13,10 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,32 +27,63 @@ import (
 	"github.com/docutag/platform/pkg/tracing"
 	"github.com/docutag/scraper"
 	"github.com/docutag/scraper/db"
+	"github.com/docutag/scraper/lang"
 	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/ollama"
 	"github.com/docutag/scraper/pkg/logging"
+	"github.com/docutag/scraper/rules"
 	"github.com/docutag/scraper/slug"
 	"github.com/docutag/scraper/storage"
 	"go.opentelemetry.io/otel/attribute"
 )
 
+// scrapeTimeout bounds a single scrape, including image analysis
+const scrapeTimeout = 10 * time.Minute
+
 // Server represents the API server
 type Server struct {
-	db              *db.DB
-	scraper         *scraper.Scraper
-	storage         storage.StorageInterface
-	addr            string
-	server          *http.Server
-	mux             *http.ServeMux
-	corsEnabled     bool
-	businessMetrics *metrics.BusinessMetrics
+	db               *db.DB
+	scraper          *scraper.Scraper
+	storage          storage.StorageInterface
+	addr             string
+	server           *http.Server
+	mux              *http.ServeMux
+	corsEnabled      bool
+	businessMetrics  *metrics.BusinessMetrics
+	adminKey         string             // Bootstrap admin API key from configuration
+	keys             apiKeyStore        // API key lookup and usage accounting
+	rateLimiter      *rateLimiter       // Per-key request rate limits
+	policies         domainPolicyStore  // Domain policy persistence
+	credentials      credentialStore    // Encrypted site credentials
+	feeds            feedStore          // Feed subscriptions and the entries they have queued
+	scrapes          scrapeStore        // Saved scrapes served by GET /api/data/{id}
+	feedQueue        chan feedJob       // Feed entries waiting for a feed worker
+	feedPending      sync.Map           // Keys of feed entries queued and not yet scraped
+	labels           scoreLabelStore    // Human score labels for calibration
+	scoreAdjustments bool               // Apply per-domain score adjustments learned from labels
+	derivatives      derivativeStore    // Generated image derivatives
+	derivativeWidths []int              // Widths image derivatives are generated at, ascending
+	imageMetadata    imageMetadataStore // Image metadata policies and retained EXIF
+	imageSafety      imageSafetyStore   // Image safety classification overrides
+}
+
+// scrapeStore reads saved scrapes
+type scrapeStore interface {
+	GetByID(id string) (*models.ScrapedData, error)
 }
 
 // Config contains server configuration
 type Config struct {
-	Addr          string
-	DBConfig      db.Config
-	S3Config      storage.S3Config
-	ScraperConfig scraper.Config
-	CORSEnabled   bool
+	Addr             string
+	DBConfig         db.Config
+	S3Config         storage.S3Config
+	ScraperConfig    scraper.Config
+	CORSEnabled      bool
+	AuthEnabled      bool   // Require API keys on /api endpoints
+	AdminKey         string // Bootstrap key with admin scope, used to issue the first stored keys
+	RulesSource      string // Link rules source: "builtin", "db", or a YAML/JSON file path
+	ScoreAdjustments bool   // Apply per-domain score adjustments learned from labels
+	DerivativeWidths []int  // Widths image derivatives are generated at (default 320, 640, 1280)
 }
 
 // NewServer creates a new API server
@@ -67,6 +101,15 @@ func NewServer(config Config) (*Server, error) {
 		return nil, fmt.Errorf("failed to initialize S3 storage: %w", err)
 	}
 
+	// Load link filtering and scoring rules
+	if config.ScraperConfig.Rules == nil {
+		ruleEngine, err := loadRules(config.RulesSource, database)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load rules: %w", err)
+		}
+		config.ScraperConfig.Rules = ruleEngine
+	}
+
 	// Initialize scraper with database and storage
 	scraperInstance := scraper.New(config.ScraperConfig, database, storageInstance)
 
@@ -74,14 +117,45 @@ func NewServer(config Config) (*Server, error) {
 	businessMetrics := metrics.NewBusinessMetrics("scraper")
 
 	s := &Server{
-		db:              database,
-		scraper:         scraperInstance,
-		storage:         storageInstance,
-		addr:            config.Addr,
-		mux:             http.NewServeMux(),
-		corsEnabled:     config.CORSEnabled,
-		businessMetrics: businessMetrics,
-	}
+		db:               database,
+		scraper:          scraperInstance,
+		storage:          storageInstance,
+		addr:             config.Addr,
+		mux:              http.NewServeMux(),
+		corsEnabled:      config.CORSEnabled,
+		businessMetrics:  businessMetrics,
+		adminKey:         config.AdminKey,
+		keys:             database,
+		rateLimiter:      newRateLimiter(),
+		policies:         database,
+		credentials:      database,
+		feeds:            database,
+		scrapes:          database,
+		feedQueue:        make(chan feedJob, feedQueueSize),
+		labels:           database,
+		scoreAdjustments: config.ScoreAdjustments,
+		derivatives:      database,
+		derivativeWidths: derivativeWidths(config.DerivativeWidths),
+		imageMetadata:    database,
+		imageSafety:      database,
+	}
+
+	// Load domain policies; the periodic refresh retries if this fails
+	if err := s.RefreshDomainPolicies(); err != nil {
+		slog.Warn("failed to load domain policies", "error", err)
+	}
+	if err := s.RefreshSiteCredentials(); err != nil {
+		slog.Warn("failed to load site credentials", "error", err)
+	}
+	if err := s.RefreshScoreAdjustments(); err != nil {
+		slog.Warn("failed to load score adjustments", "error", err)
+	}
+
+	// Record per-endpoint Ollama latency and errors
+	scraperInstance.OllamaClient().SetObserver(observeOllamaRequest)
+
+	// Count fetch retries by reason
+	scraperInstance.SetRetryObserver(observeFetchRetry)
 
 	// Register routes
 	s.registerRoutes()
@@ -94,6 +168,14 @@ func NewServer(config Config) (*Server, error) {
 	// This ensures tracing creates span BEFORE logging tries to read trace context
 	var httpHandler http.Handler = s.mux
 
+	// Enforce API keys, scopes and quotas before any handler runs; without them admin
+	// endpoints are closed
+	if config.AuthEnabled {
+		httpHandler = s.authenticate(httpHandler)
+	} else {
+		httpHandler = refuseAdmin(httpHandler)
+	}
+
 	// Add HTTP request logging (innermost, executes last)
 	httpHandler = logging.HTTPLoggingMiddleware(logger)(httpHandler)
 
@@ -122,6 +204,7 @@ func (s *Server) registerRoutes() {
 	s.mux.Handle("/metrics", promhttp.Handler()) // Prometheus metrics endpoint
 	s.mux.HandleFunc("/health", s.handleHealth)
 	s.mux.HandleFunc("/api/scrape", s.handleScrape)
+	s.mux.HandleFunc("/api/scrape/stream", s.handleScrapeStream) // Server-Sent Events progress
 	s.mux.HandleFunc("/api/process-image", s.handleProcessImage) // Handles image upload and processing
 	s.mux.HandleFunc("/api/extract-links", s.handleExtractLinks)
 	s.mux.HandleFunc("/api/score", s.handleScore)
@@ -131,6 +214,19 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/api/images/", s.handleImage) // Handles /api/images/{id} and /api/images/{id}/file
 	s.mux.HandleFunc("/api/scrapes/", s.handleScrapeImages) // Handles /api/scrapes/{id}/images and /api/scrapes/{id}/content
 	s.mux.HandleFunc("/images/", s.handleImageBySlug) // Serves images by slug for SEO static pages
+	s.mux.HandleFunc("/api/admin/keys", s.handleAPIKeys) // List and issue API keys
+	s.mux.HandleFunc("/api/admin/keys/", s.handleAPIKey) // Revoke an API key
+	s.mux.HandleFunc("/api/admin/credentials", s.handleSiteCredentials) // List site credentials
+	s.mux.HandleFunc("/api/admin/credentials/", s.handleSiteCredential) // Get, set or delete a site credential
+	s.mux.HandleFunc("/api/admin/images/", s.handleAdminImage) // An image's full EXIF, including retained metadata
+	s.mux.HandleFunc("/api/rules", s.handleRules) // Get or replace the link rules
+	s.mux.HandleFunc("/api/rules/test", s.handleRulesTest) // Explain which rules fire for a URL
+	s.mux.HandleFunc("/api/domain-policies", s.handleDomainPolicies) // List domain policies
+	s.mux.HandleFunc("/api/domain-policies/", s.handleDomainPolicy) // Get, set or delete a domain policy
+	s.mux.HandleFunc("/api/calibration", s.handleCalibration) // Score calibration report from labels
+	s.mux.HandleFunc("/api/feeds/discover", s.handleDiscoverFeeds) // Find a page's feeds and its site's sitemaps
+	s.mux.HandleFunc("/api/feeds", s.handleFeeds) // List feed subscriptions or subscribe to a feed
+	s.mux.HandleFunc("/api/feeds/", s.handleFeed) // Get or delete a feed subscription
 }
 
 // DB returns the database instance for metrics collection
@@ -138,6 +234,11 @@ func (s *Server) DB() *db.DB {
 	return s.db
 }
 
+// Rules returns the link filtering and scoring rule engine
+func (s *Server) Rules() *rules.Engine {
+	return s.scraper.Rules()
+}
+
 // Start starts the API server
 func (s *Server) Start() error {
 	slog.Info("starting API server", "addr", s.addr)
@@ -176,7 +277,7 @@ func (s *Server) middleware(next http.Handler) http.Handler {
 		if s.corsEnabled {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
@@ -202,17 +303,182 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check Ollama with a short timeout so health checks stay fast when it is down
+	ctx, cancel := context.WithTimeout(r.Context(), ollamaHealthTimeout)
+	defer cancel()
+	ollamaHealth := s.scraper.OllamaClient().Health(ctx)
+	s.recordOllamaHealth(ollamaHealth)
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"status": "healthy",
+		"status": s.healthStatus(ollamaHealth),
 		"count":  count,
 		"time":   time.Now(),
+		"ollama": ollamaHealth,
 	})
 }
 
+// healthStatus reports "degraded" when Ollama cannot serve a model the deployment uses
+// Scraping still works on rule-based fallbacks without Ollama, so this is not a failure.
+// The vision model and its pool only matter when image analysis is enabled.
+func (s *Server) healthStatus(ollamaHealth ollama.HealthStatus) string {
+	degraded := ollamaHealth.Degraded()
+	if !s.scraper.Config().EnableImageAnalysis {
+		degraded = ollamaHealth.TextDegraded()
+	}
+	if degraded {
+		return "degraded"
+	}
+	return "healthy"
+}
+
+// CheckOllamaModels verifies the configured Ollama models are installed at startup,
+// pulling missing ones when auto-pull is enabled
+func (s *Server) CheckOllamaModels(ctx context.Context) {
+	client := s.scraper.OllamaClient()
+	autoPull := s.scraper.Config().OllamaAutoPull
+
+	status := client.CheckModels(ctx, autoPull)
+	s.recordOllamaHealth(status)
+
+	for _, ep := range status.Endpoints {
+		if !ep.Reachable || ep.Error != "" || len(ep.MissingModels) > 0 {
+			slog.Warn("ollama endpoint not ready",
+				"endpoint", ep.BaseURL,
+				"pools", ep.Pools,
+				"reachable", ep.Reachable,
+				"missing_models", ep.MissingModels,
+				"pulled", ep.Pulled,
+				"error", ep.Error)
+			continue
+		}
+		slog.Info("ollama endpoint ready",
+			"endpoint", ep.BaseURL,
+			"pools", ep.Pools,
+			"pulled", ep.Pulled)
+	}
+
+	visionNeeded := s.scraper.Config().EnableImageAnalysis
+	if !status.TextModelAvailable || (visionNeeded && !status.VisionModelAvailable) {
+		slog.Warn("ollama models unavailable on every endpoint, AI features will use rule-based fallbacks",
+			"text_model", status.TextModel,
+			"text_model_available", status.TextModelAvailable,
+			"vision_model", status.VisionModel,
+			"vision_model_available", status.VisionModelAvailable,
+			"auto_pull", autoPull)
+	}
+}
+
 // ScrapeRequest represents a scrape request
 type ScrapeRequest struct {
-	URL   string `json:"url"`
-	Force bool   `json:"force"` // Force re-scrape even if exists
+	URL     string         `json:"url"`
+	Force   bool           `json:"force"`             // Force re-scrape even if exists
+	Options *ScrapeOptions `json:"options,omitempty"` // Per-request pipeline options
+}
+
+// ScrapeOptions selects which pipeline stages run for a scrape request
+// Omitted fields use the server configuration
+type ScrapeOptions struct {
+	CleanContent        *bool  `json:"clean_content,omitempty"`         // AI content cleanup
+	Images              *bool  `json:"images,omitempty"`                // Download and analyze images
+	OCR                 *bool  `json:"ocr,omitempty"`                   // Extract text from images
+	FilterLinks         *bool  `json:"filter_links,omitempty"`          // Filter navigation/low-quality links
+	Score               *bool  `json:"score,omitempty"`                 // Content quality scoring
+	MaxImages           *int   `json:"max_images,omitempty"`            // Maximum images to process (0 = unlimited)
+	TimeoutSeconds      int    `json:"timeout_seconds,omitempty"`       // Deadline for the whole scrape
+	ImageTimeoutSeconds int    `json:"image_timeout_seconds,omitempty"` // Timeout for downloading each image
+	TextModel           string `json:"text_model,omitempty"`            // Ollama text model override
+	VisionModel         string `json:"vision_model,omitempty"`          // Ollama vision model override
+	TranslateTo         string `json:"translate_to,omitempty"`          // Translate content into this language code
+}
+
+// toScraperOptions converts request options to scraper options
+// A nil receiver yields the default pipeline
+func (o *ScrapeOptions) toScraperOptions() (scraper.ScrapeOptions, error) {
+	if o == nil {
+		return scraper.ScrapeOptions{}, nil
+	}
+	if o.TimeoutSeconds < 0 || o.ImageTimeoutSeconds < 0 {
+		return scraper.ScrapeOptions{}, fmt.Errorf("timeouts cannot be negative")
+	}
+	if time.Duration(o.TimeoutSeconds)*time.Second > scrapeTimeout {
+		return scraper.ScrapeOptions{}, fmt.Errorf("timeout_seconds cannot exceed %d", int(scrapeTimeout.Seconds()))
+	}
+
+	opts := scraper.ScrapeOptions{
+		CleanContent: o.CleanContent,
+		Images:       o.Images,
+		OCR:          o.OCR,
+		FilterLinks:  o.FilterLinks,
+		Score:        o.Score,
+		MaxImages:    o.MaxImages,
+		Timeout:      time.Duration(o.TimeoutSeconds) * time.Second,
+		ImageTimeout: time.Duration(o.ImageTimeoutSeconds) * time.Second,
+		TextModel:    o.TextModel,
+		VisionModel:  o.VisionModel,
+		TranslateTo:  o.TranslateTo,
+	}
+	return opts, opts.Validate()
+}
+
+// servesOptions reports whether a cached result can answer a request with opts
+// Cached results are full scrapes, so only a translation request can rule them out:
+// it needs content in the target language.
+func servesOptions(cached *models.ScrapedData, opts scraper.ScrapeOptions) bool {
+	target := lang.Normalize(opts.TranslateTo)
+	if target == "" {
+		return true
+	}
+	if cached.Metadata.TranslatedTo != "" {
+		return cached.Metadata.TranslatedTo == target
+	}
+	return cached.Metadata.Language == target
+}
+
+// scrapeOptionsFromQuery reads scrape options from query parameters, using the
+// same names as the JSON options object
+func scrapeOptionsFromQuery(query url.Values) (*ScrapeOptions, error) {
+	opts := &ScrapeOptions{
+		TextModel:   query.Get("text_model"),
+		VisionModel: query.Get("vision_model"),
+		TranslateTo: query.Get("translate_to"),
+	}
+
+	toggles := map[string]**bool{
+		"clean_content": &opts.CleanContent,
+		"images":        &opts.Images,
+		"ocr":           &opts.OCR,
+		"filter_links":  &opts.FilterLinks,
+		"score":         &opts.Score,
+	}
+	for name, field := range toggles {
+		if value := query.Get(name); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", name, value)
+			}
+			*field = &b
+		}
+	}
+
+	ints := map[string]*int{
+		"timeout_seconds":       &opts.TimeoutSeconds,
+		"image_timeout_seconds": &opts.ImageTimeoutSeconds,
+	}
+	if value := query.Get("max_images"); value != "" {
+		opts.MaxImages = new(int)
+		ints["max_images"] = opts.MaxImages
+	}
+	for name, field := range ints {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", name, value)
+			}
+			*field = n
+		}
+	}
+
+	return opts, nil
 }
 
 // handleScrape handles single URL scraping
@@ -233,6 +499,12 @@ func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	opts, err := req.Options.toScraperOptions()
+	if err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid options: %v", err))
+		return
+	}
+
 	// Add URL to span attributes
 	tracing.SetSpanAttributes(r.Context(),
 		attribute.String("scrape.url", req.URL),
@@ -252,9 +524,10 @@ func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if existing != nil {
+		if existing != nil && servesOptions(existing, opts) {
 			// Mark as cached
 			existing.Cached = true
+			s.redactServedImages(existing.Images)
 			tracing.AddEvent(ctx, "cache_hit",
 				attribute.String("cached_id", existing.ID))
 			span.SetAttributes(
@@ -270,13 +543,96 @@ func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Scrape the URL
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(r.Context(), scrapeTimeout)
 	defer cancel()
 
+	result, err := s.scrapeAndSave(ctx, req.URL, opts)
+	if err != nil {
+		respondError(w, scrapeErrorStatus(err), fmt.Sprintf("scraping failed: %v", err))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// handleScrapeStream scrapes a URL and streams pipeline progress as Server-Sent Events
+// Emits "progress" events for each stage, then a final "result" or "error" event
+func (s *Server) handleScrapeStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	targetURL := r.URL.Query().Get("url")
+	if targetURL == "" {
+		respondError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+	force := r.URL.Query().Get("force") == "true"
+
+	queryOpts, err := scrapeOptionsFromQuery(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts, err := queryOpts.toScraperOptions()
+	if err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid options: %v", err))
+		return
+	}
+
+	tracing.SetSpanAttributes(r.Context(),
+		attribute.String("scrape.url", targetURL),
+		attribute.Bool("scrape.force", force),
+		attribute.Bool("scrape.stream", true),
+	)
+
+	// Check the cache before switching to an event stream so errors can use normal status codes
+	var existing *models.ScrapedData
+	if !force {
+		existing, err = s.db.GetByURL(targetURL)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+	}
+
+	stream := newSSEWriter(w)
+	if existing != nil && servesOptions(existing, opts) {
+		existing.Cached = true
+		s.redactServedImages(existing.Images)
+		stream.send("result", existing)
+		return
+	}
+
+	stopHeartbeat := stream.startHeartbeat(sseHeartbeatInterval)
+	defer stopHeartbeat()
+
+	ctx, cancel := context.WithTimeout(r.Context(), scrapeTimeout)
+	defer cancel()
+
+	opts.Progress = func(event scraper.ProgressEvent) {
+		stream.send("progress", event)
+	}
+	result, err := s.scrapeAndSave(ctx, targetURL, opts)
+	if err != nil {
+		stream.send("error", map[string]string{"error": fmt.Sprintf("scraping failed: %v", err)})
+		return
+	}
+
+	stream.send("result", result)
+}
+
+// scrapeAndSave scrapes a URL, records scrape metrics and persists the result
+// A failed save is logged and the result is still returned. Partial scrapes (with
+// stages switched off) are not saved so they never shadow a full cached result.
+func (s *Server) scrapeAndSave(ctx context.Context, targetURL string, opts scraper.ScrapeOptions) (*models.ScrapedData, error) {
+	progress := opts.Progress
+	parentCtx := ctx
 	ctx, scrapeSpan := tracing.StartSpan(ctx, "scraper.scrape")
 	scrapeSpan.SetAttributes(
-		attribute.String("scrape.url", req.URL),
-		attribute.String("scrape.timeout", "10m"))
+		attribute.String("scrape.url", targetURL),
+		attribute.String("scrape.timeout", scrapeTimeout.String()))
 
 	// Start metrics timer for scrape duration with exemplar support
 	startTime := time.Now()
@@ -290,13 +646,12 @@ func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	result, err := s.scraper.Scrape(ctx, req.URL)
+	result, err := s.scraper.ScrapeWithOptions(ctx, targetURL, opts)
 	if err != nil {
 		scrapeStatus = "error"
 		tracing.RecordError(ctx, err)
 		scrapeSpan.End()
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("scraping failed: %v", err))
-		return
+		return nil, err
 	}
 
 	// Record successful scrape
@@ -314,8 +669,18 @@ func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
 		attribute.String("scrape.title", result.Title))
 	scrapeSpan.End()
 
+	// Attribute the scrape to the API key that requested it
+	if key := apiKeyFromContext(ctx); key != nil {
+		result.APIKeyID = key.ID
+	}
+
+	if opts.Partial() {
+		slog.Info("partial scrape not saved", "url", targetURL, "uuid", result.ID)
+		return result, nil
+	}
+
 	// Save to database
-	ctx, saveSpan := tracing.StartSpan(r.Context(), "database.save")
+	saveCtx, saveSpan := tracing.StartSpan(parentCtx, "database.save")
 	saveSpan.SetAttributes(
 		attribute.String("db.uuid", result.ID),
 		attribute.Int("db.links", len(result.Links)),
@@ -323,15 +688,21 @@ func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
 
 	if err := s.db.SaveScrapedData(result); err != nil {
 		slog.Error("failed to save scraped data", "error", err, "uuid", result.ID)
-		tracing.RecordError(ctx, err)
+		tracing.RecordError(saveCtx, err)
 		// Still return the result even if save fails
+		if progress != nil {
+			progress(scraper.ProgressEvent{Stage: scraper.StageSaved, URL: targetURL, ID: result.ID, Warning: "Failed to save scraped data"})
+		}
 	} else {
-		tracing.AddEvent(ctx, "data_saved",
+		tracing.AddEvent(saveCtx, "data_saved",
 			attribute.String("uuid", result.ID))
+		if progress != nil {
+			progress(scraper.ProgressEvent{Stage: scraper.StageSaved, URL: targetURL, ID: result.ID})
+		}
 	}
 	saveSpan.End()
 
-	respondJSON(w, http.StatusOK, result)
+	return result, nil
 }
 
 // ExtractLinksRequest represents an extract links request
@@ -370,7 +741,7 @@ func (s *Server) handleExtractLinks(w http.ResponseWriter, r *http.Request) {
 
 	links, err := s.scraper.ExtractLinks(ctx, req.URL)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("link extraction failed: %v", err))
+		respondError(w, scrapeErrorStatus(err), fmt.Sprintf("link extraction failed: %v", err))
 		return
 	}
 
@@ -428,6 +799,12 @@ func (s *Server) handleData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check if this is a score label operation
+	if strings.HasSuffix(path, "/label") {
+		s.handleScoreLabel(w, r, strings.TrimSuffix(path, "/label"))
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		s.handleGetByID(w, r, path)
@@ -440,7 +817,7 @@ func (s *Server) handleData(w http.ResponseWriter, r *http.Request) {
 
 // handleGetByID retrieves data by ID
 func (s *Server) handleGetByID(w http.ResponseWriter, r *http.Request, id string) {
-	data, err := s.db.GetByID(id)
+	data, err := s.scrapes.GetByID(id)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "database error")
 		return
@@ -453,6 +830,7 @@ func (s *Server) handleGetByID(w http.ResponseWriter, r *http.Request, id string
 
 	// Mark as cached since it's from database
 	data.Cached = true
+	s.redactServedImages(data.Images)
 	respondJSON(w, http.StatusOK, data)
 }
 
@@ -508,6 +886,7 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 	// Mark all as cached since they're from database
 	for _, item := range data {
 		item.Cached = true
+		s.redactServedImages(item.Images)
 	}
 
 	count, _ := s.db.Count()
@@ -554,6 +933,17 @@ func (s *Server) handleImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check if this is a derivatives listing
+	if strings.HasSuffix(path, "/derivatives") {
+		id := strings.TrimSuffix(path, "/derivatives")
+		if r.Method == http.MethodGet {
+			s.handleListImageDerivatives(w, r, id)
+		} else {
+			respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
 	// Check if this is a tombstone operation
 	if strings.HasSuffix(path, "/tombstone") {
 		id := strings.TrimSuffix(path, "/tombstone")
@@ -592,6 +982,7 @@ func (s *Server) handleGetImage(w http.ResponseWriter, r *http.Request, id strin
 		return
 	}
 
+	s.redactServedImage(image)
 	respondJSON(w, http.StatusOK, image)
 }
 
@@ -685,12 +1076,36 @@ func (s *Server) handleImageBySlug(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Serve a resized or re-encoded derivative if one was asked for
+	req, ok, err := parseDerivativeRequest(r.URL.Query(), s.derivativeWidths)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if s.imageNotModified(w, r, image) {
+		return
+	}
+	if s.blurFlagged(image) {
+		s.serveBlurredImage(w, image, req)
+		return
+	}
+	if ok {
+		s.serveImageDerivative(w, image, req)
+		return
+	}
+
 	imageData, err := s.storage.ReadImage(image.FilePath)
 	if err != nil {
 		slog.Error("failed to read image file", "file_path", image.FilePath, "error", err)
 		respondError(w, http.StatusInternalServerError, "failed to read image file")
 		return
 	}
+	imageData, err = s.scrubServedImage(image, imageData)
+	if err != nil {
+		slog.Error("failed to remove image metadata", "file_path", image.FilePath, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to read image file")
+		return
+	}
 
 	// Set content type
 	contentType := image.ContentType
@@ -700,7 +1115,6 @@ func (s *Server) handleImageBySlug(w http.ResponseWriter, r *http.Request) {
 
 	// Serve the image
 	w.Header().Set("Content-Type", contentType)
-	w.Header().Set("Cache-Control", "public, max-age=31536000") // Cache for 1 year
 	w.WriteHeader(http.StatusOK)
 	w.Write(imageData)
 }
@@ -731,6 +1145,24 @@ func (s *Server) handleServeImageFile(w http.ResponseWriter, r *http.Request, id
 		return
 	}
 
+	// Serve a resized or re-encoded derivative if one was asked for
+	req, ok, err := parseDerivativeRequest(r.URL.Query(), s.derivativeWidths)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if s.imageNotModified(w, r, image) {
+		return
+	}
+	if s.blurFlagged(image) {
+		s.serveBlurredImage(w, image, req)
+		return
+	}
+	if ok {
+		s.serveImageDerivative(w, image, req)
+		return
+	}
+
 	// Read image from storage
 	imageData, err := s.storage.ReadImage(image.FilePath)
 	if err != nil {
@@ -738,6 +1170,12 @@ func (s *Server) handleServeImageFile(w http.ResponseWriter, r *http.Request, id
 		respondError(w, http.StatusInternalServerError, "failed to read image file")
 		return
 	}
+	imageData, err = s.scrubServedImage(image, imageData)
+	if err != nil {
+		slog.Error("failed to remove image metadata", "file_path", image.FilePath, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to read image file")
+		return
+	}
 
 	// Set content type header
 	if image.ContentType != "" {
@@ -749,9 +1187,6 @@ func (s *Server) handleServeImageFile(w http.ResponseWriter, r *http.Request, id
 	// Set content length header
 	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(imageData)))
 
-	// Set cache control headers (cache for 1 year since images don't change)
-	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
-
 	// Write image data
 	w.WriteHeader(http.StatusOK)
 	w.Write(imageData)
@@ -786,11 +1221,14 @@ func (s *Server) handleImageSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	images, err := s.db.SearchImagesByTags(req.Tags)
+	images, err := s.db.SearchImagesByTags(req.Tags, s.excludeFlagged())
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "database error")
 		return
 	}
+	for _, image := range images {
+		s.redactServedImage(image)
+	}
 
 	response := ImageSearchResponse{
 		Images: images,
@@ -830,6 +1268,9 @@ func (s *Server) handleScrapeImages(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "database error")
 		return
 	}
+	for _, image := range images {
+		s.redactServedImage(image)
+	}
 
 	response := ImageSearchResponse{
 		Images: images,
@@ -890,6 +1331,7 @@ type ProcessImageRequest struct {
 	ImageURL      string `json:"image_url"`      // Synthetic URL for uploaded image
 	Summary       string `json:"summary"`        // AI-generated summary
 	Tags          []string `json:"tags"`         // AI-generated tags
+	TextBlocks    []models.TextBlock `json:"text_blocks,omitempty"` // OCR text with positions, from a dedicated OCR engine
 }
 
 // handleProcessImage processes an uploaded image file
@@ -958,9 +1400,21 @@ func (s *Server) handleProcessImage(w http.ResponseWriter, r *http.Request) {
 		img.Slug = imageID // Fallback to UUID
 	}
 
+	// Extract EXIF metadata before the metadata policy removes it from the stored bytes
+	if exifData := extractEXIF(imageData); exifData != nil {
+		img.EXIF = exifData
+		slog.Info("extracted EXIF data from uploaded image")
+	}
+	storedData, err := s.scraper.ScrubImageMetadata(&img, imageData)
+	if err != nil {
+		slog.Warn("failed to remove metadata from uploaded image", "error", err)
+		respondError(w, http.StatusUnprocessableEntity, "failed to remove image metadata")
+		return
+	}
+
 	// Save image to filesystem if storage is available
 	if s.storage != nil {
-		filePath, err := s.storage.SaveImage(imageData, img.Slug, contentType)
+		filePath, err := s.storage.SaveImage(storedData, img.Slug, contentType)
 		if err != nil {
 			slog.Error("failed to save uploaded image to filesystem", "error", err, "slug", img.Slug)
 			respondError(w, http.StatusInternalServerError, "failed to save image")
@@ -970,7 +1424,7 @@ func (s *Server) handleProcessImage(w http.ResponseWriter, r *http.Request) {
 		slog.Info("saved uploaded image to filesystem", "file_path", filePath, "slug", img.Slug)
 	} else {
 		// Fallback to base64 if no storage configured
-		img.Base64Data = base64.StdEncoding.EncodeToString(imageData)
+		img.Base64Data = base64.StdEncoding.EncodeToString(storedData)
 	}
 
 	// Extract image dimensions
@@ -983,18 +1437,12 @@ func (s *Server) handleProcessImage(w http.ResponseWriter, r *http.Request) {
 		slog.Info("extracted image dimensions", "width", width, "height", height)
 	}
 
-	// Extract EXIF metadata
-	if exifData := extractEXIF(imageData); exifData != nil {
-		img.EXIF = exifData
-		slog.Info("extracted EXIF data from uploaded image")
-	}
-
 	// Process image with AI: analyze and extract text
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
 	defer cancel()
 
 	// Analyze the image with Ollama
-	summary, tags, err := s.scraper.OllamaClient().AnalyzeImage(ctx, imageData, "")
+	summary, tags, err := s.scraper.OllamaClient().AnalyzeImage(ctx, imageData, "", "")
 	if err != nil {
 		slog.Error("failed to analyze uploaded image", "error", err)
 		respondError(w, http.StatusInternalServerError, "failed to analyze image")
@@ -1004,21 +1452,14 @@ func (s *Server) handleProcessImage(w http.ResponseWriter, r *http.Request) {
 	img.Tags = tags
 	slog.Info("analyzed uploaded image", "summary_chars", len(summary), "tag_count", len(tags))
 
-	// Extract text from image using OCR
-	extractedText, err := s.scraper.OllamaClient().ExtractTextFromImage(ctx, imageData)
-	if err != nil {
-		slog.Warn("failed to extract text from uploaded image", "error", err)
-		// OCR failure is not critical, continue without text
-	} else {
-		img.ExtractedText = extractedText
-		slog.Info("extracted text from uploaded image", "text_length", len(extractedText))
-	}
+	// Extract text from image using OCR; failure is not critical, continue without text
+	s.scraper.ExtractImageText(ctx, s.scraper.OllamaClient(), &img, imageData)
 
 	// Auto-generate title from extracted text (use first 100 chars or summary)
 	title := ""
-	if extractedText != "" {
+	if img.ExtractedText != "" {
 		// Use first line or first 100 chars of extracted text
-		lines := strings.Split(extractedText, "\n")
+		lines := strings.Split(img.ExtractedText, "\n")
 		if len(lines) > 0 && len(lines[0]) > 0 {
 			title = lines[0]
 			if len(title) > 100 {
@@ -1053,6 +1494,7 @@ func (s *Server) handleProcessImage(w http.ResponseWriter, r *http.Request) {
 		ImageID:       img.ID,
 		ImageURL:      img.URL,
 		ExtractedText: img.ExtractedText,
+		TextBlocks:    img.TextBlocks,
 		Title:         title,
 		Summary:       img.Summary,
 		Tags:          img.Tags,
@@ -6,12 +6,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/models"
 	"github.com/docutag/scraper/db"
+	"github.com/docutag/scraper/ollama"
 )
 
 func setupTestServer(t *testing.T) (*Server, func()) {
@@ -362,6 +365,35 @@ func TestHandleHealth(t *testing.T) {
 	}
 }
 
+func TestHealthStatusIgnoresVisionWithoutImageAnalysis(t *testing.T) {
+	// The text endpoint is fine; the vision endpoint is down and lacks its model
+	health := ollama.HealthStatus{
+		Reachable:          true,
+		TextModel:          "llama3.2",
+		TextModelAvailable: true,
+		VisionModel:        "llama3.2-vision",
+		Endpoints: []ollama.EndpointHealth{
+			{EndpointStats: ollama.EndpointStats{BaseURL: "http://text:11434", Pools: []string{ollama.PoolText}}, Reachable: true},
+			{EndpointStats: ollama.EndpointStats{BaseURL: "http://vision:11434", Pools: []string{ollama.PoolVision}}, MissingModels: []string{"llama3.2-vision"}},
+		},
+	}
+
+	for _, tt := range []struct {
+		imageAnalysis bool
+		want          string
+	}{
+		{true, "degraded"},
+		{false, "healthy"},
+	} {
+		cfg := scraper.DefaultConfig()
+		cfg.EnableImageAnalysis = tt.imageAnalysis
+		s := &Server{scraper: scraper.New(cfg, nil, nil)}
+		if got := s.healthStatus(health); got != tt.want {
+			t.Errorf("image analysis %v: status = %q, want %q", tt.imageAnalysis, got, tt.want)
+		}
+	}
+}
+
 func TestHandleScrapeImages(t *testing.T) {
 	server, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -471,3 +503,117 @@ func TestHandleScrapeImages(t *testing.T) {
 	}
 }
 
+func TestScrapeOptionsFromQuery(t *testing.T) {
+	query, _ := url.ParseQuery("images=false&ocr=0&max_images=5&timeout_seconds=60&text_model=llama3.2")
+	opts, err := scrapeOptionsFromQuery(query)
+	if err != nil {
+		t.Fatalf("scrapeOptionsFromQuery failed: %v", err)
+	}
+
+	converted, err := opts.toScraperOptions()
+	if err != nil {
+		t.Fatalf("toScraperOptions failed: %v", err)
+	}
+	if converted.Images == nil || *converted.Images {
+		t.Error("Expected Images = false")
+	}
+	if converted.OCR == nil || *converted.OCR {
+		t.Error("Expected OCR = false")
+	}
+	if converted.CleanContent != nil {
+		t.Error("Expected CleanContent to be unset")
+	}
+	if converted.MaxImages == nil || *converted.MaxImages != 5 {
+		t.Errorf("MaxImages = %v, want 5", converted.MaxImages)
+	}
+	if converted.Timeout != time.Minute {
+		t.Errorf("Timeout = %v, want 1m", converted.Timeout)
+	}
+	if converted.TextModel != "llama3.2" {
+		t.Errorf("TextModel = %s, want llama3.2", converted.TextModel)
+	}
+}
+
+func TestScrapeOptionsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"bad toggle", "images=maybe"},
+		{"bad integer", "max_images=lots"},
+		{"negative max images", "max_images=-1"},
+		{"negative timeout", "timeout_seconds=-5"},
+		{"timeout above server limit", "timeout_seconds=3600"},
+		{"bad translation language", "translate_to=klingon!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			opts, err := scrapeOptionsFromQuery(query)
+			if err == nil {
+				_, err = opts.toScraperOptions()
+			}
+			if err == nil {
+				t.Errorf("Expected error for %q", tt.query)
+			}
+		})
+	}
+}
+
+func TestServesOptions(t *testing.T) {
+	german := &models.ScrapedData{Metadata: models.PageMetadata{Language: "de"}}
+	translated := &models.ScrapedData{Metadata: models.PageMetadata{Language: "de", TranslatedTo: "en"}}
+
+	tests := []struct {
+		name   string
+		cached *models.ScrapedData
+		opts   scraper.ScrapeOptions
+		want   bool
+	}{
+		{"no translation requested", german, scraper.ScrapeOptions{}, true},
+		{"already in target language", german, scraper.ScrapeOptions{TranslateTo: "de-AT"}, true},
+		{"needs translation", german, scraper.ScrapeOptions{TranslateTo: "en"}, false},
+		{"translated into target", translated, scraper.ScrapeOptions{TranslateTo: "en"}, true},
+		{"translated into another language", translated, scraper.ScrapeOptions{TranslateTo: "de"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := servesOptions(tt.cached, tt.opts); got != tt.want {
+				t.Errorf("servesOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrapeRequestOptionsJSON(t *testing.T) {
+	var req ScrapeRequest
+	body := `{"url": "https://example.com", "options": {"clean_content": false, "score": true, "vision_model": "llava"}}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	opts, err := req.Options.toScraperOptions()
+	if err != nil {
+		t.Fatalf("toScraperOptions failed: %v", err)
+	}
+	if opts.CleanContent == nil || *opts.CleanContent {
+		t.Error("Expected CleanContent = false")
+	}
+	if opts.Score == nil || !*opts.Score {
+		t.Error("Expected Score = true")
+	}
+	if opts.VisionModel != "llava" {
+		t.Errorf("VisionModel = %s, want llava", opts.VisionModel)
+	}
+	if !opts.Partial() {
+		t.Error("Expected Partial() = true")
+	}
+
+	// Requests without options run the full pipeline
+	var plain ScrapeRequest
+	json.Unmarshal([]byte(`{"url": "https://example.com"}`), &plain)
+	if opts, _ := plain.Options.toScraperOptions(); opts.Partial() {
+		t.Error("Expected default options to run the full pipeline")
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sseHeartbeatInterval keeps idle streams open through proxies while a slow stage (e.g. Ollama) runs
+const sseHeartbeatInterval = 15 * time.Second
+
+// sseWriter writes Server-Sent Events to a response
+// Events may be sent from multiple goroutines (image workers), so writes are serialized
+type sseWriter struct {
+	mu sync.Mutex
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// newSSEWriter sets the event-stream headers and starts the response
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx response buffering
+	w.WriteHeader(http.StatusOK)
+
+	sw := &sseWriter{w: w, rc: http.NewResponseController(w)}
+	sw.flush()
+	return sw
+}
+
+// send writes a named event with a JSON payload
+func (sw *sseWriter) send(event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if _, err := fmt.Fprintf(sw.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	sw.flush()
+	return nil
+}
+
+// startHeartbeat writes a comment line every interval until the returned stop is called
+// stop waits for the heartbeat goroutine to exit, so the handler may return (and the
+// response be reused) as soon as it does.
+func (sw *sseWriter) startHeartbeat(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				sw.mu.Lock()
+				fmt.Fprint(sw.w, ": keep-alive\n\n")
+				sw.flush()
+				sw.mu.Unlock()
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// flush pushes buffered events to the client
+// Writers that cannot flush still deliver every event, just not incrementally
+func (sw *sseWriter) flush() {
+	_ = sw.rc.Flush()
+}
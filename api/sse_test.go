@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bufio"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSSEWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := newSSEWriter(rec)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %s, want text/event-stream", ct)
+	}
+
+	// Concurrent sends must not interleave
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stream.send("progress", map[string]string{"stage": "image_analyzed"})
+		}()
+	}
+	wg.Wait()
+	stream.send("result", map[string]string{"id": "abc"})
+
+	scanner := bufio.NewScanner(strings.NewReader(rec.Body.String()))
+	var events []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			events = append(events, strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "), line == "":
+		default:
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+
+	if len(events) != 21 {
+		t.Fatalf("got %d events, want 21", len(events))
+	}
+	if events[20] != "result" {
+		t.Errorf("last event = %s, want result", events[20])
+	}
+	if !strings.HasSuffix(rec.Body.String(), "event: result\ndata: {\"id\":\"abc\"}\n\n") {
+		t.Errorf("unexpected body tail: %q", rec.Body.String())
+	}
+	if !rec.Flushed {
+		t.Error("Expected response to be flushed")
+	}
+}
+
+func TestSSEHeartbeatStops(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := newSSEWriter(rec)
+
+	stop := stream.startHeartbeat(time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	// Nothing may write to the response once stop returns; the race detector catches
+	// a heartbeat still running
+	body := rec.Body.String()
+	if !strings.Contains(body, ": keep-alive\n\n") {
+		t.Errorf("expected a keep-alive comment, got %q", body)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if rec.Body.String() != body {
+		t.Error("heartbeat wrote after stop returned")
+	}
+}
@@ -0,0 +1,71 @@
+package calibration
+
+import (
+	"math"
+
+	"github.com/docutag/scraper/models"
+)
+
+// AdjustmentOptions controls how per-domain adjustments are learned
+type AdjustmentOptions struct {
+	MinLabels     int     // Labels a domain needs before it gets an adjustment
+	MaxAdjustment float64 // Largest absolute adjustment
+	Prior         float64 // Pseudo-labels with no error, shrinking adjustments for sparsely labeled domains
+	Margin        float64 // How far past the threshold a misjudged score is pushed
+}
+
+// DefaultAdjustmentOptions returns conservative defaults
+func DefaultAdjustmentOptions() AdjustmentOptions {
+	return AdjustmentOptions{
+		MinLabels:     5,
+		MaxAdjustment: 0.3,
+		Prior:         5,
+		Margin:        0.05,
+	}
+}
+
+// DomainAdjustments learns a score adjustment per domain from labels
+//
+// Only labels the threshold got wrong contribute: an accepted page scored below the
+// threshold pulls its domain up, a rejected page at or above it pulls the domain down,
+// each by the distance needed to land Margin past the threshold. The summed error is
+// averaged over the domain's labels plus Prior and clamped to MaxAdjustment.
+func DomainAdjustments(labels []*models.ScoreLabel, threshold float64, opts AdjustmentOptions) map[string]float64 {
+	type domainError struct {
+		labels int
+		sum    float64
+	}
+
+	errs := make(map[string]*domainError)
+	for _, l := range labels {
+		if l.Domain == "" {
+			continue
+		}
+		e, ok := errs[l.Domain]
+		if !ok {
+			e = &domainError{}
+			errs[l.Domain] = e
+		}
+		e.labels++
+
+		switch {
+		case l.Accepted && l.Score < threshold:
+			e.sum += threshold + opts.Margin - l.Score
+		case !l.Accepted && l.Score >= threshold:
+			e.sum += threshold - opts.Margin - l.Score
+		}
+	}
+
+	adjustments := make(map[string]float64)
+	for domain, e := range errs {
+		if e.labels < opts.MinLabels || e.sum == 0 {
+			continue
+		}
+		adjustment := e.sum / (float64(e.labels) + opts.Prior)
+		adjustment = math.Max(-opts.MaxAdjustment, math.Min(opts.MaxAdjustment, adjustment))
+		if adjustment = round(adjustment); adjustment != 0 {
+			adjustments[domain] = adjustment
+		}
+	}
+	return adjustments
+}
@@ -0,0 +1,176 @@
+// Package calibration measures how well link scores agree with human labels
+// and learns per-domain score adjustments from them.
+package calibration
+
+import (
+	"math"
+	"sort"
+
+	"github.com/docutag/scraper/models"
+)
+
+// Score sources reported in Report.BySource
+const (
+	SourceAI       = "ai"
+	SourceFallback = "fallback"
+)
+
+// thresholdSteps is the number of intervals in the threshold sweep (0.00, 0.05, ... 1.00)
+const thresholdSteps = 20
+
+// ThresholdStats is the confusion matrix and derived metrics at one threshold
+// A label is predicted positive when its score is at or above the threshold
+type ThresholdStats struct {
+	Threshold      float64 `json:"threshold"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+	Accuracy       float64 `json:"accuracy"`
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	TrueNegatives  int     `json:"true_negatives"`
+	FalseNegatives int     `json:"false_negatives"`
+}
+
+// SegmentStats summarizes the labels in one segment at the report threshold
+type SegmentStats struct {
+	Labels            int     `json:"labels"`
+	Accepted          int     `json:"accepted"`
+	MeanScoreAccepted float64 `json:"mean_score_accepted"`
+	MeanScoreRejected float64 `json:"mean_score_rejected"`
+	ThresholdStats
+}
+
+// Report compares scores with human labels across thresholds, score sources and categories
+type Report struct {
+	Threshold  float64                 `json:"threshold"`  // Threshold the segment stats are computed at
+	Labels     int                     `json:"labels"`     // Number of labels in the report
+	Accepted   int                     `json:"accepted"`   // Number of accepted labels
+	Current    ThresholdStats          `json:"current"`    // Metrics at Threshold
+	Best       ThresholdStats          `json:"best"`       // Threshold in the sweep with the highest F1
+	Thresholds []ThresholdStats        `json:"thresholds"` // Metrics for every threshold in the sweep
+	BySource   map[string]SegmentStats `json:"by_source"`  // "ai" and "fallback" scores
+	ByCategory map[string]SegmentStats `json:"by_category"`
+}
+
+// NewReport builds a calibration report for labels at threshold
+func NewReport(labels []*models.ScoreLabel, threshold float64) *Report {
+	report := &Report{
+		Threshold:  threshold,
+		Labels:     len(labels),
+		Current:    evaluate(labels, threshold),
+		Thresholds: make([]ThresholdStats, 0, thresholdSteps+1),
+		BySource:   make(map[string]SegmentStats),
+		ByCategory: make(map[string]SegmentStats),
+	}
+
+	for _, l := range labels {
+		if l.Accepted {
+			report.Accepted++
+		}
+	}
+
+	for i := 0; i <= thresholdSteps; i++ {
+		stats := evaluate(labels, float64(i)/thresholdSteps)
+		report.Thresholds = append(report.Thresholds, stats)
+		if i == 0 || stats.F1 > report.Best.F1 {
+			report.Best = stats
+		}
+	}
+
+	bySource := make(map[string][]*models.ScoreLabel)
+	byCategory := make(map[string][]*models.ScoreLabel)
+	for _, l := range labels {
+		source := SourceFallback
+		if l.AIUsed {
+			source = SourceAI
+		}
+		bySource[source] = append(bySource[source], l)
+		for _, category := range uniqueCategories(l.Categories) {
+			byCategory[category] = append(byCategory[category], l)
+		}
+	}
+	for source, segment := range bySource {
+		report.BySource[source] = segmentStats(segment, threshold)
+	}
+	for category, segment := range byCategory {
+		report.ByCategory[category] = segmentStats(segment, threshold)
+	}
+
+	return report
+}
+
+// evaluate computes the confusion matrix for labels at threshold
+func evaluate(labels []*models.ScoreLabel, threshold float64) ThresholdStats {
+	stats := ThresholdStats{Threshold: threshold}
+	for _, l := range labels {
+		predicted := l.Score >= threshold
+		switch {
+		case predicted && l.Accepted:
+			stats.TruePositives++
+		case predicted && !l.Accepted:
+			stats.FalsePositives++
+		case !predicted && l.Accepted:
+			stats.FalseNegatives++
+		default:
+			stats.TrueNegatives++
+		}
+	}
+
+	stats.Precision = ratio(stats.TruePositives, stats.TruePositives+stats.FalsePositives)
+	stats.Recall = ratio(stats.TruePositives, stats.TruePositives+stats.FalseNegatives)
+	if stats.Precision+stats.Recall > 0 {
+		stats.F1 = round(2 * stats.Precision * stats.Recall / (stats.Precision + stats.Recall))
+	}
+	stats.Accuracy = ratio(stats.TruePositives+stats.TrueNegatives, len(labels))
+	return stats
+}
+
+// segmentStats summarizes a segment of labels at threshold
+func segmentStats(labels []*models.ScoreLabel, threshold float64) SegmentStats {
+	stats := SegmentStats{Labels: len(labels), ThresholdStats: evaluate(labels, threshold)}
+
+	var acceptedSum, rejectedSum float64
+	for _, l := range labels {
+		if l.Accepted {
+			stats.Accepted++
+			acceptedSum += l.Score
+		} else {
+			rejectedSum += l.Score
+		}
+	}
+	if stats.Accepted > 0 {
+		stats.MeanScoreAccepted = round(acceptedSum / float64(stats.Accepted))
+	}
+	if rejected := stats.Labels - stats.Accepted; rejected > 0 {
+		stats.MeanScoreRejected = round(rejectedSum / float64(rejected))
+	}
+	return stats
+}
+
+// uniqueCategories returns the distinct non-empty categories in sorted order
+func uniqueCategories(categories []string) []string {
+	seen := make(map[string]bool, len(categories))
+	unique := make([]string, 0, len(categories))
+	for _, c := range categories {
+		if c != "" && !seen[c] {
+			seen[c] = true
+			unique = append(unique, c)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// ratio returns n/d rounded for reporting, or 0 when d is 0
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return round(float64(n) / float64(d))
+}
+
+// round rounds to four decimal places
+func round(f float64) float64 {
+	return math.Round(f*10000) / 10000
+}
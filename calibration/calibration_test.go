@@ -0,0 +1,106 @@
+package calibration
+
+import (
+	"testing"
+
+	"github.com/docutag/scraper/models"
+)
+
+func label(domain string, score float64, accepted, aiUsed bool, categories ...string) *models.ScoreLabel {
+	return &models.ScoreLabel{Domain: domain, Score: score, Accepted: accepted, AIUsed: aiUsed, Categories: categories}
+}
+
+func TestNewReport(t *testing.T) {
+	labels := []*models.ScoreLabel{
+		label("a.com", 0.9, true, true, "news"),
+		label("a.com", 0.7, true, true, "news", "news"),
+		label("b.com", 0.6, false, true, "spam"),
+		label("b.com", 0.4, true, false, "technical"),
+		label("c.com", 0.2, false, false, "spam"),
+	}
+
+	report := NewReport(labels, 0.5)
+
+	if report.Labels != 5 || report.Accepted != 3 {
+		t.Errorf("labels = %d accepted = %d, want 5 and 3", report.Labels, report.Accepted)
+	}
+
+	current := report.Current
+	if current.TruePositives != 2 || current.FalsePositives != 1 || current.FalseNegatives != 1 || current.TrueNegatives != 1 {
+		t.Errorf("unexpected confusion matrix: %+v", current)
+	}
+	if current.Precision != 0.6667 || current.Recall != 0.6667 || current.Accuracy != 0.6 {
+		t.Errorf("unexpected metrics: %+v", current)
+	}
+
+	if len(report.Thresholds) != thresholdSteps+1 || report.Thresholds[0].Threshold != 0 || report.Thresholds[thresholdSteps].Threshold != 1 {
+		t.Errorf("unexpected threshold sweep: %d entries", len(report.Thresholds))
+	}
+	if report.Best.Threshold != 0.25 || report.Best.Recall != 1 {
+		t.Errorf("best = %+v, want threshold 0.25 with full recall", report.Best)
+	}
+
+	ai := report.BySource[SourceAI]
+	fallback := report.BySource[SourceFallback]
+	if ai.Labels != 3 || ai.Accepted != 2 || ai.Precision != 0.6667 {
+		t.Errorf("unexpected ai stats: %+v", ai)
+	}
+	if fallback.Labels != 2 || fallback.Recall != 0 || fallback.MeanScoreAccepted != 0.4 {
+		t.Errorf("unexpected fallback stats: %+v", fallback)
+	}
+
+	news := report.ByCategory["news"]
+	if news.Labels != 2 || news.Precision != 1 || news.MeanScoreAccepted != 0.8 {
+		t.Errorf("unexpected news stats (duplicate categories should count once): %+v", news)
+	}
+	if spam := report.ByCategory["spam"]; spam.Labels != 2 || spam.Accepted != 0 || spam.FalsePositives != 1 {
+		t.Errorf("unexpected spam stats: %+v", spam)
+	}
+}
+
+func TestNewReportEmpty(t *testing.T) {
+	report := NewReport(nil, 0.5)
+	if report.Labels != 0 || report.Current.Precision != 0 || len(report.BySource) != 0 {
+		t.Errorf("unexpected empty report: %+v", report)
+	}
+}
+
+func TestDomainAdjustments(t *testing.T) {
+	var labels []*models.ScoreLabel
+	// under.com: good pages consistently scored just below the threshold
+	for i := 0; i < 5; i++ {
+		labels = append(labels, label("under.com", 0.4, true, true))
+	}
+	// over.com: half of its recommended pages were rejected
+	for i := 0; i < 10; i++ {
+		labels = append(labels, label("over.com", 0.8, i%2 == 0, true))
+	}
+	// right.com: every page judged correctly
+	for i := 0; i < 6; i++ {
+		labels = append(labels, label("right.com", 0.9, true, true))
+	}
+	// sparse.com: too few labels
+	labels = append(labels, label("sparse.com", 0.1, true, false))
+
+	opts := DefaultAdjustmentOptions()
+	adjustments := DomainAdjustments(labels, 0.5, opts)
+
+	// 5 labels each needing +0.15, over 5 labels plus a prior of 5
+	if got := adjustments["under.com"]; got != 0.075 {
+		t.Errorf("under.com adjustment = %v, want 0.075", got)
+	}
+	// 5 rejected labels each needing -0.35, over 10 labels plus 5
+	if got := adjustments["over.com"]; got != -0.1167 {
+		t.Errorf("over.com adjustment = %v, want -0.1167", got)
+	}
+	for _, domain := range []string{"right.com", "sparse.com"} {
+		if _, ok := adjustments[domain]; ok {
+			t.Errorf("expected no adjustment for %s", domain)
+		}
+	}
+
+	opts.MaxAdjustment = 0.05
+	if got := DomainAdjustments(labels, 0.5, opts)["over.com"]; got != -0.05 {
+		t.Errorf("clamped adjustment = %v, want -0.05", got)
+	}
+}
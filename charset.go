@@ -0,0 +1,71 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// charsetPreviewBytes is how much of a page is examined for a byte order mark and
+// <meta charset>; the HTML standard prescans the first 1024 bytes
+const charsetPreviewBytes = 1024
+
+// parseHTML transcodes a page body to UTF-8 and parses it
+// It returns the name of the page's character encoding, e.g. "shift_jis" or "utf-8"
+func parseHTML(body []byte, contentType string) (*html.Node, string, error) {
+	r, name, err := decodeHTML(bytes.NewReader(body), contentType)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read page: %w", err)
+	}
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	return doc, name, nil
+}
+
+// decodeHTML returns a reader that transcodes an HTML body to UTF-8
+//
+// The encoding is taken from a byte order mark, then the Content-Type charset, then a
+// <meta charset> or http-equiv declaration in the first charsetPreviewBytes. When none
+// of those is certain the whole body is checked: a body that is valid UTF-8 is read as
+// UTF-8, since legacy text almost never forms valid multi-byte sequences, and anything
+// else keeps the detected encoding, windows-1252 for undeclared pages as in browsers.
+// Bytes that are invalid in the chosen encoding become U+FFFD.
+func decodeHTML(body io.Reader, contentType string) (io.Reader, string, error) {
+	br := bufio.NewReaderSize(body, charsetPreviewBytes)
+	preview, err := br.Peek(charsetPreviewBytes)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", err
+	}
+
+	enc, name, certain := charset.DetermineEncoding(preview, contentType)
+	if certain || enc == encoding.Nop {
+		return decodeWith(br, enc), name, nil
+	}
+
+	// The preview alone cannot tell an ASCII prefix of a UTF-8 page from a legacy one
+	all, err := io.ReadAll(br)
+	if err != nil {
+		return nil, "", err
+	}
+	if name == "windows-1252" && utf8.Valid(all) {
+		return bytes.NewReader(all), "utf-8", nil
+	}
+	return decodeWith(bytes.NewReader(all), enc), name, nil
+}
+
+// decodeWith wraps r in enc's decoder unless enc is already UTF-8
+func decodeWith(r io.Reader, enc encoding.Encoding) io.Reader {
+	if enc == encoding.Nop {
+		return r
+	}
+	return transform.NewReader(r, enc.NewDecoder())
+}
@@ -0,0 +1,127 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("failed to encode test page: %v", err)
+	}
+	return b
+}
+
+func TestDecodeHTML(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+		wantCharset string
+	}{
+		{
+			name:        "shift_jis from header",
+			body:        encode(t, japanese.ShiftJIS, "<p>今日は良い天気です</p>"),
+			contentType: "text/html; charset=Shift_JIS",
+			want:        "<p>今日は良い天気です</p>",
+			wantCharset: "shift_jis",
+		},
+		{
+			name:        "windows-1251 from meta charset",
+			body:        encode(t, charmap.Windows1251, `<meta charset="windows-1251"><p>Привет, мир</p>`),
+			contentType: "text/html",
+			want:        `<meta charset="windows-1251"><p>Привет, мир</p>`,
+			wantCharset: "windows-1251",
+		},
+		{
+			name:        "iso-8859-1 from http-equiv",
+			body:        encode(t, charmap.ISO8859_1, `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><p>Café crème</p>`),
+			contentType: "",
+			want:        `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><p>Café crème</p>`,
+			wantCharset: "windows-1252",
+		},
+		{
+			name:        "byte order mark overrides header",
+			body:        append([]byte("\xef\xbb\xbf"), "<p>Grüße</p>"...),
+			contentType: "text/html; charset=iso-8859-1",
+			want:        "<p>Grüße</p>",
+			wantCharset: "utf-8",
+		},
+		{
+			name: "undeclared utf-8 cut at the preview boundary",
+			// "é" straddles byte charsetPreviewBytes
+			body:        []byte(strings.Repeat("a", charsetPreviewBytes-1) + "é" + strings.Repeat("b", 10)),
+			want:        strings.Repeat("a", charsetPreviewBytes-1) + "é" + strings.Repeat("b", 10),
+			wantCharset: "utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, name, err := decodeHTML(strings.NewReader(string(tt.body)), tt.contentType)
+			if err != nil {
+				t.Fatalf("decodeHTML failed: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to read decoded body: %v", err)
+			}
+			if name != tt.wantCharset {
+				t.Errorf("charset = %q, want %q", name, tt.wantCharset)
+			}
+			if strings.TrimPrefix(string(got), "\ufeff") != tt.want {
+				t.Errorf("decoded = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrapeTranscodesLegacyCharset(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "[]", Done: true})
+	}))
+	defer llm.Close()
+
+	page := encode(t, japanese.ShiftJIS, `<html><head><title>市議会が予算を可決</title></head><body>
+		<p>市議会は来年度の予算をようやく可決しました。長い議論の末の決定です。</p>
+	</body></html>`)
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+		w.Write(page)
+	}))
+	defer web.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = llm.URL
+	config.EnableImageAnalysis = false
+	data, err := New(config, nil, nil).ScrapeWithOptions(context.Background(), web.URL, ScrapeOptions{CleanContent: Bool(false)})
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if data.Title != "市議会が予算を可決" {
+		t.Errorf("title = %q, want 市議会が予算を可決", data.Title)
+	}
+	if !strings.Contains(data.Content, "来年度の予算") {
+		t.Errorf("content not transcoded: %q", data.Content)
+	}
+	if data.Metadata.Charset != "shift_jis" {
+		t.Errorf("charset = %q, want shift_jis", data.Metadata.Charset)
+	}
+	if data.Metadata.Language != "ja" {
+		t.Errorf("language = %q, want ja", data.Metadata.Language)
+	}
+}
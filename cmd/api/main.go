@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -17,6 +18,10 @@ import (
 	"github.com/docutag/scraper"
 	"github.com/docutag/scraper/api"
 	"github.com/docutag/scraper/db"
+	"github.com/docutag/scraper/imaging"
+	"github.com/docutag/scraper/lang"
+	"github.com/docutag/scraper/ocr"
+	"github.com/docutag/scraper/ollama"
 	"github.com/docutag/scraper/storage"
 )
 
@@ -28,6 +33,30 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// splitList splits a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseWidths parses a comma-separated list of positive pixel widths
+func parseWidths(value string) ([]int, error) {
+	var widths []int
+	for _, item := range splitList(value) {
+		w, err := strconv.Atoi(item)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("invalid width %q", item)
+		}
+		widths = append(widths, w)
+	}
+	return widths, nil
+}
+
 func main() {
 	// Setup structured logging with JSON output
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
@@ -57,6 +86,37 @@ func main() {
 	defaultOllamaVisionModel := getEnv("OLLAMA_VISION_MODEL", defaultOllamaModel) // Default to same as text model if not specified
 	defaultLinkScoreThreshold := getEnv("LINK_SCORE_THRESHOLD", "0.5")
 	defaultMaxImages := getEnv("MAX_IMAGES", "20")
+	defaultMaxPageSize := getEnv("MAX_PAGE_SIZE", "10485760") // 10MB
+	defaultFetchRetries := getEnv("FETCH_RETRIES", "3")
+	defaultFetchRetryDelay := getEnv("FETCH_RETRY_DELAY", "500ms")
+	defaultFeedWorkers := getEnv("FEED_WORKERS", "2") // 0 disables feed polling
+	defaultOllamaAutoPull := getEnv("OLLAMA_AUTO_PULL", "false") == "true"
+	defaultBreakerThreshold := getEnv("OLLAMA_BREAKER_THRESHOLD", "5")
+	defaultBreakerCooldown := getEnv("OLLAMA_BREAKER_COOLDOWN", "30s")
+	defaultOllamaVisionURL := getEnv("OLLAMA_VISION_URL", "") // Defaults to the OLLAMA_URL endpoints
+	defaultOllamaMaxConcurrent := getEnv("OLLAMA_MAX_CONCURRENT", "3")
+	defaultOllamaRouting := getEnv("OLLAMA_ROUTING", string(ollama.RoutingLeastLoaded))
+	defaultAuthEnabled := getEnv("AUTH_ENABLED", "false") == "true"
+	adminKey := getEnv("API_ADMIN_KEY", "") // Bootstrap admin key, never logged
+	credentialsKey := getEnv("CREDENTIALS_KEY", "") // Base64 AES-256 key for site credentials, never logged
+	defaultRulesSource := getEnv("RULES_SOURCE", "builtin")
+	defaultRulesReloadInterval := getEnv("RULES_RELOAD_INTERVAL", "30s")
+	defaultScoreAdjustments := getEnv("SCORE_ADJUSTMENTS_ENABLED", "false") == "true"
+	defaultTranslateTo := getEnv("TRANSLATE_TO", "") // Empty keeps content in the page language
+	defaultBlockPrivateNetworks := getEnv("BLOCK_PRIVATE_NETWORKS", "true") != "false"
+	defaultNetworkProfiles := getEnv("NETWORK_PROFILES", "") // YAML/JSON file of per-domain proxies, headers and TLS settings
+	defaultAllowedHosts := getEnv("ALLOWED_HOSTS", "") // Comma-separated hosts, *.domains, IPs or CIDRs exempt from BLOCK_PRIVATE_NETWORKS
+	defaultDerivativeWidths := getEnv("IMAGE_DERIVATIVE_WIDTHS", "320,640,1280") // Widths ?w= is rounded up to
+	defaultImageMetadataPolicy := getEnv("IMAGE_METADATA_POLICY", string(imaging.MetadataStripGPS))
+	defaultRetainImageMetadata := getEnv("IMAGE_METADATA_RETAIN", "false") == "true" // Keep removed EXIF in the database for admins
+	defaultImageSafetyAction := getEnv("IMAGE_SAFETY_ACTION", string(scraper.SafetyOff)) // off, flag, exclude, blur or tombstone
+	defaultImageSafetyThreshold := getEnv("IMAGE_SAFETY_THRESHOLD", "0.8")
+	defaultOCREngine := getEnv("OCR_ENGINE", ocr.EngineVision) // vision, tesseract or http
+	defaultOCRURL := getEnv("OCR_URL", "")                     // Recognition endpoint for OCR_ENGINE=http
+	defaultOCRLanguages := getEnv("OCR_LANGUAGES", ocr.DefaultLanguages)
+	defaultTesseractPath := getEnv("TESSERACT_PATH", "tesseract")
+	defaultOCRVisionFallback := getEnv("OCR_VISION_FALLBACK", "true") != "false"
+	defaultOCRMinConfidence := getEnv("OCR_MIN_CONFIDENCE", "0.5")
 
 	// S3 storage configuration (required - MinIO for dev/staging, DO Spaces for production)
 	s3Endpoint := getEnv("S3_ENDPOINT", "")          // e.g., "http://minio:9000" for MinIO
@@ -77,6 +137,28 @@ func main() {
 		linkScoreThreshold = 0.5
 	}
 
+	// Parse image safety threshold
+	imageSafetyThreshold, err := strconv.ParseFloat(defaultImageSafetyThreshold, 64)
+	if err != nil {
+		logger.Warn("invalid IMAGE_SAFETY_THRESHOLD value, using default",
+			"provided", defaultImageSafetyThreshold,
+			"default", scraper.DefaultImageSafetyThreshold,
+			"error", err,
+		)
+		imageSafetyThreshold = scraper.DefaultImageSafetyThreshold
+	}
+
+	// Parse OCR minimum confidence
+	ocrMinConfidence, err := strconv.ParseFloat(defaultOCRMinConfidence, 64)
+	if err != nil {
+		logger.Warn("invalid OCR_MIN_CONFIDENCE value, using default",
+			"provided", defaultOCRMinConfidence,
+			"default", ocr.DefaultMinConfidence,
+			"error", err,
+		)
+		ocrMinConfidence = ocr.DefaultMinConfidence
+	}
+
 	// Parse max images limit
 	maxImages, err := strconv.Atoi(defaultMaxImages)
 	if err != nil {
@@ -92,16 +174,192 @@ func main() {
 		maxImages = 20
 	}
 
+	// Parse max page size
+	maxPageSize, err := strconv.ParseInt(defaultMaxPageSize, 10, 64)
+	if err != nil || maxPageSize < 0 {
+		logger.Warn("invalid MAX_PAGE_SIZE value, using default",
+			"provided", defaultMaxPageSize,
+			"default", 10*1024*1024,
+		)
+		maxPageSize = 10 * 1024 * 1024
+	}
+
+	// Parse fetch retry policy
+	fetchRetries, err := strconv.Atoi(defaultFetchRetries)
+	if err != nil || fetchRetries < 1 {
+		logger.Warn("invalid FETCH_RETRIES value, using default",
+			"provided", defaultFetchRetries,
+			"default", 3,
+		)
+		fetchRetries = 3
+	}
+	fetchRetryDelay, err := time.ParseDuration(defaultFetchRetryDelay)
+	if err != nil || fetchRetryDelay <= 0 {
+		logger.Warn("invalid FETCH_RETRY_DELAY value, using default",
+			"provided", defaultFetchRetryDelay,
+			"default", "500ms",
+		)
+		fetchRetryDelay = 500 * time.Millisecond
+	}
+
+	// Parse feed worker count
+	feedWorkers, err := strconv.Atoi(defaultFeedWorkers)
+	if err != nil || feedWorkers < 0 {
+		logger.Warn("invalid FEED_WORKERS value, using default",
+			"provided", defaultFeedWorkers,
+			"default", 2,
+		)
+		feedWorkers = 2
+	}
+
+	// Parse Ollama circuit breaker settings
+	breakerThreshold, err := strconv.Atoi(defaultBreakerThreshold)
+	if err != nil || breakerThreshold < 1 {
+		logger.Warn("invalid OLLAMA_BREAKER_THRESHOLD value, using default",
+			"provided", defaultBreakerThreshold,
+			"default", 5,
+		)
+		breakerThreshold = 5
+	}
+	breakerCooldown, err := time.ParseDuration(defaultBreakerCooldown)
+	if err != nil || breakerCooldown <= 0 {
+		logger.Warn("invalid OLLAMA_BREAKER_COOLDOWN value, using default",
+			"provided", defaultBreakerCooldown,
+			"default", "30s",
+		)
+		breakerCooldown = 30 * time.Second
+	}
+
+	// Parse per-endpoint Ollama concurrency limit
+	maxConcurrent, err := strconv.Atoi(defaultOllamaMaxConcurrent)
+	if err != nil || maxConcurrent < 1 {
+		logger.Warn("invalid OLLAMA_MAX_CONCURRENT value, using default",
+			"provided", defaultOllamaMaxConcurrent,
+			"default", ollama.DefaultMaxConcurrent,
+		)
+		maxConcurrent = ollama.DefaultMaxConcurrent
+	}
+
+	// Parse rules reload interval
+	rulesReloadInterval, err := time.ParseDuration(defaultRulesReloadInterval)
+	if err != nil || rulesReloadInterval < 0 {
+		logger.Warn("invalid RULES_RELOAD_INTERVAL value, using default",
+			"provided", defaultRulesReloadInterval,
+			"default", "30s",
+		)
+		rulesReloadInterval = 30 * time.Second
+	}
+
 	// Command-line flags (override environment variables)
 	port := flag.String("port", defaultPort, "Server port")
-	ollamaURL := flag.String("ollama-url", defaultOllamaURL, "Ollama base URL")
+	ollamaURL := flag.String("ollama-url", defaultOllamaURL, "Comma-separated Ollama base URLs, optionally with a concurrency limit (url=N)")
+	ollamaVisionURL := flag.String("ollama-vision-url", defaultOllamaVisionURL, "Comma-separated Ollama base URLs for vision tasks (defaults to -ollama-url)")
 	ollamaModel := flag.String("ollama-model", defaultOllamaModel, "Ollama model to use for text generation")
 	ollamaVisionModel := flag.String("ollama-vision-model", defaultOllamaVisionModel, "Ollama model to use for vision tasks")
 	scoreThreshold := flag.Float64("link-score-threshold", linkScoreThreshold, "Minimum score for link recommendation (0.0-1.0)")
 	disableCORS := flag.Bool("disable-cors", false, "Disable CORS")
+	authEnabled := flag.Bool("auth", defaultAuthEnabled, "Require API keys on /api endpoints")
 	disableImageAnalysis := flag.Bool("disable-image-analysis", false, "Disable AI-powered image analysis")
+	ollamaAutoPull := flag.Bool("ollama-auto-pull", defaultOllamaAutoPull, "Pull missing Ollama models at startup")
+	ollamaBreakerThreshold := flag.Int("ollama-breaker-threshold", breakerThreshold, "Consecutive Ollama failures before the circuit breaker opens")
+	ollamaBreakerCooldown := flag.Duration("ollama-breaker-cooldown", breakerCooldown, "How long the Ollama circuit breaker stays open before probing again")
+	ollamaMaxConcurrent := flag.Int("ollama-max-concurrent", maxConcurrent, "Default concurrent requests per Ollama endpoint")
+	ollamaRouting := flag.String("ollama-routing", defaultOllamaRouting, "Ollama endpoint routing strategy (least-loaded or round-robin)")
+	rulesSource := flag.String("rules", defaultRulesSource, "Link rules source: builtin, db, or a YAML/JSON file path")
+	rulesReload := flag.Duration("rules-reload-interval", rulesReloadInterval, "How often to check the rules source for changes (0 disables reloading)")
+	scoreAdjustments := flag.Bool("score-adjustments", defaultScoreAdjustments, "Apply per-domain score adjustments learned from human labels")
+	fetchRetriesFlag := flag.Int("fetch-retries", fetchRetries, "Attempts per page or image fetch, including the first (1 disables retries)")
+	fetchRetryDelayFlag := flag.Duration("fetch-retry-delay", fetchRetryDelay, "Backoff before the first fetch retry, doubled for each later one")
+	feedWorkersFlag := flag.Int("feed-workers", feedWorkers, "Workers scraping new entries from feed subscriptions (0 disables feed polling)")
+	maxPageSizeFlag := flag.Int64("max-page-size", maxPageSize, "Maximum page size in bytes; larger pages are truncated (0 = unlimited)")
+	translateTo := flag.String("translate-to", defaultTranslateTo, "Translate scraped content into this language code, e.g. en (empty keeps the page language)")
+	blockPrivateNetworks := flag.Bool("block-private-networks", defaultBlockPrivateNetworks, "Refuse to fetch private, loopback and link-local addresses")
+	allowedHosts := flag.String("allowed-hosts", defaultAllowedHosts, "Comma-separated hosts, *.domains, IPs or CIDRs exempt from -block-private-networks")
+	imageMetadataPolicyFlag := flag.String("image-metadata-policy", defaultImageMetadataPolicy, "Metadata removed from stored and served images: keep, strip-gps or strip-all")
+	retainImageMetadata := flag.Bool("image-metadata-retain", defaultRetainImageMetadata, "Keep the EXIF the metadata policy removes in the database, readable only by admins")
+	imageSafetyActionFlag := flag.String("image-safety-action", defaultImageSafetyAction, "What happens to images the vision model flags as unsafe: off, flag, exclude, blur or tombstone")
+	imageSafetyThresholdFlag := flag.Float64("image-safety-threshold", imageSafetyThreshold, "Confidence (0.0-1.0) at which a safety category flags an image")
+	ocrEngineFlag := flag.String("ocr-engine", defaultOCREngine, "OCR engine for text in images: vision, tesseract or http")
+	ocrURL := flag.String("ocr-url", defaultOCRURL, "Recognition endpoint of the http OCR engine")
+	ocrLanguages := flag.String("ocr-languages", defaultOCRLanguages, "Tesseract languages, e.g. eng+deu")
+	tesseractPath := flag.String("tesseract-path", defaultTesseractPath, "Tesseract binary")
+	ocrVisionFallback := flag.Bool("ocr-vision-fallback", defaultOCRVisionFallback, "Extract text with the vision model when the OCR engine fails")
+	ocrMinConfidenceFlag := flag.Float64("ocr-min-confidence", ocrMinConfidence, "Confidence (0.0-1.0) below which OCR text lines are dropped")
+	derivativeWidthsFlag := flag.String("image-derivative-widths", defaultDerivativeWidths, "Comma-separated widths resized images are generated at; ?w= is rounded up to the next one")
+	networkProfilesPath := flag.String("network-profiles", defaultNetworkProfiles, "YAML/JSON file of outbound network profiles (proxies, headers, cookies, TLS) by domain")
 	flag.Parse()
 
+	if *translateTo != "" && lang.Normalize(*translateTo) == "" {
+		logger.Error("invalid translation language", "provided", *translateTo)
+		os.Exit(1)
+	}
+
+	var networkProfiles []scraper.NetworkProfile
+	if *networkProfilesPath != "" {
+		networkProfiles, err = scraper.LoadNetworkProfiles(*networkProfilesPath)
+		if err != nil {
+			logger.Error("invalid network profiles", "path", *networkProfilesPath, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	imageMetadataPolicy, err := imaging.ParseMetadataPolicy(*imageMetadataPolicyFlag)
+	if err != nil {
+		logger.Error("invalid image metadata policy", "provided", *imageMetadataPolicyFlag, "error", err)
+		os.Exit(1)
+	}
+
+	imageSafetyAction, err := scraper.ParseSafetyAction(*imageSafetyActionFlag)
+	if err != nil {
+		logger.Error("invalid image safety action", "provided", *imageSafetyActionFlag, "error", err)
+		os.Exit(1)
+	}
+
+	ocrEngine, err := ocr.New(ocr.Config{
+		Engine:        *ocrEngineFlag,
+		TesseractPath: *tesseractPath,
+		Languages:     *ocrLanguages,
+		URL:           *ocrURL,
+	})
+	if err != nil {
+		logger.Error("invalid OCR engine", "engine", *ocrEngineFlag, "error", err)
+		os.Exit(1)
+	}
+
+	derivativeWidths, err := parseWidths(*derivativeWidthsFlag)
+	if err != nil || len(derivativeWidths) == 0 {
+		logger.Warn("invalid image derivative widths, using default",
+			"provided", *derivativeWidthsFlag,
+			"error", err,
+			"default", "320,640,1280",
+		)
+		derivativeWidths = nil // The server falls back to its defaults
+	}
+
+	retryPolicy := scraper.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = *fetchRetriesFlag
+	retryPolicy.BaseDelay = *fetchRetryDelayFlag
+
+	// Parse Ollama endpoint pools
+	ollamaEndpoints, err := ollama.ParseEndpoints(*ollamaURL, *ollamaMaxConcurrent)
+	if err != nil || len(ollamaEndpoints) == 0 {
+		logger.Error("invalid Ollama URL", "provided", *ollamaURL, "error", err)
+		os.Exit(1)
+	}
+	ollamaVisionEndpoints, err := ollama.ParseEndpoints(*ollamaVisionURL, *ollamaMaxConcurrent)
+	if err != nil {
+		logger.Error("invalid Ollama vision URL", "provided", *ollamaVisionURL, "error", err)
+		os.Exit(1)
+	}
+	routing := ollama.RoutingStrategy(*ollamaRouting)
+	if routing != ollama.RoutingLeastLoaded && routing != ollama.RoutingRoundRobin {
+		logger.Warn("invalid OLLAMA_ROUTING value, using default",
+			"provided", *ollamaRouting,
+			"default", ollama.RoutingLeastLoaded,
+		)
+		routing = ollama.RoutingLeastLoaded
+	}
+
 	// PostgreSQL database configuration (required)
 	dbHost := getEnv("DB_HOST", "")
 	if dbHost == "" {
@@ -117,6 +375,16 @@ func main() {
 	dbConfig := db.Config{
 		DSN: fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbPort, dbUser, dbPassword, dbName),
 	}
+	if credentialsKey != "" {
+		key, err := db.ParseCredentialsKey(credentialsKey)
+		if err != nil {
+			logger.Error("invalid CREDENTIALS_KEY", "error", err)
+			os.Exit(1)
+		}
+		dbConfig.CredentialsKey = key
+	} else {
+		logger.Warn("CREDENTIALS_KEY not set, site credentials are disabled")
+	}
 	logger.Info("using PostgreSQL database", "host", dbHost, "port", dbPort, "database", dbName)
 
 	// Configure S3 storage (only backend supported)
@@ -142,18 +410,49 @@ func main() {
 		DBConfig: dbConfig,
 		S3Config: s3Config,
 		ScraperConfig: scraper.Config{
-			HTTPTimeout:         30 * time.Second,
-			OllamaBaseURL:       *ollamaURL,
-			OllamaModel:         *ollamaModel,
-			OllamaVisionModel:   *ollamaVisionModel,
-			EnableImageAnalysis: !*disableImageAnalysis,
-			MaxImageSizeBytes:   10 * 1024 * 1024, // 10MB
-			ImageTimeout:        15 * time.Second,
-			LinkScoreThreshold:  *scoreThreshold,
-			StoragePath:         "./storage", // Legacy field, not used with S3
-			MaxImages:           maxImages,   // Maximum images to download per scrape
+			HTTPTimeout:            30 * time.Second,
+			OllamaBaseURL:          ollamaEndpoints[0].BaseURL,
+			OllamaModel:            *ollamaModel,
+			OllamaVisionModel:      *ollamaVisionModel,
+			EnableImageAnalysis:    !*disableImageAnalysis,
+			MaxImageSizeBytes:      10 * 1024 * 1024, // 10MB
+			MaxPageSizeBytes:       *maxPageSizeFlag,
+			ImageTimeout:           15 * time.Second,
+			LinkScoreThreshold:     *scoreThreshold,
+			StoragePath:            "./storage", // Legacy field, not used with S3
+			MaxImages:              maxImages,   // Maximum images to download per scrape
+			OllamaAutoPull:         *ollamaAutoPull,
+			OllamaBreakerThreshold: *ollamaBreakerThreshold,
+			OllamaBreakerCooldown:  *ollamaBreakerCooldown,
+			OllamaEndpoints:        ollamaEndpoints,
+			OllamaVisionEndpoints:  ollamaVisionEndpoints,
+			OllamaRouting:          routing,
+			OllamaMaxConcurrent:    *ollamaMaxConcurrent,
+			TranslateTo:            *translateTo,
+			BlockPrivateNetworks:   *blockPrivateNetworks,
+			AllowedHosts:           splitList(*allowedHosts),
+			NetworkProfiles:        networkProfiles,
+			Retry:                  retryPolicy,
+			ImageMetadataPolicy:    imageMetadataPolicy,
+			RetainImageMetadata:    *retainImageMetadata,
+			ImageSafetyAction:      imageSafetyAction,
+			ImageSafetyThreshold:   *imageSafetyThresholdFlag,
+			OCREngine:              ocrEngine,
+			OCRVisionFallback:      *ocrVisionFallback,
+			OCRMinConfidence:       *ocrMinConfidenceFlag,
 		},
-		CORSEnabled: !*disableCORS,
+		CORSEnabled:      !*disableCORS,
+		AuthEnabled:      *authEnabled,
+		AdminKey:         adminKey,
+		RulesSource:      *rulesSource,
+		ScoreAdjustments: *scoreAdjustments,
+		DerivativeWidths: derivativeWidths,
+	}
+
+	if !*authEnabled {
+		logger.Warn("API authentication disabled, endpoints other than /api/admin are open; set AUTH_ENABLED=true to require API keys")
+	} else if adminKey == "" {
+		logger.Warn("API_ADMIN_KEY not set, only previously issued API keys can authenticate")
 	}
 
 	// Create server
@@ -184,6 +483,76 @@ func main() {
 	}()
 	logger.Info("image metrics initialized")
 
+	// Initialize Ollama circuit breaker metrics updater
+	go func() {
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			server.UpdateOllamaMetrics()
+		}
+	}()
+
+	// Pick up domain policy and site credential changes made by other instances
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := server.RefreshDomainPolicies(); err != nil {
+				logger.Warn("failed to refresh domain policies", "error", err)
+			}
+			if err := server.RefreshSiteCredentials(); err != nil {
+				logger.Warn("failed to refresh site credentials", "error", err)
+			}
+		}
+	}()
+
+	// Relearn score adjustments from labels recorded by other instances
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := server.RefreshScoreAdjustments(); err != nil {
+				logger.Warn("failed to refresh score adjustments", "error", err)
+			}
+		}
+	}()
+
+	// Remove metadata the policy forbids from images stored before it applied
+	go func() {
+		scrubbed, err := server.BackfillImageMetadata(context.Background())
+		if err != nil {
+			logger.Error("image metadata backfill failed", "scrubbed", scrubbed, "error", err)
+			return
+		}
+		if scrubbed > 0 {
+			logger.Info("image metadata backfill complete", "scrubbed", scrubbed, "policy", imageMetadataPolicy)
+		}
+	}()
+
+	// Poll feed subscriptions and scrape their new entries
+	if *feedWorkersFlag > 0 {
+		server.StartFeedWorkers(context.Background(), *feedWorkersFlag)
+		go func() {
+			ticker := time.NewTicker(time.Minute)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := server.PollFeeds(context.Background()); err != nil {
+					logger.Warn("failed to poll feeds", "error", err)
+				}
+			}
+		}()
+	}
+
+	// Hot-reload link rules when the file or stored rule set changes
+	go server.Rules().Watch(context.Background(), *rulesReload)
+
+	// Check Ollama models in the background so a slow pull doesn't delay startup
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+		server.CheckOllamaModels(ctx)
+	}()
+
 	// Start server in a goroutine
 	go func() {
 		logger.Info("scraper service starting",
@@ -197,7 +566,28 @@ func main() {
 			"ollama_vision_model", *ollamaVisionModel,
 			"link_score_threshold", *scoreThreshold,
 			"max_images", maxImages,
+			"max_page_size", *maxPageSizeFlag,
+			"fetch_retries", *fetchRetriesFlag,
+			"fetch_retry_delay", *fetchRetryDelayFlag,
+			"feed_workers", *feedWorkersFlag,
+			"image_derivative_widths", *derivativeWidthsFlag,
+			"image_metadata_policy", imageMetadataPolicy,
+			"image_metadata_retain", *retainImageMetadata,
+			"image_safety_action", imageSafetyAction,
+			"image_safety_threshold", *imageSafetyThresholdFlag,
+			"ocr_engine", *ocrEngineFlag,
+			"ocr_vision_fallback", *ocrVisionFallback,
 			"image_analysis_enabled", !*disableImageAnalysis,
+			"ollama_auto_pull", *ollamaAutoPull,
+			"ollama_vision_url", *ollamaVisionURL,
+			"ollama_routing", routing,
+			"auth_enabled", *authEnabled,
+			"rules_source", server.Rules().Status().Source,
+			"score_adjustments", *scoreAdjustments,
+			"translate_to", *translateTo,
+			"block_private_networks", *blockPrivateNetworks,
+			"allowed_hosts", *allowedHosts,
+			"network_profiles", len(networkProfiles),
 		)
 
 		if err := server.Start(); err != nil {
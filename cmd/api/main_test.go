@@ -159,3 +159,25 @@ func TestGetEnvPrecedence(t *testing.T) {
 		t.Errorf("Expected default value when env var not set. Got %q, want %q", result, defaultValue)
 	}
 }
+
+func TestSplitList(t *testing.T) {
+	got := splitList(" wiki.corp, ,10.0.0.0/8,")
+	if len(got) != 2 || got[0] != "wiki.corp" || got[1] != "10.0.0.0/8" {
+		t.Errorf("splitList = %q, want [wiki.corp 10.0.0.0/8]", got)
+	}
+	if got := splitList(""); len(got) != 0 {
+		t.Errorf("splitList(\"\") = %q, want empty", got)
+	}
+}
+
+func TestParseWidths(t *testing.T) {
+	got, err := parseWidths("320, 640,,1280")
+	if err != nil || len(got) != 3 || got[0] != 320 || got[1] != 640 || got[2] != 1280 {
+		t.Errorf("parseWidths = %v, %v, want [320 640 1280]", got, err)
+	}
+	for _, value := range []string{"320,wide", "0", "-640"} {
+		if _, err := parseWidths(value); err == nil {
+			t.Errorf("parseWidths(%q) should fail", value)
+		}
+	}
+}
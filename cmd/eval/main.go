@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/eval"
+)
+
+func main() {
+	corpus := flag.String("corpus", "eval/testdata/golden", "Directory containing the golden corpus")
+	ollamaURL := flag.String("ollama-url", "", "Ollama server to evaluate against (default: canned fake LLM responses)")
+	ollamaModel := flag.String("ollama-model", "", "Ollama model for text (default: scraper default)")
+	ollamaVisionModel := flag.String("ollama-vision-model", "", "Ollama model for images (default: scraper default)")
+	filter := flag.String("case", "", "Only run cases whose name contains this string")
+	out := flag.String("out", "", "Write the JSON report to this file")
+	baseline := flag.String("baseline", "", "Compare with a previous JSON report and fail on regressions")
+	timeout := flag.Duration("timeout", 2*time.Minute, "Timeout for each case")
+	verbose := flag.Bool("v", false, "Show scraper logs")
+	flag.Parse()
+
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelInfo
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	cases, err := eval.LoadCorpus(*corpus)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	config := scraper.DefaultConfig()
+	if *ollamaModel != "" {
+		config.OllamaModel = *ollamaModel
+	}
+	if *ollamaVisionModel != "" {
+		config.OllamaVisionModel = *ollamaVisionModel
+	}
+
+	report := eval.Run(context.Background(), cases, eval.Options{
+		OllamaURL:   *ollamaURL,
+		Config:      &config,
+		Filter:      *filter,
+		CaseTimeout: *timeout,
+	})
+
+	if err := report.WriteText(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		err = report.WriteJSON(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	failed := report.Failed()
+	if *baseline != "" {
+		base, err := eval.LoadReport(*baseline)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		regressions := eval.Compare(base, report)
+		if len(regressions) > 0 {
+			fmt.Printf("\nRegressions against %s:\n", *baseline)
+			for _, r := range regressions {
+				fmt.Printf("  - %s\n", r)
+			}
+			failed = true
+		} else {
+			fmt.Printf("\nNo regressions against %s\n", *baseline)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
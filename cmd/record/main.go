@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/fixture"
+)
+
+// record scrapes a URL once and saves every HTTP and Ollama exchange to a fixture
+// archive, so the scrape can be replayed in tests with scraper.Config.Replay
+func main() {
+	targetURL := flag.String("url", "", "URL to scrape (required)")
+	out := flag.String("out", "", "Fixture archive to write (required)")
+	ollamaURL := flag.String("ollama-url", scraper.DefaultConfig().OllamaBaseURL, "Ollama server")
+	ollamaModel := flag.String("ollama-model", "", "Ollama model for text (default: scraper default)")
+	ollamaVisionModel := flag.String("ollama-vision-model", "", "Ollama model for images (default: scraper default)")
+	replay := flag.Bool("replay", false, "Replay the archive at -out instead of recording, and print the result")
+	timeout := flag.Duration("timeout", 5*time.Minute, "Timeout for the scrape")
+	flag.Parse()
+
+	if *targetURL == "" || *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	config := scraper.DefaultConfig()
+	config.OllamaBaseURL = *ollamaURL
+	if *ollamaModel != "" {
+		config.OllamaModel = *ollamaModel
+	}
+	if *ollamaVisionModel != "" {
+		config.OllamaVisionModel = *ollamaVisionModel
+	}
+
+	var recorder *fixture.Recorder
+	if *replay {
+		archive, err := fixture.Load(*out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		config.Replay = archive
+	} else {
+		recorder = fixture.NewRecorder()
+		config.Recorder = recorder
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	data, err := scraper.New(config, nil, nil).Scrape(ctx, *targetURL)
+	if recorder != nil {
+		// Failed scrapes are worth keeping too: they are usually the bug being reproduced
+		archive := recorder.Archive()
+		if saveErr := archive.Save(*out); saveErr != nil {
+			fmt.Fprintln(os.Stderr, saveErr)
+			os.Exit(1)
+		}
+		slog.Info("fixture archive saved", "path", *out, "entries", len(archive.Entries))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "scrape failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(data)
+}
@@ -0,0 +1,337 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/docutag/scraper/models"
+	"golang.org/x/net/html"
+)
+
+// maxLoginPageBytes bounds the login form and login response bodies read
+const maxLoginPageBytes = 2 * 1024 * 1024
+
+// siteAuth applies one stored credential and holds the session of a form login
+type siteAuth struct {
+	credential models.SiteCredential
+	mu         sync.Mutex
+	jar        *cookiejar.Jar // Session cookies from the last successful form login
+}
+
+// credentialIndex maps normalized domains to their credential
+type credentialIndex map[string]*siteAuth
+
+// SetSiteCredentials replaces the credentials applied to page and image fetches
+// Form login sessions of stored credentials whose UpdatedAt did not change are kept.
+func (s *Scraper) SetSiteCredentials(credentials []*models.SiteCredential) {
+	previous := s.credentials.Load()
+	index := make(credentialIndex, len(credentials))
+	for _, c := range credentials {
+		domain := NormalizeDomain(c.Domain)
+		if previous != nil && !c.UpdatedAt.IsZero() {
+			if auth, ok := (*previous)[domain]; ok && auth.credential.UpdatedAt.Equal(c.UpdatedAt) {
+				index[domain] = auth
+				continue
+			}
+		}
+		index[domain] = &siteAuth{credential: *c}
+	}
+	s.credentials.Store(&index)
+}
+
+// siteAuthFor returns the credential that applies to a URL, or nil if none does
+// A credential covers its domain and every subdomain; the most specific domain wins
+func (s *Scraper) siteAuthFor(rawURL string) *siteAuth {
+	index := s.credentials.Load()
+	if index == nil || len(*index) == 0 {
+		return nil
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	host := NormalizeDomain(parsed.Hostname())
+	for host != "" {
+		if auth, ok := (*index)[host]; ok {
+			return auth
+		}
+		dot := strings.Index(host, ".")
+		if dot < 0 {
+			break
+		}
+		host = host[dot+1:]
+	}
+	return nil
+}
+
+// withinDomain reports whether host is domain or one of its subdomains, the hosts a
+// credential for domain covers
+func withinDomain(host, domain string) bool {
+	host, domain = NormalizeDomain(host), NormalizeDomain(domain)
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// secureSubmit reports whether a login form whose page is at login may be posted to
+// submit: over HTTPS, or over plain HTTP only if the login page is plain HTTP too
+func secureSubmit(login, submit *url.URL) bool {
+	switch submit.Scheme {
+	case "https":
+		return true
+	case "http":
+		return login.Scheme == "http"
+	default:
+		return false
+	}
+}
+
+// authorize adds the stored credential for the request's domain to req, logging in first
+// for form logins without a session
+// The client drops Authorization and Cookie headers on redirects to other domains, so
+// credentials never follow a redirect off the site.
+func (s *Scraper) authorize(req *http.Request) error {
+	auth := s.siteAuthFor(req.URL.String())
+	if auth == nil {
+		return nil
+	}
+
+	secret := auth.credential.Secret
+	switch auth.credential.Kind {
+	case models.CredentialCookies:
+		for name, value := range secret.Cookies {
+			req.AddCookie(&http.Cookie{Name: name, Value: value})
+		}
+	case models.CredentialBearer:
+		req.Header.Set("Authorization", "Bearer "+secret.Token)
+	case models.CredentialBasic:
+		req.SetBasicAuth(secret.Username, secret.Password)
+	case models.CredentialFormLogin:
+		jar, err := s.loginSession(req.Context(), auth)
+		if err != nil {
+			return err
+		}
+		for _, cookie := range jar.Cookies(req.URL) {
+			req.AddCookie(cookie)
+		}
+	}
+	return nil
+}
+
+// expired reports whether resp shows that a form login session is no longer valid:
+// the site answered 401 or redirected to its login page
+func (a *siteAuth) expired(resp *http.Response) bool {
+	if a.credential.Kind != models.CredentialFormLogin || a.credential.Login == nil {
+		return false
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	login, err := url.Parse(a.credential.Login.URL)
+	if err != nil || resp.Request.Response == nil {
+		return false
+	}
+	final := resp.Request.URL
+	return strings.EqualFold(final.Host, login.Host) && final.Path == login.Path
+}
+
+// logout forgets the form login session so the next request logs in again
+func (a *siteAuth) logout() {
+	a.mu.Lock()
+	a.jar = nil
+	a.mu.Unlock()
+}
+
+// loginSession returns the session of a form login credential, logging in if there is none
+// Concurrent fetches for the site wait for a single login.
+func (s *Scraper) loginSession(ctx context.Context, auth *siteAuth) (*cookiejar.Jar, error) {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	if auth.jar != nil {
+		return auth.jar, nil
+	}
+
+	jar, err := s.formLogin(ctx, auth.credential)
+	if err != nil {
+		return nil, fmt.Errorf("login to %s failed: %w", auth.credential.Domain, err)
+	}
+	slog.Info("logged in to site", "domain", auth.credential.Domain)
+	auth.jar = jar
+	return jar, nil
+}
+
+// formLogin fetches the login form, submits it with the credential and returns the
+// cookies the site set
+func (s *Scraper) formLogin(ctx context.Context, c models.SiteCredential) (*cookiejar.Jar, error) {
+	recipe := c.Login
+	if recipe == nil || recipe.URL == "" {
+		return nil, fmt.Errorf("no login URL configured")
+	}
+	usernameField := recipe.UsernameField
+	if usernameField == "" {
+		usernameField = "username"
+	}
+	passwordField := recipe.PasswordField
+	if passwordField == "" {
+		passwordField = "password"
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	profile := s.profileFor(recipe.URL)
+	client := *profile.clients[profile.pick()]
+	client.Jar = jar
+
+	// The form page sets the pre-login cookies and carries hidden fields such as CSRF tokens
+	req, err := profile.newRequest(ctx, recipe.URL)
+	if err != nil {
+		return nil, err
+	}
+	formPage, err := readLoginResponse(&client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch login form: %w", err)
+	}
+
+	fields := url.Values{}
+	submitURL := recipe.SubmitURL
+	if form := findLoginForm(formPage.doc, passwordField); form != nil {
+		for _, input := range hiddenInputs(form) {
+			fields.Set(input[0], input[1])
+		}
+		if submitURL == "" {
+			action, err := formPage.url.Parse(attrValue(form, "action"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid login form action: %w", err)
+			}
+			submitURL = action.String()
+		}
+	} else if submitURL == "" {
+		return nil, fmt.Errorf("no form with a %q field at %s", passwordField, recipe.URL)
+	}
+	// The form page may name any action; the password only goes to the credential's site,
+	// and is never downgraded to plain HTTP from an HTTPS login page
+	submitted, err := url.Parse(submitURL)
+	if err != nil || !withinDomain(submitted.Hostname(), c.Domain) {
+		return nil, fmt.Errorf("refusing to submit credentials for %s to %s", c.Domain, submitURL)
+	}
+	if login, err := url.Parse(recipe.URL); err != nil || !secureSubmit(login, submitted) {
+		return nil, fmt.Errorf("refusing to submit credentials for %s from %s over insecure %s", c.Domain, recipe.URL, submitURL)
+	}
+	for name, value := range recipe.Fields {
+		fields.Set(name, value)
+	}
+	fields.Set(usernameField, c.Secret.Username)
+	fields.Set(passwordField, c.Secret.Password)
+
+	post, err := profile.newRequestWithBody(ctx, http.MethodPost, submitURL, strings.NewReader(fields.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	post.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	post.Header.Set("Referer", formPage.url.String())
+	result, err := readLoginResponse(&client, post)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit login form: %w", err)
+	}
+
+	// Sites commonly answer bad credentials by showing the form again
+	if findLoginForm(result.doc, passwordField) != nil {
+		return nil, fmt.Errorf("the login form was shown again; check the username and password")
+	}
+	if len(jar.Cookies(submitted)) == 0 {
+		return nil, fmt.Errorf("the site set no session cookies")
+	}
+	return jar, nil
+}
+
+// loginPage is a parsed response from a login step
+type loginPage struct {
+	url *url.URL // Final URL after redirects
+	doc *html.Node
+}
+
+// readLoginResponse sends a login step request and parses the page it ends on
+func readLoginResponse(client *http.Client, req *http.Request) (*loginPage, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxLoginPageBytes))
+	if err != nil {
+		return nil, err
+	}
+	doc, _, err := parseHTML(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse login page: %w", err)
+	}
+	return &loginPage{url: resp.Request.URL, doc: doc}, nil
+}
+
+// findLoginForm returns the first form with an input named passwordField, or nil
+func findLoginForm(doc *html.Node, passwordField string) *html.Node {
+	var found *html.Node
+	var walk func(n, form *html.Node)
+	walk = func(n, form *html.Node) {
+		if found != nil {
+			return
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "form":
+				form = n
+			case "input":
+				if form != nil && attrValue(n, "name") == passwordField {
+					found = form
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, form)
+		}
+	}
+	walk(doc, nil)
+	return found
+}
+
+// hiddenInputs returns the name and value of each hidden input in a form
+func hiddenInputs(form *html.Node) [][2]string {
+	var inputs [][2]string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "input" && strings.EqualFold(attrValue(n, "type"), "hidden") {
+			if name := attrValue(n, "name"); name != "" {
+				inputs = append(inputs, [2]string{name, attrValue(n, "value")})
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(form)
+	return inputs
+}
+
+// attrValue returns the value of an element's attribute, or "" if it is absent
+func attrValue(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
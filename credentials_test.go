@@ -0,0 +1,222 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestAuthorizeAppliesCredentials(t *testing.T) {
+	var got atomic.Pointer[http.Header]
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := r.Header.Clone()
+		got.Store(&h)
+		if strings.HasSuffix(r.URL.Path, ".png") {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG\r\n\x1a\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body>ok</body></html>"))
+	}))
+	defer server.Close()
+	otherHost := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	cases := []struct {
+		credential models.SiteCredential
+		check      func(h http.Header) bool
+	}{
+		{
+			models.SiteCredential{Kind: models.CredentialBearer, Secret: models.CredentialSecret{Token: "tok"}},
+			func(h http.Header) bool { return h.Get("Authorization") == "Bearer tok" },
+		},
+		{
+			models.SiteCredential{Kind: models.CredentialBasic, Secret: models.CredentialSecret{Username: "u", Password: "p"}},
+			func(h http.Header) bool { return h.Get("Authorization") == "Basic dTpw" },
+		},
+		{
+			models.SiteCredential{Kind: models.CredentialCookies, Secret: models.CredentialSecret{Cookies: map[string]string{"session": "abc"}}},
+			func(h http.Header) bool { return h.Get("Cookie") == "session=abc" },
+		},
+	}
+
+	s := New(DefaultConfig(), nil, nil)
+	ctx := context.Background()
+	for _, c := range cases {
+		c.credential.Domain = "127.0.0.1"
+		s.SetSiteCredentials([]*models.SiteCredential{&c.credential})
+
+		if _, err := s.fetchPage(ctx, server.URL+"/page"); err != nil {
+			t.Fatalf("%s: fetchPage failed: %v", c.credential.Kind, err)
+		}
+		if !c.check(*got.Load()) {
+			t.Errorf("%s: page request headers = %v", c.credential.Kind, *got.Load())
+		}
+
+		if _, _, err := s.downloadImage(ctx, server.URL+"/image.png", 5*time.Second); err != nil {
+			t.Fatalf("%s: downloadImage failed: %v", c.credential.Kind, err)
+		}
+		if !c.check(*got.Load()) {
+			t.Errorf("%s: image request headers = %v", c.credential.Kind, *got.Load())
+		}
+
+		// Other domains never see the credential
+		if _, err := s.fetchPage(ctx, otherHost+"/page"); err != nil {
+			t.Fatalf("%s: fetchPage failed: %v", c.credential.Kind, err)
+		}
+		if h := *got.Load(); h.Get("Authorization") != "" || h.Get("Cookie") != "" {
+			t.Errorf("%s: credential sent to another domain: %v", c.credential.Kind, h)
+		}
+	}
+}
+
+func TestFormLogin(t *testing.T) {
+	var logins atomic.Int32
+	var session atomic.Value
+	session.Store("sid-1")
+
+	loginForm := `<html><body><form method="post" action="/session">
+		<input type="hidden" name="csrf" value="token-123">
+		<input name="user"><input type="password" name="pass">
+	</form></body></html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "pre", Value: "1", Path: "/"})
+			w.Write([]byte(loginForm))
+		case "/session":
+			r.ParseForm()
+			pre, _ := r.Cookie("pre")
+			if pre == nil || r.PostForm.Get("csrf") != "token-123" || r.PostForm.Get("user") != "reader" || r.PostForm.Get("pass") != "hunter2" {
+				w.Write([]byte(loginForm))
+				return
+			}
+			logins.Add(1)
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: session.Load().(string), Path: "/"})
+			http.Redirect(w, r, "/home", http.StatusSeeOther)
+		case "/home":
+			w.Write([]byte("<html><body>welcome</body></html>"))
+		case "/private":
+			if sid, _ := r.Cookie("sid"); sid == nil || sid.Value != session.Load().(string) {
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return
+			}
+			w.Write([]byte("<html><body>members only</body></html>"))
+		}
+	}))
+	defer server.Close()
+
+	credential := func(password string) *models.SiteCredential {
+		return &models.SiteCredential{
+			Domain:    "127.0.0.1",
+			Kind:      models.CredentialFormLogin,
+			Login:     &models.FormLogin{URL: server.URL + "/login", UsernameField: "user", PasswordField: "pass"},
+			Secret:    models.CredentialSecret{Username: "reader", Password: password},
+			UpdatedAt: time.Now(),
+		}
+	}
+
+	s := New(DefaultConfig(), nil, nil)
+	s.SetSiteCredentials([]*models.SiteCredential{credential("hunter2")})
+	ctx := context.Background()
+
+	fetch := func() string {
+		t.Helper()
+		page, err := s.fetchPage(ctx, server.URL+"/private")
+		if err != nil {
+			t.Fatalf("fetchPage failed: %v", err)
+		}
+		return string(page.Body)
+	}
+
+	if body := fetch(); !strings.Contains(body, "members only") {
+		t.Errorf("body = %q, want the private page", body)
+	}
+	fetch()
+	if n := logins.Load(); n != 1 {
+		t.Errorf("logins = %d, want the session reused", n)
+	}
+
+	t.Run("expired session logs in again", func(t *testing.T) {
+		session.Store("sid-2")
+		if body := fetch(); !strings.Contains(body, "members only") {
+			t.Errorf("body = %q, want the private page", body)
+		}
+		if n := logins.Load(); n != 2 {
+			t.Errorf("logins = %d, want 2", n)
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		s.SetSiteCredentials([]*models.SiteCredential{credential("wrong")})
+		_, err := s.fetchPage(ctx, server.URL+"/private")
+		if err == nil || !strings.Contains(err.Error(), "login form was shown again") {
+			t.Errorf("error = %v, want a failed login", err)
+		}
+	})
+	t.Run("form action off the credential's domain", func(t *testing.T) {
+		phishing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("credentials were posted to another host")
+		}))
+		defer phishing.Close()
+		offsite := strings.Replace(phishing.URL, "127.0.0.1", "localhost", 1)
+		loginForm = `<html><body><form method="post" action="` + offsite + `/session">
+			<input name="user"><input type="password" name="pass">
+		</form></body></html>`
+
+		s.SetSiteCredentials([]*models.SiteCredential{credential("hunter2")})
+		_, err := s.fetchPage(ctx, server.URL+"/private")
+		if err == nil || !strings.Contains(err.Error(), "refusing to submit credentials") {
+			t.Errorf("error = %v, want the submit refused", err)
+		}
+	})
+}
+
+func TestWithinDomain(t *testing.T) {
+	tests := []struct {
+		host, domain string
+		want         bool
+	}{
+		{"example.com", "example.com", true},
+		{"www.example.com", "example.com", true},
+		{"login.example.com", "Example.com", true},
+		{"evilexample.com", "example.com", false},
+		{"example.com.evil.test", "example.com", false},
+		{"example.com", "login.example.com", false},
+		{"example.com", "", false},
+	}
+	for _, tt := range tests {
+		if got := withinDomain(tt.host, tt.domain); got != tt.want {
+			t.Errorf("withinDomain(%q, %q) = %v, want %v", tt.host, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestSecureSubmit(t *testing.T) {
+	tests := []struct {
+		login, submit string
+		want          bool
+	}{
+		{"https://example.com/login", "https://example.com/session", true},
+		{"http://example.com/login", "https://example.com/session", true},
+		{"http://example.com/login", "http://example.com/session", true},
+		{"https://example.com/login", "http://example.com/session", false},
+		{"https://example.com/login", "ftp://example.com/session", false},
+	}
+	for _, tt := range tests {
+		login, _ := url.Parse(tt.login)
+		submit, _ := url.Parse(tt.submit)
+		if got := secureSubmit(login, submit); got != tt.want {
+			t.Errorf("secureSubmit(%s, %s) = %v, want %v", tt.login, tt.submit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+// CreateAPIKey stores a new API key with the hash of its secret
+func (db *DB) CreateAPIKey(key *models.APIKey, keyHash string) error {
+	scopesJSON, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scopes: %w", err)
+	}
+
+	query := `
+		INSERT INTO scraper_api_keys (id, name, key_prefix, key_hash, scopes, rate_limit_per_minute, daily_scrape_quota, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = db.conn.Exec(query,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		keyHash,
+		string(scopesJSON),
+		key.RateLimitPerMinute,
+		key.DailyScrapeQuota,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save API key: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of its secret
+// Returns nil if no key matches; revoked keys are returned with RevokedAt set
+func (db *DB) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	query := `
+		SELECT id, name, key_prefix, scopes, rate_limit_per_minute, daily_scrape_quota, created_at, revoked_at
+		FROM scraper_api_keys
+		WHERE key_hash = $1
+	`
+
+	key, err := scanAPIKey(db.conn.QueryRow(query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns all API keys, newest first
+func (db *DB) ListAPIKeys() ([]*models.APIKey, error) {
+	query := `
+		SELECT id, name, key_prefix, scopes, rate_limit_per_minute, daily_scrape_quota, created_at, revoked_at
+		FROM scraper_api_keys
+		ORDER BY created_at DESC
+	`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey marks an API key as revoked so it can no longer authenticate
+func (db *DB) RevokeAPIKey(id string) error {
+	result, err := db.conn.Exec("UPDATE scraper_api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL", time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("no active API key found with id: %s", id)
+	}
+
+	return nil
+}
+
+// IncrementScrapeUsage counts a scrape against a key's quota for the given UTC day
+// Returns false without counting if the key has already used quota scrapes that day
+// A quota of 0 means unlimited
+func (db *DB) IncrementScrapeUsage(keyID string, day time.Time, quota int) (bool, error) {
+	query := `
+		INSERT INTO scraper_api_key_usage (key_id, day, scrapes)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (key_id, day) DO UPDATE SET scrapes = scraper_api_key_usage.scrapes + 1
+		WHERE $3 = 0 OR scraper_api_key_usage.scrapes < $3
+		RETURNING scrapes
+	`
+
+	var scrapes int
+	err := db.conn.QueryRow(query, keyID, day.UTC().Format("2006-01-02"), quota).Scan(&scrapes)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record API key usage: %w", err)
+	}
+
+	return true, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAPIKey scans an API key row
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopesJSON string
+	var revokedAt sql.NullTime
+
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopesJSON, &key.RateLimitPerMinute, &key.DailyScrapeQuota, &key.CreatedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan API key: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(scopesJSON), &key.Scopes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scopes: %w", err)
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
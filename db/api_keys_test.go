@@ -0,0 +1,89 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	key := &models.APIKey{
+		ID:               "key-1",
+		Name:             "test client",
+		Prefix:           "dts_abcdef",
+		Scopes:           []string{"read", "scrape"},
+		DailyScrapeQuota: 2,
+		CreatedAt:        time.Now(),
+	}
+	if err := db.CreateAPIKey(key, "hash-1"); err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+
+	found, err := db.GetAPIKeyByHash("hash-1")
+	if err != nil {
+		t.Fatalf("GetAPIKeyByHash failed: %v", err)
+	}
+	if found == nil || found.ID != key.ID || len(found.Scopes) != 2 {
+		t.Fatalf("GetAPIKeyByHash = %+v, want %+v", found, key)
+	}
+
+	missing, err := db.GetAPIKeyByHash("no-such-hash")
+	if err != nil || missing != nil {
+		t.Errorf("GetAPIKeyByHash(unknown) = %v, %v; want nil, nil", missing, err)
+	}
+
+	// Quota allows two scrapes per day
+	day := time.Now()
+	for i := 0; i < 2; i++ {
+		allowed, err := db.IncrementScrapeUsage(key.ID, day, key.DailyScrapeQuota)
+		if err != nil || !allowed {
+			t.Fatalf("scrape %d: allowed = %v, err = %v", i+1, allowed, err)
+		}
+	}
+	if allowed, _ := db.IncrementScrapeUsage(key.ID, day, key.DailyScrapeQuota); allowed {
+		t.Error("Expected third scrape to exceed quota")
+	}
+	if allowed, _ := db.IncrementScrapeUsage(key.ID, day.AddDate(0, 0, 1), key.DailyScrapeQuota); !allowed {
+		t.Error("Expected quota to reset the next day")
+	}
+
+	if err := db.RevokeAPIKey(key.ID); err != nil {
+		t.Fatalf("RevokeAPIKey failed: %v", err)
+	}
+	revoked, _ := db.GetAPIKeyByHash("hash-1")
+	if revoked.RevokedAt == nil {
+		t.Error("Expected RevokedAt to be set")
+	}
+	if err := db.RevokeAPIKey(key.ID); err == nil {
+		t.Error("Expected error revoking an already revoked key")
+	}
+}
+
+func TestScrapedDataAttributedToAPIKey(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	data := &models.ScrapedData{
+		ID:        "scrape-1",
+		URL:       "https://example.com/attributed",
+		Title:     "Attributed",
+		FetchedAt: time.Now(),
+		CreatedAt: time.Now(),
+		APIKeyID:  "key-1",
+	}
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("SaveScrapedData failed: %v", err)
+	}
+
+	var keyID string
+	if err := db.conn.QueryRow("SELECT api_key_id FROM scraper_scraped_data WHERE id = $1", data.ID).Scan(&keyID); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if keyID != "key-1" {
+		t.Errorf("api_key_id = %s, want key-1", keyID)
+	}
+}
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/docutag/scraper/models"
+)
+
+// ErrNoCredentialsKey is returned by site credential operations when no key is configured
+var ErrNoCredentialsKey = errors.New("site credentials are disabled: no credentials key configured")
+
+// ParseCredentialsKey decodes a base64 credentials key, which must be 32 bytes
+func ParseCredentialsKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("credentials key is not valid base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("credentials key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// newVault returns the AES-256-GCM cipher sealing credential secrets, or nil without a key
+func newVault(key []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, nil
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("credentials key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credentials cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealSecret encrypts a secret, binding it to its domain so rows cannot be swapped
+// The nonce is stored in front of the ciphertext.
+func sealSecret(vault cipher.AEAD, domain string, secret models.CredentialSecret) ([]byte, error) {
+	plaintext, err := json.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal credential secret: %w", err)
+	}
+	nonce := make([]byte, vault.NonceSize(), vault.NonceSize()+len(plaintext)+vault.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return vault.Seal(nonce, nonce, plaintext, []byte(domain)), nil
+}
+
+// openSecret decrypts a secret sealed by sealSecret
+func openSecret(vault cipher.AEAD, domain string, sealed []byte) (models.CredentialSecret, error) {
+	var secret models.CredentialSecret
+	if len(sealed) < vault.NonceSize() {
+		return secret, fmt.Errorf("credential secret for %s is truncated", domain)
+	}
+	nonce, ciphertext := sealed[:vault.NonceSize()], sealed[vault.NonceSize():]
+	plaintext, err := vault.Open(nil, nonce, ciphertext, []byte(domain))
+	if err != nil {
+		return secret, fmt.Errorf("failed to decrypt credential secret for %s (wrong credentials key?): %w", domain, err)
+	}
+	if err := json.Unmarshal(plaintext, &secret); err != nil {
+		return secret, fmt.Errorf("failed to unmarshal credential secret: %w", err)
+	}
+	return secret, nil
+}
+
+// CredentialsEnabled reports whether a credentials key is configured
+func (db *DB) CredentialsEnabled() bool {
+	return db.vault != nil
+}
+
+// ListSiteCredentials returns all site credentials with decrypted secrets, ordered by domain
+func (db *DB) ListSiteCredentials() ([]*models.SiteCredential, error) {
+	if db.vault == nil {
+		return nil, ErrNoCredentialsKey
+	}
+
+	query := `
+		SELECT domain, kind, COALESCE(login, ''), COALESCE(note, ''), secret, created_at, updated_at
+		FROM scraper_site_credentials
+		ORDER BY domain
+	`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list site credentials: %w", err)
+	}
+	defer rows.Close()
+
+	credentials := []*models.SiteCredential{}
+	for rows.Next() {
+		c, err := db.scanSiteCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, c)
+	}
+
+	return credentials, rows.Err()
+}
+
+// GetSiteCredential returns the credential for a domain with its decrypted secret
+// Returns nil if no credential exists for the domain
+func (db *DB) GetSiteCredential(domain string) (*models.SiteCredential, error) {
+	if db.vault == nil {
+		return nil, ErrNoCredentialsKey
+	}
+
+	query := `
+		SELECT domain, kind, COALESCE(login, ''), COALESCE(note, ''), secret, created_at, updated_at
+		FROM scraper_site_credentials
+		WHERE domain = $1
+	`
+
+	c, err := db.scanSiteCredential(db.conn.QueryRow(query, domain))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// UpsertSiteCredential creates or replaces the credential for a domain, encrypting its secret
+// CreatedAt and UpdatedAt are set from the stored row
+func (db *DB) UpsertSiteCredential(c *models.SiteCredential) error {
+	if db.vault == nil {
+		return ErrNoCredentialsKey
+	}
+
+	sealed, err := sealSecret(db.vault, c.Domain, c.Secret)
+	if err != nil {
+		return err
+	}
+	var login sql.NullString
+	if c.Login != nil {
+		loginJSON, err := json.Marshal(c.Login)
+		if err != nil {
+			return fmt.Errorf("failed to marshal login recipe: %w", err)
+		}
+		login = sql.NullString{String: string(loginJSON), Valid: true}
+	}
+
+	query := `
+		INSERT INTO scraper_site_credentials (domain, kind, login, note, secret, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (domain) DO UPDATE SET
+			kind = EXCLUDED.kind,
+			login = EXCLUDED.login,
+			note = EXCLUDED.note,
+			secret = EXCLUDED.secret,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at
+	`
+
+	if err := db.conn.QueryRow(query, c.Domain, c.Kind, login, c.Note, sealed).Scan(&c.CreatedAt, &c.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save site credential: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSiteCredential removes the credential for a domain
+func (db *DB) DeleteSiteCredential(domain string) error {
+	result, err := db.conn.Exec("DELETE FROM scraper_site_credentials WHERE domain = $1", domain)
+	if err != nil {
+		return fmt.Errorf("failed to delete site credential: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("site credential not found: %s", domain)
+	}
+
+	return nil
+}
+
+// scanSiteCredential scans a credential row and decrypts its secret
+func (db *DB) scanSiteCredential(row rowScanner) (*models.SiteCredential, error) {
+	var c models.SiteCredential
+	var login string
+	var sealed []byte
+
+	err := row.Scan(&c.Domain, &c.Kind, &login, &c.Note, &sealed, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan site credential: %w", err)
+	}
+
+	if login != "" {
+		c.Login = &models.FormLogin{}
+		if err := json.Unmarshal([]byte(login), c.Login); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal login recipe: %w", err)
+		}
+	}
+
+	secret, err := openSecret(db.vault, c.Domain, sealed)
+	if err != nil {
+		return nil, err
+	}
+	c.Secret = secret
+	return &c, nil
+}
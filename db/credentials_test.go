@@ -0,0 +1,94 @@
+package db
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestSealSecret(t *testing.T) {
+	vault, err := newVault(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("newVault failed: %v", err)
+	}
+	secret := models.CredentialSecret{Username: "reader", Password: "hunter2"}
+
+	sealed, err := sealSecret(vault, "wiki.example.com", secret)
+	if err != nil {
+		t.Fatalf("sealSecret failed: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("hunter2")) {
+		t.Error("sealed secret contains the plaintext password")
+	}
+
+	got, err := openSecret(vault, "wiki.example.com", sealed)
+	if err != nil || got.Username != "reader" || got.Password != "hunter2" {
+		t.Errorf("openSecret = %+v, %v", got, err)
+	}
+	if _, err := openSecret(vault, "other.example.com", sealed); err == nil {
+		t.Error("expected a secret moved to another domain to fail to decrypt")
+	}
+
+	other, _ := newVault(bytes.Repeat([]byte{8}, 32))
+	if _, err := openSecret(other, "wiki.example.com", sealed); err == nil {
+		t.Error("expected decryption with the wrong key to fail")
+	}
+}
+
+func TestParseCredentialsKey(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	got, err := ParseCredentialsKey(base64.StdEncoding.EncodeToString(key) + "\n")
+	if err != nil || !bytes.Equal(got, key) {
+		t.Errorf("ParseCredentialsKey = %v, %v", got, err)
+	}
+	for _, bad := range []string{"not base64!", base64.StdEncoding.EncodeToString(key[:16])} {
+		if _, err := ParseCredentialsKey(bad); err == nil {
+			t.Errorf("ParseCredentialsKey(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestSiteCredentials(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if !db.CredentialsEnabled() {
+		t.Skip("no credentials key configured")
+	}
+
+	c := &models.SiteCredential{
+		Domain: "wiki.example.com",
+		Kind:   models.CredentialFormLogin,
+		Login:  &models.FormLogin{URL: "https://wiki.example.com/login"},
+		Secret: models.CredentialSecret{Username: "reader", Password: "hunter2"},
+	}
+	if err := db.UpsertSiteCredential(c); err != nil {
+		t.Fatalf("UpsertSiteCredential failed: %v", err)
+	}
+
+	var stored []byte
+	if err := db.conn.QueryRow("SELECT secret FROM scraper_site_credentials WHERE domain = $1", c.Domain).Scan(&stored); err != nil {
+		t.Fatalf("failed to read stored secret: %v", err)
+	}
+	if strings.Contains(string(stored), "hunter2") {
+		t.Error("secret is stored in plaintext")
+	}
+
+	got, err := db.GetSiteCredential("wiki.example.com")
+	if err != nil || got == nil {
+		t.Fatalf("GetSiteCredential = %v, %v", got, err)
+	}
+	if got.Secret.Password != "hunter2" || got.Login == nil || got.Login.URL != c.Login.URL {
+		t.Errorf("unexpected credential: %+v", got)
+	}
+
+	if err := db.DeleteSiteCredential("wiki.example.com"); err != nil {
+		t.Fatalf("DeleteSiteCredential failed: %v", err)
+	}
+	if err := db.DeleteSiteCredential("wiki.example.com"); err == nil {
+		t.Error("Expected error deleting a missing credential")
+	}
+}
@@ -1,29 +1,37 @@
 package db
 
 import (
+	"crypto/cipher"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq" // PostgreSQL driver
 
 	"github.com/docutag/scraper/models"
 )
 
 // DB wraps the database connection and provides data access methods
 type DB struct {
-	conn *sql.DB
+	conn  *sql.DB
+	vault cipher.AEAD // Seals site credential secrets (nil when no key is configured)
 }
 
 // Config contains database configuration
 type Config struct {
-	DSN string // PostgreSQL connection string
+	DSN            string // PostgreSQL connection string
+	CredentialsKey []byte // 32-byte AES-256 key for site credential secrets (nil disables the vault)
 }
 
 // New creates a new database connection
 func New(config Config) (*DB, error) {
+	vault, err := newVault(config.CredentialsKey)
+	if err != nil {
+		return nil, err
+	}
+
 	conn, err := sql.Open("postgres", config.DSN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -39,7 +47,7 @@ func New(config Config) (*DB, error) {
 	conn.SetMaxIdleConns(5)
 	conn.SetConnMaxLifetime(5 * time.Minute)
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, vault: vault}
 
 	// Run PostgreSQL migrations
 	if err := Migrate(conn); err != nil {
@@ -76,13 +84,14 @@ func (db *DB) SaveScrapedData(data *models.ScrapedData) error {
 
 	// Insert or replace scraped data
 	query := `
-		INSERT INTO scraper_scraped_data (id, url, data, slug, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO scraper_scraped_data (id, url, data, slug, created_at, updated_at, api_key_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		ON CONFLICT(url) DO UPDATE SET
 			id = excluded.id,
 			data = excluded.data,
 			slug = excluded.slug,
-			updated_at = excluded.updated_at
+			updated_at = excluded.updated_at,
+			api_key_id = excluded.api_key_id
 	`
 
 	_, err = tx.Exec(
@@ -93,6 +102,7 @@ func (db *DB) SaveScrapedData(data *models.ScrapedData) error {
 		data.Slug,
 		data.FetchedAt,
 		time.Now(),
+		sql.NullString{String: data.APIKeyID, Valid: data.APIKeyID != ""},
 	)
 
 	if err != nil {
@@ -126,9 +136,22 @@ func (db *DB) SaveScrapedData(data *models.ScrapedData) error {
 			}
 		}
 
+		privateEXIF, err := marshalPrivateEXIF(image.PrivateEXIF)
+		if err != nil {
+			return err
+		}
+		safetyCategories, safetyFlagged, err := marshalSafety(image.Safety)
+		if err != nil {
+			return err
+		}
+		textBlocks, err := marshalTextBlocks(image.TextBlocks)
+		if err != nil {
+			return err
+		}
+
 		imageQuery := `
-			INSERT INTO scraper_images (id, scrape_id, url, alt_text, summary, tags, base64_data, file_path, slug, width, height, file_size_bytes, content_type, exif_data, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			INSERT INTO scraper_images (id, scrape_id, url, alt_text, summary, tags, base64_data, file_path, slug, width, height, file_size_bytes, content_type, exif_data, caption, sha256, phash, exif_private, metadata_policy, safety_categories, safety_flagged, tombstone_datetime, extracted_text, text_blocks, ocr_engine, created_at, updated_at, phash_bands)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NULLIF($15, ''), NULLIF($16, ''), $17, $18, NULLIF($19, ''), NULLIF($20, ''), $21, $22, $23, NULLIF($24, ''), NULLIF($25, ''), $26, $27, NULLIF($28::INTEGER[], '{}'))
 		`
 
 		_, err = tx.Exec(
@@ -147,8 +170,20 @@ func (db *DB) SaveScrapedData(data *models.ScrapedData) error {
 			image.FileSizeBytes,
 			image.ContentType,
 			string(exifJSON),
+			image.Caption,
+			image.SHA256,
+			phashValue(image.PerceptualHash),
+			privateEXIF,
+			image.MetadataPolicy,
+			safetyCategories,
+			safetyFlagged,
+			image.TombstoneDatetime,
+			image.ExtractedText,
+			textBlocks,
+			image.OCREngine,
 			time.Now(),
 			time.Now(),
+			pq.Array(phashBands(image.PerceptualHash)),
 		)
 
 		if err != nil {
@@ -298,9 +333,22 @@ func (db *DB) SaveImage(image *models.ImageInfo, scrapeID string) error {
 		}
 	}
 
+	privateEXIF, err := marshalPrivateEXIF(image.PrivateEXIF)
+	if err != nil {
+		return err
+	}
+	safetyCategories, safetyFlagged, err := marshalSafety(image.Safety)
+	if err != nil {
+		return err
+	}
+	textBlocks, err := marshalTextBlocks(image.TextBlocks)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO scraper_images (id, scrape_id, url, alt_text, summary, tags, extracted_text, base64_data, file_path, slug, width, height, file_size_bytes, content_type, exif_data, relevance_score, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		INSERT INTO scraper_images (id, scrape_id, url, alt_text, summary, tags, extracted_text, base64_data, file_path, slug, width, height, file_size_bytes, content_type, exif_data, relevance_score, caption, sha256, phash, exif_private, metadata_policy, safety_categories, safety_flagged, tombstone_datetime, text_blocks, ocr_engine, created_at, updated_at, phash_bands)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NULLIF($17, ''), NULLIF($18, ''), $19, $20, NULLIF($21, ''), NULLIF($22, ''), $23, $24, NULLIF($25, ''), NULLIF($26, ''), $27, $28, NULLIF($29::INTEGER[], '{}'))
 	`
 
 	_, err = db.conn.Exec(
@@ -321,8 +369,19 @@ func (db *DB) SaveImage(image *models.ImageInfo, scrapeID string) error {
 		image.ContentType,
 		string(exifJSON),
 		image.RelevanceScore,
+		image.Caption,
+		image.SHA256,
+		phashValue(image.PerceptualHash),
+		privateEXIF,
+		image.MetadataPolicy,
+		safetyCategories,
+		safetyFlagged,
+		image.TombstoneDatetime,
+		textBlocks,
+		image.OCREngine,
 		time.Now(),
 		time.Now(),
+		pq.Array(phashBands(image.PerceptualHash)),
 	)
 
 	if err != nil {
@@ -338,6 +397,7 @@ func (db *DB) GetImageByID(id string) (*models.ImageInfo, error) {
 		imageID           string
 		url               string
 		altText           string
+		caption           string
 		summary           string
 		tagsJSON          string
 		extractedText     sql.NullString
@@ -352,10 +412,17 @@ func (db *DB) GetImageByID(id string) (*models.ImageInfo, error) {
 		contentType       sql.NullString
 		exifJSON          sql.NullString
 		relevanceScore    sql.NullFloat64
+		sha256Sum         string
+		phash             sql.NullInt64
+		metadataPolicy    string
+		safetyJSON        sql.NullString
+		safetyFlagged     bool
+		textBlocksJSON    sql.NullString
+		ocrEngine         string
 	)
 
-	query := "SELECT id, url, alt_text, summary, tags, extracted_text, base64_data, file_path, slug, scrape_id, tombstone_datetime, width, height, file_size_bytes, content_type, exif_data, relevance_score FROM scraper_images WHERE id = $1"
-	err := db.conn.QueryRow(query, id).Scan(&imageID, &url, &altText, &summary, &tagsJSON, &extractedText, &base64Data, &filePath, &slugVal, &scrapeID, &tombstoneDatetime, &width, &height, &fileSizeBytes, &contentType, &exifJSON, &relevanceScore)
+	query := "SELECT id, url, alt_text, COALESCE(caption, ''), summary, tags, extracted_text, base64_data, file_path, slug, scrape_id, tombstone_datetime, width, height, file_size_bytes, content_type, exif_data, relevance_score, COALESCE(sha256, ''), phash, COALESCE(metadata_policy, ''), safety_categories, COALESCE(safety_flagged, FALSE), text_blocks, COALESCE(ocr_engine, '') FROM scraper_images WHERE id = $1"
+	err := db.conn.QueryRow(query, id).Scan(&imageID, &url, &altText, &caption, &summary, &tagsJSON, &extractedText, &base64Data, &filePath, &slugVal, &scrapeID, &tombstoneDatetime, &width, &height, &fileSizeBytes, &contentType, &exifJSON, &relevanceScore, &sha256Sum, &phash, &metadataPolicy, &safetyJSON, &safetyFlagged, &textBlocksJSON, &ocrEngine)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -375,6 +442,7 @@ func (db *DB) GetImageByID(id string) (*models.ImageInfo, error) {
 		ID:          imageID,
 		URL:         url,
 		AltText:     altText,
+		Caption:     caption,
 		Summary:     summary,
 		Tags:        tags,
 		Base64Data:  base64Data,
@@ -414,6 +482,16 @@ func (db *DB) GetImageByID(id string) (*models.ImageInfo, error) {
 	if relevanceScore.Valid {
 		image.RelevanceScore = relevanceScore.Float64
 	}
+	image.SHA256 = sha256Sum
+	image.PerceptualHash = formatPHash(phash)
+	image.MetadataPolicy = metadataPolicy
+	if image.Safety, err = unmarshalSafety(safetyJSON, safetyFlagged); err != nil {
+		return nil, err
+	}
+	if image.TextBlocks, err = unmarshalTextBlocks(textBlocksJSON); err != nil {
+		return nil, err
+	}
+	image.OCREngine = ocrEngine
 
 	return image, nil
 }
@@ -424,6 +502,7 @@ func (db *DB) GetImageByURL(url string) (*models.ImageInfo, error) {
 		imageID        string
 		imageURL       string
 		altText        string
+		caption        string
 		summary        string
 		tagsJSON       string
 		extractedText  sql.NullString
@@ -437,10 +516,17 @@ func (db *DB) GetImageByURL(url string) (*models.ImageInfo, error) {
 		contentType    sql.NullString
 		exifJSON       sql.NullString
 		relevanceScore sql.NullFloat64
+		sha256Sum      string
+		phash          sql.NullInt64
+		metadataPolicy string
+		safetyJSON     sql.NullString
+		safetyFlagged  bool
+		textBlocksJSON sql.NullString
+		ocrEngine      string
 	)
 
-	query := "SELECT id, url, alt_text, summary, tags, extracted_text, base64_data, file_path, slug, scrape_id, width, height, file_size_bytes, content_type, exif_data, relevance_score FROM scraper_images WHERE url = $1 LIMIT 1"
-	err := db.conn.QueryRow(query, url).Scan(&imageID, &imageURL, &altText, &summary, &tagsJSON, &extractedText, &base64Data, &filePath, &slugVal, &scrapeID, &width, &height, &fileSizeBytes, &contentType, &exifJSON, &relevanceScore)
+	query := "SELECT id, url, alt_text, COALESCE(caption, ''), summary, tags, extracted_text, base64_data, file_path, slug, scrape_id, width, height, file_size_bytes, content_type, exif_data, relevance_score, COALESCE(sha256, ''), phash, COALESCE(metadata_policy, ''), safety_categories, COALESCE(safety_flagged, FALSE), text_blocks, COALESCE(ocr_engine, '') FROM scraper_images WHERE url = $1 LIMIT 1"
+	err := db.conn.QueryRow(query, url).Scan(&imageID, &imageURL, &altText, &caption, &summary, &tagsJSON, &extractedText, &base64Data, &filePath, &slugVal, &scrapeID, &width, &height, &fileSizeBytes, &contentType, &exifJSON, &relevanceScore, &sha256Sum, &phash, &metadataPolicy, &safetyJSON, &safetyFlagged, &textBlocksJSON, &ocrEngine)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -460,6 +546,7 @@ func (db *DB) GetImageByURL(url string) (*models.ImageInfo, error) {
 		ID:          imageID,
 		URL:         imageURL,
 		AltText:     altText,
+		Caption:     caption,
 		Summary:     summary,
 		Tags:        tags,
 		Base64Data:  base64Data,
@@ -493,6 +580,16 @@ func (db *DB) GetImageByURL(url string) (*models.ImageInfo, error) {
 	if relevanceScore.Valid {
 		image.RelevanceScore = relevanceScore.Float64
 	}
+	image.SHA256 = sha256Sum
+	image.PerceptualHash = formatPHash(phash)
+	image.MetadataPolicy = metadataPolicy
+	if image.Safety, err = unmarshalSafety(safetyJSON, safetyFlagged); err != nil {
+		return nil, err
+	}
+	if image.TextBlocks, err = unmarshalTextBlocks(textBlocksJSON); err != nil {
+		return nil, err
+	}
+	image.OCREngine = ocrEngine
 
 	return image, nil
 }
@@ -503,6 +600,7 @@ func (db *DB) GetImageBySlug(slug string) (*models.ImageInfo, error) {
 		imageID           string
 		url               string
 		altText           string
+		caption           string
 		summary           string
 		tagsJSON          string
 		extractedText     sql.NullString
@@ -517,10 +615,17 @@ func (db *DB) GetImageBySlug(slug string) (*models.ImageInfo, error) {
 		contentType       sql.NullString
 		exifJSON          sql.NullString
 		relevanceScore    sql.NullFloat64
+		sha256Sum         string
+		phash             sql.NullInt64
+		metadataPolicy    string
+		safetyJSON        sql.NullString
+		safetyFlagged     bool
+		textBlocksJSON    sql.NullString
+		ocrEngine         string
 	)
 
-	query := "SELECT id, url, alt_text, summary, tags, extracted_text, base64_data, file_path, slug, scrape_id, tombstone_datetime, width, height, file_size_bytes, content_type, exif_data, relevance_score FROM scraper_images WHERE slug = $1 LIMIT 1"
-	err := db.conn.QueryRow(query, slug).Scan(&imageID, &url, &altText, &summary, &tagsJSON, &extractedText, &base64Data, &filePath, &slugVal, &scrapeID, &tombstoneDatetime, &width, &height, &fileSizeBytes, &contentType, &exifJSON, &relevanceScore)
+	query := "SELECT id, url, alt_text, COALESCE(caption, ''), summary, tags, extracted_text, base64_data, file_path, slug, scrape_id, tombstone_datetime, width, height, file_size_bytes, content_type, exif_data, relevance_score, COALESCE(sha256, ''), phash, COALESCE(metadata_policy, ''), safety_categories, COALESCE(safety_flagged, FALSE), text_blocks, COALESCE(ocr_engine, '') FROM scraper_images WHERE slug = $1 LIMIT 1"
+	err := db.conn.QueryRow(query, slug).Scan(&imageID, &url, &altText, &caption, &summary, &tagsJSON, &extractedText, &base64Data, &filePath, &slugVal, &scrapeID, &tombstoneDatetime, &width, &height, &fileSizeBytes, &contentType, &exifJSON, &relevanceScore, &sha256Sum, &phash, &metadataPolicy, &safetyJSON, &safetyFlagged, &textBlocksJSON, &ocrEngine)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -540,6 +645,7 @@ func (db *DB) GetImageBySlug(slug string) (*models.ImageInfo, error) {
 		ID:          imageID,
 		URL:         url,
 		AltText:     altText,
+		Caption:     caption,
 		Summary:     summary,
 		Tags:        tags,
 		Base64Data:  base64Data,
@@ -579,20 +685,31 @@ func (db *DB) GetImageBySlug(slug string) (*models.ImageInfo, error) {
 	if relevanceScore.Valid {
 		image.RelevanceScore = relevanceScore.Float64
 	}
+	image.SHA256 = sha256Sum
+	image.PerceptualHash = formatPHash(phash)
+	image.MetadataPolicy = metadataPolicy
+	if image.Safety, err = unmarshalSafety(safetyJSON, safetyFlagged); err != nil {
+		return nil, err
+	}
+	if image.TextBlocks, err = unmarshalTextBlocks(textBlocksJSON); err != nil {
+		return nil, err
+	}
+	image.OCREngine = ocrEngine
 
 	return image, nil
 }
 
 // SearchImagesByTags searches for images by tags using fuzzy matching
-// Returns images that contain any of the search tags (case-insensitive)
-func (db *DB) SearchImagesByTags(searchTags []string) ([]*models.ImageInfo, error) {
+// Returns images that contain any of the search tags (case-insensitive), leaving out
+// images the safety classifier flagged when excludeFlagged is set
+func (db *DB) SearchImagesByTags(searchTags []string, excludeFlagged bool) ([]*models.ImageInfo, error) {
 	if len(searchTags) == 0 {
 		return []*models.ImageInfo{}, nil
 	}
 
 	// Query all images
-	query := "SELECT id, url, alt_text, summary, tags, base64_data, scrape_id, tombstone_datetime, width, height, file_size_bytes, content_type, exif_data FROM scraper_images ORDER BY created_at DESC"
-	rows, err := db.conn.Query(query)
+	query := "SELECT id, url, alt_text, COALESCE(caption, ''), summary, tags, base64_data, scrape_id, tombstone_datetime, width, height, file_size_bytes, content_type, exif_data, safety_categories, COALESCE(safety_flagged, FALSE) FROM scraper_images WHERE NOT ($1 AND COALESCE(safety_flagged, FALSE)) ORDER BY created_at DESC"
+	rows, err := db.conn.Query(query, excludeFlagged)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query images: %w", err)
 	}
@@ -604,6 +721,7 @@ func (db *DB) SearchImagesByTags(searchTags []string) ([]*models.ImageInfo, erro
 			imageID           string
 			url               string
 			altText           string
+			caption           string
 			summary           string
 			tagsJSON          string
 			base64Data        string
@@ -614,9 +732,11 @@ func (db *DB) SearchImagesByTags(searchTags []string) ([]*models.ImageInfo, erro
 			fileSizeBytes     sql.NullInt64
 			contentType       sql.NullString
 			exifJSON          sql.NullString
+			safetyJSON        sql.NullString
+			safetyFlagged     bool
 		)
 
-		if err := rows.Scan(&imageID, &url, &altText, &summary, &tagsJSON, &base64Data, &scrapeID, &tombstoneDatetime, &width, &height, &fileSizeBytes, &contentType, &exifJSON); err != nil {
+		if err := rows.Scan(&imageID, &url, &altText, &caption, &summary, &tagsJSON, &base64Data, &scrapeID, &tombstoneDatetime, &width, &height, &fileSizeBytes, &contentType, &exifJSON, &safetyJSON, &safetyFlagged); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
@@ -648,6 +768,7 @@ func (db *DB) SearchImagesByTags(searchTags []string) ([]*models.ImageInfo, erro
 				ID:          imageID,
 				URL:         url,
 				AltText:     altText,
+				Caption:     caption,
 				Summary:     summary,
 				Tags:        tags,
 				Base64Data:  base64Data,
@@ -674,6 +795,9 @@ func (db *DB) SearchImagesByTags(searchTags []string) ([]*models.ImageInfo, erro
 					image.EXIF = &exif
 				}
 			}
+			if safety, err := unmarshalSafety(safetyJSON, safetyFlagged); err == nil {
+				image.Safety = safety
+			}
 			results = append(results, image)
 		}
 	}
@@ -687,7 +811,7 @@ func (db *DB) SearchImagesByTags(searchTags []string) ([]*models.ImageInfo, erro
 
 // GetImagesByScrapeID retrieves all images associated with a scrape ID
 func (db *DB) GetImagesByScrapeID(scrapeID string) ([]*models.ImageInfo, error) {
-	query := "SELECT id, url, alt_text, summary, tags, extracted_text, base64_data, scrape_id, tombstone_datetime, width, height, file_size_bytes, content_type, exif_data FROM scraper_images WHERE scrape_id = $1 ORDER BY created_at"
+	query := "SELECT id, url, alt_text, COALESCE(caption, ''), summary, tags, extracted_text, base64_data, scrape_id, tombstone_datetime, width, height, file_size_bytes, content_type, exif_data FROM scraper_images WHERE scrape_id = $1 ORDER BY created_at"
 	rows, err := db.conn.Query(query, scrapeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query images: %w", err)
@@ -700,6 +824,7 @@ func (db *DB) GetImagesByScrapeID(scrapeID string) ([]*models.ImageInfo, error)
 			imageID           string
 			url               string
 			altText           string
+			caption           string
 			summary           string
 			tagsJSON          string
 			extractedText     sql.NullString
@@ -713,7 +838,7 @@ func (db *DB) GetImagesByScrapeID(scrapeID string) ([]*models.ImageInfo, error)
 			exifJSON          sql.NullString
 		)
 
-		if err := rows.Scan(&imageID, &url, &altText, &summary, &tagsJSON, &extractedText, &base64Data, &imageScrapeID, &tombstoneDatetime, &width, &height, &fileSizeBytes, &contentType, &exifJSON); err != nil {
+		if err := rows.Scan(&imageID, &url, &altText, &caption, &summary, &tagsJSON, &extractedText, &base64Data, &imageScrapeID, &tombstoneDatetime, &width, &height, &fileSizeBytes, &contentType, &exifJSON); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
@@ -728,6 +853,7 @@ func (db *DB) GetImagesByScrapeID(scrapeID string) ([]*models.ImageInfo, error)
 			ID:          imageID,
 			URL:         url,
 			AltText:     altText,
+			Caption:     caption,
 			Summary:     summary,
 			Tags:        tags,
 			Base64Data:  base64Data,
@@ -538,7 +538,7 @@ func TestSearchImagesByTags(t *testing.T) {
 	}
 
 	// Test exact match
-	results, err := db.SearchImagesByTags([]string{"cat"})
+	results, err := db.SearchImagesByTags([]string{"cat"}, false)
 	if err != nil {
 		t.Fatalf("Failed to search images: %v", err)
 	}
@@ -548,7 +548,7 @@ func TestSearchImagesByTags(t *testing.T) {
 	}
 
 	// Test fuzzy match (should match both cat and car due to substring)
-	results, err = db.SearchImagesByTags([]string{"ca"})
+	results, err = db.SearchImagesByTags([]string{"ca"}, false)
 	if err != nil {
 		t.Fatalf("Failed to search images: %v", err)
 	}
@@ -558,7 +558,7 @@ func TestSearchImagesByTags(t *testing.T) {
 	}
 
 	// Test multiple tags
-	results, err = db.SearchImagesByTags([]string{"animal", "vehicle"})
+	results, err = db.SearchImagesByTags([]string{"animal", "vehicle"}, false)
 	if err != nil {
 		t.Fatalf("Failed to search images: %v", err)
 	}
@@ -568,7 +568,7 @@ func TestSearchImagesByTags(t *testing.T) {
 	}
 
 	// Test case-insensitive search
-	results, err = db.SearchImagesByTags([]string{"CAT"})
+	results, err = db.SearchImagesByTags([]string{"CAT"}, false)
 	if err != nil {
 		t.Fatalf("Failed to search images: %v", err)
 	}
@@ -578,7 +578,7 @@ func TestSearchImagesByTags(t *testing.T) {
 	}
 
 	// Test empty tags
-	results, err = db.SearchImagesByTags([]string{})
+	results, err = db.SearchImagesByTags([]string{}, false)
 	if err != nil {
 		t.Fatalf("Failed to search with empty tags: %v", err)
 	}
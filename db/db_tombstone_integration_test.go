@@ -58,7 +58,7 @@ func TestTombstoneImagePersistence(t *testing.T) {
 
 	// Test 2: SearchImagesByTags should return tombstone_datetime
 	t.Run("SearchImagesByTags", func(t *testing.T) {
-		images, err := db.SearchImagesByTags([]string{"test"})
+		images, err := db.SearchImagesByTags([]string{"test"}, false)
 		if err != nil {
 			t.Fatalf("Failed to search images: %v", err)
 		}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/docutag/scraper/models"
+)
+
+// ListDomainPolicies returns all domain policies ordered by domain
+func (db *DB) ListDomainPolicies() ([]*models.DomainPolicy, error) {
+	query := `
+		SELECT domain, policy, COALESCE(note, ''), created_at, updated_at
+		FROM scraper_domain_policies
+		ORDER BY domain
+	`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domain policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []*models.DomainPolicy{}
+	for rows.Next() {
+		var p models.DomainPolicy
+		if err := rows.Scan(&p.Domain, &p.Policy, &p.Note, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan domain policy: %w", err)
+		}
+		policies = append(policies, &p)
+	}
+
+	return policies, rows.Err()
+}
+
+// GetDomainPolicy returns the policy for a domain
+// Returns nil if no policy exists for the domain
+func (db *DB) GetDomainPolicy(domain string) (*models.DomainPolicy, error) {
+	query := `
+		SELECT domain, policy, COALESCE(note, ''), created_at, updated_at
+		FROM scraper_domain_policies
+		WHERE domain = $1
+	`
+
+	var p models.DomainPolicy
+	err := db.conn.QueryRow(query, domain).Scan(&p.Domain, &p.Policy, &p.Note, &p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain policy: %w", err)
+	}
+
+	return &p, nil
+}
+
+// UpsertDomainPolicy creates or replaces the policy for a domain
+// CreatedAt and UpdatedAt are set from the stored row
+func (db *DB) UpsertDomainPolicy(p *models.DomainPolicy) error {
+	query := `
+		INSERT INTO scraper_domain_policies (domain, policy, note, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (domain) DO UPDATE SET
+			policy = EXCLUDED.policy,
+			note = EXCLUDED.note,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at
+	`
+
+	if err := db.conn.QueryRow(query, p.Domain, p.Policy, p.Note).Scan(&p.CreatedAt, &p.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save domain policy: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDomainPolicy removes the policy for a domain
+func (db *DB) DeleteDomainPolicy(domain string) error {
+	result, err := db.conn.Exec("DELETE FROM scraper_domain_policies WHERE domain = $1", domain)
+	if err != nil {
+		return fmt.Errorf("failed to delete domain policy: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("domain policy not found: %s", domain)
+	}
+
+	return nil
+}
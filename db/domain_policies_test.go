@@ -0,0 +1,48 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestDomainPolicies(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	p := &models.DomainPolicy{Domain: "example.com", Policy: models.DomainPolicyBlock, Note: "spam"}
+	if err := db.UpsertDomainPolicy(p); err != nil {
+		t.Fatalf("UpsertDomainPolicy failed: %v", err)
+	}
+	if p.CreatedAt.IsZero() || p.UpdatedAt.IsZero() {
+		t.Error("Expected timestamps to be set")
+	}
+
+	p.Policy = models.DomainPolicyAllow
+	if err := db.UpsertDomainPolicy(p); err != nil {
+		t.Fatalf("UpsertDomainPolicy (update) failed: %v", err)
+	}
+
+	got, err := db.GetDomainPolicy("example.com")
+	if err != nil || got == nil {
+		t.Fatalf("GetDomainPolicy = %v, %v", got, err)
+	}
+	if got.Policy != models.DomainPolicyAllow || got.Note != "spam" {
+		t.Errorf("unexpected policy: %+v", got)
+	}
+
+	policies, err := db.ListDomainPolicies()
+	if err != nil || len(policies) != 1 {
+		t.Fatalf("ListDomainPolicies = %d policies, %v; want 1", len(policies), err)
+	}
+
+	if err := db.DeleteDomainPolicy("example.com"); err != nil {
+		t.Fatalf("DeleteDomainPolicy failed: %v", err)
+	}
+	if err := db.DeleteDomainPolicy("example.com"); err == nil {
+		t.Error("Expected error deleting a missing policy")
+	}
+	if got, _ := db.GetDomainPolicy("example.com"); got != nil {
+		t.Errorf("Expected policy to be deleted, got %+v", got)
+	}
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/docutag/scraper/models"
+)
+
+// ErrFeedSubscriptionExists is returned when subscribing to a feed URL twice
+var ErrFeedSubscriptionExists = errors.New("already subscribed to this feed")
+
+// MaxFeedEntryAttempts is how many failed scrapes of a feed entry are retried before
+// the entry is given up on
+const MaxFeedEntryAttempts = 3
+
+// feedSubscriptionColumns selects a subscription and how many entries it has scraped
+const feedSubscriptionColumns = `
+	s.id, s.feed_url, s.kind, COALESCE(s.title, ''), s.interval_seconds, s.max_entries_per_poll,
+	s.last_polled_at, COALESCE(s.last_error, ''), s.created_at,
+	(SELECT COUNT(*) FROM scraper_feed_entries e WHERE e.subscription_id = s.id AND e.scraped_at IS NOT NULL)
+`
+
+// CreateFeedSubscription stores a new feed subscription
+// Returns ErrFeedSubscriptionExists if the feed URL is already subscribed.
+func (db *DB) CreateFeedSubscription(f *models.FeedSubscription) error {
+	query := `
+		INSERT INTO scraper_feed_subscriptions (id, feed_url, kind, title, interval_seconds, max_entries_per_poll, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (feed_url) DO NOTHING
+		RETURNING created_at
+	`
+
+	err := db.conn.QueryRow(query, f.ID, f.FeedURL, f.Kind, f.Title, f.IntervalSeconds, f.MaxEntriesPerPoll).Scan(&f.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrFeedSubscriptionExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save feed subscription: %w", err)
+	}
+
+	return nil
+}
+
+// ListFeedSubscriptions returns all feed subscriptions, oldest first
+func (db *DB) ListFeedSubscriptions() ([]*models.FeedSubscription, error) {
+	query := `SELECT ` + feedSubscriptionColumns + ` FROM scraper_feed_subscriptions s ORDER BY s.created_at`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feed subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []*models.FeedSubscription{}
+	for rows.Next() {
+		f, err := scanFeedSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, f)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// GetFeedSubscription returns a feed subscription by ID
+// Returns nil if no subscription has the ID
+func (db *DB) GetFeedSubscription(id string) (*models.FeedSubscription, error) {
+	query := `SELECT ` + feedSubscriptionColumns + ` FROM scraper_feed_subscriptions s WHERE s.id = $1`
+
+	f, err := scanFeedSubscription(db.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// DeleteFeedSubscription removes a feed subscription and the entries it has seen
+func (db *DB) DeleteFeedSubscription(id string) error {
+	result, err := db.conn.Exec("DELETE FROM scraper_feed_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete feed subscription: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("feed subscription not found: %s", id)
+	}
+
+	return nil
+}
+
+// RecordFeedPoll stores when a feed was polled and why the poll failed, if it did
+// A successful poll clears the previous error.
+func (db *DB) RecordFeedPoll(id string, polledAt time.Time, pollErr string) error {
+	query := `
+		UPDATE scraper_feed_subscriptions
+		SET last_polled_at = $2, last_error = NULLIF($3, '')
+		WHERE id = $1
+	`
+
+	if _, err := db.conn.Exec(query, id, polledAt, pollErr); err != nil {
+		return fmt.Errorf("failed to record feed poll: %w", err)
+	}
+
+	return nil
+}
+
+// SeenFeedEntries returns which of urls a subscription is done with: scraped, or failed
+// MaxFeedEntryAttempts times
+func (db *DB) SeenFeedEntries(id string, urls []string) (map[string]bool, error) {
+	query := `
+		SELECT entry_url FROM scraper_feed_entries
+		WHERE subscription_id = $1 AND entry_url = ANY($2)
+		AND (scraped_at IS NOT NULL OR attempts >= $3)
+	`
+
+	rows, err := db.conn.Query(query, id, pq.Array(urls), MaxFeedEntryAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feed entries: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return nil, fmt.Errorf("failed to scan feed entry: %w", err)
+		}
+		seen[u] = true
+	}
+
+	return seen, rows.Err()
+}
+
+// RecordFeedEntries marks urls as scraped for a subscription
+// URLs already scraped are ignored.
+func (db *DB) RecordFeedEntries(id string, urls []string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO scraper_feed_entries (subscription_id, entry_url, first_seen_at, scraped_at)
+		SELECT $1, u, NOW(), NOW() FROM UNNEST($2::TEXT[]) AS u
+		ON CONFLICT (subscription_id, entry_url) DO UPDATE
+		SET scraped_at = COALESCE(scraper_feed_entries.scraped_at, EXCLUDED.scraped_at)
+	`
+
+	if _, err := db.conn.Exec(query, id, pq.Array(urls)); err != nil {
+		return fmt.Errorf("failed to record feed entries: %w", err)
+	}
+
+	return nil
+}
+
+// RecordFeedEntryFailure counts a failed scrape of a subscription's entry
+// The entry is polled again until it has failed MaxFeedEntryAttempts times.
+func (db *DB) RecordFeedEntryFailure(id, entryURL, scrapeErr string) error {
+	query := `
+		INSERT INTO scraper_feed_entries (subscription_id, entry_url, first_seen_at, attempts, last_error)
+		VALUES ($1, $2, NOW(), 1, $3)
+		ON CONFLICT (subscription_id, entry_url) DO UPDATE
+		SET attempts = scraper_feed_entries.attempts + 1, last_error = EXCLUDED.last_error
+	`
+
+	if _, err := db.conn.Exec(query, id, entryURL, scrapeErr); err != nil {
+		return fmt.Errorf("failed to record feed entry failure: %w", err)
+	}
+
+	return nil
+}
+
+// scanFeedSubscription scans a feed subscription row
+func scanFeedSubscription(row rowScanner) (*models.FeedSubscription, error) {
+	var f models.FeedSubscription
+	var lastPolledAt sql.NullTime
+	err := row.Scan(&f.ID, &f.FeedURL, &f.Kind, &f.Title, &f.IntervalSeconds, &f.MaxEntriesPerPoll,
+		&lastPolledAt, &f.LastError, &f.CreatedAt, &f.EntriesSeen)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan feed subscription: %w", err)
+	}
+	if lastPolledAt.Valid {
+		f.LastPolledAt = &lastPolledAt.Time
+	}
+	return &f, nil
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestFeedSubscriptions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	f := &models.FeedSubscription{ID: "feed-1", FeedURL: "https://example.com/feed.xml", Kind: "rss", IntervalSeconds: 900, MaxEntriesPerPoll: 10}
+	if err := db.CreateFeedSubscription(f); err != nil {
+		t.Fatalf("CreateFeedSubscription failed: %v", err)
+	}
+	if f.CreatedAt.IsZero() {
+		t.Error("Expected created_at to be set")
+	}
+	duplicate := *f
+	duplicate.ID = "feed-2"
+	if err := db.CreateFeedSubscription(&duplicate); err != ErrFeedSubscriptionExists {
+		t.Errorf("CreateFeedSubscription (duplicate) = %v, want ErrFeedSubscriptionExists", err)
+	}
+
+	if err := db.RecordFeedEntries(f.ID, []string{"https://example.com/a", "https://example.com/b"}); err != nil {
+		t.Fatalf("RecordFeedEntries failed: %v", err)
+	}
+	if err := db.RecordFeedEntries(f.ID, []string{"https://example.com/a"}); err != nil {
+		t.Fatalf("RecordFeedEntries (again) failed: %v", err)
+	}
+	seen, err := db.SeenFeedEntries(f.ID, []string{"https://example.com/a", "https://example.com/c"})
+	if err != nil {
+		t.Fatalf("SeenFeedEntries failed: %v", err)
+	}
+	if !seen["https://example.com/a"] || seen["https://example.com/c"] {
+		t.Errorf("seen = %v", seen)
+	}
+
+	// Failed entries are retried until they fail MaxFeedEntryAttempts times
+	for i := 1; i <= MaxFeedEntryAttempts; i++ {
+		if seen, _ := db.SeenFeedEntries(f.ID, []string{"https://example.com/c"}); len(seen) != 0 {
+			t.Errorf("entry seen after %d failures, want it retried", i-1)
+		}
+		if err := db.RecordFeedEntryFailure(f.ID, "https://example.com/c", "timeout"); err != nil {
+			t.Fatalf("RecordFeedEntryFailure failed: %v", err)
+		}
+	}
+	if seen, _ := db.SeenFeedEntries(f.ID, []string{"https://example.com/c"}); !seen["https://example.com/c"] {
+		t.Error("Expected the entry to be given up on")
+	}
+
+	polledAt := time.Now().UTC().Truncate(time.Second)
+	if err := db.RecordFeedPoll(f.ID, polledAt, "HTTP error: 503"); err != nil {
+		t.Fatalf("RecordFeedPoll failed: %v", err)
+	}
+	got, err := db.GetFeedSubscription(f.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetFeedSubscription = %v, %v", got, err)
+	}
+	if got.EntriesSeen != 2 || got.LastError != "HTTP error: 503" || got.LastPolledAt == nil || !got.LastPolledAt.Equal(polledAt) {
+		t.Errorf("unexpected subscription: %+v", got)
+	}
+
+	subscriptions, err := db.ListFeedSubscriptions()
+	if err != nil || len(subscriptions) != 1 {
+		t.Fatalf("ListFeedSubscriptions = %d subscriptions, %v; want 1", len(subscriptions), err)
+	}
+
+	if err := db.DeleteFeedSubscription(f.ID); err != nil {
+		t.Fatalf("DeleteFeedSubscription failed: %v", err)
+	}
+	if err := db.DeleteFeedSubscription(f.ID); err == nil {
+		t.Error("Expected error deleting a missing subscription")
+	}
+	if seen, _ := db.SeenFeedEntries(f.ID, []string{"https://example.com/a"}); len(seen) != 0 {
+		t.Errorf("Expected entries to be deleted with the subscription, got %v", seen)
+	}
+}
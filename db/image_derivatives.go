@@ -0,0 +1,78 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/docutag/scraper/models"
+)
+
+// imageDerivativeColumns are the columns scanned by scanImageDerivative
+const imageDerivativeColumns = `image_id, width, format, file_path, content_type, actual_width, actual_height, file_size_bytes, created_at`
+
+// SaveImageDerivative records a stored image derivative
+// A derivative already recorded for the same image, width and format is kept.
+func (db *DB) SaveImageDerivative(d *models.ImageDerivative) error {
+	query := `
+		INSERT INTO scraper_image_derivatives (` + imageDerivativeColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		ON CONFLICT (image_id, width, format) DO NOTHING
+	`
+
+	_, err := db.conn.Exec(query, d.ImageID, d.Width, d.Format, d.FilePath, d.ContentType, d.ActualWidth, d.ActualHeight, d.FileSizeBytes)
+	if err != nil {
+		return fmt.Errorf("failed to save image derivative: %w", err)
+	}
+
+	return nil
+}
+
+// GetImageDerivative returns an image's derivative of the given width and format
+// Returns nil if it has not been generated
+func (db *DB) GetImageDerivative(imageID string, width int, format string) (*models.ImageDerivative, error) {
+	query := `SELECT ` + imageDerivativeColumns + ` FROM scraper_image_derivatives WHERE image_id = $1 AND width = $2 AND format = $3`
+
+	d, err := scanImageDerivative(db.conn.QueryRow(query, imageID, width, format))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListImageDerivatives returns the derivatives generated for an image, smallest first
+func (db *DB) ListImageDerivatives(imageID string) ([]*models.ImageDerivative, error) {
+	query := `SELECT ` + imageDerivativeColumns + ` FROM scraper_image_derivatives WHERE image_id = $1 ORDER BY width, format`
+
+	rows, err := db.conn.Query(query, imageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list image derivatives: %w", err)
+	}
+	defer rows.Close()
+
+	derivatives := []*models.ImageDerivative{}
+	for rows.Next() {
+		d, err := scanImageDerivative(rows)
+		if err != nil {
+			return nil, err
+		}
+		derivatives = append(derivatives, d)
+	}
+
+	return derivatives, rows.Err()
+}
+
+// scanImageDerivative scans an image derivative row
+func scanImageDerivative(row rowScanner) (*models.ImageDerivative, error) {
+	var d models.ImageDerivative
+	err := row.Scan(&d.ImageID, &d.Width, &d.Format, &d.FilePath, &d.ContentType, &d.ActualWidth, &d.ActualHeight, &d.FileSizeBytes, &d.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan image derivative: %w", err)
+	}
+	return &d, nil
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestImageDerivatives(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	data := &models.ScrapedData{
+		ID:        "scrape-1",
+		URL:       "https://example.com/article",
+		Title:     "Article",
+		FetchedAt: time.Now(),
+		CreatedAt: time.Now(),
+		Images:    []models.ImageInfo{{ID: "img-1", URL: "https://example.com/photo.jpg", Tags: []string{}, FilePath: "images/2024/03/photo.jpg"}},
+	}
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("SaveScrapedData failed: %v", err)
+	}
+
+	if d, err := db.GetImageDerivative("img-1", 320, "webp"); err != nil || d != nil {
+		t.Fatalf("GetImageDerivative before saving = %+v, %v; want nil", d, err)
+	}
+
+	derivative := &models.ImageDerivative{
+		ImageID: "img-1", Width: 320, Format: "webp", FilePath: "images/2024/03/photo_w320.webp",
+		ContentType: "image/webp", ActualWidth: 320, ActualHeight: 180, FileSizeBytes: 1234,
+	}
+	if err := db.SaveImageDerivative(derivative); err != nil {
+		t.Fatalf("SaveImageDerivative failed: %v", err)
+	}
+	if err := db.SaveImageDerivative(derivative); err != nil {
+		t.Fatalf("SaveImageDerivative (again) failed: %v", err)
+	}
+	if err := db.SaveImageDerivative(&models.ImageDerivative{
+		ImageID: "img-1", Width: 0, Format: "jpeg", FilePath: "images/2024/03/photo_full.jpg",
+		ContentType: "image/jpeg", ActualWidth: 1600, ActualHeight: 900, FileSizeBytes: 5678,
+	}); err != nil {
+		t.Fatalf("SaveImageDerivative failed: %v", err)
+	}
+
+	got, err := db.GetImageDerivative("img-1", 320, "webp")
+	if err != nil || got == nil {
+		t.Fatalf("GetImageDerivative failed: %v", err)
+	}
+	if got.FilePath != derivative.FilePath || got.ActualHeight != 180 || got.FileSizeBytes != 1234 {
+		t.Errorf("derivative = %+v", got)
+	}
+
+	list, err := db.ListImageDerivatives("img-1")
+	if err != nil {
+		t.Fatalf("ListImageDerivatives failed: %v", err)
+	}
+	if len(list) != 2 || list[0].Width != 0 || list[1].Width != 320 {
+		t.Errorf("derivatives = %+v", list)
+	}
+
+	if err := db.DeleteImageByID("img-1"); err != nil {
+		t.Fatalf("DeleteImageByID failed: %v", err)
+	}
+	if list, _ := db.ListImageDerivatives("img-1"); len(list) != 0 {
+		t.Errorf("derivatives after deleting the image = %+v", list)
+	}
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/lib/pq"
+
+	"github.com/docutag/scraper/models"
+)
+
+// phashBandWidths split a 64-bit perceptual hash, most significant bits first, into 7
+// bands; two hashes within 6 bits of each other must agree on at least one whole band
+var phashBandWidths = [...]uint{10, 9, 9, 9, 9, 9, 9}
+
+// FindImageByHash returns a reference to a stored image that is the same picture as one
+// with the given hashes: a byte-identical SHA-256 match if there is one, otherwise the
+// image whose perceptual hash is nearest within maxDistance bits
+// Only images sharing a band of the perceptual hash are compared, which the GIN index on
+// phash_bands finds without scanning the table; for maxDistance above 6 some matches may
+// be missed. An empty perceptualHash matches by SHA-256 only. Tombstoned images are never
+// matched. Returns nil if nothing matches.
+func (db *DB) FindImageByHash(sha256, perceptualHash string, maxDistance int) (*models.ExistingImageRef, error) {
+	query := `
+		SELECT id, url, exact, distance FROM (
+			SELECT id, url, created_at, COALESCE(sha256 = $1, FALSE) AS exact,
+				LENGTH(REPLACE(((phash # $2)::BIT(64))::TEXT, '0', '')) AS distance
+			FROM scraper_images
+			WHERE tombstone_datetime IS NULL AND (sha256 = $1 OR phash_bands && $4)
+		) candidates
+		WHERE exact OR distance <= $3
+		ORDER BY exact DESC, distance, created_at
+		LIMIT 1
+	`
+
+	var (
+		ref      models.ExistingImageRef
+		exact    sql.NullBool
+		distance sql.NullInt64
+	)
+	err := db.conn.QueryRow(query, sha256, phashValue(perceptualHash), maxDistance, pq.Array(phashBands(perceptualHash))).Scan(&ref.ImageID, &ref.ImageURL, &exact, &distance)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query image by hash: %w", err)
+	}
+
+	if exact.Bool {
+		ref.Match = models.ImageMatchSHA256
+	} else {
+		ref.Match = models.ImageMatchPerceptual
+		ref.Distance = int(distance.Int64)
+	}
+	return &ref, nil
+}
+
+// phashBands returns the bands of a hex perceptual hash as they are indexed, each tagged
+// with its position (position<<16 | bits), or none for an empty or malformed hash
+// Migration 24 computes the same values in SQL for images stored before it.
+func phashBands(perceptualHash string) []int64 {
+	h, err := strconv.ParseUint(perceptualHash, 16, 64)
+	if err != nil {
+		return []int64{}
+	}
+	bands := make([]int64, len(phashBandWidths))
+	shift := uint(64)
+	for i, width := range phashBandWidths {
+		shift -= width
+		bands[i] = int64(i)<<16 | int64(h>>shift&(1<<width-1))
+	}
+	return bands
+}
+
+// phashValue converts a hex perceptual hash to the signed BIGINT it is stored as
+// Returns NULL for an empty or malformed hash.
+func phashValue(perceptualHash string) sql.NullInt64 {
+	h, err := strconv.ParseUint(perceptualHash, 16, 64)
+	if err != nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(h), Valid: true}
+}
+
+// formatPHash converts a stored perceptual hash back to hex
+func formatPHash(v sql.NullInt64) string {
+	if !v.Valid {
+		return ""
+	}
+	return fmt.Sprintf("%016x", uint64(v.Int64))
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"math/bits"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestFindImageByHash(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	data := &models.ScrapedData{
+		ID:        "scrape-1",
+		URL:       "https://example.com/article",
+		Title:     "Article",
+		FetchedAt: time.Now(),
+		CreatedAt: time.Now(),
+		Images: []models.ImageInfo{
+			{ID: "img-1", URL: "https://cdn-a.example.com/photo.jpg", Tags: []string{}, SHA256: "aaaa", PerceptualHash: "f0f0f0f0f0f0f0f0"},
+			{ID: "img-2", URL: "https://cdn-a.example.com/other.jpg", Tags: []string{}, SHA256: "bbbb", PerceptualHash: "ffffffff00000000"},
+			{ID: "img-3", URL: "https://cdn-a.example.com/solid.png", Tags: []string{}, SHA256: "dddd", PerceptualHash: "0000000000000000"},
+		},
+	}
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("SaveScrapedData failed: %v", err)
+	}
+
+	image, err := db.GetImageByID("img-1")
+	if err != nil || image == nil {
+		t.Fatalf("GetImageByID failed: %v", err)
+	}
+	if image.SHA256 != "aaaa" || image.PerceptualHash != "f0f0f0f0f0f0f0f0" {
+		t.Errorf("hashes = %q, %q", image.SHA256, image.PerceptualHash)
+	}
+
+	tests := []struct {
+		name, sha256, phash string
+		wantID, wantMatch   string
+		wantDistance        int
+	}{
+		{"byte-identical", "bbbb", "0000000000000000", "img-2", models.ImageMatchSHA256, 0},
+		{"resized copy", "cccc", "f0f0f0f0f0f0f0f3", "img-1", models.ImageMatchPerceptual, 2},
+		{"different picture", "cccc", "0f0f0f0f0f0f0f0f", "", "", 0},
+		{"undecodable", "cccc", "", "", "", 0},
+		{"solid color, other bytes", "eeee", "", "", "", 0},
+		{"solid color, same bytes", "dddd", "", "img-3", models.ImageMatchSHA256, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := db.FindImageByHash(tt.sha256, tt.phash, 6)
+			if err != nil {
+				t.Fatalf("FindImageByHash failed: %v", err)
+			}
+			if tt.wantID == "" {
+				if ref != nil {
+					t.Errorf("ref = %+v, want no match", ref)
+				}
+				return
+			}
+			if ref == nil || ref.ImageID != tt.wantID || ref.Match != tt.wantMatch || ref.Distance != tt.wantDistance {
+				t.Errorf("ref = %+v, want %s matched by %s at distance %d", ref, tt.wantID, tt.wantMatch, tt.wantDistance)
+			}
+		})
+	}
+
+	if err := db.TombstoneImageByID("img-1"); err != nil {
+		t.Fatalf("TombstoneImageByID failed: %v", err)
+	}
+	if ref, err := db.FindImageByHash("cccc", "f0f0f0f0f0f0f0f0", 6); err != nil || ref != nil {
+		t.Errorf("FindImageByHash after tombstone = %+v, %v; want no match", ref, err)
+	}
+}
+
+func TestPHashBands(t *testing.T) {
+	if bands := phashBands(""); len(bands) != 0 {
+		t.Errorf("bands of an empty hash = %v, want none", bands)
+	}
+
+	bands := phashBands("ffc0000000000001")
+	want := []int64{0<<16 | 1023, 1<<16 | 0, 2<<16 | 0, 3<<16 | 0, 4<<16 | 0, 5<<16 | 0, 6<<16 | 1}
+	if len(bands) != len(want) {
+		t.Fatalf("bands = %v, want %v", bands, want)
+	}
+	for i := range want {
+		if bands[i] != want[i] {
+			t.Errorf("band %d = %#x, want %#x", i, bands[i], want[i])
+		}
+	}
+
+	// Hashes within 6 bits of each other always share a band, so the index finds them
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a := rng.Uint64()
+		b := a
+		for bits.OnesCount64(a^b) < 6 {
+			b ^= 1 << rng.Intn(64)
+		}
+		if !sharesBand(phashBands(strconv.FormatUint(a, 16)), phashBands(strconv.FormatUint(b, 16))) {
+			t.Fatalf("%016x and %016x differ by 6 bits but share no band", a, b)
+		}
+	}
+}
+
+func sharesBand(a, b []int64) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
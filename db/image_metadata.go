@@ -0,0 +1,222 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/docutag/scraper/models"
+)
+
+// ListImagesForMetadataScrub returns stored images whose metadata policy is not one of
+// satisfying, ordered by ID and starting after afterID
+// Images stored before metadata policies existed have none and are always returned.
+func (db *DB) ListImagesForMetadataScrub(satisfying []string, afterID string, limit int) ([]*models.ImageInfo, error) {
+	query := `
+		SELECT id, COALESCE(file_path, ''), COALESCE(base64_data, ''), COALESCE(content_type, ''), exif_data, exif_private, COALESCE(metadata_policy, '')
+		FROM scraper_images
+		WHERE id > $2
+			AND COALESCE(metadata_policy, '') <> ALL($1)
+			AND (COALESCE(file_path, '') <> '' OR COALESCE(base64_data, '') <> '')
+		ORDER BY id
+		LIMIT $3
+	`
+
+	rows, err := db.conn.Query(query, pq.Array(satisfying), afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images for metadata scrub: %w", err)
+	}
+	defer rows.Close()
+
+	images := []*models.ImageInfo{}
+	for rows.Next() {
+		var (
+			img         models.ImageInfo
+			exifJSON    sql.NullString
+			privateJSON sql.NullString
+		)
+		if err := rows.Scan(&img.ID, &img.FilePath, &img.Base64Data, &img.ContentType, &exifJSON, &privateJSON, &img.MetadataPolicy); err != nil {
+			return nil, fmt.Errorf("failed to scan image: %w", err)
+		}
+		if img.EXIF, err = unmarshalEXIF(exifJSON); err != nil {
+			return nil, err
+		}
+		if img.PrivateEXIF, err = unmarshalEXIF(privateJSON); err != nil {
+			return nil, err
+		}
+		images = append(images, &img)
+	}
+
+	return images, rows.Err()
+}
+
+// UpdateImageMetadata records that an image was scrubbed under img.MetadataPolicy,
+// saving its EXIF, retained EXIF, size and base64 data
+// The copy of the image embedded in its scrape's data is updated to match, since that
+// copy is what GET /api/data serves.
+func (db *DB) UpdateImageMetadata(img *models.ImageInfo) error {
+	var exifJSON []byte
+	if img.EXIF != nil {
+		var err error
+		if exifJSON, err = json.Marshal(img.EXIF); err != nil {
+			return fmt.Errorf("failed to marshal EXIF: %w", err)
+		}
+	}
+	privateEXIF, err := marshalPrivateEXIF(img.PrivateEXIF)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE scraper_images
+		SET exif_data = $2, exif_private = NULLIF($3, ''), metadata_policy = $4, file_size_bytes = $5, base64_data = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING scrape_id
+	`
+
+	var scrapeID string
+	err = tx.QueryRow(query, img.ID, string(exifJSON), privateEXIF, img.MetadataPolicy, img.FileSizeBytes, img.Base64Data).Scan(&scrapeID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no image found with id: %s", img.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update image metadata: %w", err)
+	}
+
+	var data string
+	err = tx.QueryRow("SELECT data FROM scraper_scraped_data WHERE id = $1 FOR UPDATE", scrapeID).Scan(&data)
+	if err != nil {
+		return fmt.Errorf("failed to query scraped data: %w", err)
+	}
+	updated, changed, err := updateEmbeddedImage(data, img)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if _, err := tx.Exec("UPDATE scraper_scraped_data SET data = $2 WHERE id = $1", scrapeID, updated); err != nil {
+			return fmt.Errorf("failed to update scraped data: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// updateEmbeddedImage replaces the metadata fields of img's copy in a scrape's data JSON
+// Other fields are left as they are, including ones this version no longer knows.
+func updateEmbeddedImage(data string, img *models.ImageInfo) (string, bool, error) {
+	var scrape map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &scrape); err != nil {
+		return "", false, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+	var images []map[string]json.RawMessage
+	if raw, ok := scrape["images"]; ok {
+		if err := json.Unmarshal(raw, &images); err != nil {
+			return "", false, fmt.Errorf("failed to unmarshal images: %w", err)
+		}
+	}
+
+	changed := false
+	for _, embedded := range images {
+		var id string
+		if err := json.Unmarshal(embedded["id"], &id); err != nil || id != img.ID {
+			continue
+		}
+		// The same fields ImageInfo serializes, omitted when empty
+		fields := map[string]any{
+			"exif":            img.EXIF,
+			"metadata_policy": img.MetadataPolicy,
+			"file_size_bytes": img.FileSizeBytes,
+		}
+		if _, ok := embedded["base64_data"]; ok {
+			fields["base64_data"] = img.Base64Data
+		}
+		for name, value := range fields {
+			v, err := json.Marshal(value)
+			if err != nil {
+				return "", false, fmt.Errorf("failed to marshal %s: %w", name, err)
+			}
+			if string(v) == "null" || string(v) == `""` || string(v) == "0" {
+				delete(embedded, name)
+			} else {
+				embedded[name] = v
+			}
+		}
+		changed = true
+	}
+	if !changed {
+		return data, false, nil
+	}
+
+	raw, err := json.Marshal(images)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal images: %w", err)
+	}
+	scrape["images"] = raw
+	updated, err := json.Marshal(scrape)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal data: %w", err)
+	}
+	return string(updated), true, nil
+}
+
+// GetImageEXIF returns an image's EXIF, retained EXIF and metadata policy
+// Returns nil if the image does not exist.
+func (db *DB) GetImageEXIF(id string) (*models.ImageInfo, error) {
+	query := `SELECT id, exif_data, exif_private, COALESCE(metadata_policy, '') FROM scraper_images WHERE id = $1`
+
+	var (
+		img         models.ImageInfo
+		exifJSON    sql.NullString
+		privateJSON sql.NullString
+	)
+	err := db.conn.QueryRow(query, id).Scan(&img.ID, &exifJSON, &privateJSON, &img.MetadataPolicy)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query image EXIF: %w", err)
+	}
+
+	if img.EXIF, err = unmarshalEXIF(exifJSON); err != nil {
+		return nil, err
+	}
+	if img.PrivateEXIF, err = unmarshalEXIF(privateJSON); err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// marshalPrivateEXIF serializes retained EXIF, "" (stored as NULL) if there is none
+func marshalPrivateEXIF(e *models.EXIFData) (string, error) {
+	if e == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal retained EXIF: %w", err)
+	}
+	return string(b), nil
+}
+
+// unmarshalEXIF parses a stored EXIF column, nil if it is empty
+func unmarshalEXIF(v sql.NullString) (*models.EXIFData, error) {
+	if !v.Valid || v.String == "" || v.String == "null" {
+		return nil, nil
+	}
+	var e models.EXIFData
+	if err := json.Unmarshal([]byte(v.String), &e); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal EXIF: %w", err)
+	}
+	return &e, nil
+}
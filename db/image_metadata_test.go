@@ -0,0 +1,117 @@
+package db
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestImageMetadataScrub(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	gps := &models.GPSData{Latitude: 51.5, Longitude: -0.12}
+	data := &models.ScrapedData{
+		ID:        "scrape-1",
+		URL:       "https://example.com/article",
+		Title:     "Article",
+		FetchedAt: time.Now(),
+		CreatedAt: time.Now(),
+		Images: []models.ImageInfo{
+			{ID: "img-1", URL: "https://example.com/old.jpg", Tags: []string{}, FilePath: "images/old.jpg", EXIF: &models.EXIFData{Make: "Canon", GPS: gps}},
+			{ID: "img-2", URL: "https://example.com/new.jpg", Tags: []string{}, FilePath: "images/new.jpg", MetadataPolicy: "strip-gps"},
+			{ID: "img-3", URL: "https://example.com/inline.jpg", Tags: []string{}},
+		},
+	}
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("SaveScrapedData failed: %v", err)
+	}
+
+	pending, err := db.ListImagesForMetadataScrub([]string{"strip-gps", "strip-all"}, "", 10)
+	if err != nil {
+		t.Fatalf("ListImagesForMetadataScrub failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != "img-1" || pending[0].EXIF == nil || pending[0].EXIF.GPS == nil {
+		t.Fatalf("pending = %+v, want img-1 with its EXIF", pending)
+	}
+	if after, _ := db.ListImagesForMetadataScrub([]string{"strip-gps", "strip-all"}, "img-1", 10); len(after) != 0 {
+		t.Errorf("listing after img-1 = %+v, want none", after)
+	}
+
+	img := pending[0]
+	img.PrivateEXIF = img.EXIF
+	img.EXIF = &models.EXIFData{Make: "Canon"}
+	img.MetadataPolicy = "strip-gps"
+	img.FileSizeBytes = 1000
+	if err := db.UpdateImageMetadata(img); err != nil {
+		t.Fatalf("UpdateImageMetadata failed: %v", err)
+	}
+	if pending, _ := db.ListImagesForMetadataScrub([]string{"strip-gps", "strip-all"}, "", 10); len(pending) != 0 {
+		t.Errorf("pending after update = %+v, want none", pending)
+	}
+
+	got, err := db.GetImageEXIF("img-1")
+	if err != nil || got == nil {
+		t.Fatalf("GetImageEXIF failed: %v", err)
+	}
+	if got.EXIF == nil || got.EXIF.GPS != nil || got.PrivateEXIF == nil || got.PrivateEXIF.GPS == nil || got.MetadataPolicy != "strip-gps" {
+		t.Errorf("GetImageEXIF = %+v", got)
+	}
+	if image, _ := db.GetImageByID("img-1"); image == nil || image.EXIF.GPS != nil || image.MetadataPolicy != "strip-gps" {
+		t.Errorf("GetImageByID = %+v, want redacted EXIF", image)
+	}
+	scrape, err := db.GetByID("scrape-1")
+	if err != nil || scrape == nil || len(scrape.Images) != 3 {
+		t.Fatalf("GetByID = %+v, %v", scrape, err)
+	}
+	if embedded := scrape.Images[0]; embedded.EXIF == nil || embedded.EXIF.GPS != nil || embedded.EXIF.Make != "Canon" || embedded.MetadataPolicy != "strip-gps" {
+		t.Errorf("embedded image = %+v, want the scrape's copy redacted too", embedded)
+	}
+
+	if missing, err := db.GetImageEXIF("missing"); err != nil || missing != nil {
+		t.Errorf("GetImageEXIF(missing) = %+v, %v; want nil", missing, err)
+	}
+	if err := db.UpdateImageMetadata(&models.ImageInfo{ID: "missing"}); err == nil {
+		t.Error("expected an error updating a missing image")
+	}
+}
+
+func TestUpdateEmbeddedImage(t *testing.T) {
+	data := `{"id":"scrape-1","future_field":[1,2],"images":[` +
+		`{"id":"img-1","url":"https://example.com/a.jpg","exif":{"make":"Canon","gps":{"latitude":51.5,"longitude":-0.12}},"base64_data":"b2xk"},` +
+		`{"id":"img-2","url":"https://example.com/b.jpg","exif":{"make":"Nikon","gps":{"latitude":1,"longitude":2}}}]}`
+
+	img := &models.ImageInfo{ID: "img-1", EXIF: &models.EXIFData{Make: "Canon"}, MetadataPolicy: "strip-gps", FileSizeBytes: 3, Base64Data: "bmV3"}
+	updated, changed, err := updateEmbeddedImage(data, img)
+	if err != nil || !changed {
+		t.Fatalf("updateEmbeddedImage = %v, %v", changed, err)
+	}
+
+	var scrape models.ScrapedData
+	if err := json.Unmarshal([]byte(updated), &scrape); err != nil {
+		t.Fatal(err)
+	}
+	a, b := scrape.Images[0], scrape.Images[1]
+	if a.EXIF == nil || a.EXIF.GPS != nil || a.EXIF.Make != "Canon" || a.MetadataPolicy != "strip-gps" || a.FileSizeBytes != 3 || a.Base64Data != "bmV3" || a.URL != "https://example.com/a.jpg" {
+		t.Errorf("updated image = %+v", a)
+	}
+	if b.EXIF == nil || b.EXIF.GPS == nil {
+		t.Errorf("other image = %+v, want it untouched", b)
+	}
+	if !strings.Contains(updated, `"future_field":[1,2]`) {
+		t.Errorf("unknown fields were dropped: %s", updated)
+	}
+
+	// An image whose EXIF is redacted away entirely loses the field
+	updated, _, _ = updateEmbeddedImage(data, &models.ImageInfo{ID: "img-2", MetadataPolicy: "strip-all"})
+	if strings.Contains(updated, "Nikon") {
+		t.Errorf("EXIF of img-2 was not removed: %s", updated)
+	}
+
+	if _, changed, err := updateEmbeddedImage(data, &models.ImageInfo{ID: "missing"}); err != nil || changed {
+		t.Errorf("missing image: changed %v, err %v", changed, err)
+	}
+}
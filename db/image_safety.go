@@ -0,0 +1,57 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docutag/scraper/models"
+)
+
+// marshalSafety serializes an image's safety classification into its categories
+// column, "" (stored as NULL) if it was not classified, and its flagged column
+func marshalSafety(s *models.ImageSafety) (string, bool, error) {
+	if s == nil {
+		return "", false, nil
+	}
+	b, err := json.Marshal(s.Categories)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal safety categories: %w", err)
+	}
+	return string(b), s.Flagged, nil
+}
+
+// unmarshalSafety parses stored safety columns, nil if the image was neither classified
+// nor flagged by an admin
+func unmarshalSafety(categories sql.NullString, flagged bool) (*models.ImageSafety, error) {
+	s := &models.ImageSafety{Flagged: flagged}
+	if !categories.Valid || categories.String == "" || categories.String == "null" {
+		if flagged {
+			return s, nil
+		}
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(categories.String), &s.Categories); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal safety categories: %w", err)
+	}
+	return s, nil
+}
+
+// SetImageSafetyFlagged overrides the safety classifier's verdict on an image
+// An image that was never classified keeps no categories; only its flag is set.
+func (db *DB) SetImageSafetyFlagged(id string, flagged bool) error {
+	result, err := db.conn.Exec("UPDATE scraper_images SET safety_flagged = $2, updated_at = NOW() WHERE id = $1", id, flagged)
+	if err != nil {
+		return fmt.Errorf("failed to update image safety: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("no image found with id: %s", id)
+	}
+
+	return nil
+}
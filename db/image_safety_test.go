@@ -0,0 +1,81 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestImageSafety(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	tombstoned := time.Now()
+	data := &models.ScrapedData{
+		ID:        "scrape-safety",
+		URL:       "https://example.com/safety",
+		Title:     "Safety",
+		FetchedAt: time.Now(),
+		CreatedAt: time.Now(),
+		Images: []models.ImageInfo{
+			{ID: "img-safe", URL: "https://example.com/safe.jpg", Slug: "safe", Tags: []string{"beach"}, Safety: &models.ImageSafety{Categories: map[string]float64{"sexual": 0.1}}},
+			{ID: "img-flagged", URL: "https://example.com/flagged.jpg", Slug: "flagged", Tags: []string{"beach"}, Safety: &models.ImageSafety{Categories: map[string]float64{"sexual": 0.95}, Flagged: true}, TombstoneDatetime: &tombstoned},
+			{ID: "img-unclassified", URL: "https://example.com/unclassified.jpg", Tags: []string{"beach"}},
+		},
+	}
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("SaveScrapedData failed: %v", err)
+	}
+
+	flagged, err := db.GetImageBySlug("flagged")
+	if err != nil || flagged == nil {
+		t.Fatalf("GetImageBySlug failed: %v", err)
+	}
+	if flagged.Safety == nil || !flagged.Safety.Flagged || flagged.Safety.Categories["sexual"] != 0.95 {
+		t.Errorf("safety = %+v, want flagged sexual 0.95", flagged.Safety)
+	}
+	if flagged.TombstoneDatetime == nil {
+		t.Error("image tombstoned when saved has no tombstone_datetime")
+	}
+	if image, _ := db.GetImageByID("img-unclassified"); image == nil || image.Safety != nil {
+		t.Errorf("unclassified image = %+v, want nil safety", image)
+	}
+
+	all, err := db.SearchImagesByTags([]string{"beach"}, false)
+	if err != nil {
+		t.Fatalf("SearchImagesByTags failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("found %d images, want 3", len(all))
+	}
+	safe, err := db.SearchImagesByTags([]string{"beach"}, true)
+	if err != nil {
+		t.Fatalf("SearchImagesByTags failed: %v", err)
+	}
+	if len(safe) != 2 {
+		t.Errorf("found %d images excluding flagged, want 2", len(safe))
+	}
+	for _, image := range safe {
+		if image.ID == "img-flagged" {
+			t.Error("flagged image was not excluded")
+		}
+	}
+
+	// Admin overrides
+	if err := db.SetImageSafetyFlagged("img-flagged", false); err != nil {
+		t.Fatalf("SetImageSafetyFlagged failed: %v", err)
+	}
+	if err := db.SetImageSafetyFlagged("img-unclassified", true); err != nil {
+		t.Fatalf("SetImageSafetyFlagged failed: %v", err)
+	}
+	if image, _ := db.GetImageByID("img-flagged"); image == nil || image.Safety.Flagged || image.Safety.Categories["sexual"] != 0.95 {
+		t.Errorf("cleared image = %+v, want unflagged with its categories", image)
+	}
+	if image, _ := db.GetImageByID("img-unclassified"); image == nil || image.Safety == nil || !image.Safety.Flagged {
+		t.Errorf("flagged unclassified image = %+v, want flagged", image)
+	}
+	if err := db.SetImageSafetyFlagged("missing", true); err == nil {
+		t.Error("expected an error flagging a missing image")
+	}
+}
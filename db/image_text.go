@@ -0,0 +1,34 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docutag/scraper/models"
+)
+
+// marshalTextBlocks serializes an image's OCR text blocks, "" (stored as NULL) if there
+// are none
+func marshalTextBlocks(blocks []models.TextBlock) (string, error) {
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(blocks)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal text blocks: %w", err)
+	}
+	return string(b), nil
+}
+
+// unmarshalTextBlocks parses a stored text blocks column, nil if it is empty
+func unmarshalTextBlocks(v sql.NullString) ([]models.TextBlock, error) {
+	if !v.Valid || v.String == "" || v.String == "null" {
+		return nil, nil
+	}
+	var blocks []models.TextBlock
+	if err := json.Unmarshal([]byte(v.String), &blocks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal text blocks: %w", err)
+	}
+	return blocks, nil
+}
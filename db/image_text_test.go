@@ -0,0 +1,51 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestImageTextBlocks(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	blocks := []models.TextBlock{
+		{Text: "Grand Opening", Confidence: 0.94, BBox: models.BoundingBox{X: 10, Y: 10, Width: 300, Height: 25}},
+		{Text: "Saturday", Confidence: 0.88, BBox: models.BoundingBox{X: 10, Y: 45, Width: 200, Height: 25}},
+	}
+	data := &models.ScrapedData{
+		ID:        "scrape-ocr",
+		URL:       "https://example.com/ocr",
+		Title:     "OCR",
+		FetchedAt: time.Now(),
+		CreatedAt: time.Now(),
+		Images: []models.ImageInfo{
+			{ID: "img-sign", URL: "https://example.com/sign.jpg", Slug: "sign", Tags: []string{}, ExtractedText: "Grand Opening\nSaturday", TextBlocks: blocks, OCREngine: "tesseract"},
+			{ID: "img-vision", URL: "https://example.com/poster.jpg", Tags: []string{}, ExtractedText: "Poster", OCREngine: "vision"},
+		},
+	}
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("SaveScrapedData failed: %v", err)
+	}
+
+	sign, err := db.GetImageBySlug("sign")
+	if err != nil || sign == nil {
+		t.Fatalf("GetImageBySlug failed: %v", err)
+	}
+	if sign.ExtractedText != "Grand Opening\nSaturday" || sign.OCREngine != "tesseract" {
+		t.Errorf("text = %q, engine = %q", sign.ExtractedText, sign.OCREngine)
+	}
+	if len(sign.TextBlocks) != 2 || sign.TextBlocks[0] != blocks[0] || sign.TextBlocks[1] != blocks[1] {
+		t.Errorf("text blocks = %+v, want %+v", sign.TextBlocks, blocks)
+	}
+
+	poster, err := db.GetImageByURL("https://example.com/poster.jpg")
+	if err != nil || poster == nil {
+		t.Fatalf("GetImageByURL failed: %v", err)
+	}
+	if poster.TextBlocks != nil || poster.OCREngine != "vision" || poster.ExtractedText != "Poster" {
+		t.Errorf("vision image = %+v", poster)
+	}
+}
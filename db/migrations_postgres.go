@@ -153,6 +153,257 @@ var postgresMigrations = []Migration{
 			ALTER TABLE scraper_images DROP COLUMN IF EXISTS extracted_text;
 		`,
 	},
+	{
+		Version: 11,
+		Name:    "create_scraper_api_keys_tables",
+		Up: `
+			CREATE TABLE IF NOT EXISTS scraper_api_keys (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				key_prefix TEXT NOT NULL,
+				key_hash TEXT NOT NULL UNIQUE,
+				scopes TEXT NOT NULL,
+				rate_limit_per_minute INTEGER NOT NULL DEFAULT 0,
+				daily_scrape_quota INTEGER NOT NULL DEFAULT 0,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				revoked_at TIMESTAMPTZ
+			);
+			CREATE TABLE IF NOT EXISTS scraper_api_key_usage (
+				key_id TEXT NOT NULL REFERENCES scraper_api_keys(id) ON DELETE CASCADE,
+				day DATE NOT NULL,
+				scrapes INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY (key_id, day)
+			);
+			ALTER TABLE scraper_scraped_data ADD COLUMN IF NOT EXISTS api_key_id TEXT;
+			CREATE INDEX IF NOT EXISTS idx_scraper_scraped_data_api_key_id ON scraper_scraped_data(api_key_id);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_scraper_scraped_data_api_key_id;
+			ALTER TABLE scraper_scraped_data DROP COLUMN IF EXISTS api_key_id;
+			DROP TABLE IF EXISTS scraper_api_key_usage;
+			DROP TABLE IF EXISTS scraper_api_keys;
+		`,
+	},
+	{
+		Version: 12,
+		Name:    "create_scraper_rule_sets_table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS scraper_rule_sets (
+				name TEXT PRIMARY KEY,
+				document TEXT NOT NULL,
+				updated_at TIMESTAMPTZ DEFAULT NOW()
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS scraper_rule_sets;
+		`,
+	},
+	{
+		Version: 13,
+		Name:    "create_scraper_domain_policies_table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS scraper_domain_policies (
+				domain TEXT PRIMARY KEY,
+				policy TEXT NOT NULL,
+				note TEXT,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				updated_at TIMESTAMPTZ DEFAULT NOW()
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS scraper_domain_policies;
+		`,
+	},
+	{
+		Version: 14,
+		Name:    "create_scraper_score_labels_table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS scraper_score_labels (
+				id TEXT PRIMARY KEY,
+				scrape_id TEXT NOT NULL UNIQUE,
+				url TEXT NOT NULL,
+				domain TEXT NOT NULL,
+				accepted BOOLEAN NOT NULL,
+				score REAL NOT NULL,
+				ai_used BOOLEAN NOT NULL DEFAULT FALSE,
+				categories TEXT,
+				note TEXT,
+				api_key_id TEXT,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				updated_at TIMESTAMPTZ DEFAULT NOW()
+			);
+			CREATE INDEX IF NOT EXISTS idx_scraper_score_labels_domain ON scraper_score_labels(domain);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS scraper_score_labels;
+		`,
+	},
+	{
+		Version: 15,
+		Name:    "create_scraper_site_credentials_table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS scraper_site_credentials (
+				domain TEXT PRIMARY KEY,
+				kind TEXT NOT NULL,
+				login TEXT,
+				note TEXT,
+				secret BYTEA NOT NULL,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				updated_at TIMESTAMPTZ DEFAULT NOW()
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS scraper_site_credentials;
+		`,
+	},
+	{
+		Version: 16,
+		Name:    "create_scraper_feed_tables",
+		Up: `
+			CREATE TABLE IF NOT EXISTS scraper_feed_subscriptions (
+				id TEXT PRIMARY KEY,
+				feed_url TEXT NOT NULL UNIQUE,
+				kind TEXT NOT NULL,
+				title TEXT,
+				interval_seconds INTEGER NOT NULL,
+				max_entries_per_poll INTEGER NOT NULL DEFAULT 0,
+				last_polled_at TIMESTAMPTZ,
+				last_error TEXT,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			);
+
+			CREATE TABLE IF NOT EXISTS scraper_feed_entries (
+				subscription_id TEXT NOT NULL REFERENCES scraper_feed_subscriptions(id) ON DELETE CASCADE,
+				entry_url TEXT NOT NULL,
+				first_seen_at TIMESTAMPTZ DEFAULT NOW(),
+				PRIMARY KEY (subscription_id, entry_url)
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS scraper_feed_entries;
+			DROP TABLE IF EXISTS scraper_feed_subscriptions;
+		`,
+	},
+	{
+		Version: 17,
+		Name:    "add_caption_to_images",
+		Up: `
+			ALTER TABLE scraper_images ADD COLUMN IF NOT EXISTS caption TEXT;
+		`,
+		Down: `
+			ALTER TABLE scraper_images DROP COLUMN IF EXISTS caption;
+		`,
+	},
+	{
+		Version: 18,
+		Name:    "add_hashes_to_images",
+		Up: `
+			ALTER TABLE scraper_images ADD COLUMN IF NOT EXISTS sha256 TEXT;
+			ALTER TABLE scraper_images ADD COLUMN IF NOT EXISTS phash BIGINT;
+			CREATE INDEX IF NOT EXISTS idx_images_sha256 ON scraper_images(sha256);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_images_sha256;
+			ALTER TABLE scraper_images DROP COLUMN IF EXISTS phash;
+			ALTER TABLE scraper_images DROP COLUMN IF EXISTS sha256;
+		`,
+	},
+	{
+		Version: 19,
+		Name:    "create_scraper_image_derivatives",
+		Up: `
+			CREATE TABLE IF NOT EXISTS scraper_image_derivatives (
+				image_id TEXT NOT NULL REFERENCES scraper_images(id) ON DELETE CASCADE,
+				width INTEGER NOT NULL,
+				format TEXT NOT NULL,
+				file_path TEXT NOT NULL,
+				content_type TEXT NOT NULL,
+				actual_width INTEGER NOT NULL,
+				actual_height INTEGER NOT NULL,
+				file_size_bytes INTEGER NOT NULL,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				PRIMARY KEY (image_id, width, format)
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS scraper_image_derivatives;
+		`,
+	},
+	{
+		Version: 20,
+		Name:    "add_scraper_images_metadata_policy",
+		Up: `
+			ALTER TABLE scraper_images ADD COLUMN IF NOT EXISTS metadata_policy TEXT;
+			ALTER TABLE scraper_images ADD COLUMN IF NOT EXISTS exif_private TEXT;
+		`,
+		Down: `
+			ALTER TABLE scraper_images DROP COLUMN IF EXISTS exif_private;
+			ALTER TABLE scraper_images DROP COLUMN IF EXISTS metadata_policy;
+		`,
+	},
+	{
+		Version: 21,
+		Name:    "add_scraper_images_safety",
+		Up: `
+			ALTER TABLE scraper_images ADD COLUMN IF NOT EXISTS safety_categories TEXT;
+			ALTER TABLE scraper_images ADD COLUMN IF NOT EXISTS safety_flagged BOOLEAN NOT NULL DEFAULT FALSE;
+			CREATE INDEX IF NOT EXISTS idx_scraper_images_safety_flagged ON scraper_images(safety_flagged) WHERE safety_flagged;
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_scraper_images_safety_flagged;
+			ALTER TABLE scraper_images DROP COLUMN IF EXISTS safety_flagged;
+			ALTER TABLE scraper_images DROP COLUMN IF EXISTS safety_categories;
+		`,
+	},
+	{
+		Version: 22,
+		Name:    "add_scraper_images_text_blocks",
+		Up: `
+			ALTER TABLE scraper_images ADD COLUMN IF NOT EXISTS text_blocks TEXT;
+			ALTER TABLE scraper_images ADD COLUMN IF NOT EXISTS ocr_engine TEXT;
+		`,
+		Down: `
+			ALTER TABLE scraper_images DROP COLUMN IF EXISTS ocr_engine;
+			ALTER TABLE scraper_images DROP COLUMN IF EXISTS text_blocks;
+		`,
+	},
+	{
+		Version: 23,
+		Name:    "add_feed_entry_attempts",
+		Up: `
+			ALTER TABLE scraper_feed_entries ADD COLUMN IF NOT EXISTS scraped_at TIMESTAMPTZ;
+			ALTER TABLE scraper_feed_entries ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE scraper_feed_entries ADD COLUMN IF NOT EXISTS last_error TEXT;
+			UPDATE scraper_feed_entries SET scraped_at = first_seen_at WHERE scraped_at IS NULL;
+		`,
+		Down: `
+			DELETE FROM scraper_feed_entries WHERE scraped_at IS NULL;
+			ALTER TABLE scraper_feed_entries DROP COLUMN IF EXISTS last_error;
+			ALTER TABLE scraper_feed_entries DROP COLUMN IF EXISTS attempts;
+			ALTER TABLE scraper_feed_entries DROP COLUMN IF EXISTS scraped_at;
+		`,
+	},
+	{
+		Version: 24,
+		Name:    "add_phash_bands_to_images",
+		Up: `
+			ALTER TABLE scraper_images ADD COLUMN IF NOT EXISTS phash_bands INTEGER[];
+			UPDATE scraper_images SET phash_bands = ARRAY[
+				(0 << 16) | ((phash >> 54) & 1023)::INTEGER,
+				(1 << 16) | ((phash >> 45) & 511)::INTEGER,
+				(2 << 16) | ((phash >> 36) & 511)::INTEGER,
+				(3 << 16) | ((phash >> 27) & 511)::INTEGER,
+				(4 << 16) | ((phash >> 18) & 511)::INTEGER,
+				(5 << 16) | ((phash >> 9) & 511)::INTEGER,
+				(6 << 16) | (phash & 511)::INTEGER
+			] WHERE phash IS NOT NULL;
+			CREATE INDEX IF NOT EXISTS idx_images_phash_bands ON scraper_images USING GIN (phash_bands);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_images_phash_bands;
+			ALTER TABLE scraper_images DROP COLUMN IF EXISTS phash_bands;
+		`,
+	},
 }
 
 // MigratePostgres runs all pending PostgreSQL migrations
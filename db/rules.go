@@ -0,0 +1,46 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetRuleSetDocument returns a stored rule set document and when it was last updated
+// Returns an empty document if no rule set with that name has been stored
+func (db *DB) GetRuleSetDocument(name string) (string, time.Time, error) {
+	query := `
+		SELECT document, updated_at
+		FROM scraper_rule_sets
+		WHERE name = $1
+	`
+
+	var document string
+	var updatedAt time.Time
+	err := db.conn.QueryRow(query, name).Scan(&document, &updatedAt)
+	if err == sql.ErrNoRows {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get rule set: %w", err)
+	}
+
+	return document, updatedAt, nil
+}
+
+// SaveRuleSetDocument stores a rule set document, replacing any previous version
+func (db *DB) SaveRuleSetDocument(name, document string) error {
+	query := `
+		INSERT INTO scraper_rule_sets (name, document, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			document = EXCLUDED.document,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := db.conn.Exec(query, name, document); err != nil {
+		return fmt.Errorf("failed to save rule set: %w", err)
+	}
+
+	return nil
+}
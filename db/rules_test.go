@@ -0,0 +1,31 @@
+package db
+
+import "testing"
+
+func TestRuleSetDocument(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	doc, _, err := db.GetRuleSetDocument("missing")
+	if err != nil || doc != "" {
+		t.Fatalf("GetRuleSetDocument(missing) = %q, %v; want empty, nil", doc, err)
+	}
+
+	if err := db.SaveRuleSetDocument("default", "rules: []"); err != nil {
+		t.Fatalf("SaveRuleSetDocument failed: %v", err)
+	}
+	if err := db.SaveRuleSetDocument("default", "rules: [] # v2"); err != nil {
+		t.Fatalf("SaveRuleSetDocument (update) failed: %v", err)
+	}
+
+	doc, updatedAt, err := db.GetRuleSetDocument("default")
+	if err != nil {
+		t.Fatalf("GetRuleSetDocument failed: %v", err)
+	}
+	if doc != "rules: [] # v2" {
+		t.Errorf("document = %q, want updated document", doc)
+	}
+	if updatedAt.IsZero() {
+		t.Error("Expected updated_at to be set")
+	}
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docutag/scraper/models"
+)
+
+const scoreLabelColumns = "id, scrape_id, url, domain, accepted, score, ai_used, categories, COALESCE(note, ''), COALESCE(api_key_id, ''), created_at, updated_at"
+
+// SaveScoreLabel records a label for a scrape, replacing any earlier label for the same scrape
+// The label's ID, CreatedAt and UpdatedAt are set from the stored row
+func (db *DB) SaveScoreLabel(label *models.ScoreLabel) error {
+	categoriesJSON, err := json.Marshal(label.Categories)
+	if err != nil {
+		return fmt.Errorf("failed to marshal categories: %w", err)
+	}
+
+	query := `
+		INSERT INTO scraper_score_labels (id, scrape_id, url, domain, accepted, score, ai_used, categories, note, api_key_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		ON CONFLICT (scrape_id) DO UPDATE SET
+			url = EXCLUDED.url,
+			domain = EXCLUDED.domain,
+			accepted = EXCLUDED.accepted,
+			score = EXCLUDED.score,
+			ai_used = EXCLUDED.ai_used,
+			categories = EXCLUDED.categories,
+			note = EXCLUDED.note,
+			api_key_id = EXCLUDED.api_key_id,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at, updated_at
+	`
+
+	err = db.conn.QueryRow(
+		query,
+		label.ID,
+		label.ScrapeID,
+		label.URL,
+		label.Domain,
+		label.Accepted,
+		label.Score,
+		label.AIUsed,
+		string(categoriesJSON),
+		label.Note,
+		sql.NullString{String: label.APIKeyID, Valid: label.APIKeyID != ""},
+	).Scan(&label.ID, &label.CreatedAt, &label.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save score label: %w", err)
+	}
+
+	return nil
+}
+
+// GetScoreLabel returns the label recorded for a scrape
+// Returns nil if the scrape has not been labeled
+func (db *DB) GetScoreLabel(scrapeID string) (*models.ScoreLabel, error) {
+	query := "SELECT " + scoreLabelColumns + " FROM scraper_score_labels WHERE scrape_id = $1"
+
+	label, err := scanScoreLabel(db.conn.QueryRow(query, scrapeID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get score label: %w", err)
+	}
+
+	return label, nil
+}
+
+// ListScoreLabels returns all score labels, oldest first
+func (db *DB) ListScoreLabels() ([]*models.ScoreLabel, error) {
+	query := "SELECT " + scoreLabelColumns + " FROM scraper_score_labels ORDER BY created_at"
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list score labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := []*models.ScoreLabel{}
+	for rows.Next() {
+		label, err := scanScoreLabel(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan score label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, rows.Err()
+}
+
+// scanScoreLabel scans a row selected with scoreLabelColumns
+func scanScoreLabel(row rowScanner) (*models.ScoreLabel, error) {
+	var label models.ScoreLabel
+	var categoriesJSON sql.NullString
+	err := row.Scan(&label.ID, &label.ScrapeID, &label.URL, &label.Domain, &label.Accepted, &label.Score,
+		&label.AIUsed, &categoriesJSON, &label.Note, &label.APIKeyID, &label.CreatedAt, &label.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	label.Categories = []string{}
+	if categoriesJSON.Valid && categoriesJSON.String != "" {
+		if err := json.Unmarshal([]byte(categoriesJSON.String), &label.Categories); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal categories: %w", err)
+		}
+	}
+
+	return &label, nil
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestScoreLabels(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	label := &models.ScoreLabel{
+		ID:         "label-1",
+		ScrapeID:   "scrape-1",
+		URL:        "https://example.com/article",
+		Domain:     "example.com",
+		Accepted:   false,
+		Score:      0.8,
+		AIUsed:     true,
+		Categories: []string{"news"},
+	}
+	if err := db.SaveScoreLabel(label); err != nil {
+		t.Fatalf("SaveScoreLabel failed: %v", err)
+	}
+
+	// Relabeling the same scrape replaces the label but keeps its ID
+	relabel := *label
+	relabel.ID = "label-2"
+	relabel.Accepted = true
+	relabel.Note = "actually useful"
+	if err := db.SaveScoreLabel(&relabel); err != nil {
+		t.Fatalf("SaveScoreLabel (relabel) failed: %v", err)
+	}
+	if relabel.ID != "label-1" {
+		t.Errorf("relabel ID = %q, want original label-1", relabel.ID)
+	}
+
+	got, err := db.GetScoreLabel("scrape-1")
+	if err != nil || got == nil {
+		t.Fatalf("GetScoreLabel = %v, %v", got, err)
+	}
+	if !got.Accepted || got.Note != "actually useful" || len(got.Categories) != 1 {
+		t.Errorf("unexpected label: %+v", got)
+	}
+
+	if got, err := db.GetScoreLabel("missing"); err != nil || got != nil {
+		t.Errorf("GetScoreLabel(missing) = %v, %v; want nil, nil", got, err)
+	}
+
+	labels, err := db.ListScoreLabels()
+	if err != nil || len(labels) != 1 {
+		t.Fatalf("ListScoreLabels = %d labels, %v; want 1", len(labels), err)
+	}
+}
@@ -0,0 +1,145 @@
+// Package eval runs the scraper pipeline against a golden corpus of saved pages and
+// reports extraction and scoring quality, so prompt and parser changes can be checked
+// for regressions without network access.
+package eval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// CaseFile is the name of the file describing a case inside its directory
+const CaseFile = "case.yaml"
+
+// Case is one saved page with the responses the fake LLM gives for it and the expected results
+//
+// Each case lives in its own directory, which is served over HTTP as the site root.
+// Paths in links and images are relative to that root, e.g. "/articles/one.html".
+type Case struct {
+	Name        string  `yaml:"-"`
+	Dir         string  `yaml:"-"`
+	Description string  `yaml:"description"`
+	Page        string  `yaml:"page"` // Page to scrape (default: index.html)
+	FakeLLM     FakeLLM `yaml:"fake_llm"`
+	Expect      Expect  `yaml:"expect"`
+}
+
+// FakeLLM holds the canned responses for a case
+// Unset responses fall back to echoing the input, so extraction is judged on the
+// scraper's own parsing rather than on the model
+type FakeLLM struct {
+	Content string                   `yaml:"content"` // Content extraction response (default: the extracted page text)
+	Links   []string                 `yaml:"links"`   // Link filtering response (default: every candidate link)
+	Score   *FakeScore               `yaml:"score"`   // Scoring response (default: 0.5 with no categories)
+	Images  map[string]FakeImageInfo `yaml:"images"`  // Vision responses keyed by image path
+}
+
+// FakeScore is the scoring response
+type FakeScore struct {
+	Score               float64  `yaml:"score"`
+	Reason              string   `yaml:"reason"`
+	Categories          []string `yaml:"categories"`
+	MaliciousIndicators []string `yaml:"malicious_indicators"`
+}
+
+// FakeImageInfo is the vision response for one image
+type FakeImageInfo struct {
+	Summary string   `yaml:"summary"`
+	Tags    []string `yaml:"tags"`
+	Text    string   `yaml:"text"` // OCR response
+}
+
+// Expect describes the expected scrape result; empty fields are not checked
+type Expect struct {
+	Title           string                   `yaml:"title"`
+	ContentContains []string                 `yaml:"content_contains"`
+	ContentExcludes []string                 `yaml:"content_excludes"`
+	Links           []string                 `yaml:"links"` // Exact set of links the scrape should keep
+	Score           *ExpectScore             `yaml:"score"`
+	Images          map[string]ExpectedImage `yaml:"images"` // Exact set of images the scrape should keep, by path
+}
+
+// ExpectScore bounds the expected score
+type ExpectScore struct {
+	Min         *float64 `yaml:"min"`
+	Max         *float64 `yaml:"max"`
+	Recommended *bool    `yaml:"recommended"`
+	Categories  []string `yaml:"categories"` // Categories that must be present
+}
+
+// ExpectedImage is the expected analysis of one image
+type ExpectedImage struct {
+	Tags []string `yaml:"tags"`
+}
+
+// LoadCorpus loads every case in dir, one per subdirectory containing a case.yaml
+// Cases are returned sorted by name
+func LoadCorpus(dir string) ([]*Case, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	var cases []*Case
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		caseDir := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(caseDir, CaseFile)); os.IsNotExist(err) {
+			continue
+		}
+		c, err := LoadCase(caseDir)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no cases found in %s", dir)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases, nil
+}
+
+// LoadCase loads the case in dir
+func LoadCase(dir string) (*Case, error) {
+	doc, err := os.ReadFile(filepath.Join(dir, CaseFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read case: %w", err)
+	}
+
+	c := &Case{}
+	if err := yaml.UnmarshalStrict(doc, c); err != nil {
+		return nil, fmt.Errorf("invalid case %s: %w", dir, err)
+	}
+	c.Name = filepath.Base(dir)
+	c.Dir = dir
+	if c.Page == "" {
+		c.Page = "index.html"
+	}
+	if _, err := os.Stat(filepath.Join(dir, c.Page)); err != nil {
+		return nil, fmt.Errorf("invalid case %s: page %s: %w", c.Name, c.Page, err)
+	}
+	for path := range c.FakeLLM.Images {
+		if _, err := os.Stat(filepath.Join(dir, sitePath(path))); err != nil {
+			return nil, fmt.Errorf("invalid case %s: fake_llm image %s: %w", c.Name, path, err)
+		}
+	}
+	return c, nil
+}
+
+// sitePath normalizes a path relative to the case root to start with "/"
+// Absolute URLs are returned unchanged
+func sitePath(p string) string {
+	if strings.Contains(p, "://") || strings.HasPrefix(p, "/") {
+		return p
+	}
+	return "/" + p
+}
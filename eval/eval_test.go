@@ -0,0 +1,111 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+const goldenCorpus = "testdata/golden"
+
+func TestGoldenCorpus(t *testing.T) {
+	cases, err := LoadCorpus(goldenCorpus)
+	if err != nil {
+		t.Fatalf("LoadCorpus failed: %v", err)
+	}
+
+	report := Run(context.Background(), cases, Options{})
+
+	var out bytes.Buffer
+	if err := report.WriteText(&out); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	if report.Failed() {
+		t.Fatalf("golden corpus regressed:\n%s", out.String())
+	}
+
+	s := report.Summary
+	if s.Cases != len(cases) || s.Title.Total != len(cases) || s.Links.Recall != 1 || s.Tags.Precision != 1 {
+		t.Errorf("unexpected summary: %+v\n%s", s, out.String())
+	}
+}
+
+func TestRunCaseReportsDiffs(t *testing.T) {
+	c, err := LoadCase(goldenCorpus + "/news-article")
+	if err != nil {
+		t.Fatalf("LoadCase failed: %v", err)
+	}
+
+	// A model that keeps the sponsored link and drops one article
+	c.FakeLLM.Links = []string{
+		"/articles/transit-expansion.html",
+		"/articles/library-hours.html",
+		"/articles/school-board.html",
+		"/sponsored/partner-offer.html",
+	}
+	c.FakeLLM.Score.Score = 0.4
+	c.Expect.Title = "Budget Approved"
+
+	result := RunCase(context.Background(), c, Options{})
+	if result.Passed || result.Error != "" {
+		t.Fatalf("expected case to fail without error, got %+v", result)
+	}
+
+	want := []string{
+		`title: want "Budget Approved", got "City Council Approves 2025 Budget"`,
+		"links: missing /articles/council-profiles.html",
+		"links: unexpected /sponsored/partner-offer.html",
+		"score: 0.40 below min 0.70",
+		"score: recommended = false, want true",
+	}
+	diffs := strings.Join(result.Diffs, "\n")
+	for _, w := range want {
+		if !strings.Contains(diffs, w) {
+			t.Errorf("missing diff %q in:\n%s", w, diffs)
+		}
+	}
+	if m := result.LinkMetric; m.Precision != 0.75 || m.Recall != 0.75 {
+		t.Errorf("unexpected link metric: %+v", m)
+	}
+}
+
+func TestLoadCaseInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadCase(dir); err == nil {
+		t.Error("expected error for a directory without a case file")
+	}
+	if _, err := LoadCorpus(dir); err == nil {
+		t.Error("expected error for an empty corpus")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{
+		Cases: []*CaseResult{{Name: "a", Passed: true}, {Name: "b", Passed: false}},
+		Summary: Summary{
+			Title: CheckMetric{Rate: 1},
+			Links: SetMetric{Precision: 0.9, Recall: 0.8},
+		},
+	}
+	current := &Report{
+		Cases: []*CaseResult{{Name: "a", Passed: false}, {Name: "b", Passed: true}},
+		Summary: Summary{
+			Title: CheckMetric{Rate: 1},
+			Links: SetMetric{Precision: 0.95, Recall: 0.7},
+		},
+	}
+
+	regressions := Compare(baseline, current)
+	want := []string{
+		"case a: passed in baseline, now fails",
+		"links recall: 0.800 -> 0.700",
+	}
+	if strings.Join(regressions, "\n") != strings.Join(want, "\n") {
+		t.Errorf("regressions = %q, want %q", regressions, want)
+	}
+
+	if regressions := Compare(baseline, baseline); len(regressions) != 0 {
+		t.Errorf("expected no regressions against itself, got %v", regressions)
+	}
+}
@@ -0,0 +1,158 @@
+package eval
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docutag/scraper/models"
+)
+
+// Prompt openings used to tell the scraper's LLM calls apart
+const (
+	promptExtractContent = "You are a content extraction assistant"
+	promptFilterLinks    = "You are a link filtering assistant"
+	promptScoreContent   = "You are a content quality assessment assistant"
+	promptAnalyzeImage   = "Analyze this image"
+	promptExtractText    = "Extract all visible text from this image"
+)
+
+// fakeLLM is an Ollama-compatible server answering with a case's canned responses
+type fakeLLM struct {
+	c       *Case
+	siteURL string                   // Base URL the case is served from, for resolving link paths
+	images  map[string]FakeImageInfo // Vision responses keyed by base64 image data
+}
+
+// newFakeLLM creates the fake LLM for a case served at siteURL
+func newFakeLLM(c *Case, siteURL string) (*fakeLLM, error) {
+	f := &fakeLLM{c: c, siteURL: siteURL, images: make(map[string]FakeImageInfo)}
+	for path, info := range c.FakeLLM.Images {
+		data, err := os.ReadFile(filepath.Join(c.Dir, sitePath(path)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image %s: %w", path, err)
+		}
+		f.images[base64.StdEncoding.EncodeToString(data)] = info
+	}
+	return f, nil
+}
+
+// start serves the fake LLM on a local test server
+func (f *fakeLLM) start() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"models": []interface{}{}})
+	})
+	mux.HandleFunc("/api/generate", f.handleGenerate)
+	return httptest.NewServer(mux)
+}
+
+func (f *fakeLLM) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var req models.OllamaVisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	response, err := f.respond(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.OllamaResponse{Model: req.Model, Response: response, Done: true})
+}
+
+// respond returns the canned response for a request
+func (f *fakeLLM) respond(req models.OllamaVisionRequest) (string, error) {
+	switch {
+	case strings.HasPrefix(req.Prompt, promptExtractContent):
+		if f.c.FakeLLM.Content != "" {
+			return f.c.FakeLLM.Content, nil
+		}
+		return between(req.Prompt, "Text:\n", "\n\nExtracted content:"), nil
+
+	case strings.HasPrefix(req.Prompt, promptFilterLinks):
+		if f.c.FakeLLM.Links == nil {
+			return between(req.Prompt, "Links to filter:\n", "\n\nReturn ONLY"), nil
+		}
+		links := make([]string, 0, len(f.c.FakeLLM.Links))
+		for _, link := range f.c.FakeLLM.Links {
+			links = append(links, f.resolve(link))
+		}
+		data, err := json.Marshal(links)
+		return string(data), err
+
+	case strings.HasPrefix(req.Prompt, promptScoreContent):
+		score := FakeScore{Score: 0.5, Reason: "fake LLM default score"}
+		if f.c.FakeLLM.Score != nil {
+			score = *f.c.FakeLLM.Score
+		}
+		data, err := json.Marshal(map[string]interface{}{
+			"score":                score.Score,
+			"reason":               score.Reason,
+			"categories":           nonNil(score.Categories),
+			"malicious_indicators": nonNil(score.MaliciousIndicators),
+		})
+		return string(data), err
+
+	case strings.HasPrefix(req.Prompt, promptAnalyzeImage):
+		info, err := f.image(req)
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(map[string]interface{}{"summary": info.Summary, "tags": nonNil(info.Tags)})
+		return string(data), err
+
+	case strings.HasPrefix(req.Prompt, promptExtractText):
+		info, err := f.image(req)
+		return info.Text, err
+	}
+
+	return "", fmt.Errorf("fake LLM does not recognize prompt: %.60q", req.Prompt)
+}
+
+// image returns the vision response for the request's image
+// Images without a canned response get an empty analysis
+func (f *fakeLLM) image(req models.OllamaVisionRequest) (FakeImageInfo, error) {
+	if len(req.Images) == 0 {
+		return FakeImageInfo{}, fmt.Errorf("vision request without an image")
+	}
+	return f.images[req.Images[0]], nil
+}
+
+// resolve turns a site path into an absolute URL on the case server
+func (f *fakeLLM) resolve(link string) string {
+	link = sitePath(link)
+	if strings.Contains(link, "://") {
+		return link
+	}
+	return f.siteURL + link
+}
+
+// between returns the text between the first occurrence of start and the following end
+func between(s, start, end string) string {
+	i := strings.Index(s, start)
+	if i < 0 {
+		return ""
+	}
+	s = s[i+len(start):]
+	if j := strings.Index(s, end); j >= 0 {
+		s = s[:j]
+	}
+	return s
+}
+
+// nonNil returns an empty slice instead of nil so responses encode as []
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
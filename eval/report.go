@@ -0,0 +1,327 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// CheckMetric counts pass/fail checks
+type CheckMetric struct {
+	Total  int     `json:"total"`
+	Passed int     `json:"passed"`
+	Rate   float64 `json:"rate"`
+}
+
+// record counts one check and returns whether it passed
+func (m *CheckMetric) record(passed bool) bool {
+	m.Total++
+	if passed {
+		m.Passed++
+	}
+	m.Rate = rate(m.Passed, m.Total)
+	return passed
+}
+
+// add accumulates another metric
+func (m *CheckMetric) add(o CheckMetric) {
+	m.Total += o.Total
+	m.Passed += o.Passed
+	m.Rate = rate(m.Passed, m.Total)
+}
+
+// SetMetric compares an expected set with the one the scraper produced
+type SetMetric struct {
+	Expected  int     `json:"expected"`
+	Found     int     `json:"found"`
+	Matched   int     `json:"matched"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+}
+
+// add accumulates another metric
+func (m *SetMetric) add(o *SetMetric) {
+	if o == nil {
+		return
+	}
+	m.Expected += o.Expected
+	m.Found += o.Found
+	m.Matched += o.Matched
+	m.finish()
+}
+
+// finish computes precision and recall; an empty set has nothing wrong in it
+func (m *SetMetric) finish() {
+	m.Precision = 1
+	if m.Found > 0 {
+		m.Precision = rate(m.Matched, m.Found)
+	}
+	m.Recall = 1
+	if m.Expected > 0 {
+		m.Recall = rate(m.Matched, m.Expected)
+	}
+}
+
+// compareSets measures got against want, reporting missing and unexpected entries through diff
+func compareSets(name string, want, got []string, diff func(format string, args ...interface{})) *SetMetric {
+	wanted := make(map[string]bool, len(want))
+	for _, w := range want {
+		wanted[w] = true
+	}
+	found := make(map[string]bool, len(got))
+	for _, g := range got {
+		found[g] = true
+	}
+
+	m := &SetMetric{Expected: len(wanted), Found: len(found)}
+	for _, w := range sortedKeys(wanted) {
+		if found[w] {
+			m.Matched++
+		} else {
+			diff("%s: missing %s", name, w)
+		}
+	}
+	for _, g := range sortedKeys(found) {
+		if !wanted[g] {
+			diff("%s: unexpected %s", name, g)
+		}
+	}
+	m.finish()
+	return m
+}
+
+// CaseResult is the outcome of one case
+type CaseResult struct {
+	Name            string      `json:"name"`
+	Passed          bool        `json:"passed"`
+	Error           string      `json:"error,omitempty"`
+	Diffs           []string    `json:"diffs,omitempty"` // Differences from the expectations
+	Title           string      `json:"title"`
+	TitleChecked    bool        `json:"title_checked"`
+	TitleCorrect    bool        `json:"title_correct"`
+	Content         CheckMetric `json:"content"`
+	Links           []string    `json:"links"`
+	LinkMetric      *SetMetric  `json:"link_metric,omitempty"`
+	ImageMetric     *SetMetric  `json:"image_metric,omitempty"`
+	TagMetric       *SetMetric  `json:"tag_metric,omitempty"`
+	Score           float64     `json:"score"`
+	Recommended     bool        `json:"recommended"`
+	AIUsed          bool        `json:"ai_used"`
+	ScoreChecked    bool        `json:"score_checked"`
+	ScoreCorrect    bool        `json:"score_correct"`
+	DurationSeconds float64     `json:"duration_seconds"`
+}
+
+// diff records a difference from the expectations
+func (r *CaseResult) diff(format string, args ...interface{}) {
+	r.Diffs = append(r.Diffs, fmt.Sprintf(format, args...))
+}
+
+// fail records an error that prevented the case from running
+func (r *CaseResult) fail(err error) {
+	r.Error = err.Error()
+	r.Passed = false
+}
+
+// Summary aggregates metrics over all cases
+// Set metrics are micro-averaged: counts are summed before computing precision and recall
+type Summary struct {
+	Cases   int         `json:"cases"`
+	Passed  int         `json:"passed"`
+	Errors  int         `json:"errors"`
+	Title   CheckMetric `json:"title"`
+	Content CheckMetric `json:"content"`
+	Score   CheckMetric `json:"score"`
+	Links   SetMetric   `json:"links"`
+	Images  SetMetric   `json:"images"`
+	Tags    SetMetric   `json:"tags"`
+}
+
+// Report is the result of an evaluation run
+type Report struct {
+	LLM       string        `json:"llm"` // "fake" or the Ollama URL used
+	StartedAt time.Time     `json:"started_at"`
+	Summary   Summary       `json:"summary"`
+	Cases     []*CaseResult `json:"cases"`
+}
+
+// summarize computes the summary from the case results
+func (r *Report) summarize() {
+	s := Summary{Cases: len(r.Cases)}
+	s.Links.finish()
+	s.Images.finish()
+	s.Tags.finish()
+
+	for _, c := range r.Cases {
+		if c.Passed {
+			s.Passed++
+		}
+		if c.Error != "" {
+			s.Errors++
+			continue
+		}
+		if c.TitleChecked {
+			s.Title.record(c.TitleCorrect)
+		}
+		if c.ScoreChecked {
+			s.Score.record(c.ScoreCorrect)
+		}
+		s.Content.add(c.Content)
+		s.Links.add(c.LinkMetric)
+		s.Images.add(c.ImageMetric)
+		s.Tags.add(c.TagMetric)
+	}
+	r.Summary = s
+}
+
+// Failed reports whether any case failed
+func (r *Report) Failed() bool {
+	return r.Summary.Passed < r.Summary.Cases
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// LoadReport reads a report written by WriteJSON
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid report %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// WriteText writes a human-readable table of results with each failing case's diffs
+func (r *Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Golden corpus evaluation (llm: %s)\n\n", r.LLM)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CASE\tRESULT\tTITLE\tCONTENT\tLINKS P/R\tIMAGES P/R\tTAGS P/R\tSCORE")
+	for _, c := range r.Cases {
+		result := "PASS"
+		if c.Error != "" {
+			result = "ERROR"
+		} else if !c.Passed {
+			result = "FAIL"
+		}
+		title := "-"
+		if c.TitleChecked {
+			title = map[bool]string{true: "ok", false: "wrong"}[c.TitleCorrect]
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%.2f\n", c.Name, result, title,
+			checkCell(c.Content), setCell(c.LinkMetric), setCell(c.ImageMetric), setCell(c.TagMetric), c.Score)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, c := range r.Cases {
+		if c.Error == "" && len(c.Diffs) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", c.Name)
+		if c.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", c.Error)
+		}
+		for _, d := range c.Diffs {
+			fmt.Fprintf(w, "  - %s\n", d)
+		}
+	}
+
+	s := r.Summary
+	fmt.Fprintf(w, "\nSummary: %d/%d cases passed", s.Passed, s.Cases)
+	if s.Errors > 0 {
+		fmt.Fprintf(w, ", %d errors", s.Errors)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  title    %d/%d correct\n", s.Title.Passed, s.Title.Total)
+	fmt.Fprintf(w, "  content  %d/%d snippet checks\n", s.Content.Passed, s.Content.Total)
+	fmt.Fprintf(w, "  score    %d/%d within expectations\n", s.Score.Passed, s.Score.Total)
+	fmt.Fprintf(w, "  links    precision %.3f  recall %.3f\n", s.Links.Precision, s.Links.Recall)
+	fmt.Fprintf(w, "  images   precision %.3f  recall %.3f\n", s.Images.Precision, s.Images.Recall)
+	_, err := fmt.Fprintf(w, "  tags     precision %.3f  recall %.3f\n", s.Tags.Precision, s.Tags.Recall)
+	return err
+}
+
+// Compare lists regressions from baseline to current: cases that stopped passing
+// and summary metrics that dropped
+func Compare(baseline, current *Report) []string {
+	var regressions []string
+
+	passed := make(map[string]bool, len(baseline.Cases))
+	for _, c := range baseline.Cases {
+		passed[c.Name] = c.Passed
+	}
+	for _, c := range current.Cases {
+		if passed[c.Name] && !c.Passed {
+			regressions = append(regressions, fmt.Sprintf("case %s: passed in baseline, now fails", c.Name))
+		}
+	}
+
+	b, c := baseline.Summary, current.Summary
+	metrics := []struct {
+		name              string
+		baseline, current float64
+	}{
+		{"title accuracy", b.Title.Rate, c.Title.Rate},
+		{"content checks", b.Content.Rate, c.Content.Rate},
+		{"score checks", b.Score.Rate, c.Score.Rate},
+		{"links precision", b.Links.Precision, c.Links.Precision},
+		{"links recall", b.Links.Recall, c.Links.Recall},
+		{"images precision", b.Images.Precision, c.Images.Precision},
+		{"images recall", b.Images.Recall, c.Images.Recall},
+		{"tags precision", b.Tags.Precision, c.Tags.Precision},
+		{"tags recall", b.Tags.Recall, c.Tags.Recall},
+	}
+	for _, m := range metrics {
+		if m.current < m.baseline-1e-9 {
+			regressions = append(regressions, fmt.Sprintf("%s: %.3f -> %.3f", m.name, m.baseline, m.current))
+		}
+	}
+
+	return regressions
+}
+
+func checkCell(m CheckMetric) string {
+	if m.Total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", m.Passed, m.Total)
+}
+
+func setCell(m *SetMetric) string {
+	if m == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f/%.2f", m.Precision, m.Recall)
+}
+
+// rate returns n/d rounded to four decimal places, or 0 when d is 0
+func rate(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(d)*10000) / 10000
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,236 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docutag/scraper"
+	"github.com/docutag/scraper/models"
+)
+
+// Options configures an evaluation run
+type Options struct {
+	// OllamaURL points the scraper at a real or recording Ollama server instead of
+	// the fake LLM. Fake responses in the cases are then ignored.
+	OllamaURL string
+	// Config is the base scraper configuration (default: scraper.DefaultConfig())
+	Config *scraper.Config
+	// Filter runs only cases whose name contains it
+	Filter string
+	// CaseTimeout bounds each case (default: 2 minutes)
+	CaseTimeout time.Duration
+}
+
+// LLMName describes the LLM used by a run
+func (o Options) LLMName() string {
+	if o.OllamaURL != "" {
+		return o.OllamaURL
+	}
+	return "fake"
+}
+
+// Run evaluates every case and summarizes the results
+func Run(ctx context.Context, cases []*Case, opts Options) *Report {
+	report := &Report{
+		LLM:       opts.LLMName(),
+		StartedAt: time.Now().UTC(),
+		Cases:     []*CaseResult{},
+	}
+	for _, c := range cases {
+		if opts.Filter != "" && !strings.Contains(c.Name, opts.Filter) {
+			continue
+		}
+		report.Cases = append(report.Cases, RunCase(ctx, c, opts))
+	}
+	report.summarize()
+	return report
+}
+
+// RunCase serves a case over local HTTP, scrapes it and compares the result with its expectations
+func RunCase(ctx context.Context, c *Case, opts Options) *CaseResult {
+	start := time.Now()
+	result := &CaseResult{Name: c.Name}
+	defer func() { result.DurationSeconds = time.Since(start).Seconds() }()
+
+	site := httptest.NewServer(http.FileServer(http.Dir(c.Dir)))
+	defer site.Close()
+
+	config := scraper.DefaultConfig()
+	if opts.Config != nil {
+		config = *opts.Config
+	}
+	config.OllamaEndpoints = nil
+	config.OllamaVisionEndpoints = nil
+	if opts.OllamaURL != "" {
+		config.OllamaBaseURL = opts.OllamaURL
+	} else {
+		llm, err := newFakeLLM(c, site.URL)
+		if err != nil {
+			result.fail(err)
+			return result
+		}
+		llmServer := llm.start()
+		defer llmServer.Close()
+		config.OllamaBaseURL = llmServer.URL
+	}
+
+	timeout := opts.CaseTimeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	s := scraper.New(config, nil, nil)
+	data, err := s.Scrape(ctx, site.URL+sitePath(c.Page))
+	if err != nil {
+		result.fail(fmt.Errorf("scrape failed: %w", err))
+		return result
+	}
+	if len(data.Warnings) > 0 {
+		slog.Info("eval case produced warnings", "case", c.Name, "warnings", data.Warnings)
+	}
+
+	result.compare(c.Expect, data, site.URL)
+	return result
+}
+
+// relative strips the case server from a URL so results read like the expectations
+func relative(u, siteURL string) string {
+	if strings.HasPrefix(u, siteURL) {
+		if p := strings.TrimPrefix(u, siteURL); p != "" {
+			return p
+		}
+		return "/"
+	}
+	return u
+}
+
+// compare checks a scrape result against the expectations, recording metrics and diffs
+func (r *CaseResult) compare(expect Expect, data *models.ScrapedData, siteURL string) {
+	r.Title = data.Title
+	if expect.Title != "" {
+		r.TitleChecked = true
+		r.TitleCorrect = data.Title == expect.Title
+		if !r.TitleCorrect {
+			r.diff("title: want %q, got %q", expect.Title, data.Title)
+		}
+	}
+
+	for _, snippet := range expect.ContentContains {
+		if !r.Content.record(strings.Contains(data.Content, snippet)) {
+			r.diff("content: missing %q", snippet)
+		}
+	}
+	for _, snippet := range expect.ContentExcludes {
+		if !r.Content.record(!strings.Contains(data.Content, snippet)) {
+			r.diff("content: unexpected %q", snippet)
+		}
+	}
+
+	links := make([]string, 0, len(data.Links))
+	for _, link := range data.Links {
+		links = append(links, relative(link, siteURL))
+	}
+	r.Links = links
+	if expect.Links != nil {
+		want := make([]string, 0, len(expect.Links))
+		for _, link := range expect.Links {
+			want = append(want, sitePath(link))
+		}
+		r.LinkMetric = compareSets("links", want, links, r.diff)
+	}
+
+	if expect.Images != nil {
+		want := make([]string, 0, len(expect.Images))
+		for path := range expect.Images {
+			want = append(want, sitePath(path))
+		}
+		got := make([]string, 0, len(data.Images))
+		gotTags := make(map[string][]string, len(data.Images))
+		for _, img := range data.Images {
+			path := relative(img.URL, siteURL)
+			got = append(got, path)
+			gotTags[path] = img.Tags
+		}
+		r.ImageMetric = compareSets("images", want, got, r.diff)
+
+		// Tags are only compared for images that were kept
+		for _, path := range sortedPaths(expect.Images) {
+			tags, ok := gotTags[sitePath(path)]
+			if !ok || expect.Images[path].Tags == nil {
+				continue
+			}
+			if r.TagMetric == nil {
+				r.TagMetric = &SetMetric{}
+			}
+			r.TagMetric.add(compareSets("tags "+sitePath(path), expect.Images[path].Tags, tags, r.diff))
+		}
+	}
+
+	if data.Score != nil {
+		r.Score = data.Score.Score
+		r.Recommended = data.Score.IsRecommended
+		r.AIUsed = data.Score.AIUsed
+	}
+	if expect.Score != nil {
+		r.ScoreChecked = true
+		r.ScoreCorrect = r.checkScore(*expect.Score, data.Score)
+	}
+
+	r.Passed = len(r.Diffs) == 0
+}
+
+// checkScore compares the score with its expected bounds
+func (r *CaseResult) checkScore(expect ExpectScore, score *models.LinkScore) bool {
+	if score == nil {
+		r.diff("score: missing")
+		return false
+	}
+
+	correct := true
+	if expect.Min != nil && score.Score < *expect.Min {
+		r.diff("score: %.2f below min %.2f", score.Score, *expect.Min)
+		correct = false
+	}
+	if expect.Max != nil && score.Score > *expect.Max {
+		r.diff("score: %.2f above max %.2f", score.Score, *expect.Max)
+		correct = false
+	}
+	if expect.Recommended != nil && score.IsRecommended != *expect.Recommended {
+		r.diff("score: recommended = %v, want %v", score.IsRecommended, *expect.Recommended)
+		correct = false
+	}
+	for _, category := range expect.Categories {
+		if !containsString(score.Categories, category) {
+			r.diff("score: missing category %q (got %v)", category, score.Categories)
+			correct = false
+		}
+	}
+	return correct
+}
+
+// sortedPaths returns the image paths in a stable order so diffs are reproducible
+func sortedPaths(images map[string]ExpectedImage) []string {
+	paths := make([]string, 0, len(images))
+	for path := range images {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
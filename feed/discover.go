@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"mime"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// alternateTypes maps <link rel="alternate"> media types to feed kinds
+var alternateTypes = map[string]string{
+	"application/rss+xml":   KindRSS,
+	"application/rdf+xml":   KindRSS,
+	"application/atom+xml":  KindAtom,
+	"application/feed+json": KindJSON,
+}
+
+// Link is a feed or sitemap advertised by a page or robots.txt
+type Link struct {
+	URL   string `json:"url"`
+	Kind  string `json:"kind"` // KindRSS, KindAtom, KindJSON or KindSitemap
+	Title string `json:"title,omitempty"`
+}
+
+// Discover returns the feeds a page advertises with <link rel="alternate">, in page order
+func Discover(doc *html.Node, base *url.URL) []Link {
+	var links []Link
+	seen := map[string]bool{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "link" {
+			var rel, typ, href, title string
+			for _, attr := range n.Attr {
+				switch attr.Key {
+				case "rel":
+					rel = attr.Val
+				case "type":
+					typ = attr.Val
+				case "href":
+					href = attr.Val
+				case "title":
+					title = attr.Val
+				}
+			}
+			mediaType, _, _ := mime.ParseMediaType(typ)
+			kind, isFeed := alternateTypes[strings.ToLower(mediaType)]
+			if isFeed && hasToken(rel, "alternate") {
+				if u := resolve(base, href); u != "" && !seen[u] {
+					seen[u] = true
+					links = append(links, Link{URL: u, Kind: kind, Title: strings.TrimSpace(title)})
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return links
+}
+
+// RobotsSitemaps returns the sitemaps listed by "Sitemap:" lines in a robots.txt
+func RobotsSitemaps(robots []byte, base *url.URL) []Link {
+	var links []Link
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(robots))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(field), "sitemap") {
+			continue
+		}
+		if u := resolve(base, value); u != "" && !seen[u] {
+			seen[u] = true
+			links = append(links, Link{URL: u, Kind: KindSitemap})
+		}
+	}
+	return links
+}
+
+// hasToken reports whether a space-separated attribute value contains token
+func hasToken(value, token string) bool {
+	for _, field := range strings.Fields(value) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,417 @@
+// Package feed parses RSS, Atom and JSON feeds and XML sitemaps into a common list of
+// entries, and discovers feeds and sitemaps advertised by pages and robots.txt.
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Feed kinds
+const (
+	KindRSS          = "rss"
+	KindAtom         = "atom"
+	KindJSON         = "json"
+	KindSitemap      = "sitemap"
+	KindSitemapIndex = "sitemap_index"
+)
+
+// ErrUnknownFormat is returned for documents that are not a feed or sitemap
+var ErrUnknownFormat = errors.New("not an RSS, Atom, JSON feed or sitemap document")
+
+// Feed is a parsed feed or sitemap
+type Feed struct {
+	URL      string
+	Kind     string // KindRSS, KindAtom, KindJSON, KindSitemap or KindSitemapIndex
+	Title    string
+	Entries  []Entry
+	Sitemaps []string // Child sitemaps listed by a sitemap index, most recently modified first
+}
+
+// Entry is an article listed by a feed or sitemap
+type Entry struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	Published time.Time `json:"published,omitzero"` // Zero if the feed gives no date
+	Updated   time.Time `json:"updated,omitzero"`
+	FeedURL   string    `json:"feed_url"` // Feed or sitemap the entry was listed in
+}
+
+// Date returns when the entry was published, falling back to when it was updated
+func (e Entry) Date() time.Time {
+	if !e.Published.IsZero() {
+		return e.Published
+	}
+	return e.Updated
+}
+
+// SortNewestFirst orders entries by Date, newest first, keeping undated entries last
+func SortNewestFirst(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date().After(entries[j].Date())
+	})
+}
+
+// Parse parses a feed or sitemap fetched from feedURL, detecting its format from the
+// content
+// Relative entry links are resolved against feedURL and entries without a link are dropped.
+func Parse(data []byte, feedURL string) (*Feed, error) {
+	base, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed URL: %w", err)
+	}
+
+	trimmed := bytes.TrimLeft(data, "\ufeff \t\r\n")
+	var f *Feed
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		f, err = parseJSON(trimmed)
+	} else {
+		f, err = parseXML(trimmed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f.URL = feedURL
+	entries := f.Entries[:0]
+	for _, e := range f.Entries {
+		e.URL = resolve(base, e.URL)
+		if e.URL == "" {
+			continue
+		}
+		e.Title = strings.TrimSpace(e.Title)
+		e.Author = strings.TrimSpace(e.Author)
+		e.FeedURL = feedURL
+		entries = append(entries, e)
+	}
+	f.Entries = entries
+	for i, s := range f.Sitemaps {
+		f.Sitemaps[i] = resolve(base, s)
+	}
+	f.Title = strings.TrimSpace(f.Title)
+	return f, nil
+}
+
+// resolve returns ref as an absolute http(s) URL relative to base, or "" if it is not one
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
+
+// XML documents, matched by local element names so namespace prefixes do not matter
+
+type rssDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"` // RSS 1.0 puts items beside the channel
+}
+
+type rssItem struct {
+	Title   string    `xml:"title"`
+	Links   []rssLink `xml:"link"`
+	GUID    rssGUID   `xml:"guid"`
+	Author  string    `xml:"author"`
+	Creator string    `xml:"creator"` // dc:creator
+	PubDate string    `xml:"pubDate"`
+	Date    string    `xml:"date"` // dc:date
+	Updated string    `xml:"updated"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"` // atom:link inside an item
+	Rel  string `xml:"rel,attr"`
+	Text string `xml:",chardata"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomDocument struct {
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title   string     `xml:"title"`
+	Links   []atomLink `xml:"link"`
+	Authors []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type urlsetDocument struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+		News    struct {
+			Title           string `xml:"title"`
+			PublicationDate string `xml:"publication_date"`
+		} `xml:"news"` // Google News sitemap extension
+	} `xml:"url"`
+}
+
+type sitemapIndexDocument struct {
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+}
+
+// parseXML parses an RSS, Atom or sitemap document, chosen by its root element
+func parseXML(data []byte) (*Feed, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Feed{}
+	switch root {
+	case "rss", "RDF":
+		var doc rssDocument
+		if err := unmarshalXML(data, &doc); err != nil {
+			return nil, err
+		}
+		f.Kind = KindRSS
+		f.Title = doc.Channel.Title
+		for _, item := range append(doc.Channel.Items, doc.Items...) {
+			f.Entries = append(f.Entries, item.entry())
+		}
+	case "feed":
+		var doc atomDocument
+		if err := unmarshalXML(data, &doc); err != nil {
+			return nil, err
+		}
+		f.Kind = KindAtom
+		f.Title = doc.Title
+		for _, e := range doc.Entries {
+			f.Entries = append(f.Entries, e.entry())
+		}
+	case "urlset":
+		var doc urlsetDocument
+		if err := unmarshalXML(data, &doc); err != nil {
+			return nil, err
+		}
+		f.Kind = KindSitemap
+		for _, u := range doc.URLs {
+			f.Entries = append(f.Entries, Entry{
+				URL:       u.Loc,
+				Title:     u.News.Title,
+				Published: parseDate(u.News.PublicationDate),
+				Updated:   parseDate(u.LastMod),
+			})
+		}
+	case "sitemapindex":
+		var doc sitemapIndexDocument
+		if err := unmarshalXML(data, &doc); err != nil {
+			return nil, err
+		}
+		f.Kind = KindSitemapIndex
+		sort.SliceStable(doc.Sitemaps, func(i, j int) bool {
+			return parseDate(doc.Sitemaps[i].LastMod).After(parseDate(doc.Sitemaps[j].LastMod))
+		})
+		for _, s := range doc.Sitemaps {
+			if loc := strings.TrimSpace(s.Loc); loc != "" {
+				f.Sitemaps = append(f.Sitemaps, loc)
+			}
+		}
+	default:
+		return nil, ErrUnknownFormat
+	}
+	return f, nil
+}
+
+func (item rssItem) entry() Entry {
+	e := Entry{
+		Title:     item.Title,
+		Author:    firstNonEmpty(item.Creator, item.Author),
+		Published: parseDate(firstNonEmpty(item.PubDate, item.Date)),
+		Updated:   parseDate(item.Updated),
+	}
+	for _, link := range item.Links {
+		if text := strings.TrimSpace(link.Text); text != "" {
+			e.URL = text
+			break
+		}
+		if link.Href != "" && (link.Rel == "" || link.Rel == "alternate") {
+			e.URL = link.Href
+		}
+	}
+	// A permalink GUID stands in for a missing link
+	if e.URL == "" && !strings.EqualFold(item.GUID.IsPermaLink, "false") {
+		e.URL = item.GUID.Value
+	}
+	return e
+}
+
+func (entry atomEntry) entry() Entry {
+	e := Entry{
+		Title:     entry.Title,
+		Published: parseDate(entry.Published),
+		Updated:   parseDate(entry.Updated),
+	}
+	for _, link := range entry.Links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			e.URL = link.Href
+			break
+		}
+	}
+	var authors []string
+	for _, a := range entry.Authors {
+		if name := strings.TrimSpace(a.Name); name != "" {
+			authors = append(authors, name)
+		}
+	}
+	e.Author = strings.Join(authors, ", ")
+	return e
+}
+
+// rootElement returns the local name of the document's root element
+func rootElement(data []byte) (string, error) {
+	decoder := newXMLDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", ErrUnknownFormat
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// unmarshalXML decodes an XML document in any character encoding it declares
+func unmarshalXML(data []byte, v any) error {
+	if err := newXMLDecoder(data).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse feed: %w", err)
+	}
+	return nil
+}
+
+func newXMLDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false // Feeds in the wild contain HTML entities and stray ampersands
+	decoder.Entity = xml.HTMLEntity
+	return decoder
+}
+
+// jsonDocument is a JSON Feed (https://jsonfeed.org), version 1 or 1.1
+type jsonDocument struct {
+	Version string `json:"version"`
+	Title   string `json:"title"`
+	Items   []struct {
+		URL           string       `json:"url"`
+		ExternalURL   string       `json:"external_url"`
+		Title         string       `json:"title"`
+		Author        *jsonAuthor  `json:"author"` // Version 1
+		Authors       []jsonAuthor `json:"authors"`
+		DatePublished string       `json:"date_published"`
+		DateModified  string       `json:"date_modified"`
+	} `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+func parseJSON(data []byte) (*Feed, error) {
+	var doc jsonDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON feed: %w", err)
+	}
+	if !strings.HasPrefix(doc.Version, "https://jsonfeed.org/version/") {
+		return nil, ErrUnknownFormat
+	}
+
+	f := &Feed{Kind: KindJSON, Title: doc.Title}
+	for _, item := range doc.Items {
+		authors := item.Authors
+		if len(authors) == 0 && item.Author != nil {
+			authors = []jsonAuthor{*item.Author}
+		}
+		var names []string
+		for _, a := range authors {
+			if a.Name != "" {
+				names = append(names, a.Name)
+			}
+		}
+		f.Entries = append(f.Entries, Entry{
+			URL:       firstNonEmpty(item.URL, item.ExternalURL),
+			Title:     item.Title,
+			Author:    strings.Join(names, ", "),
+			Published: parseDate(item.DatePublished),
+			Updated:   parseDate(item.DateModified),
+		})
+	}
+	return f, nil
+}
+
+// dateLayouts are the date formats seen in feeds and sitemaps, most common first
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+}
+
+// parseDate parses a feed date, returning the zero time if no known layout matches
+func parseDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
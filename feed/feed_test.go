@@ -0,0 +1,178 @@
+package feed
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+func TestParse(t *testing.T) {
+	published := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		doc    string
+		kind   string
+		title  string
+		author string
+	}{
+		{
+			name: "rss 2.0",
+			doc: `<?xml version="1.0"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/"><channel><title>News &amp; Views</title>
+<item><title>First</title><link>/articles/first</link><dc:creator>Ada Lovelace</dc:creator><pubDate>Fri, 01 Mar 2024 09:30:00 GMT</pubDate></item>
+<item><title>No link</title><guid isPermaLink="false">tag:1</guid></item>
+</channel></rss>`,
+			kind: KindRSS, title: "News & Views", author: "Ada Lovelace",
+		},
+		{
+			name: "rss 1.0",
+			doc: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel><title>RDF feed</title></channel>
+<item><title>First</title><link>https://example.com/articles/first</link><dc:date>2024-03-01T09:30:00Z</dc:date><dc:creator>Ada Lovelace</dc:creator></item>
+</rdf:RDF>`,
+			kind: KindRSS, title: "RDF feed", author: "Ada Lovelace",
+		},
+		{
+			name: "atom",
+			doc: `<feed xmlns="http://www.w3.org/2005/Atom"><title>Atom feed</title>
+<entry><title>First</title><link rel="alternate" href="https://example.com/articles/first"/><link rel="edit" href="/edit/1"/>
+<author><name>Ada Lovelace</name></author><published>2024-03-01T09:30:00Z</published></entry></feed>`,
+			kind: KindAtom, title: "Atom feed", author: "Ada Lovelace",
+		},
+		{
+			name: "json feed",
+			doc: `{"version": "https://jsonfeed.org/version/1.1", "title": "JSON feed",
+"items": [{"id": "1", "url": "https://example.com/articles/first", "title": "First",
+"authors": [{"name": "Ada Lovelace"}], "date_published": "2024-03-01T09:30:00Z"}]}`,
+			kind: KindJSON, title: "JSON feed", author: "Ada Lovelace",
+		},
+		{
+			name: "news sitemap",
+			doc: `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:news="http://www.google.com/schemas/sitemap-news/0.9">
+<url><loc>https://example.com/articles/first</loc><lastmod>2024-03-02</lastmod>
+<news:news><news:title>First</news:title><news:publication_date>2024-03-01T09:30:00+00:00</news:publication_date></news:news></url></urlset>`,
+			kind: KindSitemap,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := Parse([]byte(c.doc), "https://example.com/feed")
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if f.Kind != c.kind || f.Title != c.title {
+				t.Errorf("kind, title = %q, %q; want %q, %q", f.Kind, f.Title, c.kind, c.title)
+			}
+			if len(f.Entries) != 1 {
+				t.Fatalf("entries = %+v, want 1", f.Entries)
+			}
+			e := f.Entries[0]
+			if e.URL != "https://example.com/articles/first" || e.Title != "First" || e.Author != c.author {
+				t.Errorf("entry = %+v", e)
+			}
+			if !e.Published.Equal(published) {
+				t.Errorf("published = %v, want %v", e.Published, published)
+			}
+			if e.FeedURL != "https://example.com/feed" {
+				t.Errorf("feed URL = %q", e.FeedURL)
+			}
+		})
+	}
+}
+
+func TestParseSitemapIndex(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>/sitemap-pages.xml</loc><lastmod>2023-06-01</lastmod></sitemap>
+<sitemap><loc>https://example.com/sitemap-posts.xml</loc><lastmod>2024-03-01T09:30:00Z</lastmod></sitemap>
+</sitemapindex>`
+	f, err := Parse([]byte(doc), "https://example.com/sitemap.xml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if f.Kind != KindSitemapIndex || len(f.Sitemaps) != 2 || f.Sitemaps[1] != "https://example.com/sitemap-pages.xml" {
+		t.Errorf("feed = %+v", f)
+	}
+}
+
+func TestParseRejectsOtherDocuments(t *testing.T) {
+	for _, doc := range []string{"<html><body>hi</body></html>", `{"hello": "world"}`, "plain text"} {
+		if _, err := Parse([]byte(doc), "https://example.com/"); err == nil {
+			t.Errorf("Parse(%q): expected an error", doc)
+		}
+	}
+}
+
+func TestParseLegacyCharset(t *testing.T) {
+	// "Café" in ISO-8859-1
+	doc := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><rss><channel><title>Caf\xe9</title></channel></rss>"
+	f, err := Parse([]byte(doc), "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if f.Title != "Café" {
+		t.Errorf("title = %q, want Café", f.Title)
+	}
+}
+
+func TestSortNewestFirst(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	entries := []Entry{{URL: "undated"}, {URL: "old", Published: day(1)}, {URL: "updated", Updated: day(5)}, {URL: "new", Published: day(3)}}
+	SortNewestFirst(entries)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.URL)
+	}
+	if strings.Join(got, ",") != "updated,new,old,undated" {
+		t.Errorf("order = %v", got)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	page := `<html><head>
+<link rel="alternate" type="application/rss+xml" title="Posts" href="/feed.xml">
+<link rel="alternate" type="application/atom+xml" href="https://example.com/atom">
+<link rel="alternate" type="application/feed+json" href="/feed.json">
+<link rel="alternate" type="application/json" href="/wp-json/wp/v2/posts/1">
+<link rel="alternate" hreflang="de" href="/de/">
+<link rel="stylesheet" type="application/rss+xml" href="/not-a-feed">
+</head><body></body></html>`
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/blog/")
+	links := Discover(doc, base)
+	want := []Link{
+		{URL: "https://example.com/feed.xml", Kind: KindRSS, Title: "Posts"},
+		{URL: "https://example.com/atom", Kind: KindAtom},
+		{URL: "https://example.com/feed.json", Kind: KindJSON},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("links = %+v, want %+v", links, want)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("links[%d] = %+v, want %+v", i, links[i], want[i])
+		}
+	}
+}
+
+func TestRobotsSitemaps(t *testing.T) {
+	robots := `User-agent: *
+Disallow: /admin
+sitemap: https://example.com/sitemap.xml
+Sitemap: /news-sitemap.xml # relative, but seen in the wild
+Sitemap: https://example.com/sitemap.xml
+`
+	base, _ := url.Parse("https://example.com/robots.txt")
+	links := RobotsSitemaps([]byte(robots), base)
+	if len(links) != 2 || links[0].URL != "https://example.com/sitemap.xml" || links[1].URL != "https://example.com/news-sitemap.xml" || links[1].Kind != KindSitemap {
+		t.Errorf("links = %+v", links)
+	}
+}
@@ -0,0 +1,192 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/docutag/scraper/feed"
+	"github.com/docutag/scraper/models"
+)
+
+const (
+	// maxSitemapChildren is how many child sitemaps of a sitemap index FetchFeed reads,
+	// most recently modified first
+	maxSitemapChildren = 20
+
+	// maxSitemapBytes is the sitemap protocol's limit on a sitemap's uncompressed size
+	maxSitemapBytes = 50 * 1024 * 1024
+)
+
+// feedMediaTypes are the content types a feed or sitemap fetch accepts
+// Servers label feeds inconsistently, so generic XML, JSON and text are accepted too
+// and the content decides.
+var feedMediaTypes = map[string]bool{
+	"application/rss+xml":      true,
+	"application/rdf+xml":      true,
+	"application/atom+xml":     true,
+	"application/feed+json":    true,
+	"application/json":         true,
+	"application/xml":          true,
+	"text/xml":                 true,
+	"text/plain":               true,
+	"application/gzip":         true, // sitemap.xml.gz
+	"application/x-gzip":       true,
+	"application/octet-stream": true,
+}
+
+// DiscoverFeeds returns the feeds a page advertises with <link rel="alternate"> and the
+// sitemaps its site lists in robots.txt
+// A URL that is itself a feed or sitemap is returned as the only result. A missing or
+// unreadable robots.txt is not an error.
+func (s *Scraper) DiscoverFeeds(ctx context.Context, pageURL string) ([]feed.Link, error) {
+	parsedURL, err := s.checkFeedURL(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	accepted := make(map[string]bool, len(pageMediaTypes)+len(feedMediaTypes))
+	for t := range pageMediaTypes {
+		accepted[t] = true
+	}
+	for t := range feedMediaTypes {
+		accepted[t] = true
+	}
+	page, err := s.fetch(ctx, pageURL, accepted)
+	if err != nil {
+		return nil, err
+	}
+	if page.MediaType != "text/html" && page.MediaType != "application/xhtml+xml" {
+		if f, err := parseFeedBody(page, pageURL); err == nil {
+			return []feed.Link{{URL: pageURL, Kind: linkKind(f.Kind), Title: f.Title}}, nil
+		}
+		if !pageMediaTypes[page.MediaType] {
+			return nil, &UnsupportedContentTypeError{URL: pageURL, ContentType: page.MediaType}
+		}
+	}
+
+	doc, _, err := page.document()
+	if err != nil {
+		return nil, err
+	}
+	links := feed.Discover(doc, parsedURL)
+
+	robotsURL := &url.URL{Scheme: parsedURL.Scheme, Host: parsedURL.Host, Path: "/robots.txt"}
+	robots, err := s.fetch(ctx, robotsURL.String(), map[string]bool{"text/plain": true})
+	if err != nil {
+		slog.Debug("no robots.txt sitemaps", "url", robotsURL.String(), "error", err)
+		return links, nil
+	}
+	return append(links, feed.RobotsSitemaps(robots.Body, robotsURL)...), nil
+}
+
+// FetchFeed fetches and parses an RSS, Atom or JSON feed or a sitemap
+// For a sitemap index the entries of its most recent child sitemaps are returned, up to
+// maxSitemapChildren; children that fail are logged and skipped.
+func (s *Scraper) FetchFeed(ctx context.Context, feedURL string) (*feed.Feed, error) {
+	f, err := s.fetchFeed(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	if f.Kind != feed.KindSitemapIndex {
+		return f, nil
+	}
+
+	children := f.Sitemaps
+	if len(children) > maxSitemapChildren {
+		slog.Info("sitemap index has many children, reading the newest", "url", feedURL,
+			"children", len(children), "read", maxSitemapChildren)
+		children = children[:maxSitemapChildren]
+	}
+	for _, child := range children {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sitemap, err := s.fetchFeed(ctx, child)
+		if err != nil {
+			slog.Warn("failed to fetch child sitemap", "index", feedURL, "url", child, "error", err)
+			continue
+		}
+		// Nested indexes are not followed
+		f.Entries = append(f.Entries, sitemap.Entries...)
+	}
+	return f, nil
+}
+
+// fetchFeed fetches and parses a single feed or sitemap document
+func (s *Scraper) fetchFeed(ctx context.Context, feedURL string) (*feed.Feed, error) {
+	if _, err := s.checkFeedURL(feedURL); err != nil {
+		return nil, err
+	}
+	page, err := s.fetch(ctx, feedURL, feedMediaTypes)
+	if err != nil {
+		return nil, err
+	}
+	if page.Truncated {
+		slog.Warn("feed exceeds maximum size, reading what was fetched", "url", feedURL,
+			"max_bytes", s.config.MaxPageSizeBytes)
+	}
+	return parseFeedBody(page, feedURL)
+}
+
+// checkFeedURL validates a feed or page URL and enforces the domain policy
+func (s *Scraper) checkFeedURL(targetURL string) (*url.URL, error) {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("URL must be http or https")
+	}
+	if policy := s.DomainPolicyFor(targetURL); policyBlocks(policy) {
+		return nil, &DomainBlockedError{URL: targetURL, Domain: policy.Domain}
+	}
+	return parsedURL, nil
+}
+
+// parseFeedBody parses a fetched feed, unpacking gzipped sitemaps first
+func parseFeedBody(page *fetchedPage, feedURL string) (*feed.Feed, error) {
+	data := page.Body
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress feed: %w", err)
+		}
+		data, err = io.ReadAll(io.LimitReader(gz, maxSitemapBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress feed: %w", err)
+		}
+	}
+	return feed.Parse(data, feedURL)
+}
+
+// linkKind maps a feed kind to the kind reported by discovery, where a sitemap index is
+// just a sitemap
+func linkKind(kind string) string {
+	if kind == feed.KindSitemapIndex {
+		return feed.KindSitemap
+	}
+	return kind
+}
+
+// applyFeedEntry fills metadata the page itself did not provide from the feed entry it
+// was found through
+func applyFeedEntry(metadata *models.PageMetadata, entry *feed.Entry) {
+	if entry == nil {
+		return
+	}
+	metadata.FeedURL = entry.FeedURL
+	if metadata.Author == "" {
+		metadata.Author = entry.Author
+	}
+	if metadata.PublishedDate == "" {
+		if date := entry.Date(); !date.IsZero() {
+			metadata.PublishedDate = date.Format(time.RFC3339)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/feed"
+	"github.com/docutag/scraper/models"
+)
+
+func TestDiscoverFeeds(t *testing.T) {
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blog":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><link rel="alternate" type="application/atom+xml" href="/atom.xml"></head></html>`)
+		case "/robots.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, "User-agent: *\nSitemap: /sitemap.xml\n")
+		case "/atom.xml":
+			w.Header().Set("Content-Type", "application/atom+xml")
+			fmt.Fprint(w, `<feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title></feed>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer web.Close()
+	s := New(DefaultConfig(), nil, nil)
+
+	links, err := s.DiscoverFeeds(context.Background(), web.URL+"/blog")
+	if err != nil {
+		t.Fatalf("DiscoverFeeds failed: %v", err)
+	}
+	want := []feed.Link{{URL: web.URL + "/atom.xml", Kind: feed.KindAtom}, {URL: web.URL + "/sitemap.xml", Kind: feed.KindSitemap}}
+	if len(links) != len(want) || links[0] != want[0] || links[1] != want[1] {
+		t.Errorf("links = %+v, want %+v", links, want)
+	}
+
+	// A feed URL is its own result
+	links, err = s.DiscoverFeeds(context.Background(), web.URL+"/atom.xml")
+	if err != nil {
+		t.Fatalf("DiscoverFeeds failed: %v", err)
+	}
+	if len(links) != 1 || links[0].URL != web.URL+"/atom.xml" || links[0].Title != "Blog" {
+		t.Errorf("links = %+v", links)
+	}
+
+	s.SetDomainPolicies([]*models.DomainPolicy{{Domain: "127.0.0.1", Policy: models.DomainPolicyBlock}})
+	var blocked *DomainBlockedError
+	if _, err := s.DiscoverFeeds(context.Background(), web.URL+"/blog"); !errors.As(err, &blocked) {
+		t.Errorf("err = %v, want DomainBlockedError", err)
+	}
+}
+
+func TestFetchFeedSitemapIndex(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	fmt.Fprint(gz, `<urlset><url><loc>/posts/2</loc><lastmod>2024-03-02</lastmod></url></urlset>`)
+	gz.Close()
+
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprint(w, `<sitemapindex><sitemap><loc>/posts.xml</loc></sitemap><sitemap><loc>/missing.xml</loc></sitemap>
+<sitemap><loc>/more-posts.xml.gz</loc></sitemap></sitemapindex>`)
+		case "/posts.xml":
+			w.Header().Set("Content-Type", "text/xml")
+			fmt.Fprint(w, `<urlset><url><loc>/posts/1</loc></url></urlset>`)
+		case "/more-posts.xml.gz":
+			w.Header().Set("Content-Type", "application/x-gzip")
+			w.Write(gzipped.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer web.Close()
+
+	f, err := New(DefaultConfig(), nil, nil).FetchFeed(context.Background(), web.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatalf("FetchFeed failed: %v", err)
+	}
+	if f.Kind != feed.KindSitemapIndex || len(f.Entries) != 2 {
+		t.Fatalf("feed = %+v, want both children's entries", f)
+	}
+	if f.Entries[0].URL != web.URL+"/posts/1" || f.Entries[1].URL != web.URL+"/posts/2" {
+		t.Errorf("entries = %+v", f.Entries)
+	}
+	if f.Entries[1].FeedURL != web.URL+"/more-posts.xml.gz" {
+		t.Errorf("entry feed URL = %q, want the child sitemap", f.Entries[1].FeedURL)
+	}
+}
+
+func TestScrapeFeedEntryMetadata(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "[]", Done: true})
+	}))
+	defer llm.Close()
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Post</title><meta name="author" content="Page Author">
+<link rel="alternate" type="application/rss+xml" href="/feed.xml"></head><body><p>Hello</p></body></html>`)
+	}))
+	defer web.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = llm.URL
+	config.EnableImageAnalysis = false
+	entry := &feed.Entry{
+		URL:       web.URL,
+		Author:    "Feed Author",
+		Published: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		FeedURL:   web.URL + "/feed.xml",
+	}
+	data, err := New(config, nil, nil).ScrapeWithOptions(context.Background(), web.URL,
+		ScrapeOptions{CleanContent: Bool(false), FeedEntry: entry})
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	metadata := data.Metadata
+	if metadata.Author != "Page Author" {
+		t.Errorf("author = %q, want the page's own author kept", metadata.Author)
+	}
+	if metadata.PublishedDate != "2024-03-01T09:30:00Z" {
+		t.Errorf("published date = %q, want the feed entry's", metadata.PublishedDate)
+	}
+	if metadata.FeedURL != web.URL+"/feed.xml" {
+		t.Errorf("feed URL = %q", metadata.FeedURL)
+	}
+	if len(metadata.Feeds) != 1 || metadata.Feeds[0] != web.URL+"/feed.xml" {
+		t.Errorf("feeds = %v", metadata.Feeds)
+	}
+}
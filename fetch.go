@@ -0,0 +1,256 @@
+package scraper
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"time"
+
+	"github.com/docutag/scraper/models"
+	"golang.org/x/net/html"
+)
+
+const (
+	// maxCompressionRatio is the largest decompressed-to-compressed size ratio accepted
+	// for a page; real HTML rarely compresses better than 20:1 while gzip bombs reach 1000:1
+	maxCompressionRatio = 100
+
+	// compressionRatioFloor is how much a page may decompress to before its ratio is checked,
+	// so small, highly repetitive pages are never rejected
+	compressionRatioFloor = 1024 * 1024
+)
+
+// pageMediaTypes are the content types a page fetch accepts
+var pageMediaTypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+	"application/xml":       true,
+	"text/xml":              true,
+	"application/pdf":       true,
+}
+
+// UnsupportedContentTypeError is returned when a URL serves something other than a
+// document the scraper can read, such as a video or an archive
+type UnsupportedContentTypeError struct {
+	URL         string
+	ContentType string // Media type the server sent, or the sniffed type if it sent none
+}
+
+func (e *UnsupportedContentTypeError) Error() string {
+	return fmt.Sprintf("unsupported content type %q for %s", e.ContentType, e.URL)
+}
+
+// DecompressionBombError is returned when a compressed page expands far beyond what
+// real documents do
+type DecompressionBombError struct {
+	URL          string
+	Compressed   int64 // Compressed bytes read before the page was rejected
+	Decompressed int64 // Bytes they had expanded to
+}
+
+func (e *DecompressionBombError) Error() string {
+	return fmt.Sprintf("compressed page %s expands %d bytes to more than %d bytes (limit %d:1)",
+		e.URL, e.Compressed, e.Decompressed, maxCompressionRatio)
+}
+
+// fetchedPage is a page body read within the configured limits
+type fetchedPage struct {
+	Body        []byte
+	MediaType   string // Lowercase media type without parameters, e.g. "text/html"
+	ContentType string // Content-Type header as sent (or sniffed), including any charset
+	Header      http.Header
+	Truncated   bool // Body was cut off at Config.MaxPageSizeBytes
+	Info        *models.FetchInfo
+}
+
+// fetchPage GETs a page and reads its body within the configured limits
+// Bodies larger than Config.MaxPageSizeBytes are truncated rather than rejected; callers
+// decide how to report that.
+func (s *Scraper) fetchPage(ctx context.Context, targetURL string) (*fetchedPage, error) {
+	return s.fetch(ctx, targetURL, pageMediaTypes)
+}
+
+// fetch GETs a URL like fetchPage, accepting only the given media types
+func (s *Scraper) fetch(ctx context.Context, targetURL string, mediaTypes map[string]bool) (*fetchedPage, error) {
+	trace := newFetchTrace()
+	ctx = httptrace.WithClientTrace(ctx, trace.clientTrace())
+	ctx, retries := withRetryCounter(ctx)
+
+	profile := s.profileFor(targetURL)
+	resp, client, rotations, err := s.sendPageRequest(ctx, profile, targetURL)
+	if err != nil {
+		return nil, err
+	}
+	// An expired form login session is renewed once
+	if auth := s.siteAuthFor(targetURL); auth != nil && auth.expired(resp) {
+		resp.Body.Close()
+		auth.logout()
+		slog.Info("login session expired, logging in again", "url", targetURL, "domain", auth.credential.Domain)
+		resp, client, rotations, err = s.sendPageRequest(ctx, profile, targetURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	// Reject unsupported types before reading the body when the server declares one
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" {
+		if mediaType := parseMediaType(contentType); !mediaTypes[mediaType] {
+			return nil, &UnsupportedContentTypeError{URL: targetURL, ContentType: mediaType}
+		}
+	}
+
+	body, err := decompressBody(resp, targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := s.config.MaxPageSizeBytes
+	if limit > 0 {
+		body = io.LimitReader(body, limit+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page: %w", err)
+	}
+
+	info := newFetchInfo(resp)
+	info.Timings = trace.timings(time.Now())
+	info.Profile = profile.profile.Name
+	info.Proxy = profile.proxies[client]
+	info.ProxyRotations = rotations
+	info.Retries = int(retries.n.Load())
+
+	page := &fetchedPage{Body: data, ContentType: contentType, Header: resp.Header, Info: info}
+	if limit > 0 && int64(len(data)) > limit {
+		page.Body = data[:limit]
+		page.Truncated = true
+		slog.Warn("page exceeds maximum size, truncating", "url", targetURL, "max_bytes", limit)
+	}
+
+	if page.ContentType == "" {
+		// Only the sniffed type is kept; its default charset must not override <meta charset>
+		page.ContentType = parseMediaType(http.DetectContentType(page.Body))
+	}
+	page.MediaType = parseMediaType(page.ContentType)
+	if !mediaTypes[page.MediaType] {
+		return nil, &UnsupportedContentTypeError{URL: targetURL, ContentType: page.MediaType}
+	}
+	return page, nil
+}
+
+// sendPageRequest GETs a page with the site's credentials, trying the profile's current
+// proxy first and moving to the next one while the site refuses or throttles us
+// It returns the response, the index of the client that got it and how many proxies were
+// abandoned on the way.
+func (s *Scraper) sendPageRequest(ctx context.Context, profile *outboundProfile, targetURL string) (*http.Response, int, int, error) {
+	start := profile.pick()
+	var resp *http.Response
+	var client int
+	var rotations int
+	for attempt := 0; attempt < len(profile.clients); attempt++ {
+		client = (start + attempt) % len(profile.clients)
+		req, err := profile.newRequest(ctx, targetURL)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if err := s.authorize(req); err != nil {
+			return nil, 0, 0, err
+		}
+		// Asking for gzip explicitly stops the transport from decompressing transparently,
+		// so the compression ratio can be checked
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		resp, err = profile.clients[client].Do(req)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("failed to fetch URL: %w", err)
+		}
+		if !isRotationStatus(resp.StatusCode) || attempt == len(profile.clients)-1 {
+			break
+		}
+		resp.Body.Close()
+		profile.rotate(client)
+		rotations++
+		slog.Warn("proxy refused, rotating", "url", targetURL, "profile", profile.profile.Name,
+			"proxy", profile.proxies[client], "status", resp.StatusCode)
+	}
+	return resp, client, rotations, nil
+}
+
+// document parses the page as HTML, transcoding it to UTF-8 first
+// PDFs yield an empty document, since their text is not extracted. The returned string
+// is the page's character encoding, empty for PDFs.
+func (p *fetchedPage) document() (*html.Node, string, error) {
+	if p.MediaType == "application/pdf" {
+		doc, err := html.Parse(strings.NewReader("<html><body></body></html>"))
+		return doc, "", err
+	}
+	return parseHTML(p.Body, p.ContentType)
+}
+
+// parseMediaType returns the lowercase media type of a Content-Type header
+func parseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// decompressBody returns the decoded response body, guarding gzip against decompression bombs
+func decompressBody(resp *http.Response, targetURL string) (io.Reader, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return resp.Body, nil
+	case "gzip", "x-gzip":
+		compressed := &countingReader{r: resp.Body}
+		zr, err := gzip.NewReader(compressed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress page: %w", err)
+		}
+		return &ratioReader{r: zr, compressed: compressed, url: targetURL}, nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ratioReader fails with a DecompressionBombError once decompressed output outgrows
+// its compressed input by more than maxCompressionRatio
+type ratioReader struct {
+	r            io.Reader
+	compressed   *countingReader
+	decompressed int64
+	url          string
+}
+
+func (r *ratioReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.decompressed += int64(n)
+	if r.decompressed > compressionRatioFloor && r.decompressed > r.compressed.n*maxCompressionRatio {
+		return n, &DecompressionBombError{URL: r.url, Compressed: r.compressed.n, Decompressed: r.decompressed}
+	}
+	return n, err
+}
@@ -0,0 +1,166 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+)
+
+func gzipBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		t.Fatalf("gzip failed: %v", err)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestFetchPage(t *testing.T) {
+	page := []byte("<html><head><title>Hello</title></head><body><p>Hello world</p></body></html>")
+	bomb := gzipBytes(t, bytes.Repeat([]byte{' '}, 8*1024*1024))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(page)
+		case "/gzip":
+			if r.Header.Get("Accept-Encoding") != "gzip" {
+				t.Errorf("Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+			}
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(gzipBytes(t, page))
+		case "/bomb":
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(bomb)
+		case "/large":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write(bytes.Repeat([]byte("a"), 2048))
+		case "/video":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write([]byte("not really a video"))
+		case "/pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+		case "/undeclared":
+			// No Content-Type: the body is sniffed
+			w.Header()["Content-Type"] = nil
+			w.Write([]byte{0x50, 0x4b, 0x03, 0x04, 0x14, 0x00})
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.MaxPageSizeBytes = 1024
+	s := New(config, nil, nil)
+	ctx := context.Background()
+
+	t.Run("plain page", func(t *testing.T) {
+		p, err := s.fetchPage(ctx, server.URL+"/page")
+		if err != nil {
+			t.Fatalf("fetchPage failed: %v", err)
+		}
+		if !bytes.Equal(p.Body, page) || p.MediaType != "text/html" || p.Truncated {
+			t.Errorf("got body %q, type %q, truncated %v", p.Body, p.MediaType, p.Truncated)
+		}
+	})
+
+	t.Run("gzip page is decompressed", func(t *testing.T) {
+		p, err := s.fetchPage(ctx, server.URL+"/gzip")
+		if err != nil {
+			t.Fatalf("fetchPage failed: %v", err)
+		}
+		if !bytes.Equal(p.Body, page) {
+			t.Errorf("body = %q, want %q", p.Body, page)
+		}
+	})
+
+	t.Run("large page is truncated", func(t *testing.T) {
+		p, err := s.fetchPage(ctx, server.URL+"/large")
+		if err != nil {
+			t.Fatalf("fetchPage failed: %v", err)
+		}
+		if len(p.Body) != 1024 || !p.Truncated {
+			t.Errorf("body length = %d, truncated = %v; want 1024, true", len(p.Body), p.Truncated)
+		}
+	})
+
+	t.Run("decompression bomb is rejected", func(t *testing.T) {
+		unlimited := New(DefaultConfig(), nil, nil)
+		unlimited.config.MaxPageSizeBytes = 0
+		_, err := unlimited.fetchPage(ctx, server.URL+"/bomb")
+		var bombErr *DecompressionBombError
+		if !errors.As(err, &bombErr) {
+			t.Fatalf("error = %v, want DecompressionBombError", err)
+		}
+	})
+
+	t.Run("unsupported content type is rejected", func(t *testing.T) {
+		for _, path := range []string{"/video", "/undeclared"} {
+			_, err := s.fetchPage(ctx, server.URL+path)
+			var unsupported *UnsupportedContentTypeError
+			if !errors.As(err, &unsupported) {
+				t.Errorf("%s: error = %v, want UnsupportedContentTypeError", path, err)
+			}
+		}
+	})
+
+	t.Run("pdf is accepted", func(t *testing.T) {
+		p, err := s.fetchPage(ctx, server.URL+"/pdf")
+		if err != nil {
+			t.Fatalf("fetchPage failed: %v", err)
+		}
+		doc, charset, err := p.document()
+		if err != nil || doc == nil || charset != "" {
+			t.Errorf("document() = %v, %q, %v; want empty document", doc, charset, err)
+		}
+	})
+}
+
+func TestScrapeWarnsOnTruncatedPage(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "[]", Done: true})
+	}))
+	defer llm.Close()
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><title>Long page</title></head><body><p>" + strings.Repeat("word ", 1000) + "</p></body></html>"))
+	}))
+	defer web.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = llm.URL
+	config.EnableImageAnalysis = false
+	config.MaxPageSizeBytes = 512
+	data, err := New(config, nil, nil).ScrapeWithOptions(context.Background(), web.URL, ScrapeOptions{CleanContent: Bool(false)})
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Title != "Long page" {
+		t.Errorf("title = %q, want Long page", data.Title)
+	}
+	if data.Fetch == nil || data.Fetch.FinalURL != web.URL || data.Fetch.StatusCode != http.StatusOK {
+		t.Errorf("fetch info = %+v, want the final URL and status", data.Fetch)
+	}
+	found := false
+	for _, w := range data.Warnings {
+		if strings.Contains(w, "content truncated") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("warnings = %v, want a truncation warning", data.Warnings)
+	}
+}
@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+// recordedHeaders are the response headers kept in FetchInfo
+var recordedHeaders = []string{"Content-Type", "Last-Modified", "Server", "Cache-Control"}
+
+// fetchTrace collects httptrace timings for a fetch
+// Connection phases are reset whenever a new request starts, so after redirects they
+// describe the final request.
+type fetchTrace struct {
+	mu           sync.Mutex
+	start        time.Time // Start of the whole fetch
+	requestStart time.Time // Start of the latest request
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	firstByte    time.Time
+	dns          time.Duration
+	connect      time.Duration
+	tls          time.Duration
+}
+
+func newFetchTrace() *fetchTrace {
+	now := time.Now()
+	return &fetchTrace{start: now, requestStart: now}
+}
+
+// clientTrace returns the httptrace hooks feeding t
+func (t *fetchTrace) clientTrace() *httptrace.ClientTrace {
+	at := func(f func(now time.Time)) {
+		t.mu.Lock()
+		f(time.Now())
+		t.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			at(func(now time.Time) {
+				t.requestStart, t.firstByte = now, time.Time{}
+				t.dns, t.connect, t.tls = 0, 0, 0
+			})
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { at(func(now time.Time) { t.dnsStart = now }) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { at(func(now time.Time) { t.dns = now.Sub(t.dnsStart) }) },
+		ConnectStart: func(string, string) {
+			at(func(now time.Time) { t.connectStart = now })
+		},
+		ConnectDone: func(string, string, error) {
+			at(func(now time.Time) { t.connect = now.Sub(t.connectStart) })
+		},
+		TLSHandshakeStart: func() { at(func(now time.Time) { t.tlsStart = now }) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			at(func(now time.Time) { t.tls = now.Sub(t.tlsStart) })
+		},
+		GotFirstResponseByte: func() { at(func(now time.Time) { t.firstByte = now }) },
+	}
+}
+
+// timings returns the collected timings, with the body finished reading at end
+func (t *fetchTrace) timings(end time.Time) models.FetchTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Transports that skip the hooks, such as fixture replay, report the response as
+	// arriving once the round trip returned
+	firstByte := t.firstByte
+	if firstByte.IsZero() {
+		firstByte = end
+	}
+	return models.FetchTimings{
+		DNSMs:      milliseconds(t.dns),
+		ConnectMs:  milliseconds(t.connect),
+		TLSMs:      milliseconds(t.tls),
+		TTFBMs:     milliseconds(firstByte.Sub(t.requestStart)),
+		DownloadMs: milliseconds(end.Sub(firstByte)),
+		TotalMs:    milliseconds(end.Sub(t.start)),
+	}
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// newFetchInfo describes a response and the redirects that led to it
+func newFetchInfo(resp *http.Response) *models.FetchInfo {
+	info := &models.FetchInfo{
+		FinalURL:   resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Headers:    make(map[string]string),
+	}
+	for _, name := range recordedHeaders {
+		if v := resp.Header.Get(name); v != "" {
+			info.Headers[strings.ToLower(name)] = v
+		}
+	}
+
+	// Each followed request links back to the redirect response that caused it
+	for req := resp.Request; req.Response != nil; req = req.Response.Request {
+		hop := models.RedirectHop{
+			URL:        req.Response.Request.URL.String(),
+			StatusCode: req.Response.StatusCode,
+			Location:   req.URL.String(),
+		}
+		info.RedirectChain = append([]models.RedirectHop{hop}, info.RedirectChain...)
+	}
+
+	if cs := resp.TLS; cs != nil {
+		info.TLS = &models.TLSInfo{
+			Version:            tls.VersionName(cs.Version),
+			CipherSuite:        tls.CipherSuiteName(cs.CipherSuite),
+			ServerName:         cs.ServerName,
+			NegotiatedProtocol: cs.NegotiatedProtocol,
+		}
+		if len(cs.PeerCertificates) > 0 {
+			leaf := cs.PeerCertificates[0]
+			info.TLS.CertSubject = leaf.Subject.String()
+			info.TLS.CertIssuer = leaf.Issuer.String()
+			info.TLS.CertNotAfter = leaf.NotAfter
+		}
+	}
+	return info
+}
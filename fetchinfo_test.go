@@ -0,0 +1,89 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchPageRecordsRedirectsAndHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.Redirect(w, r, "/middle", http.StatusMovedPermanently)
+		case "/middle":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+			w.Header().Set("Server", "test-server")
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("X-Ignored", "yes")
+			w.Write([]byte("<html><head><title>Final</title></head></html>"))
+		}
+	}))
+	defer server.Close()
+
+	page, err := New(DefaultConfig(), nil, nil).fetchPage(context.Background(), server.URL+"/start")
+	if err != nil {
+		t.Fatalf("fetchPage failed: %v", err)
+	}
+	info := page.Info
+
+	if info.FinalURL != server.URL+"/page" || info.StatusCode != http.StatusOK {
+		t.Errorf("final = %s %d, want %s/page 200", info.FinalURL, info.StatusCode, server.URL)
+	}
+	if len(info.RedirectChain) != 2 {
+		t.Fatalf("redirect chain = %+v, want 2 hops", info.RedirectChain)
+	}
+	if hop := info.RedirectChain[0]; hop.URL != server.URL+"/start" || hop.StatusCode != http.StatusMovedPermanently || hop.Location != server.URL+"/middle" {
+		t.Errorf("first hop = %+v", hop)
+	}
+	if hop := info.RedirectChain[1]; hop.URL != server.URL+"/middle" || hop.StatusCode != http.StatusFound || hop.Location != server.URL+"/page" {
+		t.Errorf("second hop = %+v", hop)
+	}
+
+	want := map[string]string{
+		"content-type":  "text/html; charset=utf-8",
+		"last-modified": "Wed, 21 Oct 2015 07:28:00 GMT",
+		"server":        "test-server",
+		"cache-control": "max-age=60",
+	}
+	if len(info.Headers) != len(want) {
+		t.Errorf("headers = %v, want %v", info.Headers, want)
+	}
+	for k, v := range want {
+		if info.Headers[k] != v {
+			t.Errorf("header %s = %q, want %q", k, info.Headers[k], v)
+		}
+	}
+
+	if info.TLS != nil {
+		t.Errorf("TLS = %+v for a plain HTTP fetch", info.TLS)
+	}
+	if info.Timings.TotalMs <= 0 || info.Timings.TTFBMs <= 0 || info.Timings.TotalMs < info.Timings.TTFBMs {
+		t.Errorf("timings = %+v", info.Timings)
+	}
+}
+
+func TestNewFetchInfoTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+
+	info := newFetchInfo(resp)
+	if info.TLS == nil {
+		t.Fatal("TLS info missing for an HTTPS response")
+	}
+	if info.TLS.Version == "" || info.TLS.CipherSuite == "" || info.TLS.CertIssuer == "" || info.TLS.CertNotAfter.IsZero() {
+		t.Errorf("TLS info incomplete: %+v", info.TLS)
+	}
+}
@@ -0,0 +1,131 @@
+// Package fixture records the HTTP and Ollama exchanges made during a scrape into an
+// archive and replays them later, so a scrape of a real page can be turned into a
+// deterministic regression test that needs neither network access nor a running model.
+//
+// Record by setting scraper.Config.Recorder, then save the archive:
+//
+//	rec := fixture.NewRecorder()
+//	config.Recorder = rec
+//	data, err := scraper.New(config, nil, nil).Scrape(ctx, url)
+//	err = rec.Archive().Save("testdata/fixtures/page.json")
+//
+// Replay by loading the archive into scraper.Config.Replay:
+//
+//	archive, err := fixture.Load("testdata/fixtures/page.json")
+//	config.Replay = archive
+package fixture
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+// Version is the archive format version written by Save
+const Version = 1
+
+// Kind separates page fetches from Ollama exchanges in an archive
+type Kind string
+
+const (
+	KindHTTP Kind = "http" // Pages, images and other fetches made by the scraper
+	KindLLM  Kind = "llm"  // Requests to Ollama endpoints
+)
+
+// Entry is one recorded request and its response
+type Entry struct {
+	Kind          Kind        `json:"kind"`
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	RequestSHA256 string      `json:"request_sha256,omitempty"` // Hash of the request body, if any
+	Status        int         `json:"status,omitempty"`
+	Header        http.Header `json:"header,omitempty"`
+	Body          string      `json:"body,omitempty"`        // Response body when it is valid UTF-8
+	BodyBase64    string      `json:"body_base64,omitempty"` // Response body otherwise
+	Error         string      `json:"error,omitempty"`       // Transport error instead of a response
+}
+
+// body returns the decoded response body
+func (e *Entry) body() ([]byte, error) {
+	if e.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(e.BodyBase64)
+	}
+	return []byte(e.Body), nil
+}
+
+// setBody stores a response body in its most readable form
+func (e *Entry) setBody(data []byte) {
+	if utf8.Valid(data) {
+		e.Body = string(data)
+		e.BodyBase64 = ""
+		return
+	}
+	e.Body = ""
+	e.BodyBase64 = base64.StdEncoding.EncodeToString(data)
+}
+
+// Archive is an ordered list of recorded exchanges
+type Archive struct {
+	Version    int       `json:"version"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Entries    []Entry   `json:"entries"`
+}
+
+// Load reads an archive written by Save
+func Load(path string) (*Archive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture archive: %w", err)
+	}
+	var a Archive
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("invalid fixture archive %s: %w", path, err)
+	}
+	if a.Version != Version {
+		return nil, fmt.Errorf("unsupported fixture archive version %d in %s", a.Version, path)
+	}
+	return &a, nil
+}
+
+// Save writes the archive as indented JSON, creating parent directories as needed
+func (a *Archive) Save(path string) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture archive: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write fixture archive: %w", err)
+	}
+	return nil
+}
+
+// matchKey identifies equivalent requests
+// Ollama requests match on path and body only, so an archive replays against any endpoint
+func matchKey(kind Kind, method, rawURL, requestSHA string) string {
+	if kind == KindLLM {
+		if u, err := url.Parse(rawURL); err == nil {
+			rawURL = u.RequestURI()
+		}
+	}
+	return string(kind) + " " + method + " " + rawURL + " " + requestSHA
+}
+
+// hashBody returns the hex SHA-256 of a request body, or "" for an empty body
+func hashBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+package fixture
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, method, url, body string) (int, string, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	return resp.StatusCode, string(data), nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/binary":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 0x50, 0xff, 0xfe})
+		case "/api/generate":
+			body, _ := io.ReadAll(r.Body)
+			w.Write([]byte("answer to " + string(body)))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("page " + string(rune('0'+n))))
+		}
+	}))
+
+	rec := NewRecorder()
+	pages := &http.Client{Transport: rec.Wrap(KindHTTP, nil)}
+	llm := &http.Client{Transport: rec.Wrap(KindLLM, nil)}
+
+	get(t, pages, "GET", server.URL+"/page", "")
+	get(t, pages, "GET", server.URL+"/page", "")
+	get(t, pages, "GET", server.URL+"/binary", "")
+	get(t, llm, "POST", server.URL+"/api/generate", "one")
+	get(t, llm, "POST", server.URL+"/api/generate", "two")
+	server.Close()
+
+	path := filepath.Join(t.TempDir(), "archive.json")
+	if err := rec.Archive().Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	archive, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(archive.Entries) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(archive.Entries))
+	}
+	if archive.Entries[2].BodyBase64 == "" {
+		t.Error("expected binary body to be stored as base64")
+	}
+
+	replayer := archive.Replayer()
+	pages = &http.Client{Transport: replayer.Transport(KindHTTP)}
+	llm = &http.Client{Transport: replayer.Transport(KindLLM)}
+
+	// Repeated requests replay in recorded order, then repeat the last response
+	for _, want := range []string{"page 1", "page 2", "page 2"} {
+		status, body, err := get(t, pages, "GET", server.URL+"/page", "")
+		if err != nil || status != http.StatusAccepted || body != want {
+			t.Errorf("GET /page = %d %q %v, want 202 %q", status, body, err, want)
+		}
+	}
+	if _, body, _ := get(t, pages, "GET", server.URL+"/binary", ""); body != "\x89\x50\xff\xfe" {
+		t.Errorf("binary body = %q", body)
+	}
+
+	// Ollama exchanges match on body and ignore the endpoint host
+	if _, body, err := get(t, llm, "POST", "http://other-host:11434/api/generate", "two"); err != nil || body != "answer to two" {
+		t.Errorf("POST two = %q %v", body, err)
+	}
+
+	_, _, err = get(t, llm, "POST", server.URL+"/api/generate", "three")
+	var missing *MissingError
+	if !errors.As(err, &missing) || missing.Kind != KindLLM {
+		t.Errorf("expected MissingError for unrecorded request, got %v", err)
+	}
+	// Page fetches are not served from LLM entries
+	if _, _, err := get(t, pages, "POST", server.URL+"/api/generate", "one"); !errors.As(err, &missing) {
+		t.Errorf("expected MissingError across kinds, got %v", err)
+	}
+
+	if served := replayer.Served(); served != 5 {
+		t.Errorf("Served() = %d, want 5", served)
+	}
+}
+
+func TestReplayTransportError(t *testing.T) {
+	rec := NewRecorder()
+	client := &http.Client{Transport: rec.Wrap(KindHTTP, nil)}
+	if _, _, err := get(t, client, "GET", "http://127.0.0.1:1/unreachable", ""); err == nil {
+		t.Fatal("expected connection error")
+	}
+
+	client = &http.Client{Transport: rec.Archive().Replayer().Transport(KindHTTP)}
+	if _, _, err := get(t, client, "GET", "http://127.0.0.1:1/unreachable", ""); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Errorf("expected recorded connection error, got %v", err)
+	}
+}
+
+func TestLoadRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.json")
+	if err := (&Archive{Version: 99}).Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
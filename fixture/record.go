@@ -0,0 +1,96 @@
+package fixture
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Recorder captures exchanges made through the transports it wraps
+// It is safe for concurrent use, so one recorder can capture a scrape's parallel image
+// downloads and model calls.
+type Recorder struct {
+	mu         sync.Mutex
+	recordedAt time.Time
+	entries    []Entry
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{recordedAt: time.Now().UTC()}
+}
+
+// Wrap returns a transport that sends requests through base and records them as kind
+// base defaults to http.DefaultTransport
+func (r *Recorder) Wrap(kind Kind, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &recordingTransport{recorder: r, kind: kind, base: base}
+}
+
+// Archive returns a snapshot of everything recorded so far
+func (r *Recorder) Archive() *Archive {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+	return &Archive{Version: Version, RecordedAt: r.recordedAt, Entries: entries}
+}
+
+func (r *Recorder) add(e Entry) {
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	r.mu.Unlock()
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+	kind     Kind
+	base     http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	entry := Entry{
+		Kind:          t.kind,
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestSHA256: hashBody(reqBody),
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		// Cancellations belong to the caller, not to the site being recorded
+		if req.Context().Err() == nil {
+			entry.Error = err.Error()
+			t.recorder.add(entry)
+		}
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry.Status = resp.StatusCode
+	entry.Header = resp.Header.Clone()
+	entry.setBody(body)
+	t.recorder.add(entry)
+	return resp, nil
+}
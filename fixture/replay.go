@@ -0,0 +1,123 @@
+package fixture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// MissingError is returned when a replayed request has no recorded response
+type MissingError struct {
+	Kind   Kind
+	Method string
+	URL    string
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("fixture: no recorded %s response for %s %s", e.Kind, e.Method, e.URL)
+}
+
+// Replayer serves recorded responses instead of making requests
+//
+// Requests match on method, URL and a hash of the request body; Ollama requests ignore
+// the endpoint host. When the same request was recorded several times the responses are
+// served in recorded order, and the last one is repeated once they run out.
+type Replayer struct {
+	mu      sync.Mutex
+	entries map[string][]*Entry
+	next    map[string]int
+	served  int
+}
+
+// Replayer creates a replayer serving the archive's entries
+func (a *Archive) Replayer() *Replayer {
+	r := &Replayer{entries: make(map[string][]*Entry), next: make(map[string]int)}
+	for i := range a.Entries {
+		e := &a.Entries[i]
+		key := matchKey(e.Kind, e.Method, e.URL, e.RequestSHA256)
+		r.entries[key] = append(r.entries[key], e)
+	}
+	return r
+}
+
+// Transport returns a transport that answers kind requests from the archive
+func (r *Replayer) Transport(kind Kind) http.RoundTripper {
+	return &replayTransport{replayer: r, kind: kind}
+}
+
+// Served returns the number of requests answered from the archive
+func (r *Replayer) Served() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.served
+}
+
+// lookup returns the recorded entry for a request
+func (r *Replayer) lookup(key string) *Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.entries[key]
+	if len(entries) == 0 {
+		return nil
+	}
+	i := r.next[key]
+	if i < len(entries)-1 {
+		r.next[key] = i + 1
+	}
+	r.served++
+	return entries[i]
+}
+
+type replayTransport struct {
+	replayer *Replayer
+	kind     Kind
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entry := t.replayer.lookup(matchKey(t.kind, req.Method, req.URL.String(), hashBody(reqBody)))
+	if entry == nil {
+		return nil, &MissingError{Kind: t.kind, Method: req.Method, URL: req.URL.String()}
+	}
+	if entry.Error != "" {
+		return nil, errors.New(entry.Error)
+	}
+
+	body, err := entry.body()
+	if err != nil {
+		return nil, fmt.Errorf("fixture: invalid body for %s %s: %w", entry.Method, entry.URL, err)
+	}
+	header := entry.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(entry.Status) + " " + http.StatusText(entry.Status),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
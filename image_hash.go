@@ -0,0 +1,94 @@
+package scraper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+)
+
+// maxPerceptualDistance is the largest Hamming distance between two perceptual hashes
+// that still counts as the same picture
+// Resized, recompressed or re-encoded copies typically differ by only a few bits.
+const maxPerceptualDistance = 6
+
+// minPerceptualHashBits is how many bits of a perceptual hash must be set, and how many
+// clear, for the hash to be matched perceptually
+// Solid-color and near-uniform images (placeholders, plain backgrounds) hash to nearly all
+// zeros, so they would otherwise match each other and reuse an unrelated image's analysis.
+const minPerceptualHashBits = 10
+
+// dHashSamples caps how many pixels are averaged per cell when shrinking an image for
+// its perceptual hash
+const dHashSamples = 8
+
+// imageHashes returns the SHA-256 of an image's bytes and its perceptual hash, both as
+// hex strings
+// The perceptual hash is empty when the image cannot be decoded.
+func imageHashes(data []byte) (string, string) {
+	sum := sha256.Sum256(data)
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return hex.EncodeToString(sum[:]), ""
+	}
+	return hex.EncodeToString(sum[:]), fmt.Sprintf("%016x", differenceHash(img))
+}
+
+// distinctivePerceptualHash reports whether a hex perceptual hash has enough detail to
+// identify a picture; low-detail images are only matched by SHA-256
+func distinctivePerceptualHash(hash string) bool {
+	h, err := strconv.ParseUint(hash, 16, 64)
+	if err != nil {
+		return false
+	}
+	n := bits.OnesCount64(h)
+	return n >= minPerceptualHashBits && n <= 64-minPerceptualHashBits
+}
+
+// differenceHash computes a 64-bit dHash: the image is shrunk to 9x8 grey cells and each
+// bit records whether a cell is brighter than its right-hand neighbour
+func differenceHash(img image.Image) uint64 {
+	var cells [8][9]float64
+	bounds := img.Bounds()
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 9; x++ {
+			cells[y][x] = cellLuminance(img, bounds, x, y)
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if cells[y][x] > cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// cellLuminance averages the luminance of up to dHashSamples x dHashSamples evenly spaced
+// pixels in cell (x, y) of a 9x8 grid over bounds
+func cellLuminance(img image.Image, bounds image.Rectangle, x, y int) float64 {
+	x0 := bounds.Min.X + x*bounds.Dx()/9
+	x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/9, x0+1)
+	y0 := bounds.Min.Y + y*bounds.Dy()/8
+	y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/8, y0+1)
+	stepX := max((x1-x0)/dHashSamples, 1)
+	stepY := max((y1-y0)/dHashSamples, 1)
+
+	var sum float64
+	var n int
+	for py := y0; py < y1; py += stepY {
+		for px := x0; px < x1; px += stepX {
+			r, g, b, _ := img.At(px, py).RGBA()
+			sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			n++
+		}
+	}
+	return sum / float64(n)
+}
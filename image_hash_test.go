@@ -0,0 +1,193 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/bits"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/ollama"
+)
+
+// testPicture draws a w x h picture: waves over a diagonal gradient, or its mirror image
+// The waves give it a distinctive perceptual hash; a plain gradient hashes to all zeros.
+func testPicture(w, h int, mirrored bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			if mirrored {
+				fx = 1 - fx
+			}
+			wave := math.Sin(2*math.Pi*(3*fx+fy)) * math.Cos(2*math.Pi*2*fy)
+			v := uint8(127 + 60*wave + 60*(fx+fy)/2)
+			img.Set(x, y, color.RGBA{R: v, G: v / 2, B: 255 - v, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func hashDistance(t *testing.T, a, b string) int {
+	x, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bits.OnesCount64(x ^ y)
+}
+
+func TestImageHashes(t *testing.T) {
+	original := encodePNG(t, testPicture(640, 480, false))
+	var resized bytes.Buffer
+	if err := jpeg.Encode(&resized, testPicture(320, 240, false), &jpeg.Options{Quality: 60}); err != nil {
+		t.Fatal(err)
+	}
+	different := encodePNG(t, testPicture(640, 480, true))
+
+	sha, phash := imageHashes(original)
+	if len(sha) != 64 || len(phash) != 16 {
+		t.Fatalf("hashes = %q, %q", sha, phash)
+	}
+	if again, _ := imageHashes(original); again != sha {
+		t.Error("SHA-256 is not stable")
+	}
+
+	resizedSHA, resizedPHash := imageHashes(resized.Bytes())
+	if resizedSHA == sha {
+		t.Error("re-encoded copy has the same SHA-256")
+	}
+	if d := hashDistance(t, phash, resizedPHash); d > maxPerceptualDistance {
+		t.Errorf("resized copy distance = %d, want <= %d", d, maxPerceptualDistance)
+	}
+	if _, differentPHash := imageHashes(different); hashDistance(t, phash, differentPHash) <= maxPerceptualDistance {
+		t.Error("a different picture matched perceptually")
+	}
+
+	if _, phash := imageHashes([]byte("not an image")); phash != "" {
+		t.Errorf("perceptual hash of undecodable data = %q, want empty", phash)
+	}
+}
+
+// memoryImageDB is an in-memory DB holding the hashes of stored images
+type memoryImageDB struct {
+	images []models.ImageInfo
+}
+
+func (m *memoryImageDB) GetImageByURL(url string) (*models.ImageInfo, error) {
+	return nil, nil
+}
+
+func (m *memoryImageDB) FindImageByHash(sha256, perceptualHash string, maxDistance int) (*models.ExistingImageRef, error) {
+	for _, img := range m.images {
+		if img.SHA256 == sha256 {
+			return &models.ExistingImageRef{ImageID: img.ID, ImageURL: img.URL, Match: models.ImageMatchSHA256}, nil
+		}
+	}
+	if perceptualHash == "" {
+		return nil, nil
+	}
+	for _, img := range m.images {
+		a, _ := strconv.ParseUint(img.PerceptualHash, 16, 64)
+		b, _ := strconv.ParseUint(perceptualHash, 16, 64)
+		if d := bits.OnesCount64(a ^ b); d <= maxDistance {
+			return &models.ExistingImageRef{ImageID: img.ID, ImageURL: img.URL, Match: models.ImageMatchPerceptual, Distance: d}, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestProcessImageReusesPerceptualMatch(t *testing.T) {
+	original := encodePNG(t, testPicture(640, 480, false))
+	sha, phash := imageHashes(original)
+	store := &memoryImageDB{images: []models.ImageInfo{{ID: "img-1", URL: "https://cdn-a.example.com/photo.png", SHA256: sha, PerceptualHash: phash}}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		if r.URL.Path == "/same.png" {
+			w.Write(original)
+			return
+		}
+		w.Write(encodePNG(t, testPicture(320, 240, false)))
+	}))
+	defer server.Close()
+	s := New(DefaultConfig(), store, nil)
+
+	// A nil client would panic if the image were analyzed
+	for path, want := range map[string]string{"/same.png": models.ImageMatchSHA256, "/thumb.png?w=320": models.ImageMatchPerceptual} {
+		img, ref, failure := s.processSingleImage(context.Background(), models.ImageInfo{URL: server.URL + path}, nil, ScrapeOptions{})
+		if failure != "" || ref == nil {
+			t.Fatalf("%s: ref = %+v, failure = %q; want a match", path, ref, failure)
+		}
+		if ref.ImageID != "img-1" || ref.Match != want || ref.SourceURL != server.URL+path || img.ID != "" {
+			t.Errorf("%s: ref = %+v, want img-1 matched by %s", path, ref, want)
+		}
+	}
+}
+
+func TestSolidColorImagesAreNotMatchedPerceptually(t *testing.T) {
+	solid := func(c color.RGBA) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x++ {
+				img.Set(x, y, c)
+			}
+		}
+		return encodePNG(t, img)
+	}
+	red := solid(color.RGBA{R: 255, A: 255})
+	blue := solid(color.RGBA{R: 30, G: 60, B: 200, A: 255})
+
+	redSHA, redPHash := imageHashes(red)
+	_, bluePHash := imageHashes(blue)
+	if hashDistance(t, redPHash, bluePHash) > maxPerceptualDistance {
+		t.Fatalf("solid colors hash %s and %s; the test assumes they collide", redPHash, bluePHash)
+	}
+	if distinctivePerceptualHash(redPHash) || distinctivePerceptualHash(bluePHash) {
+		t.Error("solid-color hashes should not be distinctive")
+	}
+	if _, phash := imageHashes(encodePNG(t, testPicture(64, 64, false))); !distinctivePerceptualHash(phash) {
+		t.Errorf("hash %s of a detailed picture should be distinctive", phash)
+	}
+
+	// A blue placeholder must not reuse the stored red one's analysis
+	store := &memoryImageDB{images: []models.ImageInfo{{ID: "red", URL: "https://example.com/red.png", SHA256: redSHA, PerceptualHash: redPHash, Summary: "A red square"}}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		if r.URL.Path == "/red.png" {
+			w.Write(red)
+			return
+		}
+		w.Write(blue)
+	}))
+	defer server.Close()
+	s := New(DefaultConfig(), store, nil)
+
+	if _, ref, _ := s.processSingleImage(context.Background(), models.ImageInfo{URL: server.URL + "/red.png"}, nil, ScrapeOptions{}); ref == nil || ref.Match != models.ImageMatchSHA256 {
+		t.Errorf("byte-identical copy: ref = %+v, want a SHA-256 match", ref)
+	}
+	// The blue image goes on to be analyzed, which fails against the image server
+	img, ref, _ := s.processSingleImage(context.Background(), models.ImageInfo{URL: server.URL + "/blue.png"}, ollama.NewClient(server.URL, "test"), ScrapeOptions{})
+	if ref != nil || img.Summary == "A red square" {
+		t.Errorf("solid blue image matched %+v", ref)
+	}
+}
@@ -0,0 +1,50 @@
+package scraper
+
+import (
+	"cmp"
+
+	"github.com/docutag/scraper/imaging"
+	"github.com/docutag/scraper/models"
+)
+
+// ScrubImageMetadata applies the configured metadata policy to an image's bytes before
+// they are stored, and redacts img.EXIF to match what the stored bytes still carry
+// With RetainImageMetadata, the full EXIF moves to img.PrivateEXIF instead of being
+// dropped. img.EXIF is redacted even when the bytes cannot be scrubbed, since it is
+// saved with the image either way. Returns the bytes to store.
+func (s *Scraper) ScrubImageMetadata(img *models.ImageInfo, data []byte) ([]byte, error) {
+	policy := cmp.Or(s.config.ImageMetadataPolicy, imaging.MetadataKeep)
+	if s.config.RetainImageMetadata && policy != imaging.MetadataKeep && img.PrivateEXIF == nil {
+		img.PrivateEXIF = img.EXIF
+	}
+	img.EXIF = RedactEXIF(img.EXIF, policy)
+
+	scrubbed, err := imaging.StripMetadata(data, policy)
+	if err != nil {
+		return nil, err
+	}
+	img.MetadataPolicy = string(policy)
+	img.FileSizeBytes = int64(len(scrubbed))
+	return scrubbed, nil
+}
+
+// RedactEXIF returns the part of e that survives a metadata policy: everything but the
+// GPS coordinates for MetadataStripGPS, only the orientation for MetadataStripAll
+func RedactEXIF(e *models.EXIFData, policy imaging.MetadataPolicy) *models.EXIFData {
+	if e == nil {
+		return nil
+	}
+	switch policy {
+	case imaging.MetadataStripGPS:
+		redacted := *e
+		redacted.GPS = nil
+		return &redacted
+	case imaging.MetadataStripAll:
+		if e.Orientation <= 1 {
+			return nil
+		}
+		return &models.EXIFData{Orientation: e.Orientation}
+	default:
+		return e
+	}
+}
@@ -0,0 +1,101 @@
+package scraper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docutag/scraper/imaging"
+	"github.com/docutag/scraper/models"
+)
+
+func TestRedactEXIF(t *testing.T) {
+	full := &models.EXIFData{Make: "Canon", Orientation: 6, GPS: &models.GPSData{Latitude: 51.5, Longitude: -0.12}}
+
+	if got := RedactEXIF(full, imaging.MetadataKeep); got != full {
+		t.Errorf("keep = %+v, want the full EXIF", got)
+	}
+	if got := RedactEXIF(full, imaging.MetadataStripGPS); got.GPS != nil || got.Make != "Canon" || got.Orientation != 6 {
+		t.Errorf("strip-gps = %+v, want everything but GPS", got)
+	}
+	if full.GPS == nil {
+		t.Error("RedactEXIF modified its argument")
+	}
+	if got := RedactEXIF(full, imaging.MetadataStripAll); got == nil || *got != (models.EXIFData{Orientation: 6}) {
+		t.Errorf("strip-all = %+v, want only the orientation", got)
+	}
+	if got := RedactEXIF(&models.EXIFData{Make: "Canon", Orientation: 1}, imaging.MetadataStripAll); got != nil {
+		t.Errorf("strip-all of an upright image = %+v, want nil", got)
+	}
+	if got := RedactEXIF(nil, imaging.MetadataStripGPS); got != nil {
+		t.Errorf("RedactEXIF(nil) = %+v", got)
+	}
+}
+
+func TestScrubImageMetadata(t *testing.T) {
+	data := encodePNG(t, testPicture(16, 16, false))
+	gps := &models.GPSData{Latitude: 51.5, Longitude: -0.12}
+
+	tests := []struct {
+		name        string
+		policy      imaging.MetadataPolicy
+		retain      bool
+		wantGPS     bool
+		wantPrivate bool
+		wantPolicy  string
+	}{
+		{"keep", imaging.MetadataKeep, false, true, false, "keep"},
+		{"unset keeps everything", "", false, true, false, "keep"},
+		{"strip-gps", imaging.MetadataStripGPS, false, false, false, "strip-gps"},
+		{"strip-gps retained", imaging.MetadataStripGPS, true, false, true, "strip-gps"},
+		{"keep never retains", imaging.MetadataKeep, true, true, false, "keep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.ImageMetadataPolicy = tt.policy
+			cfg.RetainImageMetadata = tt.retain
+			s := New(cfg, nil, nil)
+
+			img := models.ImageInfo{EXIF: &models.EXIFData{Make: "Canon", GPS: gps}}
+			stored, err := s.ScrubImageMetadata(&img, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(stored, data) {
+				t.Error("an image without metadata changed")
+			}
+			if got := img.EXIF != nil && img.EXIF.GPS != nil; got != tt.wantGPS {
+				t.Errorf("GPS kept = %v, want %v", got, tt.wantGPS)
+			}
+			if got := img.PrivateEXIF != nil && img.PrivateEXIF.GPS != nil; got != tt.wantPrivate {
+				t.Errorf("GPS retained = %v, want %v", got, tt.wantPrivate)
+			}
+			if img.MetadataPolicy != tt.wantPolicy || img.FileSizeBytes != int64(len(data)) {
+				t.Errorf("policy = %q, size = %d", img.MetadataPolicy, img.FileSizeBytes)
+			}
+		})
+	}
+}
+
+func TestScrubImageMetadataFailureRedactsEXIF(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ImageMetadataPolicy = imaging.MetadataStripGPS
+	cfg.RetainImageMetadata = true
+	s := New(cfg, nil, nil)
+
+	// A JPEG whose segments run past the end of the file
+	truncated := []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x10, 0x00, 'E', 'x'}
+	img := models.ImageInfo{EXIF: &models.EXIFData{Make: "Canon", GPS: &models.GPSData{Latitude: 51.5, Longitude: -0.12}}}
+	if _, err := s.ScrubImageMetadata(&img, truncated); err == nil {
+		t.Fatal("expected a truncated JPEG to fail")
+	}
+	if img.EXIF == nil || img.EXIF.GPS != nil || img.EXIF.Make != "Canon" {
+		t.Errorf("EXIF = %+v, want GPS redacted although the file was not stored", img.EXIF)
+	}
+	if img.PrivateEXIF == nil || img.PrivateEXIF.GPS == nil {
+		t.Errorf("retained EXIF = %+v, want the full EXIF", img.PrivateEXIF)
+	}
+	if img.MetadataPolicy != "" {
+		t.Errorf("policy = %q, want none recorded for an unscrubbed file", img.MetadataPolicy)
+	}
+}
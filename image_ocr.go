@@ -0,0 +1,48 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/ocr"
+	"github.com/docutag/scraper/ollama"
+)
+
+// ExtractImageText finds the text in an image with the configured OCR engine, falling
+// back to the vision model if the engine fails and OCRVisionFallback is set
+// Text from an engine is kept as img.TextBlocks; ExtractedText and OCREngine are set
+// whichever extracted it. OCR failure is not critical and is only logged.
+func (s *Scraper) ExtractImageText(ctx context.Context, client *ollama.Client, img *models.ImageInfo, data []byte) {
+	if engine := s.config.OCREngine; engine != nil {
+		blocks, err := engine.Recognize(ctx, data)
+		if err == nil {
+			img.TextBlocks = ocr.Filter(blocks, s.config.OCRMinConfidence)
+			img.ExtractedText = ocr.Text(img.TextBlocks)
+			img.OCREngine = engine.Name()
+			slog.Info("extracted text from image", "url", img.URL, "engine", engine.Name(), "blocks", len(img.TextBlocks), "dropped_blocks", len(blocks)-len(img.TextBlocks))
+			return
+		}
+		if !s.config.OCRVisionFallback {
+			slog.Warn("failed to extract text from image", "url", img.URL, "engine", engine.Name(), "error", err)
+			return
+		}
+		slog.Warn("failed to extract text from image, falling back to the vision model", "url", img.URL, "engine", engine.Name(), "error", err)
+	}
+
+	// Extract text with the vision model (with semaphore protection)
+	if err := s.acquireOllamaSlot(ctx); err != nil {
+		return
+	}
+	extractedText, err := client.ExtractTextFromImage(ctx, data)
+	s.releaseOllamaSlot()
+	if err != nil {
+		slog.Warn("failed to extract text from image", "url", img.URL, "engine", ocr.EngineVision, "error", err)
+		return
+	}
+	img.OCREngine = ocr.EngineVision
+	if extractedText != "" {
+		img.ExtractedText = extractedText
+		slog.Info("extracted text from image", "url", img.URL, "engine", ocr.EngineVision, "text_length", len(extractedText))
+	}
+}
@@ -0,0 +1,104 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/ocr"
+	"github.com/docutag/scraper/ollama"
+)
+
+// fakeOCR is an ocr.Engine returning fixed blocks or an error
+type fakeOCR struct {
+	blocks []models.TextBlock
+	err    error
+}
+
+func (f *fakeOCR) Name() string { return "fake" }
+
+func (f *fakeOCR) Recognize(ctx context.Context, imageData []byte) ([]models.TextBlock, error) {
+	return f.blocks, f.err
+}
+
+func TestExtractImageText(t *testing.T) {
+	visionCalls := 0
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		visionCalls++
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "OPEN 24 HOURS", Done: true})
+	}))
+	defer llm.Close()
+	client := ollama.NewClient(llm.URL, "test-model")
+
+	blocks := []models.TextBlock{
+		{Text: "OPEN", Confidence: 0.97, BBox: models.BoundingBox{X: 5, Y: 5, Width: 80, Height: 30}},
+		{Text: "~~//", Confidence: 0.21},
+		{Text: "24 HOURS", Confidence: 0.9},
+	}
+
+	tests := []struct {
+		name       string
+		engine     ocr.Engine
+		fallback   bool
+		wantText   string
+		wantEngine string
+		wantBlocks int
+		wantVision int
+	}{
+		{"vision model", nil, true, "OPEN 24 HOURS", ocr.EngineVision, 0, 1},
+		{"engine", &fakeOCR{blocks: blocks}, true, "OPEN\n24 HOURS", "fake", 2, 0},
+		{"engine fails over", &fakeOCR{err: errors.New("tesseract crashed")}, true, "OPEN 24 HOURS", ocr.EngineVision, 0, 1},
+		{"engine fails without fallback", &fakeOCR{err: errors.New("tesseract crashed")}, false, "", "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visionCalls = 0
+			config := DefaultConfig()
+			config.OCREngine = tt.engine
+			config.OCRVisionFallback = tt.fallback
+			s := New(config, nil, nil)
+
+			var img models.ImageInfo
+			s.ExtractImageText(context.Background(), client, &img, []byte("image"))
+			if img.ExtractedText != tt.wantText || img.OCREngine != tt.wantEngine || len(img.TextBlocks) != tt.wantBlocks {
+				t.Errorf("text = %q, engine = %q, %d blocks; want %q, %q, %d", img.ExtractedText, img.OCREngine, len(img.TextBlocks), tt.wantText, tt.wantEngine, tt.wantBlocks)
+			}
+			if visionCalls != tt.wantVision {
+				t.Errorf("vision model called %d times, want %d", visionCalls, tt.wantVision)
+			}
+		})
+	}
+}
+
+func TestProcessSingleImageOCRWithoutAnalysis(t *testing.T) {
+	picture := encodePNG(t, testPicture(64, 64, false))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/photo.png" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(picture)
+			return
+		}
+		http.Error(w, "model unavailable", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.OCREngine = &fakeOCR{blocks: []models.TextBlock{{Text: "OPEN 24 HOURS", Confidence: 0.95}}}
+	s := New(config, nil, nil)
+
+	// The vision model fails, but the OCR engine still reads the image
+	img, _, warning := s.processSingleImage(context.Background(), models.ImageInfo{URL: server.URL + "/photo.png"}, ollama.NewClient(server.URL, "test"), ScrapeOptions{})
+	if warning != "analysis_failed" {
+		t.Errorf("warning = %q, want analysis_failed", warning)
+	}
+	if img.Summary != "" {
+		t.Errorf("Summary = %q, want none", img.Summary)
+	}
+	if img.ExtractedText != "OPEN 24 HOURS" || img.OCREngine != "fake" || len(img.TextBlocks) != 1 {
+		t.Errorf("text = %q, engine = %q, %d blocks; want the OCR engine's text", img.ExtractedText, img.OCREngine, len(img.TextBlocks))
+	}
+}
@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/ollama"
+)
+
+// DefaultImageSafetyThreshold is the confidence at which a safety category flags an image
+const DefaultImageSafetyThreshold = 0.8
+
+// SafetyAction is what happens to images the safety classifier flags
+type SafetyAction string
+
+// Safety actions; every action but SafetyOff classifies images and records the result
+const (
+	SafetyOff       SafetyAction = "off"       // Do not classify images
+	SafetyFlag      SafetyAction = "flag"      // Record the classification only
+	SafetyExclude   SafetyAction = "exclude"   // Leave flagged images out of tag search results
+	SafetyBlur      SafetyAction = "blur"      // Serve flagged images blurred
+	SafetyTombstone SafetyAction = "tombstone" // Tombstone flagged images when they are stored
+)
+
+// ParseSafetyAction parses a safety action name
+func ParseSafetyAction(s string) (SafetyAction, error) {
+	switch a := SafetyAction(s); a {
+	case SafetyOff, SafetyFlag, SafetyExclude, SafetyBlur, SafetyTombstone:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown image safety action %q (want %s, %s, %s, %s or %s)", s, SafetyOff, SafetyFlag, SafetyExclude, SafetyBlur, SafetyTombstone)
+	}
+}
+
+// Enabled reports whether images are classified; an empty action is SafetyOff
+func (a SafetyAction) Enabled() bool {
+	return a != "" && a != SafetyOff
+}
+
+// classifyImageSafety rates an image for unsafe content with the vision model, recording
+// the result in img.Safety and tombstoning it if it is flagged under SafetyTombstone
+// A failed classification is logged and leaves img.Safety nil.
+func (s *Scraper) classifyImageSafety(ctx context.Context, client *ollama.Client, img *models.ImageInfo, data []byte) {
+	action := s.config.ImageSafetyAction
+	if !action.Enabled() {
+		return
+	}
+	if err := s.acquireOllamaSlot(ctx); err != nil {
+		slog.Warn("context cancelled while waiting for ollama slot", "operation", "image_safety", "url", img.URL, "error", err)
+		return
+	}
+	categories, err := client.ClassifyImageSafety(ctx, data)
+	s.releaseOllamaSlot()
+	if err != nil {
+		slog.Warn("failed to classify image safety", "url", img.URL, "error", err)
+		return
+	}
+
+	threshold := s.config.ImageSafetyThreshold
+	if threshold <= 0 {
+		threshold = DefaultImageSafetyThreshold
+	}
+	img.Safety = &models.ImageSafety{Categories: categories}
+	var flagged []string
+	for category, confidence := range categories {
+		if confidence >= threshold {
+			flagged = append(flagged, category)
+		}
+	}
+	if len(flagged) == 0 {
+		return
+	}
+
+	img.Safety.Flagged = true
+	if action == SafetyTombstone {
+		// Scheduled for deletion in 90 days, as when an image is tombstoned through the API
+		tombstone := time.Now().UTC().Add(90 * 24 * time.Hour)
+		img.TombstoneDatetime = &tombstone
+	}
+	slog.Info("image flagged as unsafe", "url", img.URL, "categories", flagged, "action", action)
+}
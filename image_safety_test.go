@@ -0,0 +1,91 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/ollama"
+)
+
+func TestParseSafetyAction(t *testing.T) {
+	for _, name := range []string{"off", "flag", "exclude", "blur", "tombstone"} {
+		if a, err := ParseSafetyAction(name); err != nil || string(a) != name {
+			t.Errorf("ParseSafetyAction(%q) = %q, %v", name, a, err)
+		}
+	}
+	if _, err := ParseSafetyAction("delete"); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+	if SafetyAction("").Enabled() || SafetyOff.Enabled() || !SafetyFlag.Enabled() {
+		t.Error("only actions other than off should classify images")
+	}
+}
+
+func TestProcessImageSafety(t *testing.T) {
+	var classifications atomic.Int32
+	safety := `{"sexual": 0.95, "violence": 0.1}`
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaVisionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		response := `{"summary": "A beach", "tags": ["beach"]}`
+		if strings.HasPrefix(req.Prompt, "You are a content moderation assistant") {
+			classifications.Add(1)
+			response = safety
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: response, Done: true})
+	}))
+	defer llm.Close()
+	data := encodePNG(t, testPicture(16, 16, false))
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	}))
+	defer images.Close()
+	client := ollama.NewClient(llm.URL, "test-model")
+
+	process := func(action SafetyAction) models.ImageInfo {
+		t.Helper()
+		config := DefaultConfig()
+		config.ImageSafetyAction = action
+		img, _, failure := New(config, nil, nil).processSingleImage(context.Background(), models.ImageInfo{URL: images.URL + "/beach.png"}, client, ScrapeOptions{})
+		if failure != "" {
+			t.Fatalf("processSingleImage failed: %s", failure)
+		}
+		return img
+	}
+
+	if img := process(SafetyOff); img.Safety != nil || classifications.Load() != 0 {
+		t.Errorf("classified with safety off: %+v", img.Safety)
+	}
+
+	img := process(SafetyFlag)
+	if img.Safety == nil || !img.Safety.Flagged || img.Safety.Categories["sexual"] != 0.95 {
+		t.Errorf("safety = %+v, want flagged", img.Safety)
+	}
+	if img.TombstoneDatetime != nil {
+		t.Error("flag action tombstoned the image")
+	}
+	if img.Summary != "A beach" {
+		t.Errorf("summary = %q, analysis should still run", img.Summary)
+	}
+
+	if img := process(SafetyTombstone); img.TombstoneDatetime == nil {
+		t.Error("tombstone action did not tombstone the flagged image")
+	}
+
+	safety = `{"sexual": 0.3}`
+	if img := process(SafetyTombstone); img.Safety == nil || img.Safety.Flagged || img.TombstoneDatetime != nil {
+		t.Errorf("image below the threshold = %+v, tombstoned %v", img.Safety, img.TombstoneDatetime)
+	}
+
+	safety = "I cannot tell"
+	if img := process(SafetyTombstone); img.Safety != nil {
+		t.Errorf("failed classification = %+v, want nil", img.Safety)
+	}
+}
@@ -0,0 +1,315 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docutag/scraper/models"
+	"golang.org/x/net/html"
+)
+
+// maxCaptionLength caps a <figcaption> passed to image analysis
+const maxCaptionLength = 500
+
+// lazySrcAttrs hold the real image URL on lazy-loaded <img> tags, whose src is often a
+// placeholder
+var lazySrcAttrs = []string{"data-src", "data-lazy-src", "data-original", "data-lazy", "data-url"}
+
+// lazySrcsetAttrs hold a lazy-loaded srcset
+var lazySrcsetAttrs = []string{"data-srcset", "data-lazy-srcset"}
+
+// lazyBackgroundAttrs hold a lazy-loaded CSS background image URL
+var lazyBackgroundAttrs = []string{"data-bg", "data-background-image"}
+
+// decodableImageTypes are the <source type> values whose images can be decoded and
+// analyzed; sources in other formats, such as AVIF, are ignored
+var decodableImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// cssURLPattern matches url(...) in a background or background-image declaration
+var cssURLPattern = regexp.MustCompile(`(?i)background(?:-image)?\s*:[^;]*?url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+
+// ImageTooLargeError is returned when an image exceeds Config.MaxImageSizeBytes
+type ImageTooLargeError struct {
+	URL   string
+	Size  int64 // Declared Content-Length, 0 if the limit was hit while reading
+	Limit int64
+}
+
+func (e *ImageTooLargeError) Error() string {
+	if e.Size == 0 {
+		return fmt.Sprintf("image too large: exceeds %d bytes", e.Limit)
+	}
+	return fmt.Sprintf("image too large: %d bytes (max: %d)", e.Size, e.Limit)
+}
+
+// imageCandidate is one rendition of an image offered by src, srcset or <source>
+type imageCandidate struct {
+	URL     string
+	Width   int     // Intrinsic width from a "640w" descriptor, 0 if unknown
+	Density float64 // Pixel density from a "2x" descriptor, 0 if unknown
+}
+
+// resolution ranks a candidate by its width, or by its density scaled to the layout width
+// when that is all that is known
+func (c imageCandidate) resolution(layoutWidth int) float64 {
+	if c.Width > 0 {
+		return float64(c.Width)
+	}
+	density := c.Density
+	if density == 0 {
+		density = 1
+	}
+	if layoutWidth > 0 {
+		return density * float64(layoutWidth)
+	}
+	return density
+}
+
+// parseSrcset parses a srcset attribute into candidates
+// URLs may contain commas, so a candidate ends at whitespace followed by a descriptor
+// or at a comma after the URL.
+func parseSrcset(srcset string) []imageCandidate {
+	var candidates []imageCandidate
+	rest := strings.TrimSpace(srcset)
+	for rest != "" {
+		rest = strings.TrimLeft(rest, ", \t\r\n")
+		if rest == "" {
+			break
+		}
+		end := strings.IndexAny(rest, " \t\r\n")
+		if end < 0 {
+			end = len(rest)
+		}
+		rawURL := rest[:end]
+		rest = rest[end:]
+		var descriptor string
+		if strings.HasSuffix(rawURL, ",") {
+			rawURL = strings.TrimRight(rawURL, ",")
+		} else {
+			descriptor, rest, _ = strings.Cut(rest, ",")
+		}
+
+		c := imageCandidate{URL: rawURL}
+		for _, d := range strings.Fields(descriptor) {
+			switch {
+			case strings.HasSuffix(d, "w"):
+				if w, err := strconv.Atoi(strings.TrimSuffix(d, "w")); err == nil && w > 0 {
+					c.Width = w
+				}
+			case strings.HasSuffix(d, "x"):
+				if x, err := strconv.ParseFloat(strings.TrimSuffix(d, "x"), 64); err == nil && x > 0 {
+					c.Density = x
+				}
+			}
+		}
+		if c.URL != "" {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates
+}
+
+// imgCandidates returns every rendition an <img> offers, including the <source>s of an
+// enclosing <picture>
+func imgCandidates(img *html.Node) []imageCandidate {
+	var candidates []imageCandidate
+	if picture := img.Parent; picture != nil && picture.Type == html.ElementNode && picture.Data == "picture" {
+		for c := picture.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "source" {
+				continue
+			}
+			if typ := strings.ToLower(strings.TrimSpace(attrValue(c, "type"))); typ != "" && !decodableImageTypes[typ] {
+				continue
+			}
+			candidates = append(candidates, parseSrcset(firstAttr(c, append([]string{"srcset"}, lazySrcsetAttrs...)))...)
+		}
+	}
+	candidates = append(candidates, parseSrcset(attrValue(img, "srcset"))...)
+	for _, attr := range lazySrcsetAttrs {
+		candidates = append(candidates, parseSrcset(attrValue(img, attr))...)
+	}
+	for _, attr := range lazySrcAttrs {
+		if v := strings.TrimSpace(attrValue(img, attr)); v != "" {
+			candidates = append(candidates, imageCandidate{URL: v})
+		}
+	}
+	if src := strings.TrimSpace(attrValue(img, "src")); src != "" {
+		candidates = append(candidates, imageCandidate{URL: src})
+	}
+	return candidates
+}
+
+// rankCandidates resolves candidates against base and orders them by resolution, highest
+// first, dropping data: URIs, unresolvable URLs and duplicates
+// Candidates of equal resolution keep their order, so a lazy-load URL beats the src it
+// replaces.
+func rankCandidates(candidates []imageCandidate, base *url.URL, layoutWidth int) []imageCandidate {
+	ranked := make([]imageCandidate, 0, len(candidates))
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		resolved, err := resolveURL(base, c.URL)
+		if err != nil || !strings.HasPrefix(resolved, "http") || seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+		c.URL = resolved
+		ranked = append(ranked, c)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].resolution(layoutWidth) > ranked[j].resolution(layoutWidth)
+	})
+	return ranked
+}
+
+// newImageInfo builds an ImageInfo from ranked candidates: the best becomes the URL and
+// the rest are kept as smaller alternates
+// Known width descriptors replace the layout size hints, keeping the aspect ratio.
+func newImageInfo(ranked []imageCandidate, alt, caption string, width, height int) models.ImageInfo {
+	img := models.ImageInfo{
+		URL:     ranked[0].URL,
+		AltText: alt,
+		Caption: caption,
+		Width:   width,  // HTML attribute hint (0 if not specified)
+		Height:  height, // HTML attribute hint (0 if not specified)
+		Summary: "",
+		Tags:    []string{},
+	}
+	if w := ranked[0].Width; w > 0 {
+		if width > 0 && height > 0 {
+			img.Height = height * w / width
+		}
+		img.Width = w
+	}
+	for _, c := range ranked[1:] {
+		img.Alternates = append(img.Alternates, c.URL)
+	}
+	return img
+}
+
+// figureCaption returns the <figcaption> of the <figure> enclosing n, if any
+func figureCaption(n *html.Node) string {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type != html.ElementNode || p.Data != "figure" {
+			continue
+		}
+		for c := p.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.Data == "figcaption" {
+				caption := strings.Join(strings.Fields(nodeText(c)), " ")
+				if len(caption) > maxCaptionLength {
+					caption = strings.ToValidUTF8(caption[:maxCaptionLength], "")
+				}
+				return caption
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// nodeText returns the text inside n
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// backgroundImageURL returns the URL of an element's CSS background image, from its
+// inline style or a lazy-load attribute
+func backgroundImageURL(n *html.Node) string {
+	for _, attr := range lazyBackgroundAttrs {
+		if v := strings.TrimSpace(attrValue(n, attr)); v != "" {
+			return v
+		}
+	}
+	if m := cssURLPattern.FindStringSubmatch(attrValue(n, "style")); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	return ""
+}
+
+// openGraphImage returns the page's og:image (or twitter:image) as an image, if it has one
+func openGraphImage(doc *html.Node, base *url.URL) (models.ImageInfo, bool) {
+	var src, alt string
+	var width, height int
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "meta" {
+			key := strings.ToLower(firstAttr(n, []string{"property", "name"}))
+			content := strings.TrimSpace(attrValue(n, "content"))
+			switch key {
+			case "og:image", "og:image:secure_url", "og:image:url":
+				if src == "" || key == "og:image:secure_url" {
+					src = content
+				}
+			case "twitter:image", "twitter:image:src":
+				if src == "" {
+					src = content
+				}
+			case "og:image:alt":
+				alt = content
+			case "og:image:width":
+				width, _ = strconv.Atoi(content)
+			case "og:image:height":
+				height, _ = strconv.Atoi(content)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if src == "" {
+		return models.ImageInfo{}, false
+	}
+	ranked := rankCandidates([]imageCandidate{{URL: src}}, base, 0)
+	if len(ranked) == 0 {
+		return models.ImageInfo{}, false
+	}
+	return newImageInfo(ranked, alt, "", width, height), true
+}
+
+// firstAttr returns the first non-empty value among n's attributes keys
+func firstAttr(n *html.Node, keys []string) string {
+	for _, key := range keys {
+		if v := attrValue(n, key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// imageDownloadOrder returns the URLs to try for an image: the preferred one, then its
+// smaller alternates
+func imageDownloadOrder(img models.ImageInfo) []string {
+	return append([]string{img.URL}, img.Alternates...)
+}
+
+// firstNonEmpty returns the first of values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/ollama"
+	"golang.org/x/net/html"
+)
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset string
+		want   []imageCandidate
+	}{
+		{"a.jpg 320w, b.jpg 1024w", []imageCandidate{{URL: "a.jpg", Width: 320}, {URL: "b.jpg", Width: 1024}}},
+		{"a.jpg, b.jpg 2x", []imageCandidate{{URL: "a.jpg"}, {URL: "b.jpg", Density: 2}}},
+		{"/img/w_320,h_200/a.jpg 320w,/img/w_640,h_400/a.jpg 640w", []imageCandidate{
+			{URL: "/img/w_320,h_200/a.jpg", Width: 320}, {URL: "/img/w_640,h_400/a.jpg", Width: 640}}},
+		{"  a.jpg  1.5x ,\n b.jpg bogus", []imageCandidate{{URL: "a.jpg", Density: 1.5}, {URL: "b.jpg"}}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := parseSrcset(tt.srcset)
+		if len(got) != len(tt.want) {
+			t.Errorf("parseSrcset(%q) = %+v, want %+v", tt.srcset, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parseSrcset(%q)[%d] = %+v, want %+v", tt.srcset, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestExtractImagesResponsive(t *testing.T) {
+	page := `<html><head>
+<meta property="og:image" content="/social.jpg"><meta property="og:image:alt" content="Social card">
+</head><body>
+<picture>
+	<source type="image/avif" srcset="/hero.avif 2000w">
+	<source type="image/webp" srcset="/hero-800.webp 800w, /hero-1600.webp 1600w">
+	<img src="/hero-400.jpg" alt="Hero" width="400" height="200">
+</picture>
+<figure>
+	<img src="/placeholder.gif" data-src="/photo.jpg" alt="">
+	<figcaption>  The harbour
+	at dawn </figcaption>
+</figure>
+<img srcset="/chart.png 1x, /chart@2x.png 2x" src="/chart.png">
+<div class="banner" style="background-image: url('/banner.jpg')" aria-label="Banner"></div>
+<img src="/social.jpg" alt="Duplicate of og:image">
+<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+</body></html>`
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/article")
+
+	images := extractImages(doc, base)
+
+	want := []struct {
+		url, alt, caption string
+		width, height     int
+		alternates        []string
+	}{
+		{"https://example.com/hero-1600.webp", "Hero", "", 1600, 800,
+			[]string{"https://example.com/hero-800.webp", "https://example.com/hero-400.jpg"}},
+		{"https://example.com/photo.jpg", "", "The harbour at dawn", 0, 0,
+			[]string{"https://example.com/placeholder.gif"}},
+		{"https://example.com/chart@2x.png", "", "", 0, 0, []string{"https://example.com/chart.png"}},
+		{"https://example.com/banner.jpg", "Banner", "", 0, 0, nil},
+		{"https://example.com/social.jpg", "Duplicate of og:image", "", 0, 0, nil},
+	}
+	if len(images) != len(want) {
+		t.Fatalf("got %d images, want %d: %+v", len(images), len(want), images)
+	}
+	for i, w := range want {
+		img := images[i]
+		if img.URL != w.url || img.AltText != w.alt || img.Caption != w.caption || img.Width != w.width || img.Height != w.height {
+			t.Errorf("image %d = %+v, want %+v", i, img, w)
+		}
+		if strings.Join(img.Alternates, ",") != strings.Join(w.alternates, ",") {
+			t.Errorf("image %d alternates = %v, want %v", i, img.Alternates, w.alternates)
+		}
+	}
+}
+
+func TestExtractImagesOpenGraph(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><head>
+<meta property="og:image" content="https://cdn.example.com/card.jpg">
+<meta property="og:image:width" content="1200"><meta property="og:image:height" content="630">
+</head><body><img src="/inline.png"></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/")
+
+	images := extractImages(doc, base)
+	if len(images) != 2 {
+		t.Fatalf("got %d images, want 2: %+v", len(images), images)
+	}
+	if images[0].URL != "https://cdn.example.com/card.jpg" || images[0].Width != 1200 || images[0].Height != 630 {
+		t.Errorf("og:image = %+v, want it first with its declared size", images[0])
+	}
+}
+
+func TestProcessImageFallsBackToSmallerRendition(t *testing.T) {
+	var prompt string
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaVisionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.HasPrefix(req.Prompt, "Analyze this image") {
+			prompt = req.Prompt
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: `{"summary": "A harbour", "tags": ["harbour"]}`, Done: true})
+	}))
+	defer llm.Close()
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		if r.URL.Path == "/large.png" {
+			w.Write(make([]byte, 4096))
+			return
+		}
+		w.Write([]byte("small"))
+	}))
+	defer images.Close()
+
+	config := DefaultConfig()
+	config.MaxImageSizeBytes = 1024
+	s := New(config, nil, nil)
+	img := models.ImageInfo{
+		URL:        images.URL + "/large.png",
+		Caption:    "The harbour at dawn",
+		Alternates: []string{images.URL + "/small.png"},
+	}
+
+	got, _, failure := s.processSingleImage(context.Background(), img, ollama.NewClient(llm.URL, "test-model"), ScrapeOptions{})
+	if failure != "" {
+		t.Fatalf("processSingleImage failed: %s", failure)
+	}
+	if got.URL != images.URL+"/small.png" || got.FileSizeBytes != 5 {
+		t.Errorf("image = %+v, want the smaller rendition", got)
+	}
+	if !strings.Contains(prompt, "Image caption (may provide context): The harbour at dawn") {
+		t.Errorf("analysis prompt does not include the caption: %q", prompt)
+	}
+
+	// Nothing fits
+	_, _, failure = s.processSingleImage(context.Background(), models.ImageInfo{URL: images.URL + "/large.png"}, nil, ScrapeOptions{})
+	if failure != "download_failed" {
+		t.Errorf("failure = %q, want download_failed", failure)
+	}
+}
@@ -0,0 +1,200 @@
+// Package imaging produces resized, re-encoded derivatives of stored images and removes
+// their embedded metadata
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Register GIF format
+	"image/jpeg"
+	_ "image/png" // Register PNG format
+
+	"github.com/chai2010/webp"
+	"github.com/rwcarlsen/goexif/exif"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Register WebP format
+)
+
+// Derivative output formats
+const (
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+)
+
+// MaxPixels bounds the decoded size of a source image, guarding against decompression bombs
+const MaxPixels = 50_000_000
+
+// jpegQuality is the quality of JPEG derivatives
+const jpegQuality = 82
+
+// webpQuality is the quality of WebP derivatives, which are lossy
+const webpQuality = 80
+
+// UnsupportedFormatError is returned for an output format other than FormatJPEG or FormatWebP
+type UnsupportedFormatError struct {
+	Format string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	return fmt.Sprintf("unsupported image format: %q", e.Format)
+}
+
+// Derivative is an encoded derivative image
+type Derivative struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// ContentType returns the MIME type of an output format
+func ContentType(format string) string {
+	switch format {
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatWebP:
+		return "image/webp"
+	default:
+		return ""
+	}
+}
+
+// Derive decodes an image, applies its EXIF orientation, scales it down to width and
+// encodes it in format
+// Images are never scaled up, and a width of 0 keeps the original width. Re-encoding
+// drops all metadata, including EXIF GPS coordinates.
+func Derive(data []byte, width int, format string) (*Derivative, error) {
+	return derive(data, width, format, false)
+}
+
+// Blur is Derive with the image blurred beyond recognition, for serving images flagged
+// as unsafe
+func Blur(data []byte, width int, format string) (*Derivative, error) {
+	return derive(data, width, format, true)
+}
+
+func derive(data []byte, width int, format string, blur bool) (*Derivative, error) {
+	contentType := ContentType(format)
+	if contentType == "" {
+		return nil, &UnsupportedFormatError{Format: format}
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("image too large to resize: %dx%d", cfg.Width, cfg.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	img := Orient(src, orientation(data))
+	if b := img.Bounds(); width > 0 && width < b.Dx() {
+		height := max(b.Dy()*width/b.Dx(), 1)
+		scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Rect, img, b, draw.Src, nil)
+		img = scaled
+	}
+	if blur {
+		img = blurred(img)
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: jpegQuality})
+	case FormatWebP:
+		err = webp.Encode(&buf, img, &webp.Options{Quality: webpQuality})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+
+	b := img.Bounds()
+	return &Derivative{Data: buf.Bytes(), ContentType: contentType, Width: b.Dx(), Height: b.Dy()}, nil
+}
+
+// orientation returns the EXIF orientation of an image, 1 (upright) if it has none
+func orientation(data []byte) int {
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 1
+	}
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		return 1
+	}
+	o, err := tag.Int(0)
+	if err != nil || o < 1 || o > 8 {
+		return 1
+	}
+	return o
+}
+
+// Orient rotates and flips img so that an image with EXIF orientation o displays upright
+func Orient(img image.Image, o int) *image.NRGBA {
+	b := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Rect, img, b.Min, draw.Src)
+	if o <= 1 || o > 8 {
+		return src
+	}
+
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w // Orientations 5-8 swap the axes
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch o {
+			case 2: // Mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // Rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // Transposed
+				sx, sy = y, x
+			case 6: // Rotated 90° clockwise to display
+				sx, sy = y, h-1-x
+			case 7: // Transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // Rotated 90° counter-clockwise to display
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// blurDivisor is how many times smaller than the image its blurred copy is sampled at
+const blurDivisor = 32
+
+// blurred scales img down to a few dozen pixels and back up, leaving only soft patches
+// of color
+func blurred(img *image.NRGBA) *image.NRGBA {
+	b := img.Bounds()
+	small := image.NewNRGBA(image.Rect(0, 0, max(b.Dx()/blurDivisor, 1), max(b.Dy()/blurDivisor, 1)))
+	draw.CatmullRom.Scale(small, small.Rect, img, b, draw.Src, nil)
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.BiLinear.Scale(dst, dst.Rect, small, small.Rect, draw.Src, nil)
+	return dst
+}
+
+// flatten composites img onto white, since JPEG has no transparency
+func flatten(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, b, img, b.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,166 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// gradient draws a w x h image with distinct, smoothly varying colors
+func gradient(w, h int, alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := uint8(255)
+			if alpha {
+				a = uint8(x * 255 / w)
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 255 / w), G: uint8(y * 255 / h), B: uint8((x + y) % 256), A: a})
+		}
+	}
+	return img
+}
+
+func TestDeriveWebPIsLossy(t *testing.T) {
+	var original bytes.Buffer
+	if err := png.Encode(&original, gradient(640, 480, false)); err != nil {
+		t.Fatal(err)
+	}
+	jpg, err := Derive(original.Bytes(), 0, FormatJPEG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wp, err := Derive(original.Bytes(), 0, FormatWebP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(wp.Data[:16], []byte("VP8 ")) {
+		t.Errorf("WebP derivative is not lossy (VP8): % x", wp.Data[:16])
+	}
+	if len(wp.Data) > len(jpg.Data) {
+		t.Errorf("WebP derivative is %d bytes, larger than the %d byte JPEG", len(wp.Data), len(jpg.Data))
+	}
+}
+
+func TestDerive(t *testing.T) {
+	var original bytes.Buffer
+	if err := png.Encode(&original, gradient(400, 200, false)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		width         int
+		format        string
+		wantWidth     int
+		wantHeight    int
+		wantType      string
+		decodeDerived func([]byte) (image.Image, error)
+	}{
+		{"thumbnail webp", 100, FormatWebP, 100, 50, "image/webp", func(b []byte) (image.Image, error) { return webp.Decode(bytes.NewReader(b)) }},
+		{"thumbnail jpeg", 100, FormatJPEG, 100, 50, "image/jpeg", func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }},
+		{"never upscaled", 1000, FormatJPEG, 400, 200, "image/jpeg", func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }},
+		{"original width", 0, FormatWebP, 400, 200, "image/webp", func(b []byte) (image.Image, error) { return webp.Decode(bytes.NewReader(b)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Derive(original.Bytes(), tt.width, tt.format)
+			if err != nil {
+				t.Fatalf("Derive failed: %v", err)
+			}
+			if d.Width != tt.wantWidth || d.Height != tt.wantHeight || d.ContentType != tt.wantType {
+				t.Errorf("derivative = %dx%d %s, want %dx%d %s", d.Width, d.Height, d.ContentType, tt.wantWidth, tt.wantHeight, tt.wantType)
+			}
+			img, err := tt.decodeDerived(d.Data)
+			if err != nil {
+				t.Fatalf("derivative does not decode: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.wantWidth || b.Dy() != tt.wantHeight {
+				t.Errorf("decoded size = %v", b)
+			}
+		})
+	}
+
+	if _, err := Derive(original.Bytes(), 100, "avif"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+	if _, err := Derive([]byte("not an image"), 100, FormatJPEG); err == nil {
+		t.Error("expected an error for undecodable data")
+	}
+}
+
+func TestBlur(t *testing.T) {
+	// A checkerboard of 8px squares, which a blur reduces to flat gray
+	board := image.NewNRGBA(image.Rect(0, 0, 256, 128))
+	for y := 0; y < 128; y++ {
+		for x := 0; x < 256; x++ {
+			if (x/8+y/8)%2 == 0 {
+				board.SetNRGBA(x, y, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				board.SetNRGBA(x, y, color.NRGBA{A: 255})
+			}
+		}
+	}
+	var original bytes.Buffer
+	if err := png.Encode(&original, board); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Blur(original.Bytes(), 128, FormatWebP)
+	if err != nil {
+		t.Fatalf("Blur failed: %v", err)
+	}
+	if d.Width != 128 || d.Height != 64 || d.ContentType != "image/webp" {
+		t.Errorf("blurred = %dx%d %s, want 128x64 image/webp", d.Width, d.Height, d.ContentType)
+	}
+	img, err := webp.Decode(bytes.NewReader(d.Data))
+	if err != nil {
+		t.Fatalf("blurred image does not decode: %v", err)
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r>>8 < 64 || r>>8 > 192 {
+				t.Fatalf("pixel (%d, %d) = %d, want the checkerboard blurred to gray", x, y, r>>8)
+			}
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image whose top-left pixel is marked
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+
+	tests := []struct {
+		orientation int
+		wantW       int
+		wantH       int
+		markX       int
+		markY       int
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+	for _, tt := range tests {
+		got := Orient(img, tt.orientation)
+		if b := got.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+			t.Errorf("orientation %d: size = %v, want %dx%d", tt.orientation, b, tt.wantW, tt.wantH)
+			continue
+		}
+		if got.NRGBAAt(tt.markX, tt.markY).R != 255 {
+			t.Errorf("orientation %d: marked pixel not at (%d, %d)", tt.orientation, tt.markX, tt.markY)
+		}
+	}
+}
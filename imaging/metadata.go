@@ -0,0 +1,437 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// MetadataPolicy is how much embedded metadata is removed from stored images
+type MetadataPolicy string
+
+// Metadata policies, from least to most strict
+const (
+	MetadataKeep     MetadataPolicy = "keep"      // Store images byte for byte
+	MetadataStripGPS MetadataPolicy = "strip-gps" // Remove EXIF GPS coordinates and XMP packets
+	MetadataStripAll MetadataPolicy = "strip-all" // Remove EXIF, XMP, IPTC and comments, keeping only the orientation
+)
+
+// ErrMalformedImage is returned when an image's container cannot be parsed to remove its
+// metadata
+var ErrMalformedImage = errors.New("malformed image container")
+
+// ParseMetadataPolicy parses a metadata policy name
+func ParseMetadataPolicy(s string) (MetadataPolicy, error) {
+	switch p := MetadataPolicy(s); p {
+	case MetadataKeep, MetadataStripGPS, MetadataStripAll:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown metadata policy %q (want %s, %s or %s)", s, MetadataKeep, MetadataStripGPS, MetadataStripAll)
+	}
+}
+
+// Covers reports whether an image stored under p already satisfies policy q
+// An empty or unknown policy is treated as MetadataKeep.
+func (p MetadataPolicy) Covers(q MetadataPolicy) bool {
+	return p.strictness() >= q.strictness()
+}
+
+// strictness orders policies from MetadataKeep (0) to MetadataStripAll (2)
+func (p MetadataPolicy) strictness() int {
+	switch p {
+	case MetadataStripGPS:
+		return 1
+	case MetadataStripAll:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// StripMetadata removes the metadata policy forbids from a JPEG, PNG or WebP image
+// Pixel data, ICC color profiles and the EXIF orientation are kept, so the image looks
+// the same. Other formats are returned unchanged.
+func StripMetadata(data []byte, policy MetadataPolicy) ([]byte, error) {
+	if policy.strictness() == 0 {
+		return data, nil
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return stripJPEG(data, policy)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNG(data, policy)
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return stripWebP(data, policy)
+	default:
+		return data, nil
+	}
+}
+
+// JPEG
+
+var (
+	exifHeader       = []byte("Exif\x00\x00")
+	xmpHeader        = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	xmpExtHeader     = []byte("http://ns.adobe.com/xmp/extension/\x00")
+	iccProfileHeader = []byte("ICC_PROFILE\x00")
+)
+
+// stripJPEG rewrites a JPEG's marker segments, copying the entropy-coded data verbatim
+func stripJPEG(data []byte, policy MetadataPolicy) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	orient := 1
+	pendingOrientation := false
+
+	for pos := 2; ; {
+		if pos >= len(data) || data[pos] != 0xFF {
+			return nil, fmt.Errorf("%w: expected a JPEG marker at offset %d", ErrMalformedImage, pos)
+		}
+		for pos < len(data) && data[pos] == 0xFF {
+			pos++ // Markers may be preceded by fill bytes
+		}
+		if pos >= len(data) {
+			return nil, fmt.Errorf("%w: truncated JPEG marker", ErrMalformedImage)
+		}
+		marker := data[pos]
+		pos++
+
+		// Standalone markers carry no length
+		if marker == 0xD9 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 {
+			out = append(out, 0xFF, marker)
+			if marker == 0xD9 {
+				return out, nil
+			}
+			continue
+		}
+
+		if pos+2 > len(data) {
+			return nil, fmt.Errorf("%w: truncated JPEG segment", ErrMalformedImage)
+		}
+		length := int(binary.BigEndian.Uint16(data[pos:]))
+		if length < 2 || pos+length > len(data) {
+			return nil, fmt.Errorf("%w: JPEG segment overruns the file", ErrMalformedImage)
+		}
+		payload := data[pos+2 : pos+length]
+		segment := data[pos-2 : pos+length]
+		pos += length
+
+		// A replacement orientation goes after the JFIF header, which must come first
+		if pendingOrientation && marker != 0xE0 {
+			out = appendJPEGSegment(out, 0xE1, append(append([]byte{}, exifHeader...), orientationTIFF(orient)...))
+			pendingOrientation = false
+		}
+
+		if marker == 0xDA {
+			// Start of scan: the rest of the file is image data
+			out = append(out, segment...)
+			return append(out, data[pos:]...), nil
+		}
+
+		keep, replaced, err := filterJPEGSegment(marker, payload, policy)
+		if err != nil {
+			return nil, err
+		}
+		if marker == 0xE1 && bytes.HasPrefix(payload, exifHeader) && policy == MetadataStripAll {
+			if o := tiffOrientation(payload[len(exifHeader):]); o > 1 {
+				orient, pendingOrientation = o, true
+			}
+		}
+		switch {
+		case replaced != nil:
+			out = appendJPEGSegment(out, marker, replaced)
+		case keep:
+			out = append(out, segment...)
+		}
+	}
+}
+
+// filterJPEGSegment decides what happens to one JPEG marker segment
+// It returns whether to keep the segment as is, or a replacement payload.
+func filterJPEGSegment(marker byte, payload []byte, policy MetadataPolicy) (keep bool, replaced []byte, err error) {
+	switch {
+	case marker == 0xE1 && bytes.HasPrefix(payload, exifHeader):
+		if policy == MetadataStripAll {
+			return false, nil, nil
+		}
+		scrubbed := append([]byte{}, payload...)
+		if err := scrubGPS(scrubbed[len(exifHeader):]); err != nil {
+			return false, nil, err
+		}
+		return false, scrubbed, nil
+	case marker == 0xE1 && (bytes.HasPrefix(payload, xmpHeader) || bytes.HasPrefix(payload, xmpExtHeader)):
+		return false, nil, nil // XMP packets can repeat the GPS coordinates
+	case policy != MetadataStripAll:
+		return true, nil, nil
+	case marker == 0xE0 || marker == 0xEE:
+		return true, nil, nil // JFIF and Adobe headers affect how colors decode
+	case marker == 0xE2:
+		return bytes.HasPrefix(payload, iccProfileHeader), nil, nil
+	case marker >= 0xE1 && marker <= 0xEF, marker == 0xFE:
+		return false, nil, nil // Other application segments and comments
+	default:
+		return true, nil, nil
+	}
+}
+
+// appendJPEGSegment appends a marker segment with the given payload
+func appendJPEGSegment(out []byte, marker byte, payload []byte) []byte {
+	out = append(out, 0xFF, marker)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+	return append(out, payload...)
+}
+
+// PNG
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// stripPNG drops or rewrites a PNG's metadata chunks
+func stripPNG(data []byte, policy MetadataPolicy) ([]byte, error) {
+	out := append(make([]byte, 0, len(data)), pngSignature...)
+	for pos := len(pngSignature); pos < len(data); {
+		if pos+8 > len(data) {
+			return nil, fmt.Errorf("%w: truncated PNG chunk", ErrMalformedImage)
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, fmt.Errorf("%w: PNG chunk overruns the file", ErrMalformedImage)
+		}
+		body := data[pos+8 : pos+8+length]
+		chunk := data[pos:end]
+		pos = end
+
+		switch {
+		case kind == "eXIf" && policy == MetadataStripAll:
+			if o := tiffOrientation(body); o > 1 {
+				out = appendPNGChunk(out, kind, orientationTIFF(o))
+			}
+		case kind == "eXIf":
+			scrubbed := append([]byte{}, body...)
+			if err := scrubGPS(scrubbed); err != nil {
+				return nil, err
+			}
+			out = appendPNGChunk(out, kind, scrubbed)
+		case (kind == "tEXt" || kind == "zTXt" || kind == "iTXt") && (policy == MetadataStripAll || bytes.HasPrefix(body, []byte("XML:com.adobe.xmp\x00"))):
+			// Dropped: text chunks, or just XMP for MetadataStripGPS
+		case kind == "tIME" && policy == MetadataStripAll:
+			// Dropped
+		default:
+			out = append(out, chunk...)
+		}
+		if kind == "IEND" {
+			break
+		}
+	}
+	return out, nil
+}
+
+// appendPNGChunk appends a chunk with its CRC
+func appendPNGChunk(out []byte, kind string, body []byte) []byte {
+	out = binary.BigEndian.AppendUint32(out, uint32(len(body)))
+	start := len(out)
+	out = append(out, kind...)
+	out = append(out, body...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[start:]))
+}
+
+// WebP
+
+// VP8X header flags for metadata chunks
+const (
+	vp8xEXIF = 0x08
+	vp8xXMP  = 0x04
+)
+
+// stripWebP drops or rewrites a WebP's EXIF and XMP chunks
+func stripWebP(data []byte, policy MetadataPolicy) ([]byte, error) {
+	out := append(make([]byte, 0, len(data)), data[:12]...)
+	vp8x := -1 // Offset of the VP8X flags byte in out
+	hasEXIF := false
+
+	for pos := 12; pos < len(data); {
+		if pos+8 > len(data) {
+			return nil, fmt.Errorf("%w: truncated WebP chunk", ErrMalformedImage)
+		}
+		kind := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size%2
+		if pos+8+size > len(data) {
+			return nil, fmt.Errorf("%w: WebP chunk overruns the file", ErrMalformedImage)
+		}
+		end = min(end, len(data))
+		body := data[pos+8 : pos+8+size]
+		chunk := data[pos:end]
+		pos = end
+
+		switch kind {
+		case "VP8X":
+			vp8x = len(out) + 8
+			out = append(out, chunk...)
+		case "EXIF":
+			if policy == MetadataStripAll {
+				continue
+			}
+			scrubbed := append([]byte{}, body...)
+			tiff := bytes.TrimPrefix(scrubbed, exifHeader) // Some writers keep the JPEG prefix
+			if err := scrubGPS(tiff); err != nil {
+				return nil, err
+			}
+			out = appendWebPChunk(out, kind, scrubbed)
+			hasEXIF = true
+		case "XMP ":
+			// Dropped
+		default:
+			out = append(out, chunk...)
+		}
+	}
+
+	if vp8x >= 0 && vp8x < len(out) {
+		out[vp8x] &^= vp8xXMP
+		if !hasEXIF {
+			out[vp8x] &^= vp8xEXIF
+		}
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
+
+// appendWebPChunk appends a RIFF chunk, padded to an even length
+func appendWebPChunk(out []byte, kind string, body []byte) []byte {
+	out = append(out, kind...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(body)))
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// TIFF (the EXIF payload)
+
+// EXIF tags used here
+const (
+	tagOrientation = 0x0112
+	tagGPSInfo     = 0x8825
+)
+
+// tiffTypeSizes are the byte sizes of TIFF field types 1-12
+var tiffTypeSizes = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+// tiffReader reads a TIFF structure with its byte order
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// newTIFFReader checks a TIFF header and returns a reader and the offset of IFD0
+func newTIFFReader(data []byte) (*tiffReader, int, error) {
+	if len(data) < 8 {
+		return nil, 0, fmt.Errorf("%w: truncated EXIF header", ErrMalformedImage)
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 0, fmt.Errorf("%w: unknown EXIF byte order", ErrMalformedImage)
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return nil, 0, fmt.Errorf("%w: bad EXIF magic number", ErrMalformedImage)
+	}
+	return &tiffReader{data: data, order: order}, int(order.Uint32(data[4:])), nil
+}
+
+// entries returns the offset of the first entry of the IFD at off and how many there are
+func (t *tiffReader) entries(off int) (int, int, error) {
+	if off < 8 || off+2 > len(t.data) {
+		return 0, 0, fmt.Errorf("%w: EXIF directory out of range", ErrMalformedImage)
+	}
+	n := int(t.order.Uint16(t.data[off:]))
+	if off+2+12*n > len(t.data) {
+		return 0, 0, fmt.Errorf("%w: EXIF directory overruns the data", ErrMalformedImage)
+	}
+	return off + 2, n, nil
+}
+
+// find returns the offset of the entry for tag in the IFD at off, or -1
+func (t *tiffReader) find(off int, tag uint16) (int, error) {
+	first, n, err := t.entries(off)
+	if err != nil {
+		return -1, err
+	}
+	for i := 0; i < n; i++ {
+		if e := first + 12*i; t.order.Uint16(t.data[e:]) == tag {
+			return e, nil
+		}
+	}
+	return -1, nil
+}
+
+// scrubGPS empties the GPS directory of a TIFF structure in place, zeroing its entries
+// and any values they point to
+// Offsets elsewhere in the structure are untouched, so nothing else moves.
+func scrubGPS(tiff []byte) error {
+	t, ifd0, err := newTIFFReader(tiff)
+	if err != nil {
+		return err
+	}
+	ptr, err := t.find(ifd0, tagGPSInfo)
+	if err != nil || ptr < 0 {
+		return err
+	}
+	gps := int(t.order.Uint32(tiff[ptr+8:]))
+	first, n, err := t.entries(gps)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		e := first + 12*i
+		typ := int(t.order.Uint16(tiff[e+2:]))
+		count := int(t.order.Uint32(tiff[e+4:]))
+		if typ > 0 && typ < len(tiffTypeSizes) {
+			if size := tiffTypeSizes[typ] * count; size > 4 {
+				if off := int(t.order.Uint32(tiff[e+8:])); off >= 0 && off+size <= len(tiff) && size > 0 {
+					clear(tiff[off : off+size])
+				}
+			}
+		}
+		clear(tiff[e : e+12])
+	}
+	t.order.PutUint16(tiff[gps:], 0)
+	return nil
+}
+
+// tiffOrientation returns the orientation tag of a TIFF structure, 1 if it has none
+func tiffOrientation(tiff []byte) int {
+	t, ifd0, err := newTIFFReader(tiff)
+	if err != nil {
+		return 1
+	}
+	e, err := t.find(ifd0, tagOrientation)
+	if err != nil || e < 0 {
+		return 1
+	}
+	if o := int(t.order.Uint16(tiff[e+8:])); o >= 1 && o <= 8 {
+		return o
+	}
+	return 1
+}
+
+// orientationTIFF returns a TIFF structure holding only an orientation tag
+func orientationTIFF(o int) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08") // Big-endian header, IFD0 at offset 8
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, tagOrientation)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(o))
+	tiff = append(tiff, 0, 0)       // Value padding
+	return append(tiff, 0, 0, 0, 0) // No next IFD
+}
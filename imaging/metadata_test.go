@@ -0,0 +1,238 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"golang.org/x/image/webp"
+)
+
+// testTIFF builds a big-endian EXIF structure with a camera make, an orientation and GPS
+// coordinates of 51°30'N 0°7'W
+func testTIFF(orientation int) []byte {
+	be := binary.BigEndian
+	entry := func(b []byte, tag, typ uint16, count, value uint32) []byte {
+		b = be.AppendUint16(b, tag)
+		b = be.AppendUint16(b, typ)
+		b = be.AppendUint32(b, count)
+		return be.AppendUint32(b, value)
+	}
+
+	// Layout: header (8), IFD0 with 3 entries (2+36+4) at 8, make at 50, GPS IFD at 56
+	// with 4 entries (2+48+4), latitude at 110, longitude at 134
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = be.AppendUint16(tiff, 3)
+	tiff = entry(tiff, 0x010F, 2, 6, 50)                              // Make, ASCII
+	tiff = entry(tiff, tagOrientation, 3, 1, uint32(orientation)<<16) // SHORT, left-justified
+	tiff = entry(tiff, tagGPSInfo, 4, 1, 56)
+	tiff = be.AppendUint32(tiff, 0)
+	tiff = append(tiff, "Canon\x00"...)
+
+	tiff = be.AppendUint16(tiff, 4)
+	tiff = entry(tiff, 0x0001, 2, 2, 'N'<<24) // GPSLatitudeRef
+	tiff = entry(tiff, 0x0002, 5, 3, 110)     // GPSLatitude, RATIONAL
+	tiff = entry(tiff, 0x0003, 2, 2, 'W'<<24) // GPSLongitudeRef
+	tiff = entry(tiff, 0x0004, 5, 3, 134)     // GPSLongitude
+	tiff = be.AppendUint32(tiff, 0)
+	for _, v := range []uint32{51, 1, 30, 1, 0, 1, 0, 1, 7, 1, 0, 1} {
+		tiff = be.AppendUint32(tiff, v)
+	}
+	return tiff
+}
+
+// testJPEG returns a JPEG carrying EXIF, XMP and a comment
+func testJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, gradient(16, 8, false), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	var out []byte
+	out = append(out, data[:2]...)
+	out = appendJPEGSegment(out, 0xE1, append([]byte("Exif\x00\x00"), testTIFF(6)...))
+	out = appendJPEGSegment(out, 0xE1, append([]byte("http://ns.adobe.com/xap/1.0/\x00"), "<x:xmpmeta>51.5N</x:xmpmeta>"...))
+	out = appendJPEGSegment(out, 0xFE, []byte("shot at home"))
+	return append(out, data[2:]...)
+}
+
+func TestStripMetadataJPEG(t *testing.T) {
+	original := testJPEG(t)
+	if x, err := exif.Decode(bytes.NewReader(original)); err != nil {
+		t.Fatalf("test JPEG has no EXIF: %v", err)
+	} else if _, _, err := x.LatLong(); err != nil {
+		t.Fatalf("test JPEG has no GPS: %v", err)
+	}
+
+	kept, err := StripMetadata(original, MetadataKeep)
+	if err != nil || !bytes.Equal(kept, original) {
+		t.Errorf("MetadataKeep changed the image (err %v)", err)
+	}
+
+	t.Run("strip-gps", func(t *testing.T) {
+		stripped, err := StripMetadata(original, MetadataStripGPS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stripped) >= len(original) {
+			t.Errorf("stripped %d bytes, original %d", len(stripped), len(original))
+		}
+		x, err := exif.Decode(bytes.NewReader(stripped))
+		if err != nil {
+			t.Fatalf("EXIF lost: %v", err)
+		}
+		if lat, lon, err := x.LatLong(); err == nil {
+			t.Errorf("GPS survived: %v, %v", lat, lon)
+		}
+		if tag, err := x.Get(exif.Make); err != nil {
+			t.Errorf("camera make lost: %v", err)
+		} else if v, _ := tag.StringVal(); v != "Canon" {
+			t.Errorf("make = %q", v)
+		}
+		if bytes.Contains(stripped, []byte("xmpmeta")) {
+			t.Error("XMP survived")
+		}
+		if !bytes.Contains(stripped, []byte("shot at home")) {
+			t.Error("comment removed, only strip-all should remove it")
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+			t.Errorf("stripped JPEG does not decode: %v", err)
+		}
+
+		again, err := StripMetadata(stripped, MetadataStripGPS)
+		if err != nil || !bytes.Equal(again, stripped) {
+			t.Errorf("stripping twice changed the image (err %v)", err)
+		}
+	})
+
+	t.Run("strip-all", func(t *testing.T) {
+		stripped, err := StripMetadata(original, MetadataStripAll)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(stripped, []byte("Canon")) || bytes.Contains(stripped, []byte("xmpmeta")) || bytes.Contains(stripped, []byte("shot at home")) {
+			t.Error("metadata survived")
+		}
+		if o := orientation(stripped); o != 6 {
+			t.Errorf("orientation = %d, want 6", o)
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+			t.Errorf("stripped JPEG does not decode: %v", err)
+		}
+	})
+
+	if _, err := StripMetadata(original[:40], MetadataStripGPS); !errors.Is(err, ErrMalformedImage) {
+		t.Errorf("truncated JPEG: err = %v, want ErrMalformedImage", err)
+	}
+}
+
+func TestStripMetadataPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gradient(8, 8, false)); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + 13
+	var original []byte
+	original = append(original, data[:ihdrEnd]...)
+	original = appendPNGChunk(original, "eXIf", testTIFF(3))
+	original = appendPNGChunk(original, "tEXt", []byte("Author\x00Jane"))
+	original = appendPNGChunk(original, "iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00<x:xmpmeta/>"))
+	original = append(original, data[ihdrEnd:]...)
+
+	for _, policy := range []MetadataPolicy{MetadataStripGPS, MetadataStripAll} {
+		stripped, err := StripMetadata(original, policy)
+		if err != nil {
+			t.Fatalf("%s: %v", policy, err)
+		}
+		if _, err := png.Decode(bytes.NewReader(stripped)); err != nil {
+			t.Errorf("%s: stripped PNG does not decode: %v", policy, err)
+		}
+		if bytes.Contains(stripped, []byte("xmpmeta")) {
+			t.Errorf("%s: XMP survived", policy)
+		}
+		if bytes.Contains(stripped, []byte{0, 0, 0, 51, 0, 0, 0, 1}) {
+			t.Errorf("%s: GPS latitude survived", policy)
+		}
+		if got := bytes.Contains(stripped, []byte("Jane")); got != (policy == MetadataStripGPS) {
+			t.Errorf("%s: text chunk kept = %v", policy, got)
+		}
+		if got := bytes.Contains(stripped, []byte("Canon")); got != (policy == MetadataStripGPS) {
+			t.Errorf("%s: camera make kept = %v", policy, got)
+		}
+	}
+}
+
+func TestStripMetadataWebP(t *testing.T) {
+	// A lossless 1x1 image chunk
+	vp8l := []byte("VP8L\x10\x00\x00\x00/\x00\x00\x00\x00\xcd\xf5 \"\x02E\xaeP\xad\"\x00")
+
+	// An extended WebP: VP8X header, image data, then EXIF and XMP chunks
+	vp8x := []byte{vp8xEXIF | vp8xXMP, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	original := []byte("RIFF\x00\x00\x00\x00WEBP")
+	original = appendWebPChunk(original, "VP8X", vp8x)
+	original = append(original, vp8l...)
+	original = appendWebPChunk(original, "EXIF", testTIFF(1))
+	original = appendWebPChunk(original, "XMP ", []byte("<x:xmpmeta/>"))
+	binary.LittleEndian.PutUint32(original[4:], uint32(len(original)-8))
+	if _, err := webp.Decode(bytes.NewReader(original)); err != nil {
+		t.Fatalf("test WebP does not decode: %v", err)
+	}
+
+	tests := []struct {
+		policy    MetadataPolicy
+		wantFlags byte
+		wantMake  bool
+	}{
+		{MetadataStripGPS, vp8xEXIF, true},
+		{MetadataStripAll, 0, false},
+	}
+	for _, tt := range tests {
+		stripped, err := StripMetadata(original, tt.policy)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.policy, err)
+		}
+		if _, err := webp.Decode(bytes.NewReader(stripped)); err != nil {
+			t.Errorf("%s: stripped WebP does not decode: %v", tt.policy, err)
+		}
+		if size := binary.LittleEndian.Uint32(stripped[4:]); int(size) != len(stripped)-8 {
+			t.Errorf("%s: RIFF size %d, file %d", tt.policy, size, len(stripped))
+		}
+		if flags := stripped[20]; flags != tt.wantFlags {
+			t.Errorf("%s: VP8X flags = %#x, want %#x", tt.policy, flags, tt.wantFlags)
+		}
+		if bytes.Contains(stripped, []byte("xmpmeta")) || bytes.Contains(stripped, []byte{0, 0, 0, 51, 0, 0, 0, 1}) {
+			t.Errorf("%s: XMP or GPS survived", tt.policy)
+		}
+		if got := bytes.Contains(stripped, []byte("Canon")); got != tt.wantMake {
+			t.Errorf("%s: camera make kept = %v", tt.policy, got)
+		}
+	}
+}
+
+func TestMetadataPolicyCovers(t *testing.T) {
+	tests := []struct {
+		stored, configured MetadataPolicy
+		want               bool
+	}{
+		{"", MetadataKeep, true},
+		{"", MetadataStripGPS, false},
+		{MetadataStripGPS, MetadataStripGPS, true},
+		{MetadataStripGPS, MetadataStripAll, false},
+		{MetadataStripAll, MetadataStripGPS, true},
+	}
+	for _, tt := range tests {
+		if got := tt.stored.Covers(tt.configured); got != tt.want {
+			t.Errorf("%q.Covers(%q) = %v, want %v", tt.stored, tt.configured, got, tt.want)
+		}
+	}
+
+	if _, err := ParseMetadataPolicy("strip-everything"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,263 @@
+// Package lang detects the language of a scraped page from its declared language and
+// from statistics of its text.
+package lang
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// Sources of a detected language
+const (
+	SourceHTML   = "html"   // <html lang> attribute
+	SourceHeader = "header" // Content-Language response header
+	SourceText   = "text"   // Script and stopword statistics of the page text
+)
+
+// minLetters is the number of letters needed before text statistics are trusted
+const minLetters = 20
+
+// overrideConfidence is the text confidence needed to override a declared language
+// written in a different script, e.g. a template declaring lang="en" around Russian text
+const overrideConfidence = 0.8
+
+// Detection is the detected language of a page
+type Detection struct {
+	Code       string  // ISO 639-1 code where one exists, e.g. "en", "zh"; empty if unknown
+	Source     string  // Where the language came from: SourceHTML, SourceHeader or SourceText
+	Confidence float64 // 1 for declared languages, the share of supporting evidence for text
+}
+
+// Detect determines a page language from the <html lang> attribute, the Content-Language
+// header and the page text
+// A declared language wins unless the text is clearly written in another script.
+func Detect(htmlLang, contentLanguage, text string) Detection {
+	declared := Detection{Code: Normalize(htmlLang), Source: SourceHTML, Confidence: 1}
+	if declared.Code == "" {
+		// Content-Language may list several languages; the first is the primary audience
+		first, _, _ := strings.Cut(contentLanguage, ",")
+		declared = Detection{Code: Normalize(first), Source: SourceHeader, Confidence: 1}
+	}
+
+	code, confidence := DetectText(text)
+	if declared.Code == "" {
+		if code == "" {
+			return Detection{}
+		}
+		return Detection{Code: code, Source: SourceText, Confidence: confidence}
+	}
+	if code != "" && code != declared.Code && confidence >= overrideConfidence && script(code) != script(declared.Code) {
+		return Detection{Code: code, Source: SourceText, Confidence: confidence}
+	}
+	return declared
+}
+
+// Normalize reduces a BCP 47 tag such as "en-US" or "zh-Hant-TW" to its base language
+// Invalid and undetermined tags return ""
+func Normalize(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return ""
+	}
+	t, err := language.Parse(tag)
+	if err != nil {
+		return ""
+	}
+	base, confidence := t.Base()
+	if confidence != language.Exact {
+		return ""
+	}
+	return base.String()
+}
+
+// Name returns the English name of a language code, e.g. "German" for "de"
+// Unknown codes are returned unchanged
+func Name(code string) string {
+	t, err := language.Parse(code)
+	if err != nil {
+		return code
+	}
+	if name := display.English.Languages().Name(t); name != "" {
+		return name
+	}
+	return code
+}
+
+// script returns the usual script of a language, e.g. "Cyrl" for "ru"
+func script(code string) string {
+	s, _ := language.Make(code).Script()
+	return s.String()
+}
+
+// DetectText guesses the language of text from the scripts of its letters and, for
+// Latin-script text, from common function words
+// It returns "" when there is too little text to decide.
+func DetectText(text string) (code string, confidence float64) {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		counts[scriptOf(r)]++
+	}
+	if letters < minLetters {
+		return "", 0
+	}
+
+	dominant, n := "", 0
+	for s, c := range counts {
+		if c > n || (c == n && s < dominant) {
+			dominant, n = s, c
+		}
+	}
+	share := float64(n) / float64(letters)
+
+	switch dominant {
+	case "Latin":
+		return detectLatin(text)
+	case "Han":
+		// Japanese mixes kanji with kana; Chinese uses none
+		if kana := counts["Kana"]; float64(kana)/float64(letters) >= 0.05 {
+			return "ja", float64(n+kana) / float64(letters)
+		}
+		return "zh", share
+	case "Kana":
+		return "ja", float64(n+counts["Han"]) / float64(letters)
+	case "Cyrillic":
+		return detectCyrillic(text), share
+	case "Arabic":
+		return detectArabic(text), share
+	case "":
+		return "", 0
+	}
+	return scriptLanguages[dominant], share
+}
+
+// scriptLanguages maps scripts used mainly by one language to that language
+var scriptLanguages = map[string]string{
+	"Hangul":     "ko",
+	"Greek":      "el",
+	"Hebrew":     "he",
+	"Thai":       "th",
+	"Devanagari": "hi",
+	"Armenian":   "hy",
+	"Georgian":   "ka",
+}
+
+// scripts checked by scriptOf, with the script names used in DetectText
+var scripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Han", unicode.Han},
+	{"Kana", unicode.Hiragana},
+	{"Kana", unicode.Katakana},
+	{"Hangul", unicode.Hangul},
+	{"Arabic", unicode.Arabic},
+	{"Greek", unicode.Greek},
+	{"Hebrew", unicode.Hebrew},
+	{"Thai", unicode.Thai},
+	{"Devanagari", unicode.Devanagari},
+	{"Armenian", unicode.Armenian},
+	{"Georgian", unicode.Georgian},
+}
+
+// scriptOf returns the script name of a letter, or "" for scripts not tracked
+func scriptOf(r rune) string {
+	for _, s := range scripts {
+		if unicode.Is(s.table, r) {
+			return s.name
+		}
+	}
+	return ""
+}
+
+// detectCyrillic tells Ukrainian and Belarusian apart from Russian by their distinct letters
+func detectCyrillic(text string) string {
+	switch {
+	case strings.ContainsAny(text, "іїєґІЇЄҐ") && !strings.ContainsAny(text, "ўЎ"):
+		return "uk"
+	case strings.ContainsAny(text, "ўЎ"):
+		return "be"
+	case strings.ContainsAny(text, "ђћџљњЂЋЏЉЊ"):
+		return "sr"
+	}
+	return "ru"
+}
+
+// detectArabic tells Persian and Urdu apart from Arabic by their additional letters
+func detectArabic(text string) string {
+	switch {
+	case strings.ContainsAny(text, "ٹڈڑںے"):
+		return "ur"
+	case strings.ContainsAny(text, "پچژگی"):
+		return "fa"
+	}
+	return "ar"
+}
+
+// stopwords are frequent function words of Latin-script languages
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "was", "on", "are", "this", "be", "by", "from", "have"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "dans", "que", "pour", "qui", "pas", "sur", "du", "au", "avec", "sont", "ce"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "mit", "den", "von", "zu", "sich", "auf", "für", "dem", "auch", "wird"},
+	"es": {"el", "la", "los", "las", "y", "que", "del", "en", "una", "por", "con", "para", "es", "se", "su", "al", "como", "más"},
+	"it": {"il", "di", "che", "e", "la", "per", "una", "sono", "del", "della", "non", "con", "gli", "le", "nel", "alla", "anche", "è"},
+	"pt": {"o", "a", "os", "as", "que", "do", "da", "em", "um", "uma", "para", "com", "não", "por", "dos", "mais", "é", "são"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "op", "te", "zijn", "met", "voor", "niet", "die", "aan", "ook", "wordt", "bij"},
+	"sv": {"och", "att", "det", "som", "en", "är", "av", "för", "med", "till", "den", "har", "inte", "om", "ett", "på", "var", "jag"},
+	"pl": {"i", "w", "nie", "na", "się", "jest", "że", "do", "z", "to", "jak", "dla", "przez", "po", "oraz", "od", "ale", "są"},
+}
+
+// stopwordSets indexes stopwords for lookup
+var stopwordSets = func() map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(stopwords))
+	for code, words := range stopwords {
+		sets[code] = make(map[string]bool, len(words))
+		for _, w := range words {
+			sets[code][w] = true
+		}
+	}
+	return sets
+}()
+
+// detectLatin picks the Latin-script language whose function words occur most often
+// Confidence is the share of function words that belong to that language; text with
+// fewer than three function words is left undetected.
+func detectLatin(text string) (string, float64) {
+	hits := make(map[string]int)
+	total := 0
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, w := range words {
+		matched := false
+		for code, set := range stopwordSets {
+			if set[w] {
+				hits[code]++
+				matched = true
+			}
+		}
+		if matched {
+			total++
+		}
+	}
+	if total < 3 {
+		return "", 0
+	}
+
+	best, n := "", 0
+	for code, c := range hits {
+		if c > n || (c == n && code < best) {
+			best, n = code, c
+		}
+	}
+	return best, float64(n) / float64(total)
+}
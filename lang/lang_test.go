@@ -0,0 +1,87 @@
+package lang
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"en":         "en",
+		"en-US":      "en",
+		" de-CH ":    "de",
+		"zh-Hant-TW": "zh",
+		"iw":         "he",
+		"":           "",
+		"und":        "",
+		"not a tag!": "",
+	}
+	for in, want := range tests {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	if got := Name("de"); got != "German" {
+		t.Errorf("Name(de) = %q, want German", got)
+	}
+	if got := Name("zz-bogus!"); got != "zz-bogus!" {
+		t.Errorf("Name of invalid code = %q, want it unchanged", got)
+	}
+}
+
+func TestDetectText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "The council approved the budget for the next year and it was a close vote.", "en"},
+		{"french", "Le conseil a approuvé le budget pour l'année prochaine et les élus sont satisfaits.", "fr"},
+		{"german", "Der Rat hat den Haushalt für das nächste Jahr beschlossen und die Mehrheit ist nicht knapp.", "de"},
+		{"spanish", "El consejo aprobó el presupuesto para el próximo año y los concejales están de acuerdo con la decisión.", "es"},
+		{"russian", "Городской совет утвердил бюджет на следующий год после долгого обсуждения.", "ru"},
+		{"ukrainian", "Міська рада ухвалила бюджет на наступний рік після тривалого обговорення.", "uk"},
+		{"chinese", "市议会批准了明年的预算，经过长时间的讨论以后大家都同意了这个决定。", "zh"},
+		{"japanese", "市議会は来年度の予算を承認しました。長い議論の後で決まりました。", "ja"},
+		{"korean", "시의회는 오랜 논의 끝에 내년 예산을 승인했습니다.", "ko"},
+		{"arabic", "وافق مجلس المدينة على ميزانية العام المقبل بعد نقاش طويل.", "ar"},
+		{"persian", "شورای شهر پس از بحث طولانی بودجه سال آینده را تصویب کرد.", "fa"},
+		{"greek", "Το δημοτικό συμβούλιο ενέκρινε τον προϋπολογισμό του επόμενου έτους.", "el"},
+		{"too short", "Hello", ""},
+		{"no function words", "Lorem ipsum dolor sit amet consectetur adipiscing", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := DetectText(tt.text); got != tt.want {
+				t.Errorf("DetectText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	russian := "Городской совет утвердил бюджет на следующий год после долгого обсуждения."
+	english := "The council approved the budget for the next year and it was a close vote."
+
+	tests := []struct {
+		name            string
+		htmlLang        string
+		contentLanguage string
+		text            string
+		want            Detection
+	}{
+		{"html lang wins", "en-GB", "de", english, Detection{Code: "en", Source: SourceHTML, Confidence: 1}},
+		{"header used without html lang", "", "de-DE, en", "", Detection{Code: "de", Source: SourceHeader, Confidence: 1}},
+		{"text used without declarations", "", "", russian, Detection{Code: "ru", Source: SourceText, Confidence: 1}},
+		{"text overrides a declaration in another script", "en", "", russian, Detection{Code: "ru", Source: SourceText, Confidence: 1}},
+		{"declaration kept for the same script", "de", "", english, Detection{Code: "de", Source: SourceHTML, Confidence: 1}},
+		{"nothing known", "", "", "", Detection{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.htmlLang, tt.contentLanguage, tt.text); got != tt.want {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+)
+
+// languageTestServers serves page with the given Content-Language header and an Ollama
+// fake that records prompts and answers translation requests
+func languageTestServers(t *testing.T, page, contentLanguage string) (web, llm *httptest.Server, prompts func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var seen []string
+
+	llm = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		seen = append(seen, req.Prompt)
+		mu.Unlock()
+
+		response := "Der Stadtrat hat den Haushalt beschlossen."
+		switch {
+		case strings.HasPrefix(req.Prompt, "You are a translation assistant"):
+			response = "The city council approved the budget."
+		case strings.HasPrefix(req.Prompt, "You are a link filtering assistant"):
+			response = "[]"
+		case strings.HasPrefix(req.Prompt, "You are a content quality assessment assistant"):
+			response = `{"score": 0.8, "reason": "news", "categories": ["news"], "malicious_indicators": []}`
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: response, Done: true})
+	}))
+	web = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentLanguage != "" {
+			w.Header().Set("Content-Language", contentLanguage)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(func() {
+		web.Close()
+		llm.Close()
+	})
+
+	return web, llm, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func TestScrapeDetectsLanguage(t *testing.T) {
+	page := `<html lang="de-DE"><head><title>Haushalt beschlossen</title></head><body>
+		<p>Der Stadtrat hat den Haushalt für das nächste Jahr beschlossen und die Mehrheit ist nicht knapp.</p>
+	</body></html>`
+	web, llm, prompts := languageTestServers(t, page, "")
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = llm.URL
+	config.EnableImageAnalysis = false
+	data, err := New(config, nil, nil).Scrape(context.Background(), web.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if data.Metadata.Language != "de" || data.Metadata.LanguageSource != "html" {
+		t.Errorf("language = %q from %q, want de from html", data.Metadata.Language, data.Metadata.LanguageSource)
+	}
+	if data.Metadata.TranslatedTo != "" {
+		t.Errorf("content translated to %q without a target language", data.Metadata.TranslatedTo)
+	}
+
+	for _, p := range prompts() {
+		switch {
+		case strings.HasPrefix(p, "You are a content extraction assistant"):
+			if !strings.Contains(p, "The text is written in German") {
+				t.Errorf("extraction prompt does not name the language:\n%s", p)
+			}
+		case strings.HasPrefix(p, "You are a content quality assessment assistant"):
+			if !strings.Contains(p, "Language: German") {
+				t.Errorf("scoring prompt does not name the language:\n%s", p)
+			}
+		case strings.HasPrefix(p, "You are a translation assistant"):
+			t.Error("content translated without a target language")
+		}
+	}
+}
+
+func TestScrapeDetectsLanguageFromTextAndTransliteratesSlug(t *testing.T) {
+	page := `<html><head><title>Городской совет</title></head><body>
+		<p>Городской совет утвердил бюджет на следующий год после долгого обсуждения.</p>
+	</body></html>`
+	web, llm, _ := languageTestServers(t, page, "")
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = llm.URL
+	config.EnableImageAnalysis = false
+	data, err := New(config, nil, nil).ScrapeWithOptions(context.Background(), web.URL, ScrapeOptions{CleanContent: Bool(false)})
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if data.Metadata.Language != "ru" || data.Metadata.LanguageSource != "text" {
+		t.Errorf("language = %q from %q, want ru from text", data.Metadata.Language, data.Metadata.LanguageSource)
+	}
+	if data.Slug != "gorodskoy-sovet" {
+		t.Errorf("slug = %q, want gorodskoy-sovet", data.Slug)
+	}
+}
+
+func TestScrapeTranslatesContent(t *testing.T) {
+	page := `<html><head><title>Haushalt</title></head><body>
+		<p>Der Stadtrat hat den Haushalt für das nächste Jahr beschlossen.</p>
+	</body></html>`
+	web, llm, prompts := languageTestServers(t, page, "de")
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = llm.URL
+	config.EnableImageAnalysis = false
+
+	var stages []ProgressStage
+	data, err := New(config, nil, nil).ScrapeWithOptions(context.Background(), web.URL, ScrapeOptions{
+		TranslateTo: "en-US",
+		Progress:    func(e ProgressEvent) { stages = append(stages, e.Stage) },
+	})
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if data.Content != "The city council approved the budget." {
+		t.Errorf("content = %q, want the translation", data.Content)
+	}
+	if data.Metadata.Language != "de" || data.Metadata.LanguageSource != "header" || data.Metadata.TranslatedTo != "en" {
+		t.Errorf("unexpected metadata %+v", data.Metadata)
+	}
+	if !strings.Contains(data.RawText, "Stadtrat") {
+		t.Errorf("raw text should keep the original language, got %q", data.RawText)
+	}
+
+	translated := false
+	for _, stage := range stages {
+		translated = translated || stage == StageTranslated
+	}
+	if !translated {
+		t.Errorf("no %s progress event in %v", StageTranslated, stages)
+	}
+
+	var translationPrompt string
+	for _, p := range prompts() {
+		if strings.HasPrefix(p, "You are a translation assistant") {
+			translationPrompt = p
+		}
+	}
+	if !strings.Contains(translationPrompt, "Translate the following German text into English") {
+		t.Errorf("unexpected translation prompt:\n%s", translationPrompt)
+	}
+}
+
+func TestScrapeSkipsTranslationIntoPageLanguage(t *testing.T) {
+	page := `<html lang="de"><head><title>Haushalt</title></head><body><p>Der Stadtrat hat getagt.</p></body></html>`
+	web, llm, prompts := languageTestServers(t, page, "")
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = llm.URL
+	config.EnableImageAnalysis = false
+	config.TranslateTo = "de"
+	data, err := New(config, nil, nil).Scrape(context.Background(), web.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if data.Metadata.TranslatedTo != "" {
+		t.Errorf("content translated into its own language")
+	}
+	for _, p := range prompts() {
+		if strings.HasPrefix(p, "You are a translation assistant") {
+			t.Error("unexpected translation request")
+		}
+	}
+}
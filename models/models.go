@@ -19,6 +19,51 @@ type ScrapedData struct {
 	Score          *LinkScore   `json:"score,omitempty"` // Quality score for the URL
 	Warnings       []string     `json:"warnings,omitempty"` // Non-fatal processing warnings
 	Slug           string       `json:"slug,omitempty"` // SEO-friendly URL slug
+	APIKeyID       string       `json:"api_key_id,omitempty"` // API key that created the scrape
+	Fetch          *FetchInfo   `json:"fetch,omitempty"` // HTTP exchange that fetched the page (nil for direct image URLs)
+}
+
+// FetchInfo describes the HTTP exchange that fetched a page
+type FetchInfo struct {
+	FinalURL       string            `json:"final_url"`                 // URL the page was served from after redirects
+	StatusCode     int               `json:"status_code"`               // Status of the final response
+	RedirectChain  []RedirectHop     `json:"redirect_chain,omitempty"`  // Redirects followed, in order
+	Headers        map[string]string `json:"headers,omitempty"`         // Selected response headers, keyed by lowercase name
+	TLS            *TLSInfo          `json:"tls,omitempty"`             // Connection security of the final response (nil for plain HTTP)
+	Timings        FetchTimings      `json:"timings"`                   // How long each phase of the fetch took
+	Profile        string            `json:"profile,omitempty"`         // Outbound network profile used (empty when fetched directly)
+	Proxy          string            `json:"proxy,omitempty"`           // Proxy the final request went through, without credentials
+	ProxyRotations int               `json:"proxy_rotations,omitempty"` // Proxies abandoned after a 403 or 429
+	Retries        int               `json:"retries,omitempty"`         // Requests retried after transient failures
+}
+
+// RedirectHop is one redirect response on the way to the final URL
+type RedirectHop struct {
+	URL        string `json:"url"`         // URL that answered with a redirect
+	StatusCode int    `json:"status_code"` // 301, 302, 303, 307 or 308
+	Location   string `json:"location"`    // Location header it redirected to
+}
+
+// TLSInfo describes the TLS connection a page was fetched over
+type TLSInfo struct {
+	Version            string    `json:"version"`                       // e.g. "TLS 1.3"
+	CipherSuite        string    `json:"cipher_suite"`                  // e.g. "TLS_AES_128_GCM_SHA256"
+	ServerName         string    `json:"server_name,omitempty"`         // SNI sent to the server
+	NegotiatedProtocol string    `json:"negotiated_protocol,omitempty"` // ALPN protocol, e.g. "h2"
+	CertSubject        string    `json:"cert_subject,omitempty"`        // Leaf certificate subject
+	CertIssuer         string    `json:"cert_issuer,omitempty"`         // Leaf certificate issuer
+	CertNotAfter       time.Time `json:"cert_not_after"`                // Leaf certificate expiry
+}
+
+// FetchTimings breaks down how long a page fetch took, in milliseconds
+// DNS, connect and TLS cover the final request and are zero when it reused a connection.
+type FetchTimings struct {
+	DNSMs      float64 `json:"dns_ms"`      // Resolving the host
+	ConnectMs  float64 `json:"connect_ms"`  // Opening the TCP connection
+	TLSMs      float64 `json:"tls_ms"`      // TLS handshake
+	TTFBMs     float64 `json:"ttfb_ms"`     // From starting the final request to its first response byte
+	DownloadMs float64 `json:"download_ms"` // From the first response byte to the end of the body
+	TotalMs    float64 `json:"total_ms"`    // Whole fetch, including redirects
 }
 
 // ImageInfo contains information about an extracted image
@@ -26,9 +71,12 @@ type ImageInfo struct {
 	ID                 string     `json:"id,omitempty"` // UUID for the image
 	URL                string     `json:"url"`
 	AltText            string     `json:"alt_text"`
+	Caption            string     `json:"caption,omitempty"` // Text of the enclosing <figure>'s <figcaption>
 	Summary            string     `json:"summary"`
 	Tags               []string   `json:"tags"`
 	ExtractedText      string     `json:"extracted_text,omitempty"` // OCR extracted text from image
+	TextBlocks         []TextBlock `json:"text_blocks,omitempty"`   // Text found by a dedicated OCR engine, with positions and confidences
+	OCREngine          string     `json:"ocr_engine,omitempty"`     // Engine ExtractedText came from ("tesseract", "http" or "vision")
 	Base64Data         string     `json:"base64_data,omitempty"` // Base64 encoded image data (deprecated, use FilePath)
 	FilePath           string     `json:"file_path,omitempty"` // Filesystem path to image
 	Slug               string     `json:"slug,omitempty"` // SEO-friendly URL slug
@@ -38,8 +86,35 @@ type ImageInfo struct {
 	Height             int        `json:"height,omitempty"`      // Image height in pixels
 	FileSizeBytes      int64      `json:"file_size_bytes,omitempty"` // File size in bytes
 	ContentType        string     `json:"content_type,omitempty"` // MIME type (e.g., "image/jpeg")
-	EXIF               *EXIFData  `json:"exif,omitempty"`        // EXIF metadata from image file
+	EXIF               *EXIFData  `json:"exif,omitempty"`        // EXIF metadata from image file, less what the metadata policy removes
+	PrivateEXIF        *EXIFData  `json:"-"`                      // Full EXIF when the metadata policy removed some and it is retained; admin-only
+	MetadataPolicy     string     `json:"metadata_policy,omitempty"` // Metadata policy the stored bytes were scrubbed with ("keep", "strip-gps" or "strip-all")
 	RelevanceScore     float64    `json:"relevance_score,omitempty"` // Relevance score (0.0-1.0) for article thumbnail selection
+	SHA256             string     `json:"sha256,omitempty"`          // Hex SHA-256 of the image bytes
+	PerceptualHash     string     `json:"perceptual_hash,omitempty"` // Hex 64-bit dHash, empty if the image could not be decoded
+	Safety             *ImageSafety `json:"safety,omitempty"`        // Content safety classification, nil if the image was not classified
+	Alternates         []string   `json:"-"`                      // Smaller renditions from srcset, tried in order when URL is too large to download
+}
+
+// TextBlock is a line of text found in an image by OCR
+type TextBlock struct {
+	Text       string      `json:"text"`
+	Confidence float64     `json:"confidence"` // Engine's confidence in the text (0.0-1.0)
+	BBox       BoundingBox `json:"bbox"`       // Position in the image, in pixels
+}
+
+// BoundingBox is a rectangle in an image, in pixels from the top-left corner
+type BoundingBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// ImageSafety is the vision model's content safety classification of an image
+type ImageSafety struct {
+	Categories map[string]float64 `json:"categories"` // Confidence (0.0-1.0) per unsafe category, e.g. "sexual" or "violence"
+	Flagged    bool               `json:"flagged"`    // A category reached the configured threshold
 }
 
 // EXIFData contains EXIF metadata extracted from an image
@@ -70,14 +145,31 @@ type PageMetadata struct {
 	Author               string                 `json:"author,omitempty"`
 	PublishedDate        string                 `json:"published_date,omitempty"`
 	ExistingImageRefs    []ExistingImageRef     `json:"existing_image_refs,omitempty"` // References to images already in database
+	Charset              string                 `json:"charset,omitempty"`             // Character encoding the page was decoded from, e.g. "shift_jis"
+	Language             string                 `json:"language,omitempty"`            // Detected page language (ISO 639-1 where available, e.g. "en")
+	LanguageSource       string                 `json:"language_source,omitempty"`     // Where the language came from: "html", "header" or "text"
+	TranslatedTo         string                 `json:"translated_to,omitempty"`       // Language the content was translated into, if translated
+	Feeds                []string               `json:"feeds,omitempty"`               // Feeds the page advertises with <link rel="alternate">
+	FeedURL              string                 `json:"feed_url,omitempty"`            // Feed or sitemap the page was ingested from
 }
 
 // ExistingImageRef represents a reference to an existing image that was not re-downloaded
+// or re-analyzed
 type ExistingImageRef struct {
-	ImageID  string `json:"image_id"`  // ID of the existing image
-	ImageURL string `json:"image_url"` // URL of the image (for reference)
+	ImageID   string `json:"image_id"`             // ID of the existing image
+	ImageURL  string `json:"image_url"`            // URL of the image (for reference)
+	SourceURL string `json:"source_url,omitempty"` // URL on this page, when it differs from ImageURL
+	Match     string `json:"match,omitempty"`      // How the image was matched: "url", "sha256" or "perceptual"
+	Distance  int    `json:"distance,omitempty"`   // Perceptual hash Hamming distance for a "perceptual" match
 }
 
+// Existing image match kinds
+const (
+	ImageMatchURL        = "url"        // Same source URL
+	ImageMatchSHA256     = "sha256"     // Byte-identical image from another URL
+	ImageMatchPerceptual = "perceptual" // Visually similar image, e.g. resized or recompressed
+)
+
 // OllamaRequest represents a request to the Ollama API
 type OllamaRequest struct {
 	Model  string `json:"model"`
@@ -102,6 +194,31 @@ type OllamaVisionRequest struct {
 	Stream bool     `json:"stream"`
 }
 
+// OllamaModel describes a locally available model as reported by /api/tags
+type OllamaModel struct {
+	Name       string `json:"name"`
+	Model      string `json:"model"`
+	Size       int64  `json:"size"`
+	ModifiedAt string `json:"modified_at"`
+}
+
+// OllamaTagsResponse represents the response from the Ollama /api/tags endpoint
+type OllamaTagsResponse struct {
+	Models []OllamaModel `json:"models"`
+}
+
+// OllamaPullRequest represents a request to pull a model via /api/pull
+type OllamaPullRequest struct {
+	Model  string `json:"model"`
+	Stream bool   `json:"stream"`
+}
+
+// OllamaPullResponse represents the final status returned by /api/pull
+type OllamaPullResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
 // LinkScore represents a scored link with quality assessment
 type LinkScore struct {
 	URL               string   `json:"url"`
@@ -111,6 +228,9 @@ type LinkScore struct {
 	IsRecommended       bool     `json:"is_recommended"`     // Whether the link is recommended for ingestion
 	MaliciousIndicators []string `json:"malicious_indicators,omitempty"` // Any detected malicious patterns
 	AIUsed              bool     `json:"ai_used"`            // Whether AI (Ollama) was used for scoring (true) or rule-based fallback (false)
+	Policy              string   `json:"policy,omitempty"`        // Domain policy that applied, if any
+	PolicyDomain        string   `json:"policy_domain,omitempty"` // Domain the applied policy is configured for
+	Adjustment          float64  `json:"adjustment,omitempty"`    // Learned per-domain adjustment applied to Score, after clamping
 }
 
 // ScoreRequest represents a request to score a URL
@@ -123,3 +243,135 @@ type ScoreResponse struct {
 	URL   string    `json:"url"`
 	Score LinkScore `json:"score"`
 }
+
+// APIKey is a REST API credential; only a hash of the secret is stored
+type APIKey struct {
+	ID                 string     `json:"id"`
+	Name               string     `json:"name"`                  // Human-readable label, e.g. the client it was issued to
+	Prefix             string     `json:"prefix"`                // First characters of the key, for identification
+	Scopes             []string   `json:"scopes"`                // "read", "scrape" and/or "admin"
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"` // Requests per minute (0 = unlimited)
+	DailyScrapeQuota   int        `json:"daily_scrape_quota"`    // Scrape requests per UTC day (0 = unlimited)
+	CreatedAt          time.Time  `json:"created_at"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Domain policy states
+const (
+	DomainPolicyAllow               = "allow"                 // Trusted: exempt from rule-based blocking
+	DomainPolicyBlock               = "block"                 // Never fetched, scored or returned as a link
+	DomainPolicyAlwaysRecommend     = "always_recommend"      // Allowed and always recommended regardless of score
+	DomainPolicyNeverDownloadImages = "never_download_images" // Scraped without downloading images
+)
+
+// DomainPolicy is an operator-defined policy for a domain and its subdomains
+type DomainPolicy struct {
+	Domain    string    `json:"domain"`
+	Policy    string    `json:"policy"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ValidDomainPolicy reports whether policy is a known domain policy state
+func ValidDomainPolicy(policy string) bool {
+	switch policy {
+	case DomainPolicyAllow, DomainPolicyBlock, DomainPolicyAlwaysRecommend, DomainPolicyNeverDownloadImages:
+		return true
+	}
+	return false
+}
+
+// Site credential kinds
+const (
+	CredentialCookies   = "cookies"    // Fixed cookies, e.g. a session copied from a browser
+	CredentialBearer    = "bearer"     // Authorization: Bearer token
+	CredentialBasic     = "basic"      // HTTP basic auth
+	CredentialFormLogin = "form_login" // Log in through an HTML form and keep its session cookies
+)
+
+// SiteCredential is a stored login applied to fetches for a domain and its subdomains
+// Secret is encrypted at rest and never serialized, so API responses cannot leak it.
+type SiteCredential struct {
+	Domain    string           `json:"domain"`
+	Kind      string           `json:"kind"`
+	Login     *FormLogin       `json:"login,omitempty"` // Recipe for form_login credentials
+	Note      string           `json:"note,omitempty"`
+	Secret    CredentialSecret `json:"-"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// CredentialSecret holds the secret parts of a site credential
+type CredentialSecret struct {
+	Cookies  map[string]string `json:"cookies,omitempty"`  // cookies
+	Token    string            `json:"token,omitempty"`    // bearer
+	Username string            `json:"username,omitempty"` // basic and form_login
+	Password string            `json:"password,omitempty"` // basic and form_login
+}
+
+// FormLogin describes how to log in to a site through its login form
+type FormLogin struct {
+	URL           string            `json:"url"`                      // Page with the login form, fetched first for cookies and hidden fields
+	SubmitURL     string            `json:"submit_url,omitempty"`     // Where to POST the form (default: the form's action)
+	UsernameField string            `json:"username_field,omitempty"` // Default "username"
+	PasswordField string            `json:"password_field,omitempty"` // Default "password"
+	Fields        map[string]string `json:"fields,omitempty"`         // Extra fields to submit
+}
+
+// ValidCredentialKind reports whether kind is a known site credential kind
+func ValidCredentialKind(kind string) bool {
+	switch kind {
+	case CredentialCookies, CredentialBearer, CredentialBasic, CredentialFormLogin:
+		return true
+	}
+	return false
+}
+
+// ScoreLabel is a human judgement of whether a scrape's URL should have been recommended
+type ScoreLabel struct {
+	ID         string    `json:"id"`
+	ScrapeID   string    `json:"scrape_id"`
+	URL        string    `json:"url"`
+	Domain     string    `json:"domain"`
+	Accepted   bool      `json:"accepted"`             // Whether the reviewer accepted the page for ingestion
+	Score      float64   `json:"score"`                // Score before any learned adjustment
+	AIUsed     bool      `json:"ai_used"`              // Whether the score came from AI or rule-based fallback
+	Categories []string  `json:"categories"`           // Categories of the labeled score
+	Note       string    `json:"note,omitempty"`       // Optional reviewer comment
+	APIKeyID   string    `json:"api_key_id,omitempty"` // API key that recorded the label
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// FeedSubscription is a feed or sitemap polled for new entries to scrape
+type FeedSubscription struct {
+	ID                string     `json:"id"`
+	FeedURL           string     `json:"feed_url"`
+	Kind              string     `json:"kind"`                     // "rss", "atom", "json", "sitemap" or "sitemap_index"
+	Title             string     `json:"title,omitempty"`          // Title the feed gives itself
+	IntervalSeconds   int        `json:"interval_seconds"`         // How often the feed is polled
+	MaxEntriesPerPoll int        `json:"max_entries_per_poll"`     // New entries scraped per poll, newest first (0 = all)
+	LastPolledAt      *time.Time `json:"last_polled_at,omitempty"` // When the feed was last fetched
+	LastError         string     `json:"last_error,omitempty"`     // Why the last poll failed, empty if it succeeded
+	EntriesSeen       int        `json:"entries_seen"`             // Entries scraped so far
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// Due reports whether the subscription should be polled at now
+func (f *FeedSubscription) Due(now time.Time) bool {
+	return f.LastPolledAt == nil || !now.Before(f.LastPolledAt.Add(time.Duration(f.IntervalSeconds)*time.Second))
+}
+
+// ImageDerivative is a stored, resized or re-encoded copy of an image
+type ImageDerivative struct {
+	ImageID       string    `json:"image_id"`
+	Width         int       `json:"width"`         // Requested width (0 = original width); the key together with ImageID and Format
+	Format        string    `json:"format"`        // "jpeg" or "webp"
+	FilePath      string    `json:"file_path"`     // Storage key, next to the original
+	ContentType   string    `json:"content_type"`  // MIME type of the derivative
+	ActualWidth   int       `json:"actual_width"`  // Pixel width; smaller than Width when the original is narrower
+	ActualHeight  int       `json:"actual_height"` // Pixel height
+	FileSizeBytes int64     `json:"file_size_bytes"`
+	CreatedAt     time.Time `json:"created_at"`
+}
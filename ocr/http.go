@@ -0,0 +1,73 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+// HTTP sends images to an OCR model behind an HTTP API
+// The image is POSTed as the request body with its content type, and the response is
+// JSON of the form {"blocks": [{"text": "...", "confidence": 0.97, "bbox": {"x": 0,
+// "y": 0, "width": 100, "height": 20}}]}, confidences from 0.0 to 1.0.
+type HTTP struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTP returns an engine posting images to endpoint, each recognition limited to
+// timeout (default DefaultTimeout)
+func NewHTTP(endpoint string, timeout time.Duration) (*HTTP, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("OCR URL must be an http or https URL: %q", endpoint)
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &HTTP{url: endpoint, client: &http.Client{Timeout: timeout}}, nil
+}
+
+// Name returns EngineHTTP
+func (h *HTTP) Name() string {
+	return EngineHTTP
+}
+
+// Recognize posts an image to the OCR endpoint
+func (h *HTTP) Recognize(ctx context.Context, imageData []byte) ([]models.TextBlock, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(imageData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCR request: %w", err)
+	}
+	req.Header.Set("Content-Type", http.DetectContentType(imageData))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OCR request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("OCR request failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	var result struct {
+		Blocks []models.TextBlock `json:"blocks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode OCR response: %w", err)
+	}
+	for i := range result.Blocks {
+		result.Blocks[i].Confidence = max(0, min(result.Blocks[i].Confidence, 1))
+	}
+	return result.Blocks, nil
+}
@@ -0,0 +1,81 @@
+// Package ocr extracts text from images with a dedicated OCR engine, a local Tesseract
+// binary or an OCR model behind an HTTP API, returning each line with its position and
+// confidence.
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+// Engine names
+const (
+	EngineVision    = "vision"    // The Ollama vision model, which is not an Engine
+	EngineTesseract = "tesseract" // A local Tesseract binary
+	EngineHTTP      = "http"      // An OCR model behind an HTTP API
+)
+
+// DefaultLanguages are the Tesseract languages used when none are configured
+const DefaultLanguages = "eng"
+
+// DefaultMinConfidence is the confidence below which recognized lines are dropped;
+// Tesseract reads patterns in photographs as low-confidence gibberish
+const DefaultMinConfidence = 0.5
+
+// DefaultTimeout bounds a single recognition by an HTTP engine
+const DefaultTimeout = 60 * time.Second
+
+// Engine finds text in images
+type Engine interface {
+	// Name returns the engine name, EngineTesseract or EngineHTTP
+	Name() string
+	// Recognize returns the lines of text in an image, in reading order
+	Recognize(ctx context.Context, imageData []byte) ([]models.TextBlock, error)
+}
+
+// Config selects and configures an OCR engine
+type Config struct {
+	Engine        string        // EngineVision, EngineTesseract or EngineHTTP
+	TesseractPath string        // Tesseract binary (default "tesseract" on the PATH)
+	Languages     string        // Tesseract languages, e.g. "eng+deu" (default DefaultLanguages)
+	URL           string        // Recognition endpoint of an HTTP engine
+	Timeout       time.Duration // Timeout of an HTTP recognition (default DefaultTimeout)
+}
+
+// New returns the engine a configuration selects, nil for EngineVision or no engine
+func New(config Config) (Engine, error) {
+	switch config.Engine {
+	case "", EngineVision:
+		return nil, nil
+	case EngineTesseract:
+		return NewTesseract(config.TesseractPath, config.Languages)
+	case EngineHTTP:
+		return NewHTTP(config.URL, config.Timeout)
+	default:
+		return nil, fmt.Errorf("unknown OCR engine %q (want %s, %s or %s)", config.Engine, EngineVision, EngineTesseract, EngineHTTP)
+	}
+}
+
+// Filter returns the blocks whose confidence is at least minConfidence
+func Filter(blocks []models.TextBlock, minConfidence float64) []models.TextBlock {
+	kept := blocks[:0:0]
+	for _, b := range blocks {
+		if b.Confidence >= minConfidence && strings.TrimSpace(b.Text) != "" {
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
+
+// Text joins the text of blocks, one per line
+func Text(blocks []models.TextBlock) string {
+	lines := make([]string, len(blocks))
+	for i, b := range blocks {
+		lines[i] = b.Text
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,77 @@
+package ocr
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{"", EngineVision} {
+		if engine, err := New(Config{Engine: name}); engine != nil || err != nil {
+			t.Errorf("New(%q) = %v, %v; want no engine", name, engine, err)
+		}
+	}
+	if engine, err := New(Config{Engine: EngineHTTP, URL: "http://ocr:8000/recognize"}); err != nil || engine.Name() != EngineHTTP {
+		t.Errorf("New(http) = %v, %v", engine, err)
+	}
+	if _, err := New(Config{Engine: EngineHTTP, URL: "ocr:8000"}); err == nil {
+		t.Error("expected an error for an HTTP engine without a URL")
+	}
+	if _, err := New(Config{Engine: "paddle"}); err == nil {
+		t.Error("expected an error for an unknown engine")
+	}
+}
+
+func TestFilterAndText(t *testing.T) {
+	blocks := []models.TextBlock{
+		{Text: "Grand Opening", Confidence: 0.94},
+		{Text: "~#%", Confidence: 0.2},
+		{Text: " ", Confidence: 0.9},
+		{Text: "Saturday", Confidence: 0.6},
+	}
+	kept := Filter(blocks, 0.6)
+	if got := Text(kept); got != "Grand Opening\nSaturday" {
+		t.Errorf("Text = %q", got)
+	}
+	if len(blocks) != 4 || blocks[1].Text != "~#%" {
+		t.Error("Filter modified its argument")
+	}
+}
+
+func TestHTTPRecognize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || string(body) != "\x89PNG\r\n\x1a\n" || r.Header.Get("Content-Type") != "image/png" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"blocks": []map[string]any{
+			{"text": "EXIT", "confidence": 0.99, "bbox": map[string]int{"x": 4, "y": 8, "width": 60, "height": 20}},
+			{"text": "ONLY", "confidence": 1.7},
+		}})
+	}))
+	defer server.Close()
+
+	engine, err := NewHTTP(server.URL, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := engine.Recognize(context.Background(), []byte("\x89PNG\r\n\x1a\n"))
+	if err != nil {
+		t.Fatalf("Recognize failed: %v", err)
+	}
+	want := models.TextBlock{Text: "EXIT", Confidence: 0.99, BBox: models.BoundingBox{X: 4, Y: 8, Width: 60, Height: 20}}
+	if len(blocks) != 2 || blocks[0] != want || blocks[1].Confidence != 1 {
+		t.Errorf("blocks = %+v", blocks)
+	}
+
+	if _, err := engine.Recognize(context.Background(), []byte("not an image")); err == nil {
+		t.Error("expected an error for a failed request")
+	}
+}
@@ -0,0 +1,131 @@
+package ocr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/docutag/scraper/models"
+)
+
+// Tesseract runs a local Tesseract binary
+type Tesseract struct {
+	path      string
+	languages string
+}
+
+// NewTesseract returns an engine running the Tesseract binary at path (default
+// "tesseract" on the PATH) with languages such as "eng+deu" (default DefaultLanguages)
+// The binary must exist; the languages are only checked when an image is recognized.
+func NewTesseract(path, languages string) (*Tesseract, error) {
+	if path == "" {
+		path = "tesseract"
+	}
+	if languages == "" {
+		languages = DefaultLanguages
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("tesseract not found: %w", err)
+	}
+	return &Tesseract{path: resolved, languages: languages}, nil
+}
+
+// Name returns EngineTesseract
+func (t *Tesseract) Name() string {
+	return EngineTesseract
+}
+
+// Recognize pipes an image through Tesseract and returns one block per line
+func (t *Tesseract) Recognize(ctx context.Context, imageData []byte) ([]models.TextBlock, error) {
+	cmd := exec.CommandContext(ctx, t.path, "stdin", "stdout", "-l", t.languages, "tsv")
+	cmd.Stdin = bytes.NewReader(imageData)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("tesseract failed: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("tesseract failed: %w", err)
+	}
+	return parseTSV(&stdout)
+}
+
+// tsvLine is a line of words being assembled from Tesseract's TSV output
+type tsvLine struct {
+	words      []string
+	confidence float64
+	box        models.BoundingBox
+}
+
+// parseTSV reads Tesseract's TSV output, which has a row per page, block, paragraph,
+// line and word, into one block per line of words
+// A line's confidence is the mean of its words'.
+func parseTSV(r io.Reader) ([]models.TextBlock, error) {
+	var (
+		blocks  []models.TextBlock
+		current *tsvLine
+		key     string
+	)
+	flush := func() {
+		if current != nil && len(current.words) > 0 {
+			blocks = append(blocks, models.TextBlock{
+				Text:       strings.Join(current.words, " "),
+				Confidence: current.confidence / float64(len(current.words)) / 100,
+				BBox:       current.box,
+			})
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for header := true; scanner.Scan(); header = false {
+		if header {
+			continue // level page_num block_num par_num line_num word_num left top width height conf text
+		}
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 12 {
+			continue
+		}
+		var n [11]float64
+		for i := range n {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed tesseract output: %q", scanner.Text())
+			}
+			n[i] = v
+		}
+		box := models.BoundingBox{X: int(n[6]), Y: int(n[7]), Width: int(n[8]), Height: int(n[9])}
+
+		switch int(n[0]) {
+		case 4: // Line
+			flush()
+			key = strings.Join(fields[1:5], ".")
+			current = &tsvLine{box: box}
+		case 5: // Word
+			text := strings.TrimSpace(fields[11])
+			if text == "" || n[10] < 0 {
+				continue
+			}
+			if wordKey := strings.Join(fields[1:5], "."); current == nil || wordKey != key {
+				// A word without its line row; its own box stands in for the line's
+				flush()
+				key = wordKey
+				current = &tsvLine{box: box}
+			}
+			current.words = append(current.words, text)
+			current.confidence += n[10]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tesseract output: %w", err)
+	}
+	flush()
+	return blocks, nil
+}
@@ -0,0 +1,94 @@
+package ocr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+)
+
+// sampleTSV is Tesseract output for two lines, one with a word it could not read
+const sampleTSV = "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+	"1\t1\t0\t0\t0\t0\t0\t0\t400\t200\t-1\t\n" +
+	"2\t1\t1\t0\t0\t0\t10\t10\t300\t60\t-1\t\n" +
+	"3\t1\t1\t1\t0\t0\t10\t10\t300\t60\t-1\t\n" +
+	"4\t1\t1\t1\t1\t0\t10\t10\t300\t25\t-1\t\n" +
+	"5\t1\t1\t1\t1\t1\t10\t10\t120\t25\t96.5\tGrand\n" +
+	"5\t1\t1\t1\t1\t2\t140\t10\t170\t25\t91.5\tOpening\n" +
+	"4\t1\t1\t1\t2\t0\t10\t45\t200\t25\t-1\t\n" +
+	"5\t1\t1\t1\t2\t1\t10\t45\t90\t25\t88\tSaturday\n" +
+	"5\t1\t1\t1\t2\t2\t110\t45\t100\t25\t0\t \n" +
+	"4\t1\t2\t1\t1\t0\t10\t150\t50\t20\t-1\t\n"
+
+func TestParseTSV(t *testing.T) {
+	blocks, err := parseTSV(strings.NewReader(sampleTSV))
+	if err != nil {
+		t.Fatalf("parseTSV failed: %v", err)
+	}
+
+	want := []models.TextBlock{
+		{Text: "Grand Opening", Confidence: 0.94, BBox: models.BoundingBox{X: 10, Y: 10, Width: 300, Height: 25}},
+		{Text: "Saturday", Confidence: 0.88, BBox: models.BoundingBox{X: 10, Y: 45, Width: 200, Height: 25}},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("got %d blocks, want %d: %+v", len(blocks), len(want), blocks)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("block %d = %+v, want %+v", i, blocks[i], want[i])
+		}
+	}
+
+	if _, err := parseTSV(strings.NewReader("header\nfive\t1\t1\t1\t1\t1\t0\t0\t1\t1\t90\tword\n")); err == nil {
+		t.Error("expected an error for malformed output")
+	}
+}
+
+func TestTesseractRecognize(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of tesseract")
+	}
+	dir := t.TempDir()
+	tsv := filepath.Join(dir, "out.tsv")
+	args := filepath.Join(dir, "args")
+	if err := os.WriteFile(tsv, []byte(sampleTSV), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho \"$@\" > " + args + "\ncat > /dev/null\ncat " + tsv + "\n"
+	bin := filepath.Join(dir, "tesseract")
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	engine, err := NewTesseract(bin, "eng+deu")
+	if err != nil {
+		t.Fatalf("NewTesseract failed: %v", err)
+	}
+	blocks, err := engine.Recognize(context.Background(), []byte("image"))
+	if err != nil {
+		t.Fatalf("Recognize failed: %v", err)
+	}
+	if len(blocks) != 2 || blocks[0].Text != "Grand Opening" {
+		t.Errorf("blocks = %+v", blocks)
+	}
+	if got, _ := os.ReadFile(args); strings.TrimSpace(string(got)) != "stdin stdout -l eng+deu tsv" {
+		t.Errorf("tesseract arguments = %q", got)
+	}
+
+	failing := filepath.Join(dir, "failing")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho 'Failed loading language xyz' >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	engine, _ = NewTesseract(failing, "xyz")
+	if _, err := engine.Recognize(context.Background(), []byte("image")); err == nil || !strings.Contains(err.Error(), "Failed loading language") {
+		t.Errorf("error = %v, want tesseract's message", err)
+	}
+
+	if _, err := NewTesseract(filepath.Join(dir, "missing"), ""); err == nil {
+		t.Error("expected an error for a missing binary")
+	}
+}
@@ -0,0 +1,157 @@
+package ollama
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerCooldown         = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned when the circuit breaker is open and requests are fast-failed
+var ErrCircuitOpen = errors.New("ollama circuit breaker is open")
+
+// CircuitState represents the state of the circuit breaker
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // Requests flow normally
+	CircuitHalfOpen                     // A single trial request is allowed through
+	CircuitOpen                         // Requests are rejected until the cooldown elapses
+)
+
+// String returns the lowercase name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half-open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the state as its name so it reads well in JSON
+func (s CircuitState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// BreakerStats is a point-in-time snapshot of the circuit breaker
+type BreakerStats struct {
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	Rejected            uint64       `json:"rejected"` // Total requests fast-failed while open
+}
+
+// CircuitBreaker stops calls to Ollama after repeated failures so callers fall back
+// immediately instead of waiting for each request to time out
+type CircuitBreaker struct {
+	mu         sync.Mutex
+	state      CircuitState
+	failures   int
+	threshold  int
+	cooldown   time.Duration
+	openedAt   time.Time
+	halfOpenAt time.Time
+	rejected   uint64
+	now        func() time.Time
+}
+
+// NewCircuitBreaker creates a circuit breaker that opens after threshold consecutive
+// failures and allows a trial request once cooldown has elapsed
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerFailureThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &CircuitBreaker{
+		state:     CircuitClosed,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a request may proceed
+// While open, the first caller after the cooldown becomes the half-open trial; everyone
+// else is rejected until the trial reports back (or itself exceeds the cooldown)
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.openedAt) >= b.cooldown {
+			b.state = CircuitHalfOpen
+			b.halfOpenAt = now
+			return true
+		}
+	case CircuitHalfOpen:
+		// Don't get stuck if the trial request never reports (e.g. caller cancelled)
+		if now.Sub(b.halfOpenAt) >= b.cooldown {
+			b.halfOpenAt = now
+			return true
+		}
+	default:
+		return true
+	}
+
+	b.rejected++
+	return false
+}
+
+// RecordSuccess closes the breaker and resets the failure count
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+}
+
+// RecordFailure counts a failure, opening the breaker once the threshold is reached
+// A failed half-open trial reopens the breaker immediately
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// State returns the current breaker state
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Stats returns a snapshot of the breaker for health and metrics reporting
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := BreakerStats{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Rejected:            b.rejected,
+	}
+	if b.state != CircuitClosed {
+		openedAt := b.openedAt
+		stats.OpenedAt = &openedAt
+	}
+	return stats
+}
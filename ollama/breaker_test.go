@@ -0,0 +1,180 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b := NewCircuitBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		b.RecordFailure()
+		if b.State() != CircuitClosed {
+			t.Fatalf("after %d failures state = %s, want closed", i+1, b.State())
+		}
+	}
+
+	b.RecordFailure()
+	if b.State() != CircuitOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+	if b.Allow() {
+		t.Error("Allow() = true while open, want false")
+	}
+	if got := b.Stats().Rejected; got != 1 {
+		t.Errorf("Rejected = %d, want 1", got)
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	b := NewCircuitBreaker(2, time.Minute)
+
+	b.RecordFailure()
+	b.RecordSuccess()
+	b.RecordFailure()
+
+	if b.State() != CircuitClosed {
+		t.Errorf("state = %s, want closed (failures should not accumulate across a success)", b.State())
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker(1, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	b.RecordFailure()
+	if b.Allow() {
+		t.Fatal("Allow() = true before cooldown elapsed")
+	}
+
+	// After the cooldown exactly one trial is let through
+	now = now.Add(31 * time.Second)
+	if !b.Allow() {
+		t.Fatal("Allow() = false after cooldown, want trial request")
+	}
+	if b.State() != CircuitHalfOpen {
+		t.Fatalf("state = %s, want half-open", b.State())
+	}
+	if b.Allow() {
+		t.Error("Allow() = true for second caller while trial in flight")
+	}
+
+	// Failed trial reopens immediately
+	b.RecordFailure()
+	if b.State() != CircuitOpen {
+		t.Fatalf("state = %s, want open after failed trial", b.State())
+	}
+
+	// Successful trial closes
+	now = now.Add(31 * time.Second)
+	if !b.Allow() {
+		t.Fatal("Allow() = false after second cooldown")
+	}
+	b.RecordSuccess()
+	if b.State() != CircuitClosed {
+		t.Errorf("state = %s, want closed after successful trial", b.State())
+	}
+}
+
+func TestClientFastFailsWhenCircuitOpen(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Internal server error"))
+	}))
+	defer server.Close()
+
+	client := NewPoolClient("test-model", "", PoolConfig{
+		TextEndpoints:    []EndpointConfig{{BaseURL: server.URL}},
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.Generate(ctx, "test"); err == nil {
+			t.Fatal("Expected error from failing server")
+		}
+	}
+
+	_, err := client.Generate(ctx, "test")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("server received %d calls, want 2 (third should be fast-failed)", got)
+	}
+}
+
+func TestClientProbesBeforeTrialRequest(t *testing.T) {
+	var generateCalls, tagsCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			atomic.AddInt32(&tagsCalls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			atomic.AddInt32(&generateCalls, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := NewPoolClient("test-model", "", PoolConfig{
+		TextEndpoints:    []EndpointConfig{{BaseURL: server.URL}},
+		BreakerThreshold: 1,
+		BreakerCooldown:  10 * time.Second,
+	})
+	now := time.Now()
+	breaker := client.text.endpoints[0].breaker
+	breaker.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	client.Generate(ctx, "test") // Opens the breaker
+
+	now = now.Add(11 * time.Second)
+	_, err := client.Generate(ctx, "test")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen from failed probe", err)
+	}
+	if got := atomic.LoadInt32(&tagsCalls); got != 1 {
+		t.Errorf("probe calls = %d, want 1", got)
+	}
+	if got := atomic.LoadInt32(&generateCalls); got != 1 {
+		t.Errorf("generate calls = %d, want 1 (trial should not run after failed probe)", got)
+	}
+	if breaker.State() != CircuitOpen {
+		t.Errorf("state = %s, want open", breaker.State())
+	}
+}
+
+func TestClientCancellationDoesNotTripBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewPoolClient("test-model", "", PoolConfig{
+		TextEndpoints:    []EndpointConfig{{BaseURL: server.URL}},
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.Generate(ctx, "test"); err == nil {
+		t.Fatal("Expected error from cancelled context")
+	}
+	if state := client.text.endpoints[0].breaker.State(); state != CircuitClosed {
+		t.Errorf("state = %s, want closed", state)
+	}
+}
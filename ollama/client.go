@@ -11,39 +11,33 @@ import (
 	"strings"
 	"time"
 
+	"github.com/docutag/scraper/lang"
 	"github.com/docutag/scraper/models"
 )
 
 const (
-	DefaultBaseURL = "http://localhost:11434"
-	DefaultModel   = "llama3.2"
-	DefaultTimeout = 120 * time.Second
+	DefaultBaseURL      = "http://localhost:11434"
+	DefaultModel        = "llama3.2"
+	DefaultTimeout      = 120 * time.Second
+	DefaultProbeTimeout = 5 * time.Second
 )
 
 // Client is a client for interacting with Ollama
+// Requests are routed across a pool of endpoints; text and vision models have separate pools
 type Client struct {
-	baseURL     string
 	httpClient  *http.Client
 	model       string
 	visionModel string
+	text        *pool
+	vision      *pool
+	endpoints   []*endpoint // All unique endpoints across both pools
+	observer    RequestObserver
+	language    string // Language code of the content being processed, if known
 }
 
 // NewClient creates a new Ollama client
 func NewClient(baseURL, model string) *Client {
-	if baseURL == "" {
-		baseURL = DefaultBaseURL
-	}
-	if model == "" {
-		model = DefaultModel
-	}
-	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: DefaultTimeout,
-		},
-		model:       model,
-		visionModel: model, // Default to same model for backward compatibility
-	}
+	return NewClientWithVisionModel(baseURL, model, model)
 }
 
 // NewClientWithVisionModel creates a new Ollama client with separate vision model
@@ -51,19 +45,181 @@ func NewClientWithVisionModel(baseURL, model, visionModel string) *Client {
 	if baseURL == "" {
 		baseURL = DefaultBaseURL
 	}
+	return NewPoolClient(model, visionModel, PoolConfig{
+		TextEndpoints: []EndpointConfig{{BaseURL: baseURL}},
+	})
+}
+
+// NewPoolClient creates an Ollama client that balances requests across multiple endpoints
+func NewPoolClient(model, visionModel string, config PoolConfig) *Client {
 	if model == "" {
 		model = DefaultModel
 	}
 	if visionModel == "" {
 		visionModel = model // Fall back to text model if not specified
 	}
+	text, vision, endpoints := buildPools(config)
 	return &Client{
-		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: DefaultTimeout,
+			Timeout:   DefaultTimeout,
+			Transport: config.Transport,
 		},
 		model:       model,
 		visionModel: visionModel,
+		text:        text,
+		vision:      vision,
+		endpoints:   endpoints,
+	}
+}
+
+// SetObserver registers a callback invoked after every endpoint request attempt
+func (c *Client) SetObserver(observer RequestObserver) {
+	c.observer = observer
+}
+
+// Model returns the text model name
+func (c *Client) Model() string {
+	return c.model
+}
+
+// VisionModel returns the vision model name
+func (c *Client) VisionModel() string {
+	return c.visionModel
+}
+
+// WithModels returns a client that uses different models but shares this client's
+// endpoints, concurrency limits and circuit breakers
+// Empty names keep the current model
+func (c *Client) WithModels(model, visionModel string) *Client {
+	if (model == "" || model == c.model) && (visionModel == "" || visionModel == c.visionModel) {
+		return c
+	}
+	clone := *c
+	if model != "" {
+		clone.model = model
+	}
+	if visionModel != "" {
+		clone.visionModel = visionModel
+	}
+	return &clone
+}
+
+// WithLanguage returns a client whose prompts tell the model which language the
+// content is written in, sharing this client's endpoints and breakers
+// An empty code leaves the language unspecified
+func (c *Client) WithLanguage(code string) *Client {
+	if code == c.language {
+		return c
+	}
+	clone := *c
+	clone.language = code
+	return &clone
+}
+
+// Language returns the language code set with WithLanguage
+func (c *Client) Language() string {
+	return c.language
+}
+
+// languageNote returns a prompt sentence naming the content language, or "" if unknown
+func (c *Client) languageNote(format string) string {
+	if c.language == "" {
+		return ""
+	}
+	return fmt.Sprintf(format, lang.Name(c.language))
+}
+
+// Capacity returns the total number of concurrent requests the endpoints accept
+func (c *Client) Capacity() int {
+	total := 0
+	for _, ep := range c.endpoints {
+		total += cap(ep.slots)
+	}
+	return total
+}
+
+// Endpoints returns a snapshot of every endpoint for health and metrics reporting
+func (c *Client) Endpoints() []EndpointStats {
+	stats := make([]EndpointStats, 0, len(c.endpoints))
+	for _, ep := range c.endpoints {
+		stats = append(stats, ep.stats())
+	}
+	return stats
+}
+
+// send POSTs body to path on an endpoint from the pool, failing over to the next
+// endpoint on transport errors and 5xx responses
+// Endpoints whose circuit breaker is open are skipped; if every endpoint is ejected this
+// fails fast with ErrCircuitOpen. When an endpoint's cooldown has elapsed a cheap
+// /api/tags probe runs first so a dead server is detected in seconds rather than after a
+// full generation timeout.
+func (c *Client) send(ctx context.Context, p *pool, path string, body []byte) (*http.Response, error) {
+	lastErr := ErrCircuitOpen
+
+	for _, ep := range p.candidates() {
+		if !ep.breaker.Allow() {
+			continue
+		}
+
+		if ep.breaker.State() == CircuitHalfOpen {
+			probeCtx, cancel := context.WithTimeout(ctx, DefaultProbeTimeout)
+			_, err := c.listModels(probeCtx, ep.baseURL)
+			cancel()
+			if err != nil {
+				ep.breaker.RecordFailure()
+				lastErr = fmt.Errorf("%w: probe of %s failed: %v", ErrCircuitOpen, ep.baseURL, err)
+				continue
+			}
+		}
+
+		if err := ep.acquire(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", ep.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			ep.release()
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		start := time.Now()
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			ep.release()
+			// A caller cancelling its own context says nothing about Ollama's health
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			ep.breaker.RecordFailure()
+			c.observe(p, ep, start, err)
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			respBody, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			ep.release()
+			lastErr = fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(respBody))
+			ep.breaker.RecordFailure()
+			c.observe(p, ep, start, lastErr)
+			continue
+		}
+
+		ep.breaker.RecordSuccess()
+		c.observe(p, ep, start, nil)
+		resp.Body = &releasingBody{ReadCloser: resp.Body, release: ep.release}
+		return resp, nil
+	}
+
+	return nil, lastErr
+}
+
+// observe reports a request attempt to the observer, if any
+func (c *Client) observe(p *pool, ep *endpoint, start time.Time, err error) {
+	if c.observer != nil {
+		c.observer(p.name, ep.baseURL, time.Since(start), err)
 	}
 }
 
@@ -80,13 +236,7 @@ func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(ctx, c.text, "/api/generate", jsonData)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
@@ -122,13 +272,7 @@ func (c *Client) GenerateWithVision(ctx context.Context, prompt string, imageDat
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(ctx, c.vision, "/api/generate", jsonData)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
@@ -151,18 +295,19 @@ func (c *Client) GenerateWithVision(ctx context.Context, prompt string, imageDat
 func (c *Client) ExtractContent(ctx context.Context, rawText string) (string, error) {
 	prompt := fmt.Sprintf(`You are a content extraction assistant. Given the following text extracted from a webpage, identify and return ONLY the meaningful human-readable content. Remove advertisements, navigation menus, footers, cookie notices, social media widgets, and other non-essential elements.
 
-Return only the main content that a human would want to read. Do not add any commentary or explanations.
+Return only the main content that a human would want to read. Do not add any commentary or explanations.%s
 
 Text:
 %s
 
-Extracted content:`, rawText)
+Extracted content:`, c.languageNote(" The text is written in %s; return the content in %[1]s and do not translate it."), rawText)
 
 	return c.Generate(ctx, prompt)
 }
 
 // AnalyzeImage uses Ollama vision to generate a summary and tags for an image
-func (c *Client) AnalyzeImage(ctx context.Context, imageData []byte, altText string) (summary string, tags []string, err error) {
+// altText and caption, from the image's <figcaption>, are passed as context when set.
+func (c *Client) AnalyzeImage(ctx context.Context, imageData []byte, altText, caption string) (summary string, tags []string, err error) {
 	prompt := `Analyze this image and provide:
 1. A 4-5 sentence summary describing what you see
 2. A list of up to 10 relevant tags for categorizing the image
@@ -183,6 +328,9 @@ Format your response as JSON with the following structure:
 	if altText != "" {
 		prompt += fmt.Sprintf("\n\nImage alt text (may provide context): %s", altText)
 	}
+	if caption != "" {
+		prompt += fmt.Sprintf("\n\nImage caption (may provide context): %s", caption)
+	}
 
 	response, err := c.GenerateWithVision(ctx, prompt, imageData)
 	if err != nil {
@@ -233,6 +381,62 @@ Rules:
 	return response, nil
 }
 
+// SafetyCategories are the unsafe content categories ClassifyImageSafety rates
+var SafetyCategories = []string{"sexual", "nudity", "violence", "gore", "self-harm", "hate", "drugs"}
+
+// ClassifyImageSafety uses Ollama vision to rate an image against SafetyCategories
+// Returns each category's confidence (0.0-1.0). Unlike AnalyzeImage, an unparseable
+// response is an error, since the image's safety is then unknown.
+func (c *Client) ClassifyImageSafety(ctx context.Context, imageData []byte) (map[string]float64, error) {
+	prompt := `You are a content moderation assistant. Rate how confident you are that this image contains each of the following kinds of content, from 0.0 (certainly not) to 1.0 (certainly):
+
+- sexual: sexual activity or sexually explicit content
+- nudity: exposed genitals, buttocks or female nipples, including non-sexual nudity
+- violence: people or animals being harmed, weapons used against people
+- gore: blood, injuries, mutilation or dead bodies
+- self-harm: self-injury, suicide or eating disorders
+- hate: hate symbols, slurs or extremist propaganda
+- drugs: illegal drug use or paraphernalia
+
+Ordinary news, medical, historical and artistic imagery should be rated by what is actually shown.
+
+Format your response as JSON with a number for every category:
+{"sexual": 0.0, "nudity": 0.0, "violence": 0.0, "gore": 0.0, "self-harm": 0.0, "hate": 0.0, "drugs": 0.0}`
+
+	response, err := c.GenerateWithVision(ctx, prompt, imageData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to classify image safety: %w", err)
+	}
+
+	var ratings map[string]float64
+	if err := json.Unmarshal([]byte(StripMarkdownCodeBlocks(response)), &ratings); err != nil {
+		return nil, fmt.Errorf("failed to parse safety classification: %w", err)
+	}
+
+	categories := make(map[string]float64, len(SafetyCategories))
+	for _, category := range SafetyCategories {
+		categories[category] = max(0, min(ratings[category], 1))
+	}
+	return categories, nil
+}
+
+// Translate uses Ollama to translate text into the target language
+// The source language is the client's language when set, otherwise the model infers it
+func (c *Client) Translate(ctx context.Context, text, target string) (string, error) {
+	prompt := fmt.Sprintf(`You are a translation assistant. Translate the following %stext into %s. Preserve paragraph breaks, names, numbers and URLs. Return ONLY the translation without any commentary or explanations.
+
+Text:
+%s
+
+Translation:`, c.languageNote("%s "), lang.Name(target), text)
+
+	response, err := c.Generate(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to translate content: %w", err)
+	}
+	return strings.TrimSpace(response), nil
+}
+
 // normalizeTag normalizes a tag according to the tagging rules:
 // - Converts to lowercase
 // - Replaces spaces and underscores with hyphens
@@ -293,7 +497,7 @@ func (c *Client) ScoreContent(ctx context.Context, url string, title string, con
 URL: %s
 Title: %s
 Content Preview: %s
-
+%s
 Evaluate the content and assign a quality score from 0.0 to 1.0 where:
 - 1.0 = High quality, substantive content (articles, research, documentation, guides)
 - 0.5-0.9 = Moderate quality content
@@ -335,7 +539,8 @@ Categories should include any applicable labels: "social_media", "gambling", "ad
 Malicious indicators should list any suspicious patterns detected: "phishing", "malware", "scam", "misleading", etc.`,
 		url,
 		truncateString(title, 200),
-		truncateString(content, 1000))
+		truncateString(content, 1000),
+		c.languageNote("Language: %s (judge quality regardless of language; do not lower the score because the content is not in English)\n"))
 
 	response, err := c.Generate(ctx, prompt)
 	if err != nil {
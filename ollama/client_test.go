@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,8 +39,8 @@ func TestNewClient(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			client := NewClient(tt.baseURL, tt.model)
-			if client.baseURL != tt.wantBaseURL {
-				t.Errorf("baseURL = %s, want %s", client.baseURL, tt.wantBaseURL)
+			if got := client.text.endpoints[0].baseURL; got != tt.wantBaseURL {
+				t.Errorf("baseURL = %s, want %s", got, tt.wantBaseURL)
 			}
 			if client.model != tt.wantModel {
 				t.Errorf("model = %s, want %s", client.model, tt.wantModel)
@@ -156,6 +157,48 @@ func TestExtractContent(t *testing.T) {
 	}
 }
 
+func TestWithLanguagePrompts(t *testing.T) {
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompts = append(prompts, req.Prompt)
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "Der Rat hat den Haushalt beschlossen.", Done: true})
+	}))
+	defer server.Close()
+
+	base := NewClient(server.URL, "test-model")
+	client := base.WithLanguage("de")
+	if base.Language() != "" || client.Language() != "de" {
+		t.Fatalf("WithLanguage must not change the original client: base %q, clone %q", base.Language(), client.Language())
+	}
+
+	ctx := context.Background()
+	if _, err := client.ExtractContent(ctx, "Der Rat hat den Haushalt beschlossen."); err != nil {
+		t.Fatalf("ExtractContent failed: %v", err)
+	}
+	if _, err := base.ExtractContent(ctx, "The council approved the budget."); err != nil {
+		t.Fatalf("ExtractContent failed: %v", err)
+	}
+	translated, err := client.Translate(ctx, "Der Rat hat den Haushalt beschlossen.", "en")
+	if err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if translated != "Der Rat hat den Haushalt beschlossen." {
+		t.Errorf("unexpected translation %q", translated)
+	}
+
+	if !strings.Contains(prompts[0], "The text is written in German; return the content in German") {
+		t.Errorf("extraction prompt does not name the language:\n%s", prompts[0])
+	}
+	if strings.Contains(prompts[1], "written in") {
+		t.Errorf("extraction prompt names a language although none is set:\n%s", prompts[1])
+	}
+	if !strings.Contains(prompts[2], "Translate the following German text into English") {
+		t.Errorf("unexpected translation prompt:\n%s", prompts[2])
+	}
+}
+
 func TestAnalyzeImage(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify it's a vision request with images
@@ -182,7 +225,7 @@ func TestAnalyzeImage(t *testing.T) {
 	ctx := context.Background()
 
 	imageData := []byte("fake image data")
-	summary, tags, err := client.AnalyzeImage(ctx, imageData, "alt text")
+	summary, tags, err := client.AnalyzeImage(ctx, imageData, "alt text", "")
 	if err != nil {
 		t.Fatalf("AnalyzeImage failed: %v", err)
 	}
@@ -212,7 +255,7 @@ func TestAnalyzeImageInvalidJSON(t *testing.T) {
 	ctx := context.Background()
 
 	imageData := []byte("fake image data")
-	summary, tags, err := client.AnalyzeImage(ctx, imageData, "")
+	summary, tags, err := client.AnalyzeImage(ctx, imageData, "", "")
 
 	// Should not error, but return the raw response
 	if err != nil {
@@ -243,7 +286,7 @@ func TestAnalyzeImageWithMarkdownWrappedJSON(t *testing.T) {
 	ctx := context.Background()
 
 	imageData := []byte("fake image data")
-	summary, tags, err := client.AnalyzeImage(ctx, imageData, "")
+	summary, tags, err := client.AnalyzeImage(ctx, imageData, "", "")
 
 	if err != nil {
 		t.Fatalf("AnalyzeImage failed: %v", err)
@@ -538,3 +581,38 @@ func TestExtractTextFromImageContextCancellation(t *testing.T) {
 		t.Error("Expected timeout error, got nil")
 	}
 }
+
+func TestClassifyImageSafety(t *testing.T) {
+	response := "```json\n{\"sexual\": 0.92, \"violence\": 1.4, \"gore\": -0.1, \"weather\": 0.8}\n```"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaVisionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Images) == 0 {
+			t.Errorf("expected a vision request with an image (err %v)", err)
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: response, Done: true})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-model")
+	categories, err := client.ClassifyImageSafety(context.Background(), []byte("fake image data"))
+	if err != nil {
+		t.Fatalf("ClassifyImageSafety failed: %v", err)
+	}
+
+	if len(categories) != len(SafetyCategories) {
+		t.Errorf("got %d categories, want %d: %v", len(categories), len(SafetyCategories), categories)
+	}
+	for category, want := range map[string]float64{"sexual": 0.92, "violence": 1, "gore": 0, "drugs": 0} {
+		if categories[category] != want {
+			t.Errorf("%s = %v, want %v", category, categories[category], want)
+		}
+	}
+	if _, ok := categories["weather"]; ok {
+		t.Error("unknown category was kept")
+	}
+
+	response = "The image looks fine"
+	if _, err := client.ClassifyImageSafety(context.Background(), []byte("fake image data")); err == nil {
+		t.Error("expected an error for an unparseable classification")
+	}
+}
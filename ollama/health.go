@@ -0,0 +1,264 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/docutag/scraper/models"
+)
+
+// EndpointHealth reports reachability and model availability for a single endpoint
+type EndpointHealth struct {
+	EndpointStats
+	Reachable     bool     `json:"reachable"`
+	Error         string   `json:"error,omitempty"`
+	Models        []string `json:"models,omitempty"`         // All models installed on the endpoint
+	MissingModels []string `json:"missing_models,omitempty"` // Configured models the endpoint's pools need but lacks
+	Pulled        []string `json:"pulled,omitempty"`         // Models pulled during this check
+}
+
+// HealthStatus describes Ollama reachability, model availability and circuit breaker state
+// A model is available if at least one endpoint in its pool is reachable and has it installed
+type HealthStatus struct {
+	Reachable            bool             `json:"reachable"`
+	TextModel            string           `json:"text_model"`
+	TextModelAvailable   bool             `json:"text_model_available"`
+	VisionModel          string           `json:"vision_model"`
+	VisionModelAvailable bool             `json:"vision_model_available"`
+	Endpoints            []EndpointHealth `json:"endpoints"`
+}
+
+// Degraded reports whether any endpoint is unreachable, ejected or missing a model
+func (h HealthStatus) Degraded() bool {
+	if !h.TextModelAvailable || !h.VisionModelAvailable {
+		return true
+	}
+	for _, ep := range h.Endpoints {
+		if !ep.Reachable || ep.Circuit.State != CircuitClosed || len(ep.MissingModels) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TextDegraded is Degraded for deployments that do not analyze images: only the text
+// model and the endpoints in the text pool are checked
+func (h HealthStatus) TextDegraded() bool {
+	if !h.TextModelAvailable {
+		return true
+	}
+	for _, ep := range h.Endpoints {
+		if !containsString(ep.Pools, PoolText) {
+			continue
+		}
+		if !ep.Reachable || ep.Circuit.State != CircuitClosed || containsString(ep.MissingModels, h.TextModel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListModels returns the names of the models installed on any endpoint (/api/tags)
+// It fails only if no endpoint can be reached
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	var names []string
+	var lastErr error
+	seen := make(map[string]bool)
+	reached := false
+
+	for _, ep := range c.endpoints {
+		models, err := c.listModels(ctx, ep.baseURL)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		reached = true
+		for _, m := range models {
+			if !seen[m] {
+				seen[m] = true
+				names = append(names, m)
+			}
+		}
+	}
+
+	if !reached {
+		return nil, lastErr
+	}
+	return names, nil
+}
+
+// listModels returns the models installed on a single endpoint
+// This bypasses the circuit breaker so it can be used as a probe
+func (c *Client) listModels(ctx context.Context, baseURL string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tagsResp models.OllamaTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tagsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	names := make([]string, 0, len(tagsResp.Models))
+	for _, m := range tagsResp.Models {
+		names = append(names, m.Name)
+	}
+	return names, nil
+}
+
+// pullModel downloads a model onto an endpoint (/api/pull)
+// Pulls can take many minutes, so the deadline is taken from ctx rather than the client timeout
+func (c *Client) pullModel(ctx context.Context, baseURL, model string) error {
+	reqBody := models.OllamaPullRequest{
+		Model:  model,
+		Stream: false,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/pull", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	pullClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := pullClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var pullResp models.OllamaPullResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pullResp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if pullResp.Error != "" {
+		return fmt.Errorf("failed to pull model %s: %s", model, pullResp.Error)
+	}
+
+	return nil
+}
+
+// CheckModels checks every endpoint for the models its pools need
+// If autoPull is true, missing models are pulled onto the endpoint; pull failures are
+// reported in the endpoint's Error and the model stays in MissingModels
+func (c *Client) CheckModels(ctx context.Context, autoPull bool) HealthStatus {
+	health := HealthStatus{
+		TextModel:   c.model,
+		VisionModel: c.visionModel,
+	}
+
+	for _, ep := range c.endpoints {
+		epHealth := EndpointHealth{EndpointStats: ep.stats()}
+
+		installed, err := c.listModels(ctx, ep.baseURL)
+		if err != nil {
+			epHealth.Error = err.Error()
+			health.Endpoints = append(health.Endpoints, epHealth)
+			continue
+		}
+		epHealth.Reachable = true
+		epHealth.Models = installed
+		health.Reachable = true
+
+		for _, model := range c.modelsFor(ep) {
+			if hasModel(installed, model) {
+				continue
+			}
+			if autoPull {
+				if err := c.pullModel(ctx, ep.baseURL, model); err != nil {
+					epHealth.Error = err.Error()
+				} else {
+					epHealth.Pulled = append(epHealth.Pulled, model)
+					continue
+				}
+			}
+			epHealth.MissingModels = append(epHealth.MissingModels, model)
+		}
+
+		for _, name := range ep.pools {
+			available := !containsString(epHealth.MissingModels, c.poolModel(name))
+			if name == PoolText && available {
+				health.TextModelAvailable = true
+			}
+			if name == PoolVision && available {
+				health.VisionModelAvailable = true
+			}
+		}
+
+		health.Endpoints = append(health.Endpoints, epHealth)
+	}
+
+	return health
+}
+
+// Health checks Ollama reachability and model availability on every endpoint
+// It never returns an error; failures are reported in the returned status
+func (c *Client) Health(ctx context.Context) HealthStatus {
+	return c.CheckModels(ctx, false)
+}
+
+// modelsFor returns the distinct models an endpoint must serve for its pools
+func (c *Client) modelsFor(ep *endpoint) []string {
+	var needed []string
+	for _, name := range ep.pools {
+		model := c.poolModel(name)
+		if !containsString(needed, model) {
+			needed = append(needed, model)
+		}
+	}
+	return needed
+}
+
+// poolModel returns the model served by the named pool
+func (c *Client) poolModel(name string) string {
+	if name == PoolVision {
+		return c.visionModel
+	}
+	return c.model
+}
+
+// hasModel reports whether name is in the list of installed models
+// Ollama reports untagged models with an explicit ":latest" tag
+func hasModel(available []string, name string) bool {
+	if !strings.Contains(name, ":") {
+		name += ":latest"
+	}
+	return containsString(available, name)
+}
+
+// containsString reports whether slice contains s
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+)
+
+// newModelServer returns a mock Ollama server with the given models installed
+// Pulled models are added to the installed list
+func newModelServer(t *testing.T, installed ...string) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var pulled []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/tags":
+			resp := models.OllamaTagsResponse{}
+			for _, name := range installed {
+				resp.Models = append(resp.Models, models.OllamaModel{Name: name, Model: name})
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/api/pull":
+			var req models.OllamaPullRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Stream {
+				t.Error("Expected non-streaming pull request")
+			}
+			pulled = append(pulled, req.Model)
+			installed = append(installed, req.Model)
+			json.NewEncoder(w).Encode(models.OllamaPullResponse{Status: "success"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &pulled
+}
+
+func TestListModels(t *testing.T) {
+	server, _ := newModelServer(t, "llama3.2:latest", "llava:13b")
+	client := NewClient(server.URL, "llama3.2")
+
+	names, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(names) != 2 || names[0] != "llama3.2:latest" || names[1] != "llava:13b" {
+		t.Errorf("ListModels = %v", names)
+	}
+}
+
+func TestCheckModels(t *testing.T) {
+	tests := []struct {
+		name        string
+		installed   []string
+		model       string
+		visionModel string
+		autoPull    bool
+		wantText    bool
+		wantVision  bool
+		wantPulled  []string
+	}{
+		{
+			name:        "untagged model matches latest",
+			installed:   []string{"llama3.2:latest"},
+			model:       "llama3.2",
+			visionModel: "llama3.2",
+			wantText:    true,
+			wantVision:  true,
+		},
+		{
+			name:        "missing vision model without auto-pull",
+			installed:   []string{"llama3.2:latest"},
+			model:       "llama3.2",
+			visionModel: "llava:13b",
+			wantText:    true,
+			wantVision:  false,
+		},
+		{
+			name:        "auto-pull missing models",
+			installed:   []string{},
+			model:       "llama3.2",
+			visionModel: "llava:13b",
+			autoPull:    true,
+			wantText:    true,
+			wantVision:  true,
+			wantPulled:  []string{"llama3.2", "llava:13b"},
+		},
+		{
+			name:        "auto-pull shared model once",
+			installed:   []string{},
+			model:       "llama3.2",
+			visionModel: "llama3.2",
+			autoPull:    true,
+			wantText:    true,
+			wantVision:  true,
+			wantPulled:  []string{"llama3.2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, pulled := newModelServer(t, tt.installed...)
+			client := NewClientWithVisionModel(server.URL, tt.model, tt.visionModel)
+
+			status := client.CheckModels(context.Background(), tt.autoPull)
+			if !status.Reachable {
+				t.Fatalf("CheckModels unreachable: %+v", status.Endpoints)
+			}
+			if status.TextModelAvailable != tt.wantText {
+				t.Errorf("TextModelAvailable = %v, want %v", status.TextModelAvailable, tt.wantText)
+			}
+			if status.VisionModelAvailable != tt.wantVision {
+				t.Errorf("VisionModelAvailable = %v, want %v", status.VisionModelAvailable, tt.wantVision)
+			}
+			if len(*pulled) != len(tt.wantPulled) {
+				t.Fatalf("pulled = %v, want %v", *pulled, tt.wantPulled)
+			}
+			for i := range tt.wantPulled {
+				if (*pulled)[i] != tt.wantPulled[i] {
+					t.Errorf("pulled[%d] = %s, want %s", i, (*pulled)[i], tt.wantPulled[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHealthUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close() // Closed server refuses connections
+
+	client := NewClient(server.URL, "llama3.2")
+	health := client.Health(context.Background())
+
+	if health.Reachable {
+		t.Error("Expected Reachable = false")
+	}
+	if len(health.Endpoints) != 1 || health.Endpoints[0].Error == "" {
+		t.Fatalf("Expected one endpoint with Error set, got %+v", health.Endpoints)
+	}
+	if health.TextModel != "llama3.2" {
+		t.Errorf("TextModel = %s, want llama3.2", health.TextModel)
+	}
+	if state := health.Endpoints[0].Circuit.State; state != CircuitClosed {
+		t.Errorf("Circuit.State = %s, want closed", state)
+	}
+	if !health.Degraded() {
+		t.Error("Expected Degraded() = true")
+	}
+}
+
+func TestTextDegraded(t *testing.T) {
+	healthy := EndpointHealth{EndpointStats: EndpointStats{Pools: []string{PoolText}}, Reachable: true}
+	visionDown := EndpointHealth{EndpointStats: EndpointStats{Pools: []string{PoolVision}}}
+	sharedMissingVision := EndpointHealth{EndpointStats: EndpointStats{Pools: []string{PoolText, PoolVision}}, Reachable: true, MissingModels: []string{"llava"}}
+	sharedMissingText := EndpointHealth{EndpointStats: EndpointStats{Pools: []string{PoolText, PoolVision}}, Reachable: true, MissingModels: []string{"llama3.2"}}
+
+	tests := []struct {
+		name      string
+		endpoints []EndpointHealth
+		available bool
+		want      bool
+	}{
+		{"healthy", []EndpointHealth{healthy}, true, false},
+		{"vision endpoint down", []EndpointHealth{healthy, visionDown}, true, false},
+		{"vision model missing", []EndpointHealth{sharedMissingVision}, true, false},
+		{"text model missing", []EndpointHealth{healthy, sharedMissingText}, true, true},
+		{"text model unavailable", []EndpointHealth{healthy}, false, true},
+	}
+	for _, tt := range tests {
+		h := HealthStatus{TextModel: "llama3.2", TextModelAvailable: tt.available, VisionModel: "llava", Endpoints: tt.endpoints}
+		if got := h.TextDegraded(); got != tt.want {
+			t.Errorf("%s: TextDegraded() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package ollama
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxConcurrent is the default number of concurrent requests sent to each endpoint
+const DefaultMaxConcurrent = 3
+
+// RoutingStrategy selects which endpoint in a pool receives the next request
+type RoutingStrategy string
+
+const (
+	RoutingLeastLoaded RoutingStrategy = "least-loaded" // Endpoint with the lowest in-flight/capacity ratio
+	RoutingRoundRobin  RoutingStrategy = "round-robin"  // Rotate through endpoints in order
+)
+
+// Pool names used in health reports and metrics
+const (
+	PoolText   = "text"
+	PoolVision = "vision"
+)
+
+// EndpointConfig configures a single Ollama server
+type EndpointConfig struct {
+	BaseURL       string
+	MaxConcurrent int // Maximum concurrent requests to this endpoint (0 = DefaultMaxConcurrent)
+}
+
+// PoolConfig configures the Ollama endpoints used by a Client
+type PoolConfig struct {
+	TextEndpoints    []EndpointConfig
+	VisionEndpoints  []EndpointConfig  // Defaults to TextEndpoints if empty
+	Routing          RoutingStrategy   // Defaults to RoutingLeastLoaded
+	BreakerThreshold int               // Consecutive failures before an endpoint is ejected
+	BreakerCooldown  time.Duration     // How long an endpoint stays ejected before probing
+	Transport        http.RoundTripper // Defaults to http.DefaultTransport
+}
+
+// RequestObserver is notified after every request attempt to an endpoint
+// err is non-nil for transport failures and 5xx responses
+type RequestObserver func(pool, endpoint string, duration time.Duration, err error)
+
+// EndpointStats is a point-in-time snapshot of an endpoint for health and metrics reporting
+type EndpointStats struct {
+	BaseURL       string       `json:"base_url"`
+	Pools         []string     `json:"pools"`
+	InFlight      int          `json:"in_flight"`
+	MaxConcurrent int          `json:"max_concurrent"`
+	Circuit       BreakerStats `json:"circuit_breaker"`
+}
+
+// endpoint is a single Ollama server with its own concurrency limit and circuit breaker
+type endpoint struct {
+	baseURL  string
+	slots    chan struct{}
+	inFlight int64
+	breaker  *CircuitBreaker
+	pools    []string
+}
+
+// acquire waits for a free request slot on the endpoint
+func (e *endpoint) acquire(ctx context.Context) error {
+	select {
+	case e.slots <- struct{}{}:
+		atomic.AddInt64(&e.inFlight, 1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a request slot on the endpoint
+func (e *endpoint) release() {
+	atomic.AddInt64(&e.inFlight, -1)
+	<-e.slots
+}
+
+// load returns the fraction of the endpoint's capacity currently in use
+func (e *endpoint) load() float64 {
+	return float64(atomic.LoadInt64(&e.inFlight)) / float64(cap(e.slots))
+}
+
+// stats returns a snapshot of the endpoint
+func (e *endpoint) stats() EndpointStats {
+	return EndpointStats{
+		BaseURL:       e.baseURL,
+		Pools:         e.pools,
+		InFlight:      int(atomic.LoadInt64(&e.inFlight)),
+		MaxConcurrent: cap(e.slots),
+		Circuit:       e.breaker.Stats(),
+	}
+}
+
+// pool is a set of endpoints serving one kind of model
+type pool struct {
+	name      string
+	endpoints []*endpoint
+	routing   RoutingStrategy
+	cursor    uint64
+}
+
+// candidates returns the pool's endpoints in the order they should be tried
+func (p *pool) candidates() []*endpoint {
+	n := len(p.endpoints)
+	start := int(atomic.AddUint64(&p.cursor, 1)-1) % n
+
+	// Rotate so ties (and round-robin) spread across endpoints
+	ordered := make([]*endpoint, 0, n)
+	for i := 0; i < n; i++ {
+		ordered = append(ordered, p.endpoints[(start+i)%n])
+	}
+
+	if p.routing != RoutingRoundRobin {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].load() < ordered[j].load()
+		})
+	}
+
+	return ordered
+}
+
+// releasingBody frees the endpoint slot once the response body is closed,
+// so the slot covers the whole generation rather than just the headers
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// buildPools creates the text and vision pools, sharing endpoints that appear in both
+// so a host's concurrency limit applies across model types
+func buildPools(config PoolConfig) (*pool, *pool, []*endpoint) {
+	routing := config.Routing
+	if routing == "" {
+		routing = RoutingLeastLoaded
+	}
+
+	textConfigs := config.TextEndpoints
+	if len(textConfigs) == 0 {
+		textConfigs = []EndpointConfig{{BaseURL: DefaultBaseURL}}
+	}
+	visionConfigs := config.VisionEndpoints
+	if len(visionConfigs) == 0 {
+		visionConfigs = textConfigs
+	}
+
+	byURL := make(map[string]*endpoint)
+	var all []*endpoint
+	build := func(name string, configs []EndpointConfig) *pool {
+		p := &pool{name: name, routing: routing}
+		for _, ec := range configs {
+			baseURL := strings.TrimRight(ec.BaseURL, "/")
+			if baseURL == "" {
+				baseURL = DefaultBaseURL
+			}
+			ep, ok := byURL[baseURL]
+			if !ok {
+				maxConcurrent := ec.MaxConcurrent
+				if maxConcurrent <= 0 {
+					maxConcurrent = DefaultMaxConcurrent
+				}
+				ep = &endpoint{
+					baseURL: baseURL,
+					slots:   make(chan struct{}, maxConcurrent),
+					breaker: NewCircuitBreaker(config.BreakerThreshold, config.BreakerCooldown),
+				}
+				byURL[baseURL] = ep
+				all = append(all, ep)
+			}
+			if len(ep.pools) == 0 || ep.pools[len(ep.pools)-1] != name {
+				ep.pools = append(ep.pools, name)
+			}
+			p.endpoints = append(p.endpoints, ep)
+		}
+		return p
+	}
+
+	text := build(PoolText, textConfigs)
+	vision := build(PoolVision, visionConfigs)
+	return text, vision, all
+}
+
+// ParseEndpoints parses a comma-separated list of Ollama base URLs
+// Each URL may carry a per-endpoint concurrency limit as "url=N", e.g.
+// "http://gpu1:11434=4,http://gpu2:11434". Endpoints without a limit use defaultMaxConcurrent.
+func ParseEndpoints(spec string, defaultMaxConcurrent int) ([]EndpointConfig, error) {
+	var endpoints []EndpointConfig
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		ec := EndpointConfig{BaseURL: entry, MaxConcurrent: defaultMaxConcurrent}
+		if idx := strings.LastIndex(entry, "="); idx > 0 {
+			n, err := strconv.Atoi(entry[idx+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid concurrency limit in %q", entry)
+			}
+			ec.BaseURL = entry[:idx]
+			ec.MaxConcurrent = n
+		}
+
+		parsed, err := url.Parse(ec.BaseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid Ollama URL %q", ec.BaseURL)
+		}
+
+		endpoints = append(endpoints, ec)
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,252 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+// newGenerateServer returns a mock Ollama server that counts generate calls
+// and answers with the given status code
+func newGenerateServer(t *testing.T, status int, delay time.Duration) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(delay)
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			json.NewEncoder(w).Encode(models.OllamaResponse{Response: "ok", Done: true})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestPoolFailover(t *testing.T) {
+	bad, badCalls := newGenerateServer(t, http.StatusInternalServerError, 0)
+	good, goodCalls := newGenerateServer(t, http.StatusOK, 0)
+
+	var mu sync.Mutex
+	observed := make(map[string]int)
+	client := NewPoolClient("test-model", "", PoolConfig{
+		TextEndpoints:    []EndpointConfig{{BaseURL: bad.URL}, {BaseURL: good.URL}},
+		Routing:          RoutingRoundRobin,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	})
+	client.SetObserver(func(pool, endpoint string, duration time.Duration, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			observed[endpoint]++
+		}
+	})
+
+	for i := 0; i < 4; i++ {
+		resp, err := client.Generate(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("Generate %d failed: %v", i, err)
+		}
+		if resp != "ok" {
+			t.Errorf("Generate %d = %q, want ok", i, resp)
+		}
+	}
+
+	// The failing endpoint is ejected after its first failure
+	if got := atomic.LoadInt32(badCalls); got != 1 {
+		t.Errorf("bad endpoint calls = %d, want 1", got)
+	}
+	if got := atomic.LoadInt32(goodCalls); got != 4 {
+		t.Errorf("good endpoint calls = %d, want 4", got)
+	}
+	if observed[bad.URL] != 1 {
+		t.Errorf("observed errors for bad endpoint = %d, want 1", observed[bad.URL])
+	}
+}
+
+func TestPoolAllEndpointsEjected(t *testing.T) {
+	bad, _ := newGenerateServer(t, http.StatusInternalServerError, 0)
+	client := NewPoolClient("test-model", "", PoolConfig{
+		TextEndpoints:    []EndpointConfig{{BaseURL: bad.URL}},
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	})
+
+	client.Generate(context.Background(), "test") // Ejects the only endpoint
+	if _, err := client.Generate(context.Background(), "test"); err == nil {
+		t.Fatal("Expected error when all endpoints are ejected")
+	}
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	a, aCalls := newGenerateServer(t, http.StatusOK, 0)
+	b, bCalls := newGenerateServer(t, http.StatusOK, 0)
+
+	client := NewPoolClient("test-model", "", PoolConfig{
+		TextEndpoints: []EndpointConfig{{BaseURL: a.URL}, {BaseURL: b.URL}},
+		Routing:       RoutingRoundRobin,
+	})
+
+	for i := 0; i < 6; i++ {
+		if _, err := client.Generate(context.Background(), "test"); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+	}
+
+	if atomic.LoadInt32(aCalls) != 3 || atomic.LoadInt32(bCalls) != 3 {
+		t.Errorf("calls = %d/%d, want 3/3", atomic.LoadInt32(aCalls), atomic.LoadInt32(bCalls))
+	}
+}
+
+func TestPoolLeastLoaded(t *testing.T) {
+	slow, slowCalls := newGenerateServer(t, http.StatusOK, 200*time.Millisecond)
+	fast, fastCalls := newGenerateServer(t, http.StatusOK, 0)
+
+	client := NewPoolClient("test-model", "", PoolConfig{
+		TextEndpoints: []EndpointConfig{
+			{BaseURL: slow.URL, MaxConcurrent: 1},
+			{BaseURL: fast.URL, MaxConcurrent: 4},
+		},
+	})
+
+	// Occupy the slow endpoint's only slot
+	slowEndpoint := client.text.endpoints[0]
+	slowEndpoint.acquire(context.Background())
+	defer slowEndpoint.release()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Generate(context.Background(), "test"); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+	}
+
+	if got := atomic.LoadInt32(slowCalls); got != 0 {
+		t.Errorf("busy endpoint calls = %d, want 0", got)
+	}
+	if got := atomic.LoadInt32(fastCalls); got != 3 {
+		t.Errorf("idle endpoint calls = %d, want 3", got)
+	}
+}
+
+func TestEndpointConcurrencyLimit(t *testing.T) {
+	var current, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "ok", Done: true})
+	}))
+	defer server.Close()
+
+	client := NewPoolClient("test-model", "", PoolConfig{
+		TextEndpoints: []EndpointConfig{{BaseURL: server.URL, MaxConcurrent: 2}},
+	})
+	if client.Capacity() != 2 {
+		t.Errorf("Capacity() = %d, want 2", client.Capacity())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Generate(context.Background(), "test")
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&peak); got > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", got)
+	}
+}
+
+func TestSharedEndpointsAcrossPools(t *testing.T) {
+	client := NewPoolClient("text-model", "vision-model", PoolConfig{
+		TextEndpoints:   []EndpointConfig{{BaseURL: "http://gpu1:11434"}, {BaseURL: "http://gpu2:11434/"}},
+		VisionEndpoints: []EndpointConfig{{BaseURL: "http://gpu2:11434"}},
+	})
+
+	if len(client.endpoints) != 2 {
+		t.Fatalf("endpoints = %d, want 2 (gpu2 shared)", len(client.endpoints))
+	}
+	if client.vision.endpoints[0] != client.text.endpoints[1] {
+		t.Error("Expected vision pool to share the gpu2 endpoint with the text pool")
+	}
+	pools := client.text.endpoints[1].pools
+	if len(pools) != 2 || pools[0] != PoolText || pools[1] != PoolVision {
+		t.Errorf("gpu2 pools = %v, want [text vision]", pools)
+	}
+	if client.Capacity() != 2*DefaultMaxConcurrent {
+		t.Errorf("Capacity() = %d, want %d", client.Capacity(), 2*DefaultMaxConcurrent)
+	}
+}
+
+func TestParseEndpoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []EndpointConfig
+		wantErr bool
+	}{
+		{
+			name: "single url",
+			spec: "http://localhost:11434",
+			want: []EndpointConfig{{BaseURL: "http://localhost:11434", MaxConcurrent: 3}},
+		},
+		{
+			name: "multiple urls with limits",
+			spec: "http://gpu1:11434=4, http://gpu2:11434",
+			want: []EndpointConfig{
+				{BaseURL: "http://gpu1:11434", MaxConcurrent: 4},
+				{BaseURL: "http://gpu2:11434", MaxConcurrent: 3},
+			},
+		},
+		{
+			name: "empty",
+			spec: "",
+			want: nil,
+		},
+		{
+			name:    "invalid limit",
+			spec:    "http://gpu1:11434=zero",
+			wantErr: true,
+		},
+		{
+			name:    "missing scheme",
+			spec:    "gpu1:11434",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEndpoints(tt.spec, 3)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("got[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package scraper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/docutag/scraper/feed"
+	"github.com/docutag/scraper/lang"
+)
+
+// ScrapeOptions controls the pipeline for a single Scrape call
+// The zero value runs the full pipeline using the scraper Config; nil toggles use their defaults
+type ScrapeOptions struct {
+	CleanContent *bool         // AI content cleanup (default true); false keeps the raw page text
+	Images       *bool         // Download and analyze images (default Config.EnableImageAnalysis)
+	OCR          *bool         // Extract text from downloaded images (default true)
+	FilterLinks  *bool         // Pattern and AI link filtering (default true); false returns every extracted link
+	Score        *bool         // Content quality scoring (default true); false leaves ScrapedData.Score nil
+	MaxImages    *int          // Maximum images to process (default Config.MaxImages, 0 = unlimited)
+	Timeout      time.Duration // Deadline for the whole scrape (0 = caller's context only)
+	ImageTimeout time.Duration // Timeout for downloading each image (0 = Config.ImageTimeout)
+	TextModel    string        // Ollama text model override
+	VisionModel  string        // Ollama vision model override
+	TranslateTo  string        // Translate content into this language code (default Config.TranslateTo)
+	Progress     ProgressFunc  // Receives pipeline stage events (may be nil)
+	FeedEntry    *feed.Entry   // Feed entry the URL came from; fills missing author and date metadata
+}
+
+// Bool returns a pointer to v, for setting ScrapeOptions toggles
+func Bool(v bool) *bool {
+	return &v
+}
+
+// Int returns a pointer to v, for setting ScrapeOptions.MaxImages
+func Int(v int) *int {
+	return &v
+}
+
+// Validate reports options that cannot be applied
+func (o ScrapeOptions) Validate() error {
+	if o.MaxImages != nil && *o.MaxImages < 0 {
+		return fmt.Errorf("max_images cannot be negative")
+	}
+	if o.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	if o.ImageTimeout < 0 {
+		return fmt.Errorf("image timeout cannot be negative")
+	}
+	if o.TranslateTo != "" && lang.Normalize(o.TranslateTo) == "" {
+		return fmt.Errorf("invalid translate_to language: %s", o.TranslateTo)
+	}
+	return nil
+}
+
+// Partial reports whether any pipeline stage is switched off, a model is overridden or the
+// content is translated on request, i.e. the result differs from what a default scrape would
+// produce
+// Partial results are not saved, and nothing is written to storage for them.
+func (o ScrapeOptions) Partial() bool {
+	if o.TranslateTo != "" || o.TextModel != "" || o.VisionModel != "" {
+		return true
+	}
+	for _, toggle := range []*bool{o.CleanContent, o.Images, o.OCR, o.FilterLinks, o.Score} {
+		if toggle != nil && !*toggle {
+			return true
+		}
+	}
+	return false
+}
+
+// enabled returns the value of a toggle, or def if it is unset
+func enabled(toggle *bool, def bool) bool {
+	if toggle == nil {
+		return def
+	}
+	return *toggle
+}
+
+// imagesEnabled reports whether images are downloaded and analyzed
+func (s *Scraper) imagesEnabled(opts ScrapeOptions) bool {
+	return enabled(opts.Images, s.config.EnableImageAnalysis)
+}
+
+// maxImages returns the image limit for a call
+func (s *Scraper) maxImages(opts ScrapeOptions) int {
+	if opts.MaxImages != nil {
+		return *opts.MaxImages
+	}
+	return s.config.MaxImages
+}
+
+// translateTo returns the normalized translation target for a call, or "" for none
+func (s *Scraper) translateTo(opts ScrapeOptions) string {
+	if opts.TranslateTo != "" {
+		return lang.Normalize(opts.TranslateTo)
+	}
+	return lang.Normalize(s.config.TranslateTo)
+}
+
+// imageTimeout returns the per-image download timeout for a call
+func (s *Scraper) imageTimeout(opts ScrapeOptions) time.Duration {
+	if opts.ImageTimeout > 0 {
+		return opts.ImageTimeout
+	}
+	return s.config.ImageTimeout
+}
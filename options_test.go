@@ -0,0 +1,242 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/ollama"
+)
+
+func TestScrapeWithOptions(t *testing.T) {
+	var mu sync.Mutex
+	var modelsUsed []string
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		modelsUsed = append(modelsUsed, req.Model)
+		mu.Unlock()
+
+		response := "Cleaned content"
+		if contains(req.Prompt, "quality assessment") {
+			response = `{"score": 0.8, "reason": "Good", "categories": ["news"], "malicious_indicators": []}`
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: response, Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>Options Test</title></head><body>
+			<p>Raw article text.</p>
+			<img src="https://example.com/photo.jpg" alt="Photo">
+			<a href="https://example.com/article">Article</a>
+			<a href="https://example.com/privacy">Privacy</a>
+		</body></html>`)
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.HTTPTimeout = 10 * time.Second
+	config.OllamaBaseURL = ollamaServer.URL
+	config.OllamaModel = "default-model"
+	s := New(config, nil, nil)
+
+	tests := []struct {
+		name       string
+		opts       ScrapeOptions
+		wantModels []string
+		check      func(t *testing.T, data *models.ScrapedData)
+	}{
+		{
+			name: "text only",
+			opts: ScrapeOptions{
+				CleanContent: Bool(false),
+				Images:       Bool(false),
+				FilterLinks:  Bool(false),
+				Score:        Bool(false),
+			},
+			wantModels: nil,
+			check: func(t *testing.T, data *models.ScrapedData) {
+				if data.Content != data.RawText {
+					t.Errorf("Content = %q, want raw text", data.Content)
+				}
+				if data.Score != nil {
+					t.Errorf("Score = %+v, want nil", data.Score)
+				}
+				if len(data.Links) != 2 {
+					t.Errorf("Links = %v, want both unfiltered links", data.Links)
+				}
+				if len(data.Images) != 1 || data.Images[0].ID != "" {
+					t.Errorf("Images = %+v, want one unprocessed image", data.Images)
+				}
+			},
+		},
+		{
+			name: "model override",
+			opts: ScrapeOptions{
+				Images:      Bool(false),
+				FilterLinks: Bool(false),
+				TextModel:   "override-model",
+			},
+			wantModels: []string{"override-model", "override-model"},
+			check: func(t *testing.T, data *models.ScrapedData) {
+				if data.Content != "Cleaned content" {
+					t.Errorf("Content = %q, want cleaned content", data.Content)
+				}
+				if data.Score == nil || !data.Score.AIUsed {
+					t.Errorf("Score = %+v, want AI score", data.Score)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			modelsUsed = nil
+			mu.Unlock()
+
+			data, err := s.ScrapeWithOptions(context.Background(), webServer.URL, tt.opts)
+			if err != nil {
+				t.Fatalf("ScrapeWithOptions failed: %v", err)
+			}
+			tt.check(t, data)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(modelsUsed) != len(tt.wantModels) {
+				t.Fatalf("Ollama calls used models %v, want %v", modelsUsed, tt.wantModels)
+			}
+			for i := range tt.wantModels {
+				if modelsUsed[i] != tt.wantModels[i] {
+					t.Errorf("call %d model = %s, want %s", i, modelsUsed[i], tt.wantModels[i])
+				}
+			}
+		})
+	}
+}
+
+// recordingStorage counts writes to storage
+type recordingStorage struct {
+	mu       sync.Mutex
+	images   int
+	contents int
+}
+
+func (r *recordingStorage) SaveImage(imageData []byte, slug, contentType string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.images++
+	return "images/" + slug, nil
+}
+
+func (r *recordingStorage) SaveContent(content, slug string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contents++
+	return "content/" + slug + ".html", nil
+}
+
+func (r *recordingStorage) ReadImage(relPath string) ([]byte, error)   { return nil, nil }
+func (r *recordingStorage) ReadContent(relPath string) (string, error) { return "", nil }
+func (r *recordingStorage) DeleteImage(relPath string) error           { return nil }
+func (r *recordingStorage) DeleteContent(relPath string) error         { return nil }
+
+func TestPartialScrapeSkipsStorage(t *testing.T) {
+	picture := encodePNG(t, testPicture(64, 64, false))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/generate":
+			response := "Cleaned content"
+			var req models.OllamaRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if contains(req.Prompt, "quality assessment") {
+				response = `{"score": 0.8, "reason": "Good", "categories": ["news"], "malicious_indicators": []}`
+			}
+			json.NewEncoder(w).Encode(models.OllamaResponse{Response: response, Done: true})
+		case "/photo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(picture)
+		default:
+			fmt.Fprint(w, `<html><head><title>Storage Test</title></head><body><p>Article text.</p></body></html>`)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = server.URL
+	config.EnableImageAnalysis = false
+	store := &recordingStorage{}
+	s := New(config, nil, store)
+
+	// A partial scrape would overwrite the full scrape's content under the same slug
+	if _, err := s.ScrapeWithOptions(context.Background(), server.URL, ScrapeOptions{TextModel: "override-model"}); err != nil {
+		t.Fatalf("partial scrape failed: %v", err)
+	}
+	if store.contents != 0 {
+		t.Errorf("partial scrape saved content %d times, want 0", store.contents)
+	}
+	if _, err := s.ScrapeWithOptions(context.Background(), server.URL, ScrapeOptions{}); err != nil {
+		t.Fatalf("full scrape failed: %v", err)
+	}
+	if store.contents != 1 {
+		t.Errorf("full scrape saved content %d times, want 1", store.contents)
+	}
+
+	// Images of a partial scrape are returned inline rather than left orphaned in storage
+	client := ollama.NewClient(server.URL, "test")
+	img, _, _ := s.processSingleImage(context.Background(), models.ImageInfo{URL: server.URL + "/photo.png"}, client, ScrapeOptions{VisionModel: "override-model"})
+	if store.images != 0 || img.FilePath != "" || img.Base64Data == "" {
+		t.Errorf("partial scrape image: %d saves, file_path %q, inline %v; want inline only", store.images, img.FilePath, img.Base64Data != "")
+	}
+	img, _, _ = s.processSingleImage(context.Background(), models.ImageInfo{URL: server.URL + "/photo.png"}, client, ScrapeOptions{})
+	if store.images != 1 || img.FilePath == "" {
+		t.Errorf("full scrape image: %d saves, file_path %q; want it stored", store.images, img.FilePath)
+	}
+}
+
+func TestScrapeOptionsValidate(t *testing.T) {
+	if err := (ScrapeOptions{MaxImages: Int(-1)}).Validate(); err == nil {
+		t.Error("Expected error for negative MaxImages")
+	}
+	if err := (ScrapeOptions{Timeout: -time.Second}).Validate(); err == nil {
+		t.Error("Expected error for negative Timeout")
+	}
+	if err := (ScrapeOptions{TranslateTo: "not a language!"}).Validate(); err == nil {
+		t.Error("Expected error for invalid TranslateTo")
+	}
+	if err := (ScrapeOptions{MaxImages: Int(0), TranslateTo: "en-GB"}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestScrapeOptionsPartial(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ScrapeOptions
+		want bool
+	}{
+		{"zero value", ScrapeOptions{}, false},
+		{"explicitly enabled", ScrapeOptions{Images: Bool(true), Score: Bool(true)}, false},
+		{"text model override", ScrapeOptions{TextModel: "other"}, true},
+		{"vision model override", ScrapeOptions{VisionModel: "other"}, true},
+		{"ocr disabled", ScrapeOptions{OCR: Bool(false)}, true},
+		{"scoring disabled", ScrapeOptions{Score: Bool(false)}, true},
+		{"translated", ScrapeOptions{TranslateTo: "en"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Partial(); got != tt.want {
+				t.Errorf("Partial() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/rules"
+)
+
+// DomainBlockedError is returned when a domain policy blocks a URL
+type DomainBlockedError struct {
+	URL    string
+	Domain string // Domain the block policy is configured for
+}
+
+func (e *DomainBlockedError) Error() string {
+	return fmt.Sprintf("domain %s is blocked by policy", e.Domain)
+}
+
+// domainPolicyIndex maps normalized domains to their policy
+type domainPolicyIndex map[string]models.DomainPolicy
+
+// SetDomainPolicies replaces the domain policies enforced by the scraper
+func (s *Scraper) SetDomainPolicies(policies []*models.DomainPolicy) {
+	index := make(domainPolicyIndex, len(policies))
+	for _, p := range policies {
+		index[NormalizeDomain(p.Domain)] = *p
+	}
+	s.domainPolicies.Store(&index)
+}
+
+// DomainPolicyFor returns the policy that applies to a URL, or nil if none does
+// A policy covers its domain and every subdomain; the most specific domain wins
+func (s *Scraper) DomainPolicyFor(rawURL string) *models.DomainPolicy {
+	index := s.domainPolicies.Load()
+	if index == nil || len(*index) == 0 {
+		return nil
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	host := NormalizeDomain(parsed.Hostname())
+	for host != "" {
+		if p, ok := (*index)[host]; ok {
+			return &p
+		}
+		dot := strings.Index(host, ".")
+		if dot < 0 {
+			break
+		}
+		host = host[dot+1:]
+	}
+	return nil
+}
+
+// NormalizeDomain lowercases a domain and strips a leading "www."
+func NormalizeDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+}
+
+// policyBlocks reports whether a policy blocks the URL it was looked up for
+func policyBlocks(p *models.DomainPolicy) bool {
+	return p != nil && p.Policy == models.DomainPolicyBlock
+}
+
+// policyTrusts reports whether a policy exempts its domain from rule-based blocking
+func policyTrusts(p *models.DomainPolicy) bool {
+	return p != nil && (p.Policy == models.DomainPolicyAllow || p.Policy == models.DomainPolicyAlwaysRecommend)
+}
+
+// rulesFor returns the active rule set, with a trusted policy's domain on the allow list
+func (s *Scraper) rulesFor(p *models.DomainPolicy) *rules.RuleSet {
+	rs := s.ruleEngine.Rules()
+	if policyTrusts(p) {
+		rs = rs.WithAllowed(p.Domain)
+	}
+	return rs
+}
+
+// applyDomainPolicy records the policy on a score and forces recommendation for always_recommend
+func applyDomainPolicy(score *models.LinkScore, p *models.DomainPolicy) {
+	if score == nil || p == nil {
+		return
+	}
+	score.Policy = p.Policy
+	score.PolicyDomain = p.Domain
+	if p.Policy == models.DomainPolicyAlwaysRecommend {
+		score.IsRecommended = true
+	}
+}
+
+// blockedLinkScore is the score returned for a URL blocked by a domain policy, without fetching it
+func blockedLinkScore(targetURL string, p *models.DomainPolicy) *models.LinkScore {
+	return &models.LinkScore{
+		URL:                 targetURL,
+		Score:               0.0,
+		Reason:              fmt.Sprintf("Domain %s is blocked by policy", p.Domain),
+		Categories:          []string{"blocked"},
+		IsRecommended:       false,
+		MaliciousIndicators: []string{},
+		AIUsed:              false,
+		Policy:              p.Policy,
+		PolicyDomain:        p.Domain,
+	}
+}
@@ -0,0 +1,137 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/rules"
+)
+
+func TestDomainPolicyFor(t *testing.T) {
+	s := New(DefaultConfig(), nil, nil)
+
+	if p := s.DomainPolicyFor("https://example.com/"); p != nil {
+		t.Fatalf("expected no policy before any are set, got %+v", p)
+	}
+
+	s.SetDomainPolicies([]*models.DomainPolicy{
+		{Domain: "example.com", Policy: models.DomainPolicyBlock},
+		{Domain: "WWW.Docs.Example.com", Policy: models.DomainPolicyAllow},
+	})
+
+	tests := []struct {
+		url    string
+		domain string
+		policy string
+	}{
+		{"https://example.com/page", "example.com", models.DomainPolicyBlock},
+		{"https://www.example.com/page", "example.com", models.DomainPolicyBlock},
+		{"https://blog.example.com/page", "example.com", models.DomainPolicyBlock},
+		{"https://docs.example.com/guide", "WWW.Docs.Example.com", models.DomainPolicyAllow},
+		{"https://api.docs.example.com/v1", "WWW.Docs.Example.com", models.DomainPolicyAllow},
+		{"https://notexample.com/page", "", ""},
+		{"https://example.org/page", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			p := s.DomainPolicyFor(tt.url)
+			if tt.policy == "" {
+				if p != nil {
+					t.Errorf("expected no policy, got %+v", p)
+				}
+				return
+			}
+			if p == nil || p.Policy != tt.policy || p.Domain != tt.domain {
+				t.Errorf("DomainPolicyFor(%q) = %+v, want %s from %s", tt.url, p, tt.policy, tt.domain)
+			}
+		})
+	}
+}
+
+func TestDomainPolicyBlocksBeforeFetch(t *testing.T) {
+	var hits atomic.Int32
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Test</title></head><body><p>Content</p></body></html>`))
+	}))
+	defer webServer.Close()
+
+	s := New(DefaultConfig(), nil, nil)
+	s.SetDomainPolicies([]*models.DomainPolicy{{Domain: "127.0.0.1", Policy: models.DomainPolicyBlock}})
+	ctx := context.Background()
+
+	_, err := s.Scrape(ctx, webServer.URL)
+	var blocked *DomainBlockedError
+	if !errors.As(err, &blocked) || blocked.Domain != "127.0.0.1" {
+		t.Errorf("Scrape error = %v, want DomainBlockedError", err)
+	}
+
+	_, err = s.ExtractLinks(ctx, webServer.URL)
+	if !errors.As(err, &blocked) {
+		t.Errorf("ExtractLinks error = %v, want DomainBlockedError", err)
+	}
+
+	score, err := s.ScoreLinkContent(ctx, webServer.URL)
+	if err != nil {
+		t.Fatalf("ScoreLinkContent failed: %v", err)
+	}
+	if score.Policy != models.DomainPolicyBlock || score.PolicyDomain != "127.0.0.1" || score.IsRecommended {
+		t.Errorf("unexpected score for blocked domain: %+v", score)
+	}
+
+	if n := hits.Load(); n != 0 {
+		t.Errorf("expected blocked domain never to be fetched, got %d requests", n)
+	}
+}
+
+func TestFilterLowQualityLinksDomainPolicies(t *testing.T) {
+	s := New(DefaultConfig(), nil, nil)
+	s.SetDomainPolicies([]*models.DomainPolicy{
+		{Domain: "reddit.com", Policy: models.DomainPolicyAllow},
+		{Domain: "spam.example", Policy: models.DomainPolicyBlock},
+	})
+
+	links := []string{
+		"https://www.reddit.com/r/golang/comments/abc",
+		"https://spam.example/article",
+		"https://example.com/article",
+		"https://facebook.com/page",
+	}
+
+	filtered, count := s.filterLowQualityLinks(rules.Default(), links)
+	if count != 2 {
+		t.Errorf("filtered %d links, want 2", count)
+	}
+	want := []string{"https://www.reddit.com/r/golang/comments/abc", "https://example.com/article"}
+	if len(filtered) != len(want) || filtered[0] != want[0] || filtered[1] != want[1] {
+		t.Errorf("filtered = %v, want %v", filtered, want)
+	}
+}
+
+func TestDomainPolicyTrustedBypassesRules(t *testing.T) {
+	s := New(DefaultConfig(), nil, nil)
+	s.SetDomainPolicies([]*models.DomainPolicy{{Domain: "reddit.com", Policy: models.DomainPolicyAlwaysRecommend}})
+
+	target := "https://reddit.com/r/golang"
+	policy := s.DomainPolicyFor(target)
+
+	if skip, _, _, _, _ := checkForLowQualityPatterns(s.ruleEngine.Rules(), target, ""); !skip {
+		t.Fatal("expected reddit.com to be blocked by the default rules")
+	}
+	if skip, _, _, _, _ := checkForLowQualityPatterns(s.rulesFor(policy), target, ""); skip {
+		t.Error("expected trusted domain to bypass rule-based blocking")
+	}
+
+	score := &models.LinkScore{URL: target, Score: 0.2}
+	applyDomainPolicy(score, policy)
+	if !score.IsRecommended || score.Policy != models.DomainPolicyAlwaysRecommend || score.PolicyDomain != "reddit.com" {
+		t.Errorf("unexpected score after always_recommend policy: %+v", score)
+	}
+}
@@ -0,0 +1,361 @@
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/docutag/scraper/fixture"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.yaml.in/yaml/v2"
+)
+
+// NetworkProfile configures how requests to a set of domains leave the scraper
+// A profile covers its domains and every subdomain. A profile without domains is the
+// default for all other domains; without one, other domains are fetched directly.
+type NetworkProfile struct {
+	Name      string            `yaml:"name" json:"name"`
+	Domains   []string          `yaml:"domains,omitempty" json:"domains,omitempty"`
+	Proxies   []string          `yaml:"proxies,omitempty" json:"proxies,omitempty"`       // http, https or socks5 proxy URLs, rotated on 403 and 429
+	Headers   map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`       // Extra request headers
+	Cookies   map[string]string `yaml:"cookies,omitempty" json:"cookies,omitempty"`       // Cookies sent with every request, by name
+	UserAgent string            `yaml:"user_agent,omitempty" json:"user_agent,omitempty"` // Replaces the default browser user agent
+	TLS       ProfileTLS        `yaml:"tls,omitempty" json:"tls,omitempty"`
+	HTTP2     bool              `yaml:"http2,omitempty" json:"http2,omitempty"` // Negotiate HTTP/2; the default is HTTP/1.1 only
+}
+
+// ProfileTLS holds a profile's TLS settings
+type ProfileTLS struct {
+	MinVersion         string `yaml:"min_version,omitempty" json:"min_version,omitempty"`                   // "1.0", "1.1", "1.2" or "1.3"
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty" json:"insecure_skip_verify,omitempty"` // Accept any certificate
+	CAFile             string `yaml:"ca_file,omitempty" json:"ca_file,omitempty"`                           // PEM file of extra trusted root certificates
+}
+
+// tlsVersions maps ProfileTLS.MinVersion values to crypto/tls versions
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// networkProfilesDocument is the YAML or JSON form of a profile list
+type networkProfilesDocument struct {
+	Profiles []NetworkProfile `yaml:"profiles" json:"profiles"`
+}
+
+// ParseNetworkProfiles parses and validates a YAML or JSON document with a "profiles" list
+func ParseNetworkProfiles(doc []byte) ([]NetworkProfile, error) {
+	var d networkProfilesDocument
+	if err := yaml.UnmarshalStrict(doc, &d); err != nil {
+		return nil, fmt.Errorf("failed to parse network profiles: %w", err)
+	}
+	if err := validateNetworkProfiles(d.Profiles); err != nil {
+		return nil, err
+	}
+	return d.Profiles, nil
+}
+
+// LoadNetworkProfiles reads network profiles from a YAML or JSON file
+func LoadNetworkProfiles(path string) ([]NetworkProfile, error) {
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read network profiles: %w", err)
+	}
+	return ParseNetworkProfiles(doc)
+}
+
+// validateNetworkProfiles checks names, domains, proxies and TLS settings
+func validateNetworkProfiles(profiles []NetworkProfile) error {
+	names := make(map[string]bool, len(profiles))
+	domains := make(map[string]string)
+	hasDefault := false
+	for i, p := range profiles {
+		if p.Name == "" {
+			return fmt.Errorf("network profile %d needs a name", i)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate network profile %q", p.Name)
+		}
+		names[p.Name] = true
+
+		if len(p.Domains) == 0 {
+			if hasDefault {
+				return fmt.Errorf("network profile %q: only one profile may omit domains", p.Name)
+			}
+			hasDefault = true
+		}
+		for _, d := range p.Domains {
+			domain := NormalizeDomain(d)
+			if domain == "" {
+				return fmt.Errorf("network profile %q: invalid domain %q", p.Name, d)
+			}
+			if other, ok := domains[domain]; ok {
+				return fmt.Errorf("network profile %q: domain %s already belongs to %q", p.Name, domain, other)
+			}
+			domains[domain] = p.Name
+		}
+
+		for _, raw := range p.Proxies {
+			if _, err := parseProxyURL(raw); err != nil {
+				return fmt.Errorf("network profile %q: %w", p.Name, err)
+			}
+		}
+		if _, err := p.tlsConfig(); err != nil {
+			return fmt.Errorf("network profile %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// parseProxyURL parses and checks a proxy URL
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", redactProxy(raw), err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy %s must use http, https or socks5", redactProxy(raw))
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy %s has no host", redactProxy(raw))
+	}
+	return u, nil
+}
+
+// redactProxy returns a proxy URL without credentials, for logs and scrape records
+func redactProxy(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "invalid proxy URL"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// tlsConfig returns the profile's TLS client configuration, or nil for Go's defaults
+func (p *NetworkProfile) tlsConfig() (*tls.Config, error) {
+	if p.TLS == (ProfileTLS{}) {
+		return nil, nil
+	}
+	config := &tls.Config{InsecureSkipVerify: p.TLS.InsecureSkipVerify}
+	if p.TLS.MinVersion != "" {
+		version, ok := tlsVersions[p.TLS.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS min_version %q", p.TLS.MinVersion)
+		}
+		config.MinVersion = version
+	}
+	if p.TLS.CAFile != "" {
+		pem, err := os.ReadFile(p.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", p.TLS.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// outboundProfile is a network profile with its HTTP clients
+type outboundProfile struct {
+	profile NetworkProfile
+	clients []*http.Client // One per proxy, or a single direct client
+	proxies []string       // Proxy each client uses, without credentials; "" for direct
+	current atomic.Uint32  // Client fetches start with until it is rotated away from
+}
+
+// pick returns the index of the client to start a fetch with
+func (o *outboundProfile) pick() int {
+	return int(o.current.Load()) % len(o.clients)
+}
+
+// rotate moves past client i after it was refused
+// Concurrent refusals of the same client rotate only once.
+func (o *outboundProfile) rotate(i int) {
+	o.current.CompareAndSwap(uint32(i), uint32((i+1)%len(o.clients)))
+}
+
+// newRequest creates a GET request carrying the profile's user agent, headers and cookies
+func (o *outboundProfile) newRequest(ctx context.Context, targetURL string) (*http.Request, error) {
+	return o.newRequestWithBody(ctx, "GET", targetURL, nil)
+}
+
+// newRequestWithBody is newRequest for any method and body
+func (o *outboundProfile) newRequestWithBody(ctx context.Context, method, targetURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	if o.profile.UserAgent != "" {
+		req.Header.Set("User-Agent", o.profile.UserAgent)
+	}
+	for name, value := range o.profile.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range o.profile.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	return req, nil
+}
+
+// profileIndex maps normalized domains to their outbound profile
+type profileIndex struct {
+	byDomain map[string]*outboundProfile
+	fallback *outboundProfile // Profile for every other domain
+}
+
+// profileFor returns the outbound profile for a URL
+// The most specific domain wins, then the default profile, then direct fetching.
+func (s *Scraper) profileFor(rawURL string) *outboundProfile {
+	if parsed, err := url.Parse(rawURL); err == nil && len(s.profiles.byDomain) > 0 {
+		host := NormalizeDomain(parsed.Hostname())
+		for host != "" {
+			if p, ok := s.profiles.byDomain[host]; ok {
+				return p
+			}
+			dot := strings.Index(host, ".")
+			if dot < 0 {
+				break
+			}
+			host = host[dot+1:]
+		}
+	}
+	return s.profiles.fallback
+}
+
+// transportFactory builds page-fetching clients that share the scraper's fixtures and
+// network guard
+type transportFactory struct {
+	config   Config
+	replayer *fixture.Replayer
+	allow    *hostAllowlist // Hosts exempt from BlockPrivateNetworks (nil when not blocking)
+	hooks    *retryHooks
+}
+
+// client builds an HTTP client for profile p, sending requests through proxy if set
+// rotating clients leave 403 and 429 to proxy rotation instead of retrying them.
+func (f *transportFactory) client(p *NetworkProfile, proxy *url.URL, rotating bool) (*http.Client, error) {
+	base := &http.Transport{}
+	if p.HTTP2 {
+		base.ForceAttemptHTTP2 = true
+	} else {
+		// HTTP/1.1 only: some servers have issues with HTTP/2 from Go clients
+		base.TLSNextProto = make(map[string]func(authority string, c *tls.Conn) http.RoundTripper)
+	}
+	tlsConfig, err := p.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	base.TLSClientConfig = tlsConfig
+
+	var transport http.RoundTripper = base
+	if proxy != nil {
+		base.Proxy = http.ProxyURL(proxy)
+	}
+	if f.allow != nil {
+		// Guard the dialer so every connection, including redirects, is checked
+		allow := f.allow
+		if proxy != nil {
+			// The proxy itself is operator-configured; the targets behind it are checked
+			// before each request instead, since the proxy resolves them
+			allow = allow.withHost(proxy.Hostname())
+			transport = &proxiedTargetGuard{base: base, allow: f.allow}
+		}
+		base.DialContext = guardedDialContext(allow)
+	}
+
+	// Fixtures replace or tap the network below the tracing layer
+	if f.replayer != nil {
+		transport = f.replayer.Transport(fixture.KindHTTP)
+	} else if f.config.Recorder != nil {
+		transport = f.config.Recorder.Wrap(fixture.KindHTTP, transport)
+	}
+
+	// Retries sit above the fixtures so every attempt is recorded and replayed
+	var skip []int
+	if rotating {
+		skip = []int{http.StatusForbidden, http.StatusTooManyRequests}
+	}
+	transport = newRetryTransport(transport, f.config.Retry, f.hooks, skip...)
+
+	// Wrap transport with OpenTelemetry instrumentation for trace propagation
+	instrumented := otelhttp.NewTransport(transport,
+		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+			return "scraper HTTP " + r.Method + " " + r.URL.Host
+		}),
+	)
+	return &http.Client{Timeout: f.config.HTTPTimeout, Transport: instrumented}, nil
+}
+
+// outbound builds the clients for a profile, one per proxy
+func (f *transportFactory) outbound(p NetworkProfile) (*outboundProfile, error) {
+	o := &outboundProfile{profile: p}
+	if len(p.Proxies) == 0 {
+		client, err := f.client(&p, nil, false)
+		if err != nil {
+			return nil, err
+		}
+		o.clients, o.proxies = []*http.Client{client}, []string{""}
+		return o, nil
+	}
+	for _, raw := range p.Proxies {
+		proxy, err := parseProxyURL(raw)
+		if err != nil {
+			return nil, err
+		}
+		client, err := f.client(&p, proxy, len(p.Proxies) > 1)
+		if err != nil {
+			return nil, err
+		}
+		o.clients = append(o.clients, client)
+		o.proxies = append(o.proxies, redactProxy(raw))
+	}
+	return o, nil
+}
+
+// buildProfiles builds the outbound profiles for the configured network profiles
+// Invalid profiles are logged and skipped, leaving their domains on the default profile.
+func (f *transportFactory) buildProfiles(direct *outboundProfile) profileIndex {
+	index := profileIndex{byDomain: make(map[string]*outboundProfile), fallback: direct}
+	if err := validateNetworkProfiles(f.config.NetworkProfiles); err != nil {
+		slog.Error("invalid network profiles, fetching directly", "error", err)
+		return index
+	}
+	for _, p := range f.config.NetworkProfiles {
+		o, err := f.outbound(p)
+		if err != nil {
+			slog.Error("skipping network profile", "profile", p.Name, "error", err)
+			continue
+		}
+		if len(p.Domains) == 0 {
+			index.fallback = o
+		}
+		for _, d := range p.Domains {
+			index.byDomain[NormalizeDomain(d)] = o
+		}
+	}
+	return index
+}
+
+// isRotationStatus reports whether a response status suggests the egress IP is blocked
+// or throttled, so another proxy should be tried
+func isRotationStatus(code int) bool {
+	return code == http.StatusForbidden || code == http.StatusTooManyRequests
+}
@@ -0,0 +1,49 @@
+package scraper
+
+// ProgressStage identifies a step of the scrape pipeline
+type ProgressStage string
+
+const (
+	StageFetched         ProgressStage = "fetched"          // Page downloaded and parsed
+	StageTextExtracted   ProgressStage = "text_extracted"   // Title and raw text extracted
+	StageContentCleaned  ProgressStage = "content_cleaned"  // AI content extraction finished (or fell back to raw text)
+	StageTranslated      ProgressStage = "translated"       // Content translated to the target language (only when translation is requested)
+	StageImagesFound     ProgressStage = "images_found"     // Images selected for processing
+	StageImageDownloaded ProgressStage = "image_downloaded" // A single image was downloaded
+	StageImageAnalyzed   ProgressStage = "image_analyzed"   // A single image finished analysis (or was skipped)
+	StageLinksFiltered   ProgressStage = "links_filtered"   // Links extracted and filtered
+	StageScored          ProgressStage = "scored"           // Content quality score assigned
+	StageSaved           ProgressStage = "saved"            // Result persisted by the caller (not emitted by Scrape)
+)
+
+// ProgressEvent reports that a scrape reached a pipeline stage
+type ProgressEvent struct {
+	Stage    ProgressStage `json:"stage"`
+	URL      string        `json:"url"`                // Page URL, or image URL for image stages
+	Index    int           `json:"index,omitempty"`    // 1-based image position for image stages
+	Total    int           `json:"total,omitempty"`    // Number of images being processed
+	Count    int           `json:"count,omitempty"`    // Items produced by the stage (links, images)
+	Score    float64       `json:"score,omitempty"`    // Quality score for the scored stage
+	ID       string        `json:"id,omitempty"`       // Scrape ID for the saved stage
+	Existing bool          `json:"existing,omitempty"` // Image was already stored and was not reprocessed
+	Warning  string        `json:"warning,omitempty"`  // Non-fatal issue encountered during the stage
+}
+
+// ProgressFunc receives progress events during a scrape
+// Image events are emitted from worker goroutines, so implementations must be safe for concurrent use
+type ProgressFunc func(ProgressEvent)
+
+// emit sends an event if a progress callback is registered
+func (p ProgressFunc) emit(event ProgressEvent) {
+	if p != nil {
+		p(event)
+	}
+}
+
+// lastWarning returns the most recent warning added after the first n, or "" if none were added
+func lastWarning(warnings []string, n int) string {
+	if len(warnings) > n {
+		return warnings[len(warnings)-1]
+	}
+	return ""
+}
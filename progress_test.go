@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+func TestScrapeWithProgress(t *testing.T) {
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		response := "Cleaned content"
+		if contains(req.Prompt, "quality assessment") {
+			response = `{"score": 0.8, "reason": "Good", "categories": ["news"], "malicious_indicators": []}`
+		} else if contains(req.Prompt, "Analyze this image") {
+			response = `{"summary": "A red pixel", "tags": ["red"]}`
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: response, Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 1x1 red pixel PNG
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{
+			0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+			0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+			0x08, 0x02, 0x00, 0x00, 0x00, 0x90, 0x77, 0x53, 0xde, 0x00, 0x00, 0x00,
+			0x0c, 0x49, 0x44, 0x41, 0x54, 0x08, 0xd7, 0x63, 0xf8, 0xcf, 0xc0, 0x00,
+			0x00, 0x03, 0x01, 0x01, 0x00, 0x18, 0xdd, 0x8d, 0xb4, 0x00, 0x00, 0x00,
+			0x00, 0x49, 0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+		})
+	}))
+	defer imageServer.Close()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><head><title>Progress Test</title></head><body>
+			<p>Some article text.</p>
+			<img src="%s/one.png" alt="One">
+			<img src="%s/two.png" alt="Two">
+		</body></html>`, imageServer.URL, imageServer.URL)
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.HTTPTimeout = 10 * time.Second
+	config.OllamaBaseURL = ollamaServer.URL
+	s := New(config, nil, nil)
+
+	var mu sync.Mutex
+	var events []ProgressEvent
+	progress := func(event ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	_, err := s.ScrapeWithOptions(context.Background(), webServer.URL, ScrapeOptions{Progress: progress})
+	if err != nil {
+		t.Fatalf("ScrapeWithProgress failed: %v", err)
+	}
+
+	counts := make(map[ProgressStage]int)
+	order := make(map[ProgressStage]int)
+	for i, event := range events {
+		counts[event.Stage]++
+		if _, ok := order[event.Stage]; !ok {
+			order[event.Stage] = i
+		}
+		if event.Stage == StageImageAnalyzed && (event.Total != 2 || event.Index < 1 || event.Index > 2) {
+			t.Errorf("image event index/total = %d/%d, want 1-2 of 2", event.Index, event.Total)
+		}
+	}
+
+	want := map[ProgressStage]int{
+		StageFetched:         1,
+		StageTextExtracted:   1,
+		StageContentCleaned:  1,
+		StageImagesFound:     1,
+		StageImageDownloaded: 2,
+		StageImageAnalyzed:   2,
+		StageLinksFiltered:   1,
+		StageScored:          1,
+	}
+	for stage, n := range want {
+		if counts[stage] != n {
+			t.Errorf("%s events = %d, want %d", stage, counts[stage], n)
+		}
+	}
+	if counts[StageSaved] != 0 {
+		t.Error("Scrape should not emit the saved stage")
+	}
+
+	stages := []ProgressStage{StageFetched, StageTextExtracted, StageContentCleaned, StageImagesFound, StageLinksFiltered, StageScored}
+	for i := 1; i < len(stages); i++ {
+		if order[stages[i]] < order[stages[i-1]] {
+			t.Errorf("%s emitted before %s", stages[i], stages[i-1])
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/fixture"
+	"github.com/docutag/scraper/models"
+)
+
+func TestScrapeRecordAndReplay(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	for x := 0; x < 200; x++ {
+		for y := 0; y < 200; y++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode image: %v", err)
+	}
+	squarePNG := buf.Bytes()
+
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaVisionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		response := "Recorded article content about solar panels"
+		if len(req.Images) > 0 {
+			response = `{"summary": "A red square", "tags": ["red", "square"]}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: response, Done: true})
+	}))
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/square.png" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(squarePNG)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Solar Panels</title></head><body>
+			<p>Solar panels convert sunlight into electricity.</p>
+			<img src="/square.png" alt="Square">
+			<a href="/next.html">Next</a>
+		</body></html>`))
+	}))
+	pageURL := webServer.URL + "/article.html"
+
+	config := DefaultConfig()
+	config.HTTPTimeout = 10 * time.Second
+	config.OllamaBaseURL = ollamaServer.URL
+	config.OllamaModel = "test-model"
+
+	recorder := fixture.NewRecorder()
+	config.Recorder = recorder
+	recorded, err := New(config, nil, nil).Scrape(context.Background(), pageURL)
+	if err != nil {
+		t.Fatalf("recording scrape failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "fixtures", "article.json")
+	if err := recorder.Archive().Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Nothing is reachable during replay
+	ollamaServer.Close()
+	webServer.Close()
+
+	archive, err := fixture.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	config.Recorder = nil
+	config.Replay = archive
+	config.OllamaBaseURL = "http://ollama.invalid:11434" // Ollama exchanges replay against any endpoint
+	replayed, err := New(config, nil, nil).Scrape(context.Background(), pageURL)
+	if err != nil {
+		t.Fatalf("replayed scrape failed: %v", err)
+	}
+
+	if replayed.Title != recorded.Title || replayed.Content != recorded.Content {
+		t.Errorf("replay differs: title %q content %q, recorded title %q content %q",
+			replayed.Title, replayed.Content, recorded.Title, recorded.Content)
+	}
+	if !reflect.DeepEqual(replayed.Links, recorded.Links) {
+		t.Errorf("links = %v, recorded %v", replayed.Links, recorded.Links)
+	}
+	if len(replayed.Images) != 1 || len(recorded.Images) != 1 {
+		t.Fatalf("expected one image in both scrapes, got %d and %d", len(replayed.Images), len(recorded.Images))
+	}
+	if !reflect.DeepEqual(replayed.Images[0].Tags, recorded.Images[0].Tags) || replayed.Images[0].Summary != "A red square" {
+		t.Errorf("image = %+v, recorded %+v", replayed.Images[0], recorded.Images[0])
+	}
+	if len(replayed.Warnings) != len(recorded.Warnings) {
+		t.Errorf("warnings = %v, recorded %v", replayed.Warnings, recorded.Warnings)
+	}
+}
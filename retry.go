@@ -0,0 +1,210 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DefaultRetryStatuses are the status codes retried by default
+// Other codes, such as 404, are never retried.
+var DefaultRetryStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy controls how transient fetch failures are retried
+// Only idempotent requests without a body (GET and HEAD) are retried.
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts per request including the first (0 or 1 disables retries)
+	BaseDelay   time.Duration // Backoff before the first retry, doubled for each later one
+	MaxDelay    time.Duration // Longest backoff; a longer Retry-After gives up instead of waiting
+	Statuses    []int         // Status codes to retry (default DefaultRetryStatuses)
+}
+
+// DefaultRetryPolicy returns the default retry policy: three attempts, 500ms base backoff
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		Statuses:    DefaultRetryStatuses,
+	}
+}
+
+// RetryObserver is notified before every retried fetch
+// reason is the status code that triggered the retry, e.g. "503", or "network".
+type RetryObserver func(reason string)
+
+// retryHooks lets the observer be set after the transports are built
+type retryHooks struct {
+	observer atomic.Pointer[RetryObserver]
+}
+
+// SetRetryObserver registers a callback invoked before every retried page or image fetch
+func (s *Scraper) SetRetryObserver(observer RetryObserver) {
+	s.retryHooks.observer.Store(&observer)
+}
+
+// retryCounter counts the retries made on behalf of one fetch or set of fetches
+type retryCounter struct {
+	n atomic.Int32
+}
+
+type retryCounterKey struct{}
+
+// withRetryCounter returns a context whose fetches count their retries in the returned counter
+func withRetryCounter(ctx context.Context) (context.Context, *retryCounter) {
+	c := &retryCounter{}
+	return context.WithValue(ctx, retryCounterKey{}, c), c
+}
+
+// retryTransport retries idempotent requests that fail transiently
+type retryTransport struct {
+	base     http.RoundTripper
+	policy   RetryPolicy
+	statuses map[int]bool
+	hooks    *retryHooks
+}
+
+// newRetryTransport wraps base with policy, leaving out statuses in skip
+// It returns base unchanged when the policy makes a single attempt.
+func newRetryTransport(base http.RoundTripper, policy RetryPolicy, hooks *retryHooks, skip ...int) http.RoundTripper {
+	if policy.MaxAttempts <= 1 {
+		return base
+	}
+	codes := policy.Statuses
+	if codes == nil {
+		codes = DefaultRetryStatuses
+	}
+	statuses := make(map[int]bool, len(codes))
+	for _, code := range codes {
+		statuses[code] = true
+	}
+	for _, code := range skip {
+		delete(statuses, code)
+	}
+	return &retryTransport{base: base, policy: policy, statuses: statuses, hooks: hooks}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+
+		reason := ""
+		switch {
+		case err != nil && isTransientError(req.Context(), err):
+			reason = "network"
+		case err == nil && t.statuses[resp.StatusCode]:
+			reason = strconv.Itoa(resp.StatusCode)
+		}
+		if reason == "" || attempt >= t.policy.MaxAttempts {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if wait, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if wait > t.policy.MaxDelay {
+					// The server wants longer than we are prepared to wait
+					return resp, nil
+				}
+				delay = wait
+			}
+			// Drain a little so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		if counter, ok := req.Context().Value(retryCounterKey{}).(*retryCounter); ok {
+			counter.n.Add(1)
+		}
+		if observer := t.hooks.observer.Load(); observer != nil {
+			(*observer)(reason)
+		}
+		slog.Warn("retrying fetch", "url", req.URL.String(), "reason", reason, "attempt", attempt+1,
+			"max_attempts", t.policy.MaxAttempts, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before retry number attempt, with jitter over its upper half
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.policy.BaseDelay << (attempt - 1)
+	if delay > t.policy.MaxDelay || delay <= 0 {
+		delay = t.policy.MaxDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half+1)
+}
+
+// isIdempotent reports whether a request can be sent again safely
+func isIdempotent(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+// isTransientError reports whether a transport error may succeed on retry
+// Refused targets, missing fixtures, unknown hosts and cancellations never will.
+func isTransientError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var blocked *BlockedAddressError
+	if errors.As(err, &blocked) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
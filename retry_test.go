@@ -0,0 +1,206 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docutag/scraper/models"
+)
+
+// fastRetryConfig returns a config that retries without noticeable backoff
+func fastRetryConfig() Config {
+	config := DefaultConfig()
+	config.Retry.BaseDelay = time.Millisecond
+	config.Retry.MaxDelay = 50 * time.Millisecond
+	return config
+}
+
+func TestRetryTransport(t *testing.T) {
+	var hits sync.Map
+	count := func(path string) int32 {
+		n, _ := hits.LoadOrStore(path, new(atomic.Int32))
+		return n.(*atomic.Int32).Load()
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := hits.LoadOrStore(r.URL.Path, new(atomic.Int32))
+		hit := n.(*atomic.Int32).Add(1)
+		switch r.URL.Path {
+		case "/flaky":
+			if hit <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>ok</body></html>"))
+		case "/throttled":
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/back-tomorrow":
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/dropped":
+			if hit == 1 {
+				// Close the connection without a response
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>ok</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	s := New(fastRetryConfig(), nil, nil)
+	var mu sync.Mutex
+	var reasons []string
+	s.SetRetryObserver(func(reason string) {
+		mu.Lock()
+		reasons = append(reasons, reason)
+		mu.Unlock()
+	})
+	ctx := context.Background()
+
+	t.Run("transient status is retried", func(t *testing.T) {
+		page, err := s.fetchPage(ctx, server.URL+"/flaky")
+		if err != nil {
+			t.Fatalf("fetchPage failed: %v", err)
+		}
+		if page.Info.Retries != 2 || count("/flaky") != 3 {
+			t.Errorf("retries = %d, requests = %d; want 2, 3", page.Info.Retries, count("/flaky"))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(reasons) != 2 || reasons[0] != "503" {
+			t.Errorf("observed reasons = %v, want two 503s", reasons)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		if _, err := s.fetchPage(ctx, server.URL+"/throttled"); err == nil {
+			t.Fatal("expected an error")
+		}
+		if n := count("/throttled"); n != 3 {
+			t.Errorf("requests = %d, want 3", n)
+		}
+	})
+
+	t.Run("not found is never retried", func(t *testing.T) {
+		if _, err := s.fetchPage(ctx, server.URL+"/missing"); err == nil {
+			t.Fatal("expected an error")
+		}
+		if n := count("/missing"); n != 1 {
+			t.Errorf("requests = %d, want 1", n)
+		}
+	})
+
+	t.Run("long Retry-After is not waited for", func(t *testing.T) {
+		start := time.Now()
+		if _, err := s.fetchPage(ctx, server.URL+"/back-tomorrow"); err == nil {
+			t.Fatal("expected an error")
+		}
+		if n := count("/back-tomorrow"); n != 1 || time.Since(start) > 5*time.Second {
+			t.Errorf("requests = %d after %v, want 1 without waiting", n, time.Since(start))
+		}
+	})
+
+	t.Run("dropped connection is retried", func(t *testing.T) {
+		// A fresh client, since the transport itself resends on a reused connection
+		page, err := New(fastRetryConfig(), nil, nil).fetchPage(ctx, server.URL+"/dropped")
+		if err != nil {
+			t.Fatalf("fetchPage failed: %v", err)
+		}
+		if page.Info.Retries != 1 {
+			t.Errorf("retries = %d, want 1", page.Info.Retries)
+		}
+	})
+
+	t.Run("non-idempotent requests are not retried", func(t *testing.T) {
+		resp, err := s.httpClient.Post(server.URL+"/throttled?post", "text/plain", strings.NewReader("body"))
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		if n := count("/throttled"); n != 4 {
+			t.Errorf("requests = %d, want one more than before", n)
+		}
+	})
+
+	t.Run("disabled policy makes one attempt", func(t *testing.T) {
+		config := fastRetryConfig()
+		config.Retry = RetryPolicy{}
+		before := count("/throttled")
+		New(config, nil, nil).fetchPage(ctx, server.URL+"/throttled")
+		if n := count("/throttled") - before; n != 1 {
+			t.Errorf("requests = %d, want 1", n)
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"Wed, 01 Jan 2025 12:00:30 GMT", 30 * time.Second, true},
+		{"Wed, 01 Jan 2025 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := retryAfter(c.value, now)
+		if got != c.want || ok != c.ok {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", c.value, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestScrapeWarnsOnRetries(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "[]", Done: true})
+	}))
+	defer llm.Close()
+	var hits atomic.Int32
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><title>Eventually</title></head><body><p>Hello</p></body></html>"))
+	}))
+	defer web.Close()
+
+	config := fastRetryConfig()
+	config.OllamaBaseURL = llm.URL
+	config.EnableImageAnalysis = false
+	data, err := New(config, nil, nil).ScrapeWithOptions(context.Background(), web.URL, ScrapeOptions{CleanContent: Bool(false)})
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Fetch == nil || data.Fetch.Retries != 1 {
+		t.Errorf("fetch info = %+v, want 1 retry", data.Fetch)
+	}
+	found := false
+	for _, w := range data.Warnings {
+		if strings.Contains(w, "after 1 retries") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("warnings = %v, want a retry warning", data.Warnings)
+	}
+}
@@ -0,0 +1,202 @@
+package rules
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// isCategoryPage detects if a URL is likely a category/section landing page
+// These pages are useful for link extraction but shouldn't be in the final results
+func isCategoryPage(targetURL string) bool {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return false
+	}
+
+	path := strings.Trim(parsedURL.Path, "/")
+
+	// Empty path or just the domain (homepage) - not a category
+	if path == "" {
+		return false
+	}
+
+	// Split path into segments
+	segments := strings.Split(path, "/")
+
+	// Common category/section indicators in the path
+	categoryIndicators := []string{
+		"section", "sections", "category", "categories", "topic", "topics",
+		"tag", "tags", "archive", "archives", "index",
+	}
+
+	// Check if any segment contains category indicators (strip file extensions first)
+	for _, segment := range segments {
+		segmentLower := strings.ToLower(segment)
+
+		// Strip common file extensions
+		segmentLower = strings.TrimSuffix(segmentLower, ".html")
+		segmentLower = strings.TrimSuffix(segmentLower, ".htm")
+		segmentLower = strings.TrimSuffix(segmentLower, ".php")
+		segmentLower = strings.TrimSuffix(segmentLower, ".asp")
+		segmentLower = strings.TrimSuffix(segmentLower, ".aspx")
+
+		for _, indicator := range categoryIndicators {
+			if segmentLower == indicator || strings.HasPrefix(segmentLower, indicator+"-") || strings.HasPrefix(segmentLower, indicator+"_") {
+				return true
+			}
+		}
+	}
+
+	// Common news section/category names
+	newsSections := []string{
+		// General news sections
+		"news", "world", "national", "local", "us", "uk", "international", "global",
+		"politics", "political", "government", "policy",
+		"business", "finance", "economy", "markets", "money",
+		"technology", "tech", "science", "innovation",
+		"technology", "tech", "science", "innovation",
+		"health", "medical", "wellness", "healthcare",
+		"sports", "sport", "football", "basketball", "baseball", "soccer",
+		"entertainment", "culture", "arts", "music", "movies", "film", "tv", "television",
+		"lifestyle", "life", "living", "fashion", "food", "travel", "style",
+		"opinion", "opinions", "editorial", "editorials", "commentary", "columnists",
+		"investigations", "analysis", "features", "special-reports",
+		"environment", "climate", "weather", "energy",
+		"education", "schools", "university", "college",
+		"crime", "law", "justice", "courts",
+		"religion", "faith", "beliefs",
+		"obituaries", "obits", "deaths",
+
+		// Regional/location-based sections
+		"asia", "europe", "africa", "americas", "middle-east", "middleeast",
+		"asia-pacific", "latin-america", "north-america", "south-america",
+		"england", "scotland", "wales", "northern-ireland",
+		"us-canada", "latin-america", "middle-east-asia",
+
+		// Media-specific sections
+		"video", "videos", "podcasts", "audio", "multimedia", "gallery", "galleries",
+		"photos", "pictures", "images",
+
+		// Time-based sections
+		"today", "latest", "breaking", "live", "now", "updates",
+	}
+
+	// Check if URL looks like an article (has numeric IDs or article patterns)
+	hasArticlePattern := false
+	for _, segment := range segments {
+		segmentLower := strings.ToLower(segment)
+
+		// Check for "articles" segment (common in modern news sites)
+		if segmentLower == "articles" || segmentLower == "article" || segmentLower == "story" {
+			hasArticlePattern = true
+			break
+		}
+
+		// Check for segments with 8+ digit numbers (common article IDs)
+		// e.g., "world-middle-east-12345678"
+		if len(segmentLower) >= 8 {
+			digitCount := 0
+			for _, char := range segmentLower {
+				if char >= '0' && char <= '9' {
+					digitCount++
+				}
+			}
+			if digitCount >= 8 {
+				hasArticlePattern = true
+				break
+			}
+		}
+	}
+
+	// If it has article patterns, it's not a category page
+	if hasArticlePattern {
+		return false
+	}
+
+	// Check all segments against known section names
+	// If URL has 1-4 path segments and consists primarily of section names, it's likely a category page
+	if len(segments) <= 4 {
+		sectionMatchCount := 0
+		for _, segment := range segments {
+			segmentLower := strings.ToLower(segment)
+
+			// Strip file extensions before checking
+			segmentLower = strings.TrimSuffix(segmentLower, ".html")
+			segmentLower = strings.TrimSuffix(segmentLower, ".htm")
+			segmentLower = strings.TrimSuffix(segmentLower, ".php")
+			segmentLower = strings.TrimSuffix(segmentLower, ".asp")
+			segmentLower = strings.TrimSuffix(segmentLower, ".aspx")
+
+			matched := false
+			for _, section := range newsSections {
+				// Exact match or starts with section name
+				if segmentLower == section || strings.HasPrefix(segmentLower, section+"-") || strings.HasPrefix(segmentLower, section+"_") {
+					matched = true
+					break
+				}
+				// Also check if segment starts with the section name (for compound names like "sciencetech")
+				if len(section) >= 4 && strings.HasPrefix(segmentLower, section) {
+					matched = true
+					break
+				}
+			}
+			if matched {
+				sectionMatchCount++
+			}
+		}
+
+		// If most segments are section names, it's a category page
+		// For 1-2 segments: all must match
+		// For 3 segments: at least 2 must match
+		// For 4 segments: at least 3 must match (to avoid false positives with article titles)
+		if len(segments) <= 2 && sectionMatchCount == len(segments) {
+			return true
+		}
+		if len(segments) == 3 && sectionMatchCount >= 2 {
+			return true
+		}
+		if len(segments) == 4 && sectionMatchCount >= 3 {
+			return true
+		}
+	}
+
+	// Check for patterns like "/section/name" or "/category/name" with no further depth
+	if len(segments) == 2 {
+		firstSegment := strings.ToLower(segments[0])
+		for _, indicator := range categoryIndicators {
+			if firstSegment == indicator {
+				return true
+			}
+		}
+	}
+
+	// Check for year-based archive pages (e.g., /2024/, /2024/01/)
+	if len(segments) >= 1 && len(segments) <= 3 {
+		// Check if first segment is a 4-digit year
+		if len(segments[0]) == 4 {
+			_, err := strconv.Atoi(segments[0])
+			if err == nil {
+				// If it's just /YYYY/ or /YYYY/MM/ or /YYYY/MM/DD/, likely an archive
+				if len(segments) <= 2 {
+					return true
+				}
+				// /YYYY/MM/DD/ is still a category if all are numbers
+				if len(segments) == 3 {
+					allNumbers := true
+					for _, seg := range segments {
+						if _, err := strconv.Atoi(seg); err != nil {
+							allNumbers = false
+							break
+						}
+					}
+					if allNumbers {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
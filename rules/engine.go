@@ -0,0 +1,184 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrReadOnly is returned when updating rules loaded from a source that cannot be written
+var ErrReadOnly = errors.New("rule source is read-only")
+
+// Source loads a serialized rule set
+type Source interface {
+	// Load returns the rule set document and a version that changes whenever the document does
+	Load() (doc []byte, version string, err error)
+	// String describes the source for logs and the API
+	String() string
+}
+
+// WritableSource is a Source that can store a new document
+type WritableSource interface {
+	Source
+	Save(doc []byte) error
+}
+
+// FileSource loads rules from a YAML or JSON file
+type FileSource struct {
+	Path string
+}
+
+// Load reads the file, using its modification time and size as the version
+func (f FileSource) Load() ([]byte, string, error) {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to stat rules file: %w", err)
+	}
+	doc, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read rules file: %w", err)
+	}
+	return doc, fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+func (f FileSource) String() string {
+	return "file:" + f.Path
+}
+
+// Engine holds the active rule set and reloads it from its source
+type Engine struct {
+	source Source
+
+	mu       sync.RWMutex
+	rules    *RuleSet
+	version  string
+	loadedAt time.Time
+}
+
+// NewEngine returns an engine serving a fixed rule set
+func NewEngine(rs *RuleSet) *Engine {
+	return &Engine{rules: rs, version: rs.Version, loadedAt: time.Now()}
+}
+
+// Load creates an engine from a source, failing if the initial rule set is invalid
+func Load(source Source) (*Engine, error) {
+	e := &Engine{source: source}
+	if _, err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Rules returns the active rule set
+func (e *Engine) Rules() *RuleSet {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.rules
+}
+
+// Status describes the active rule set and where it came from
+type Status struct {
+	Source   string    `json:"source"`
+	Version  string    `json:"version"`
+	LoadedAt time.Time `json:"loaded_at"`
+	Writable bool      `json:"writable"`
+	Rules    *RuleSet  `json:"rules"`
+}
+
+// Status returns the active rule set with its source and version
+func (e *Engine) Status() Status {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	status := Status{
+		Source:   "builtin",
+		Version:  e.version,
+		LoadedAt: e.loadedAt,
+		Rules:    e.rules,
+	}
+	if e.source != nil {
+		status.Source = e.source.String()
+		_, status.Writable = e.source.(WritableSource)
+	}
+	return status
+}
+
+// Reload loads the source and swaps in the new rule set if its version changed
+// An invalid document leaves the active rule set in place
+func (e *Engine) Reload() (bool, error) {
+	if e.source == nil {
+		return false, nil
+	}
+
+	doc, version, err := e.source.Load()
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.RLock()
+	unchanged := e.rules != nil && version == e.version
+	e.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	rs, err := Parse(doc)
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	e.rules = rs
+	e.version = version
+	e.loadedAt = time.Now()
+	e.mu.Unlock()
+
+	return true, nil
+}
+
+// Update validates and stores a new rule set document, then activates it
+func (e *Engine) Update(doc []byte) error {
+	writable, ok := e.source.(WritableSource)
+	if !ok {
+		return ErrReadOnly
+	}
+	if _, err := Parse(doc); err != nil {
+		return err
+	}
+	if err := writable.Save(doc); err != nil {
+		return err
+	}
+	_, err := e.Reload()
+	return err
+}
+
+// Watch polls the source for changes until ctx is cancelled
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	if e.source == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := e.Reload()
+			if err != nil {
+				slog.Warn("failed to reload rules, keeping previous rule set", "source", e.source.String(), "error", err)
+				continue
+			}
+			if changed {
+				rs := e.Rules()
+				slog.Info("reloaded rules", "source", e.source.String(), "rules", len(rs.Rules))
+			}
+		}
+	}
+}
@@ -0,0 +1,518 @@
+package rules
+
+import (
+	_ "embed"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// Target is the decision a rule applies to
+type Target string
+
+const (
+	TargetLinks  Target = "links"  // Filtering extracted links
+	TargetScore  Target = "score"  // Scoring a link's content
+	TargetImages Target = "images" // Skipping images during a scrape
+)
+
+// Targets lists every rule target
+var Targets = []Target{TargetLinks, TargetScore, TargetImages}
+
+// Action is what happens when a rule matches
+type Action string
+
+const (
+	ActionBlock  Action = "block"  // Filter the link, skip the image, or score the page low without calling Ollama
+	ActionAdjust Action = "adjust" // Add Weight to the rule-based content score
+)
+
+// DefaultBlockScore is the score given to pages blocked by a rule without an explicit score
+const DefaultBlockScore = 0.1
+
+//go:embed default.yaml
+var defaultDocument []byte
+
+var defaultRuleSet = mustParse(defaultDocument)
+
+// DefaultDocument returns the built-in rule set document
+func DefaultDocument() []byte {
+	return append([]byte(nil), defaultDocument...)
+}
+
+// Default returns the built-in rule set
+func Default() *RuleSet {
+	return defaultRuleSet
+}
+
+// RuleSet is a declarative set of domain lists and URL rules
+// Rule sets are immutable once parsed and safe for concurrent use
+type RuleSet struct {
+	Version string  `yaml:"version,omitempty" json:"version,omitempty"`
+	Domains Domains `yaml:"domains" json:"domains"`
+	Rules   []*Rule `yaml:"rules" json:"rules"`
+}
+
+// Domains holds the domain allow and deny lists
+// Domains match the host itself and any subdomain
+type Domains struct {
+	Allow []string     `yaml:"allow,omitempty" json:"allow,omitempty"` // Never blocked by rules
+	Deny  []DomainRule `yaml:"deny,omitempty" json:"deny,omitempty"`   // Filtered from links and scored low
+}
+
+// DomainRule is a denied domain and the category it is reported under
+type DomainRule struct {
+	Domain   string `yaml:"domain" json:"domain"`
+	Category string `yaml:"category" json:"category"`
+}
+
+// Rule matches URLs and applies an action to one or more targets
+type Rule struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Targets     []Target `yaml:"targets" json:"targets"`
+	Action      Action   `yaml:"action" json:"action"`
+	Score       *float64 `yaml:"score,omitempty" json:"score,omitempty"`   // Score for blocked pages (default DefaultBlockScore)
+	Weight      float64  `yaml:"weight,omitempty" json:"weight,omitempty"` // Score adjustment for adjust rules
+	Reason      string   `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Categories  []string `yaml:"categories,omitempty" json:"categories,omitempty"`
+	Indicators  []string `yaml:"indicators,omitempty" json:"indicators,omitempty"`
+	Match       Match    `yaml:"match" json:"match"`
+}
+
+// Match holds a rule's conditions
+// Every condition that is set must match; a list condition matches if any entry does
+type Match struct {
+	Domains       []string `yaml:"domains,omitempty" json:"domains,omitempty"`               // Host or parent domain
+	HostRegex     string   `yaml:"host_regex,omitempty" json:"host_regex,omitempty"`         // Regular expression on the host
+	Paths         []string `yaml:"paths,omitempty" json:"paths,omitempty"`                   // Globs on the path; * stays within a segment, ** spans segments
+	PathRegex     string   `yaml:"path_regex,omitempty" json:"path_regex,omitempty"`         // Regular expression on the path
+	Segments      []string `yaml:"segments,omitempty" json:"segments,omitempty"`             // Exact path segment, ignoring page extensions
+	Extensions    []string `yaml:"extensions,omitempty" json:"extensions,omitempty"`         // Path suffix such as ".mp3"
+	Fragments     []string `yaml:"fragments,omitempty" json:"fragments,omitempty"`           // URL fragment without the leading #
+	Keywords      []string `yaml:"keywords,omitempty" json:"keywords,omitempty"`             // Substring anywhere in the URL
+	TitleKeywords []string `yaml:"title_keywords,omitempty" json:"title_keywords,omitempty"` // Substring in the page title
+	CategoryPage  bool     `yaml:"category_page,omitempty" json:"category_page,omitempty"`   // Section or archive landing page
+
+	hostRegex *regexp.Regexp
+	pathRegex *regexp.Regexp
+	paths     []*regexp.Regexp
+}
+
+// Parse parses and validates a YAML or JSON rule set document
+func Parse(doc []byte) (*RuleSet, error) {
+	var rs RuleSet
+	if err := yaml.UnmarshalStrict(doc, &rs); err != nil {
+		return nil, fmt.Errorf("failed to parse rule set: %w", err)
+	}
+	if err := rs.compile(); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+func mustParse(doc []byte) *RuleSet {
+	rs, err := Parse(doc)
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in rule set: %v", err))
+	}
+	return rs
+}
+
+// compile validates the rule set and prepares its patterns
+func (rs *RuleSet) compile() error {
+	for i, domain := range rs.Domains.Allow {
+		rs.Domains.Allow[i] = normalizeDomain(domain)
+	}
+	for i, deny := range rs.Domains.Deny {
+		if deny.Domain == "" || deny.Category == "" {
+			return fmt.Errorf("deny entry %d needs a domain and a category", i)
+		}
+		rs.Domains.Deny[i].Domain = normalizeDomain(deny.Domain)
+	}
+
+	names := make(map[string]bool, len(rs.Rules))
+	for i, rule := range rs.Rules {
+		if rule == nil || rule.Name == "" {
+			return fmt.Errorf("rule %d has no name", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate rule name: %s", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if err := rule.compile(); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) compile() error {
+	if len(r.Targets) == 0 {
+		return fmt.Errorf("at least one target is required")
+	}
+	for _, target := range r.Targets {
+		if target != TargetLinks && target != TargetScore && target != TargetImages {
+			return fmt.Errorf("invalid target: %s", target)
+		}
+	}
+
+	switch r.Action {
+	case ActionBlock:
+		if r.Score != nil && (*r.Score < 0 || *r.Score > 1) {
+			return fmt.Errorf("score must be between 0 and 1")
+		}
+	case ActionAdjust:
+		if r.Weight < -1 || r.Weight > 1 {
+			return fmt.Errorf("weight must be between -1 and 1")
+		}
+	default:
+		return fmt.Errorf("invalid action: %q", r.Action)
+	}
+
+	m := &r.Match
+	if len(m.Domains) == 0 && m.HostRegex == "" && len(m.Paths) == 0 && m.PathRegex == "" &&
+		len(m.Segments) == 0 && len(m.Extensions) == 0 && len(m.Fragments) == 0 &&
+		len(m.Keywords) == 0 && len(m.TitleKeywords) == 0 && !m.CategoryPage {
+		return fmt.Errorf("at least one match condition is required")
+	}
+
+	var err error
+	if m.HostRegex != "" {
+		if m.hostRegex, err = regexp.Compile(m.HostRegex); err != nil {
+			return fmt.Errorf("invalid host_regex: %w", err)
+		}
+	}
+	if m.PathRegex != "" {
+		if m.pathRegex, err = regexp.Compile(m.PathRegex); err != nil {
+			return fmt.Errorf("invalid path_regex: %w", err)
+		}
+	}
+	m.paths = make([]*regexp.Regexp, 0, len(m.Paths))
+	for _, glob := range m.Paths {
+		re, err := compileGlob(glob)
+		if err != nil {
+			return err
+		}
+		m.paths = append(m.paths, re)
+	}
+
+	for i, domain := range m.Domains {
+		m.Domains[i] = normalizeDomain(domain)
+	}
+	lowerAll(m.Segments)
+	lowerAll(m.Extensions)
+	lowerAll(m.Fragments)
+	lowerAll(m.Keywords)
+	lowerAll(m.TitleKeywords)
+
+	return nil
+}
+
+// compileGlob converts a path glob to a regular expression
+// * matches within a path segment and ** matches across segments
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	if !strings.HasPrefix(glob, "/") {
+		return nil, fmt.Errorf("path glob must start with /: %s", glob)
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(strings.ToLower(b.String()))
+}
+
+// Hit records a rule that matched and the condition that matched it
+type Hit struct {
+	Rule      string  `json:"rule"`
+	Action    Action  `json:"action"`
+	Condition string  `json:"condition"`
+	Weight    float64 `json:"weight,omitempty"`
+	Skipped   bool    `json:"skipped,omitempty"` // Block ignored because the domain is allowed
+}
+
+// Result is the outcome of evaluating a URL for one target
+type Result struct {
+	Target     Target   `json:"target"`
+	Blocked    bool     `json:"blocked"`
+	Allowed    bool     `json:"allowed"`              // Host is on the allow list
+	Score      float64  `json:"score,omitempty"`      // Score of the first block (score target only)
+	Reason     string   `json:"reason,omitempty"`     // Reason of the first block
+	Categories []string `json:"categories,omitempty"` // Categories of the first block and all adjustments
+	Indicators []string `json:"indicators,omitempty"` // Indicators of the first block
+	Adjustment float64  `json:"adjustment,omitempty"` // Sum of adjust rule weights
+	Reasons    []string `json:"reasons,omitempty"`    // Reasons of adjust rules
+	Hits       []Hit    `json:"hits"`                 // Every rule that matched, in evaluation order
+}
+
+// Evaluate evaluates a URL (and optionally its page title) against the rules for target
+// The allow list is checked first, then the deny list, then rules in order; the first
+// block determines the result and later matches are still recorded for explanation
+func (rs *RuleSet) Evaluate(target Target, rawURL, title string) Result {
+	result := Result{Target: target, Hits: []Hit{}}
+	in := newInput(rawURL, title)
+
+	for _, domain := range rs.Domains.Allow {
+		if in.inDomain(domain) {
+			result.Allowed = true
+			result.Hits = append(result.Hits, Hit{Rule: "domains.allow", Action: "allow", Condition: "domain " + domain})
+			break
+		}
+	}
+
+	if target != TargetImages {
+		for _, deny := range rs.Domains.Deny {
+			if !in.inDomain(deny.Domain) {
+				continue
+			}
+			hit := Hit{Rule: "domains.deny", Action: ActionBlock, Condition: "domain " + deny.Domain}
+			if result.Allowed {
+				hit.Skipped = true
+			} else if !result.Blocked {
+				result.Blocked = true
+				result.Score = DefaultBlockScore
+				result.Reason = "Blocked content type detected: " + deny.Category
+				result.Categories = []string{deny.Category, "low-quality"}
+				result.Indicators = []string{deny.Category}
+			}
+			result.Hits = append(result.Hits, hit)
+		}
+	}
+
+	for _, rule := range rs.Rules {
+		if !rule.appliesTo(target) {
+			continue
+		}
+		condition, ok := rule.Match.match(in)
+		if !ok {
+			continue
+		}
+
+		hit := Hit{Rule: rule.Name, Action: rule.Action, Condition: condition}
+		switch rule.Action {
+		case ActionBlock:
+			if result.Allowed {
+				hit.Skipped = true
+			} else if !result.Blocked {
+				result.Blocked = true
+				result.Score = DefaultBlockScore
+				if rule.Score != nil {
+					result.Score = *rule.Score
+				}
+				result.Reason = rule.Reason
+				if result.Reason == "" {
+					result.Reason = "Blocked by rule " + rule.Name
+				}
+				result.Categories = append(result.Categories, rule.Categories...)
+				result.Indicators = append([]string(nil), rule.Indicators...)
+			}
+		case ActionAdjust:
+			hit.Weight = rule.Weight
+			result.Adjustment += rule.Weight
+			result.Categories = append(result.Categories, rule.Categories...)
+			if rule.Reason != "" {
+				result.Reasons = append(result.Reasons, rule.Reason)
+			}
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+
+	return result
+}
+
+// WithAllowed returns a copy of the rule set with extra domains on the allow list
+func (rs *RuleSet) WithAllowed(domains ...string) *RuleSet {
+	allowed := *rs
+	allowed.Domains.Allow = make([]string, 0, len(rs.Domains.Allow)+len(domains))
+	allowed.Domains.Allow = append(allowed.Domains.Allow, rs.Domains.Allow...)
+	for _, domain := range domains {
+		allowed.Domains.Allow = append(allowed.Domains.Allow, normalizeDomain(domain))
+	}
+	return &allowed
+}
+
+// Blocked reports whether a URL is blocked for target
+func (rs *RuleSet) Blocked(target Target, rawURL string) bool {
+	return rs.Evaluate(target, rawURL, "").Blocked
+}
+
+func (r *Rule) appliesTo(target Target) bool {
+	for _, t := range r.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// input is a URL split into the parts rules match against
+type input struct {
+	url      string // Lowercased full URL
+	host     string
+	path     string
+	segments []string
+	fragment string
+	title    string
+}
+
+func newInput(rawURL, title string) input {
+	in := input{
+		url:   strings.ToLower(rawURL),
+		title: strings.ToLower(title),
+	}
+	if parsed, err := url.Parse(rawURL); err == nil {
+		in.host = strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+		in.path = strings.ToLower(parsed.Path)
+		in.fragment = strings.ToLower(parsed.Fragment)
+		for _, segment := range strings.Split(strings.Trim(in.path, "/"), "/") {
+			if segment != "" {
+				in.segments = append(in.segments, stripPageExtension(segment))
+			}
+		}
+	}
+	return in
+}
+
+func (in input) inDomain(domain string) bool {
+	return in.host == domain || strings.HasSuffix(in.host, "."+domain)
+}
+
+// match reports whether every set condition matches, describing the first matching entry of each
+func (m *Match) match(in input) (string, bool) {
+	var conditions []string
+
+	if len(m.Domains) > 0 {
+		matched := first(m.Domains, in.inDomain)
+		if matched == "" {
+			return "", false
+		}
+		conditions = append(conditions, "domain "+matched)
+	}
+	if m.hostRegex != nil {
+		if !m.hostRegex.MatchString(in.host) {
+			return "", false
+		}
+		conditions = append(conditions, fmt.Sprintf("host matches %q", m.HostRegex))
+	}
+	if len(m.paths) > 0 {
+		matched := ""
+		for i, re := range m.paths {
+			if re.MatchString(in.path) {
+				matched = m.Paths[i]
+				break
+			}
+		}
+		if matched == "" {
+			return "", false
+		}
+		conditions = append(conditions, "path "+matched)
+	}
+	if m.pathRegex != nil {
+		if !m.pathRegex.MatchString(in.path) {
+			return "", false
+		}
+		conditions = append(conditions, fmt.Sprintf("path matches %q", m.PathRegex))
+	}
+	if len(m.Segments) > 0 {
+		matched := first(m.Segments, func(s string) bool { return containsString(in.segments, s) })
+		if matched == "" {
+			return "", false
+		}
+		conditions = append(conditions, "segment "+matched)
+	}
+	if len(m.Extensions) > 0 {
+		matched := first(m.Extensions, func(ext string) bool { return strings.HasSuffix(in.path, ext) })
+		if matched == "" {
+			return "", false
+		}
+		conditions = append(conditions, "extension "+matched)
+	}
+	if len(m.Fragments) > 0 {
+		matched := first(m.Fragments, func(f string) bool { return in.fragment == f })
+		if matched == "" {
+			return "", false
+		}
+		conditions = append(conditions, "fragment #"+matched)
+	}
+	if len(m.Keywords) > 0 {
+		matched := first(m.Keywords, func(k string) bool { return strings.Contains(in.url, k) })
+		if matched == "" {
+			return "", false
+		}
+		conditions = append(conditions, "keyword "+matched)
+	}
+	if len(m.TitleKeywords) > 0 {
+		matched := first(m.TitleKeywords, func(k string) bool { return strings.Contains(in.title, k) })
+		if matched == "" {
+			return "", false
+		}
+		conditions = append(conditions, "title keyword "+matched)
+	}
+	if m.CategoryPage {
+		if !isCategoryPage(in.url) {
+			return "", false
+		}
+		conditions = append(conditions, "category page")
+	}
+
+	return strings.Join(conditions, ", "), true
+}
+
+// first returns the first entry for which match is true, or ""
+func first(entries []string, match func(string) bool) string {
+	for _, entry := range entries {
+		if match(entry) {
+			return entry
+		}
+	}
+	return ""
+}
+
+// stripPageExtension removes common page extensions from a path segment
+func stripPageExtension(segment string) string {
+	switch ext := path.Ext(segment); ext {
+	case ".html", ".htm", ".php", ".asp", ".aspx":
+		return strings.TrimSuffix(segment, ext)
+	}
+	return segment
+}
+
+func normalizeDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+}
+
+func lowerAll(values []string) {
+	for i, v := range values {
+		values[i] = strings.ToLower(v)
+	}
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,554 @@
+package rules
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDefaultRuleSet(t *testing.T) {
+	rs := Default()
+	if len(rs.Rules) == 0 {
+		t.Fatal("Expected built-in rules")
+	}
+
+	reparsed, err := Parse(DefaultDocument())
+	if err != nil {
+		t.Fatalf("Failed to parse built-in document: %v", err)
+	}
+	if len(reparsed.Rules) != len(rs.Rules) {
+		t.Errorf("Expected %d rules, got %d", len(rs.Rules), len(reparsed.Rules))
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	doc := `{
+		"domains": {"allow": ["www.Example.com"], "deny": [{"domain": "spam.test", "category": "spam"}]},
+		"rules": [{"name": "drafts", "targets": ["links"], "action": "block", "match": {"paths": ["/drafts/**"]}}]
+	}`
+
+	rs, err := Parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if rs.Domains.Allow[0] != "example.com" {
+		t.Errorf("Expected allow domain to be normalized, got %q", rs.Domains.Allow[0])
+	}
+	if !rs.Blocked(TargetLinks, "https://example.org/drafts/2024/post") {
+		t.Error("Expected drafts path to be blocked")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"unknown field", "rules:\n  - name: a\n    targets: [links]\n    action: block\n    match: {colour: red}", "colour"},
+		{"missing name", "rules:\n  - targets: [links]\n    action: block\n    match: {keywords: [x]}", "no name"},
+		{"duplicate name", "rules:\n  - {name: a, targets: [links], action: block, match: {keywords: [x]}}\n  - {name: a, targets: [links], action: block, match: {keywords: [y]}}", "duplicate"},
+		{"no targets", "rules:\n  - {name: a, action: block, match: {keywords: [x]}}", "target"},
+		{"bad target", "rules:\n  - {name: a, targets: [pages], action: block, match: {keywords: [x]}}", "invalid target"},
+		{"bad action", "rules:\n  - {name: a, targets: [links], action: drop, match: {keywords: [x]}}", "invalid action"},
+		{"no conditions", "rules:\n  - {name: a, targets: [links], action: block, match: {}}", "match condition"},
+		{"bad regex", "rules:\n  - {name: a, targets: [links], action: block, match: {host_regex: '('}}", "host_regex"},
+		{"relative glob", "rules:\n  - {name: a, targets: [links], action: block, match: {paths: [drafts]}}", "must start with /"},
+		{"score out of range", "rules:\n  - {name: a, targets: [score], action: block, score: 2, match: {keywords: [x]}}", "score"},
+		{"weight out of range", "rules:\n  - {name: a, targets: [score], action: adjust, weight: -3, match: {keywords: [x]}}", "weight"},
+		{"deny without category", "domains:\n  deny: [{domain: a.com}]", "category"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.doc))
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDefaultRulesAvoidSubstringFalsePositives(t *testing.T) {
+	rs := Default()
+
+	tests := []struct {
+		url     string
+		target  Target
+		blocked bool
+	}{
+		// Previously matched "bet" and "/team" as substrings
+		{"https://betterexplained.com/articles/intuitive-guide", TargetScore, false},
+		{"https://example.com/sport/team-wins-championship-12345678", TargetLinks, false},
+		{"https://example.com/news/author/jane-doe-interview-12345678", TargetLinks, false},
+		{"https://example.com/blog/subscribers-share-their-stories", TargetLinks, false},
+		// Still blocked
+		{"https://bet365.com/", TargetScore, true},
+		{"https://www.betcasino.com", TargetScore, true},
+		{"https://example.com/about/team", TargetLinks, true},
+		{"https://example.com/team", TargetScore, true},
+		{"https://example.com/login.php", TargetScore, true},
+		{"https://example.com/post#comments", TargetLinks, true},
+		{"https://example.com/report.pdf", TargetLinks, true},
+		{"https://example.com/podcast/episode.mp3?token=abc", TargetLinks, true},
+		{"https://m.facebook.com/some-page", TargetLinks, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			result := rs.Evaluate(tt.target, tt.url, "")
+			if result.Blocked != tt.blocked {
+				t.Errorf("Blocked = %v, want %v (hits: %+v)", result.Blocked, tt.blocked, result.Hits)
+			}
+		})
+	}
+}
+
+func TestEvaluateExplanation(t *testing.T) {
+	doc := `
+domains:
+  allow: [trusted.example]
+  deny:
+    - {domain: social.example, category: social-media}
+rules:
+  - name: login
+    targets: [links, score]
+    action: block
+    score: 0.05
+    reason: Login page
+    categories: [account]
+    indicators: [account-page]
+    match:
+      segments: [login]
+  - name: docs
+    targets: [score]
+    action: adjust
+    weight: 0.2
+    reason: Documentation
+    categories: [docs]
+    match:
+      paths: ["/docs/**"]
+  - name: guides
+    targets: [score]
+    action: adjust
+    weight: 0.1
+    match:
+      path_regex: '^/docs/guides?/'
+      title_keywords: [guide]
+`
+	rs, err := Parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	t.Run("block", func(t *testing.T) {
+		result := rs.Evaluate(TargetScore, "https://site.example/login", "")
+		if !result.Blocked || result.Score != 0.05 || result.Reason != "Login page" {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		if len(result.Hits) != 1 || result.Hits[0].Rule != "login" || result.Hits[0].Condition != "segment login" {
+			t.Errorf("Unexpected hits: %+v", result.Hits)
+		}
+	})
+
+	t.Run("deny list", func(t *testing.T) {
+		result := rs.Evaluate(TargetLinks, "https://www.social.example/login", "")
+		if !result.Blocked || result.Reason != "Blocked content type detected: social-media" {
+			t.Errorf("Expected deny list to decide the result, got: %+v", result)
+		}
+		// The login rule still matches and is recorded
+		if len(result.Hits) != 2 || result.Hits[1].Rule != "login" {
+			t.Errorf("Expected both matches to be explained, got: %+v", result.Hits)
+		}
+	})
+
+	t.Run("deny list ignored for images", func(t *testing.T) {
+		if rs.Blocked(TargetImages, "https://social.example/photo.jpg") {
+			t.Error("Expected deny list not to apply to images")
+		}
+	})
+
+	t.Run("allow list", func(t *testing.T) {
+		result := rs.Evaluate(TargetScore, "https://docs.trusted.example/login", "")
+		if result.Blocked || !result.Allowed {
+			t.Errorf("Expected allowed domain not to be blocked, got: %+v", result)
+		}
+		if len(result.Hits) != 2 || !result.Hits[1].Skipped {
+			t.Errorf("Expected skipped block to be explained, got: %+v", result.Hits)
+		}
+	})
+
+	t.Run("adjust", func(t *testing.T) {
+		result := rs.Evaluate(TargetScore, "https://site.example/docs/guides/setup", "Setup Guide")
+		if result.Blocked {
+			t.Fatal("Adjust rules should not block")
+		}
+		if result.Adjustment < 0.29 || result.Adjustment > 0.31 {
+			t.Errorf("Expected adjustment 0.3, got %.2f", result.Adjustment)
+		}
+		if len(result.Reasons) != 1 || result.Reasons[0] != "Documentation" {
+			t.Errorf("Unexpected reasons: %v", result.Reasons)
+		}
+		if len(result.Hits) != 2 || !strings.Contains(result.Hits[1].Condition, "title keyword guide") {
+			t.Errorf("Unexpected hits: %+v", result.Hits)
+		}
+	})
+
+	t.Run("all conditions must match", func(t *testing.T) {
+		result := rs.Evaluate(TargetScore, "https://site.example/docs/guides/setup", "Setup")
+		if len(result.Hits) != 1 {
+			t.Errorf("Expected only the docs rule to match, got: %+v", result.Hits)
+		}
+	})
+}
+
+func TestWithAllowed(t *testing.T) {
+	rs := Default()
+	allowed := rs.WithAllowed("WWW.Reddit.com")
+
+	if !rs.Blocked(TargetLinks, "https://reddit.com/r/golang") {
+		t.Fatal("expected reddit.com to be denied by the default rules")
+	}
+	if allowed.Blocked(TargetLinks, "https://old.reddit.com/r/golang") {
+		t.Error("expected allowed domain and its subdomains not to be blocked")
+	}
+	if len(rs.Domains.Allow) != 0 {
+		t.Errorf("WithAllowed modified the original rule set: %v", rs.Domains.Allow)
+	}
+}
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"/drafts", "/drafts", true},
+		{"/drafts", "/drafts/x", false},
+		{"/drafts/*", "/drafts/x", true},
+		{"/drafts/*", "/drafts/x/y", false},
+		{"/drafts/**", "/drafts/x/y", true},
+		{"/**/print", "/a/b/print", true},
+		{"/page-?", "/page-2", true},
+		{"/Tags/*", "/tags/go", true},
+		{"/a.b", "/axb", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.path, func(t *testing.T) {
+			re, err := compileGlob(tt.glob)
+			if err != nil {
+				t.Fatalf("compileGlob failed: %v", err)
+			}
+			if got := re.MatchString(tt.path); got != tt.match {
+				t.Errorf("match = %v, want %v", got, tt.match)
+			}
+		})
+	}
+}
+
+func TestEngineReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	write := func(doc string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	base := time.Now().Add(-time.Hour)
+	write("rules:\n  - {name: a, targets: [links], action: block, match: {segments: [drafts]}}\n", base)
+
+	engine, err := Load(FileSource{Path: path})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !engine.Rules().Blocked(TargetLinks, "https://example.com/drafts") {
+		t.Error("Expected initial rules to be active")
+	}
+
+	changed, err := engine.Reload()
+	if err != nil || changed {
+		t.Errorf("Expected unchanged file not to reload, got changed=%v err=%v", changed, err)
+	}
+
+	// Invalid documents keep the previous rule set
+	write("rules: [", base.Add(time.Minute))
+	if _, err := engine.Reload(); err == nil {
+		t.Error("Expected invalid document to fail")
+	}
+	if !engine.Rules().Blocked(TargetLinks, "https://example.com/drafts") {
+		t.Error("Expected previous rules to stay active")
+	}
+
+	write("rules:\n  - {name: b, targets: [links], action: block, match: {segments: [archive]}}\n", base.Add(2*time.Minute))
+	changed, err = engine.Reload()
+	if err != nil || !changed {
+		t.Fatalf("Expected reload, got changed=%v err=%v", changed, err)
+	}
+	if engine.Rules().Blocked(TargetLinks, "https://example.com/drafts") {
+		t.Error("Expected old rules to be replaced")
+	}
+
+	if err := engine.Update([]byte("rules: []")); err != ErrReadOnly {
+		t.Errorf("Expected ErrReadOnly for file source, got %v", err)
+	}
+
+	status := engine.Status()
+	if status.Source != "file:"+path || status.Writable {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+}
+
+// memorySource is a writable in-memory Source
+type memorySource struct {
+	mu      sync.Mutex
+	doc     []byte
+	version int
+}
+
+func (m *memorySource) Load() ([]byte, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.doc, strconv.Itoa(m.version), nil
+}
+
+func (m *memorySource) Save(doc []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.doc = doc
+	m.version++
+	return nil
+}
+
+func (m *memorySource) String() string { return "memory" }
+
+func TestEngineUpdate(t *testing.T) {
+	source := &memorySource{doc: DefaultDocument()}
+	engine, err := Load(source)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if err := engine.Update([]byte("rules: [{name: a}]")); err == nil {
+		t.Error("Expected invalid update to fail")
+	}
+	if source.version != 0 {
+		t.Error("Invalid documents must not be saved")
+	}
+
+	if err := engine.Update([]byte("rules:\n  - {name: a, targets: [images], action: block, match: {keywords: [cat]}}\n")); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if !engine.Rules().Blocked(TargetImages, "https://example.com/cat.jpg") {
+		t.Error("Expected updated rules to be active")
+	}
+	if !engine.Status().Writable {
+		t.Error("Expected writable status")
+	}
+}
+
+func TestEngineWatch(t *testing.T) {
+	source := &memorySource{doc: []byte("rules: []")}
+	engine, err := Load(source)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		engine.Watch(ctx, 5*time.Millisecond)
+		close(done)
+	}()
+
+	source.Save([]byte("rules:\n  - {name: a, targets: [links], action: block, match: {segments: [x]}}\n"))
+
+	deadline := time.Now().Add(time.Second)
+	for !engine.Rules().Blocked(TargetLinks, "https://example.com/x") {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected watcher to pick up the new rules")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestIsCategoryPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		isCategory bool
+	}{
+		// Should be detected as category pages
+		{
+			name:       "BBC Arts section",
+			url:        "https://www.bbc.com/arts",
+			isCategory: true,
+		},
+		{
+			name:       "Guardian World Asia",
+			url:        "https://www.theguardian.com/world/asia",
+			isCategory: true,
+		},
+		{
+			name:       "NYTimes Politics",
+			url:        "https://www.nytimes.com/section/politics",
+			isCategory: true,
+		},
+		{
+			name:       "CNN Business",
+			url:        "https://www.cnn.com/business",
+			isCategory: true,
+		},
+		{
+			name:       "Tech category",
+			url:        "https://example.com/technology",
+			isCategory: true,
+		},
+		{
+			name:       "Sports section",
+			url:        "https://example.com/sports",
+			isCategory: true,
+		},
+		{
+			name:       "Category with subcategory",
+			url:        "https://example.com/category/tech",
+			isCategory: true,
+		},
+		{
+			name:       "Year archive",
+			url:        "https://example.com/2024",
+			isCategory: true,
+		},
+		{
+			name:       "Year/Month archive",
+			url:        "https://example.com/2024/01",
+			isCategory: true,
+		},
+		{
+			name:       "Year/Month/Day archive (all numbers)",
+			url:        "https://example.com/2024/01/15",
+			isCategory: true,
+		},
+		{
+			name:       "Tag page",
+			url:        "https://example.com/tag/ai",
+			isCategory: true,
+		},
+		{
+			name:       "Topic page",
+			url:        "https://example.com/topic/climate",
+			isCategory: true,
+		},
+		{
+			name:       "Multiple sections",
+			url:        "https://example.com/world",
+			isCategory: true,
+		},
+		{
+			name:       "Opinion section",
+			url:        "https://example.com/opinion",
+			isCategory: true,
+		},
+		{
+			name:       "Guardian Science section",
+			url:        "https://www.theguardian.com/science",
+			isCategory: true,
+		},
+		{
+			name:       "DailyMail sciencetech index",
+			url:        "https://www.dailymail.co.uk/sciencetech/index.html",
+			isCategory: true,
+		},
+		{
+			name:       "BBC News section",
+			url:        "https://www.bbc.com/news",
+			isCategory: true,
+		},
+		{
+			name:       "BBC World/Asia section",
+			url:        "https://www.bbc.com/news/world/asia",
+			isCategory: true,
+		},
+		{
+			name:       "BBC World/Europe section",
+			url:        "https://www.bbc.com/news/world/europe",
+			isCategory: true,
+		},
+
+		// Should NOT be detected as category pages
+		{
+			name:       "Homepage",
+			url:        "https://www.bbc.com",
+			isCategory: false,
+		},
+		{
+			name:       "Article with world in path",
+			url:        "https://www.theguardian.com/world/asia/2024/jan/15/story-title",
+			isCategory: false,
+		},
+		{
+			name:       "Specific article",
+			url:        "https://www.bbc.com/news/article-title-12345",
+			isCategory: false,
+		},
+		{
+			name:       "BBC article with 8-digit ID",
+			url:        "https://www.bbc.com/news/world-middle-east-12345678",
+			isCategory: false,
+		},
+		{
+			name:       "BBC article in articles path",
+			url:        "https://www.bbc.com/news/articles/c1234567",
+			isCategory: false,
+		},
+		{
+			name:       "Article with date in path",
+			url:        "https://example.com/2024/01/15/article-title",
+			isCategory: false,
+		},
+		{
+			name:       "Deep nested article",
+			url:        "https://example.com/world/asia/china/article-name",
+			isCategory: false,
+		},
+		{
+			name:       "Article with ID",
+			url:        "https://example.com/article/12345",
+			isCategory: false,
+		},
+		{
+			name:       "Blog post",
+			url:        "https://example.com/blog/my-post-title",
+			isCategory: false,
+		},
+		{
+			name:       "Invalid URL",
+			url:        "not a url",
+			isCategory: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := isCategoryPage(tt.url)
+			if result != tt.isCategory {
+				t.Errorf("isCategoryPage(%q) = %v, want %v", tt.url, result, tt.isCategory)
+			}
+		})
+	}
+}
@@ -3,9 +3,9 @@ package scraper
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	_ "image/gif"  // Register GIF format
@@ -19,15 +19,21 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	exif "github.com/rwcarlsen/goexif/exif"
 	_ "golang.org/x/image/webp" // Register WebP format
+	"github.com/docutag/scraper/feed"
+	"github.com/docutag/scraper/fixture"
+	"github.com/docutag/scraper/imaging"
+	"github.com/docutag/scraper/lang"
 	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/ocr"
 	"github.com/docutag/scraper/ollama"
+	"github.com/docutag/scraper/rules"
 	"github.com/docutag/scraper/slug"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"golang.org/x/net/html"
 )
 
@@ -38,47 +44,89 @@ const (
 
 // Config contains scraper configuration
 type Config struct {
-	HTTPTimeout         time.Duration
-	OllamaBaseURL       string
-	OllamaModel         string
-	OllamaVisionModel   string        // Separate model for vision tasks (can be same as OllamaModel)
-	EnableImageAnalysis bool          // Enable AI-powered image analysis
-	MaxImageSizeBytes   int64         // Maximum image size to download (bytes)
-	ImageTimeout        time.Duration // Timeout for downloading individual images
-	LinkScoreThreshold  float64       // Minimum score for link to be recommended (0.0-1.0)
-	StoragePath         string        // Base path for filesystem storage
-	MaxImages           int           // Maximum number of images to download per scrape (0 = unlimited)
+	HTTPTimeout            time.Duration
+	OllamaBaseURL          string
+	OllamaModel            string
+	OllamaVisionModel      string                  // Separate model for vision tasks (can be same as OllamaModel)
+	EnableImageAnalysis    bool                    // Enable AI-powered image analysis
+	MaxImageSizeBytes      int64                   // Maximum image size to download (bytes)
+	MaxPageSizeBytes       int64                   // Maximum page size to read (bytes, 0 = unlimited); larger pages are truncated
+	ImageTimeout           time.Duration           // Timeout for downloading individual images
+	LinkScoreThreshold     float64                 // Minimum score for link to be recommended (0.0-1.0)
+	StoragePath            string                  // Base path for filesystem storage
+	MaxImages              int                     // Maximum number of images to download per scrape (0 = unlimited)
+	OllamaAutoPull         bool                    // Pull missing Ollama models at startup
+	OllamaBreakerThreshold int                     // Consecutive Ollama failures before the circuit breaker opens
+	OllamaBreakerCooldown  time.Duration           // How long the breaker stays open before probing Ollama again
+	OllamaEndpoints        []ollama.EndpointConfig // Text model endpoints (defaults to OllamaBaseURL)
+	OllamaVisionEndpoints  []ollama.EndpointConfig // Vision model endpoints (defaults to the text endpoints)
+	OllamaRouting          ollama.RoutingStrategy  // How requests are spread across endpoints (default least-loaded)
+	OllamaMaxConcurrent    int                     // Default concurrent requests per endpoint
+	Rules                  *rules.Engine           // Link filtering and scoring rules (defaults to the built-in rule set)
+	Recorder               *fixture.Recorder       // Record every HTTP and Ollama exchange into a fixture archive
+	Replay                 *fixture.Archive        // Serve HTTP and Ollama responses from a fixture archive instead of the network
+	TranslateTo            string                  // Translate content into this language code (empty = keep the page language)
+	BlockPrivateNetworks   bool                    // Refuse to fetch pages and images from private, loopback and link-local addresses
+	AllowedHosts           []string                // Hostnames, "*.domain" wildcards, IPs or CIDRs exempt from BlockPrivateNetworks
+	NetworkProfiles        []NetworkProfile        // Proxies, headers, cookies and TLS settings per domain (default: direct fetching)
+	Retry                  RetryPolicy             // Retries of transient page and image fetch failures (zero value disables retries)
+	ImageMetadataPolicy    imaging.MetadataPolicy  // Metadata removed from image bytes before they are stored (empty keeps everything)
+	RetainImageMetadata    bool                    // Keep the EXIF the policy removes in the database, readable only by admins
+	ImageSafetyAction      SafetyAction            // What happens to images the vision model flags as unsafe (empty = no classification)
+	ImageSafetyThreshold   float64                 // Confidence (0.0-1.0) at which a safety category flags an image
+	OCREngine              ocr.Engine              // Dedicated OCR engine for text in images (nil = the vision model)
+	OCRVisionFallback      bool                    // Extract text with the vision model when the OCR engine fails
+	OCRMinConfidence       float64                 // Confidence (0.0-1.0) below which OCR text blocks are dropped
 }
 
 // DefaultConfig returns default scraper configuration
 func DefaultConfig() Config {
 	return Config{
-		HTTPTimeout:         30 * time.Second,
-		OllamaBaseURL:       ollama.DefaultBaseURL,
-		OllamaModel:         ollama.DefaultModel,
-		OllamaVisionModel:   ollama.DefaultModel, // Default to same model as text
-		EnableImageAnalysis: true,                // Enable image analysis by default
-		MaxImageSizeBytes:   10 * 1024 * 1024,    // 10MB max image size
-		ImageTimeout:        15 * time.Second,    // 15s timeout per image
-		LinkScoreThreshold:  0.5,                 // Default threshold for link scoring
-		StoragePath:         "./storage",         // Default storage path
-		MaxImages:           20,                  // Download max 20 images per scrape
+		HTTPTimeout:            30 * time.Second,
+		OllamaBaseURL:          ollama.DefaultBaseURL,
+		OllamaModel:            ollama.DefaultModel,
+		OllamaVisionModel:      ollama.DefaultModel, // Default to same model as text
+		EnableImageAnalysis:    true,                // Enable image analysis by default
+		MaxImageSizeBytes:      10 * 1024 * 1024,    // 10MB max image size
+		MaxPageSizeBytes:       10 * 1024 * 1024,    // 10MB max page size
+		ImageTimeout:           15 * time.Second,    // 15s timeout per image
+		LinkScoreThreshold:     0.5,                 // Default threshold for link scoring
+		StoragePath:            "./storage",         // Default storage path
+		MaxImages:              20,                  // Download max 20 images per scrape
+		OllamaBreakerThreshold: ollama.DefaultBreakerFailureThreshold,
+		OllamaBreakerCooldown:  ollama.DefaultBreakerCooldown,
+		OllamaRouting:          ollama.RoutingLeastLoaded,
+		OllamaMaxConcurrent:    ollama.DefaultMaxConcurrent,
+		Retry:                  DefaultRetryPolicy(),
+		ImageMetadataPolicy:    imaging.MetadataStripGPS,
+		ImageSafetyAction:      SafetyOff,
+		ImageSafetyThreshold:   DefaultImageSafetyThreshold,
+		OCRVisionFallback:      true,
+		OCRMinConfidence:       ocr.DefaultMinConfidence,
 	}
 }
 
 // DB interface defines the database operations needed by the scraper
+// FindImageByHash matches by SHA-256 only when perceptualHash is empty.
 type DB interface {
 	GetImageByURL(url string) (*models.ImageInfo, error)
+	FindImageByHash(sha256, perceptualHash string, maxDistance int) (*models.ExistingImageRef, error)
 }
 
 // Scraper handles web scraping operations
 type Scraper struct {
-	config          Config
-	httpClient      *http.Client
-	ollamaClient    *ollama.Client
-	ollamaSemaphore chan struct{} // Semaphore to limit concurrent Ollama requests
-	db              DB            // Database for checking existing images
-	storage         StorageBackend // Storage backend for images and content
+	config           Config
+	httpClient       *http.Client
+	ollamaClient     *ollama.Client
+	ollamaSemaphore  chan struct{}  // Semaphore to limit concurrent Ollama requests
+	db               DB             // Database for checking existing images
+	storage          StorageBackend // Storage backend for images and content
+	ruleEngine       *rules.Engine  // Link filtering and scoring rules
+	domainPolicies   atomic.Pointer[domainPolicyIndex]
+	scoreAdjustments atomic.Pointer[scoreAdjustments] // Learned per-domain score adjustments
+	profiles         profileIndex                     // Outbound network profiles by domain
+	retryHooks       *retryHooks                      // Observer of fetch retries, for metrics
+	credentials      atomic.Pointer[credentialIndex]  // Site credentials by domain
 }
 
 // StorageBackend interface defines the storage operations needed by the scraper
@@ -95,32 +143,61 @@ type StorageBackend interface {
 // db parameter can be nil if image deduplication is not needed
 // storage parameter can be nil if storage is not needed
 func New(config Config, db DB, storage StorageBackend) *Scraper {
-	// Limit concurrent Ollama requests to 3 to prevent overload during batch operations
-	maxConcurrentOllamaRequests := 3
+	factory := &transportFactory{config: config, hooks: &retryHooks{}}
+	if config.BlockPrivateNetworks {
+		allow, err := parseHostAllowlist(config.AllowedHosts)
+		if err != nil {
+			slog.Warn("ignoring invalid allowed hosts", "error", err)
+		}
+		factory.allow = allow
+	}
+
+	// Fixtures replace or tap the network below the tracing layer
+	var ollamaTransport http.RoundTripper
+	if config.Replay != nil {
+		factory.replayer = config.Replay.Replayer()
+		ollamaTransport = factory.replayer.Transport(fixture.KindLLM)
+	} else if config.Recorder != nil {
+		ollamaTransport = config.Recorder.Wrap(fixture.KindLLM, nil)
+	}
+
+	// Pages and images are fetched directly unless a network profile covers their domain
+	direct, _ := factory.outbound(NetworkProfile{}) // Only TLS files and proxies can fail, and it has neither
+	profiles := factory.buildProfiles(direct)
 
-	// Create HTTP client with HTTP/1.1 only (disable HTTP/2)
-	// Some servers have issues with HTTP/2 from Go clients
-	transport := &http.Transport{
-		TLSNextProto: make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
+	textEndpoints := config.OllamaEndpoints
+	if len(textEndpoints) == 0 {
+		textEndpoints = []ollama.EndpointConfig{{BaseURL: config.OllamaBaseURL, MaxConcurrent: config.OllamaMaxConcurrent}}
 	}
 
-	// Wrap transport with OpenTelemetry instrumentation for trace propagation
-	instrumentedTransport := otelhttp.NewTransport(transport,
-		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
-			return "scraper HTTP " + r.Method + " " + r.URL.Host
-		}),
-	)
+	// Each endpoint has its own breaker so a dead server is ejected while the rest keep serving
+	ollamaClient := ollama.NewPoolClient(config.OllamaModel, config.OllamaVisionModel, ollama.PoolConfig{
+		TextEndpoints:    textEndpoints,
+		VisionEndpoints:  config.OllamaVisionEndpoints,
+		Routing:          config.OllamaRouting,
+		BreakerThreshold: config.OllamaBreakerThreshold,
+		BreakerCooldown:  config.OllamaBreakerCooldown,
+		Transport:        ollamaTransport,
+	})
+
+	ruleEngine := config.Rules
+	if ruleEngine == nil {
+		ruleEngine = rules.NewEngine(rules.Default())
+	}
+
+	// Limit concurrent Ollama requests to the combined endpoint capacity to prevent overload during batch operations
+	maxConcurrentOllamaRequests := ollamaClient.Capacity()
 
 	return &Scraper{
-		config: config,
-		httpClient: &http.Client{
-			Timeout:   config.HTTPTimeout,
-			Transport: instrumentedTransport,
-		},
-		ollamaClient:    ollama.NewClientWithVisionModel(config.OllamaBaseURL, config.OllamaModel, config.OllamaVisionModel),
+		config:          config,
+		httpClient:      direct.clients[0],
+		profiles:        profiles,
+		retryHooks:      factory.hooks,
+		ollamaClient:    ollamaClient,
 		ollamaSemaphore: make(chan struct{}, maxConcurrentOllamaRequests),
 		db:              db,
 		storage:         storage,
+		ruleEngine:      ruleEngine,
 	}
 }
 
@@ -144,6 +221,11 @@ func (s *Scraper) Config() Config {
 	return s.config
 }
 
+// Rules returns the link filtering and scoring rule engine
+func (s *Scraper) Rules() *rules.Engine {
+	return s.ruleEngine
+}
+
 // OllamaClient returns the Ollama client for external use
 func (s *Scraper) OllamaClient() *ollama.Client {
 	return s.ollamaClient
@@ -151,9 +233,25 @@ func (s *Scraper) OllamaClient() *ollama.Client {
 
 // Scrape fetches and processes a URL
 func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.ScrapedData, error) {
+	return s.ScrapeWithOptions(ctx, targetURL, ScrapeOptions{})
+}
+
+// ScrapeWithOptions fetches and processes a URL, running only the pipeline stages enabled in opts
+func (s *Scraper) ScrapeWithOptions(ctx context.Context, targetURL string, opts ScrapeOptions) (*models.ScrapedData, error) {
 	start := time.Now()
 	warnings := []string{} // Track non-fatal processing issues
 
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	progress := opts.Progress
+	ollamaClient := s.ollamaClient.WithModels(opts.TextModel, opts.VisionModel)
+
 	// Validate URL
 	parsedURL, err := url.Parse(targetURL)
 	if err != nil {
@@ -163,8 +261,20 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 		return nil, fmt.Errorf("URL must be http or https")
 	}
 
+	// Enforce the domain policy before fetching anything
+	policy := s.DomainPolicyFor(targetURL)
+	if policyBlocks(policy) {
+		return nil, &DomainBlockedError{URL: targetURL, Domain: policy.Domain}
+	}
+	if policy != nil && policy.Policy == models.DomainPolicyNeverDownloadImages {
+		slog.Info("image downloads disabled by domain policy", "url", targetURL, "domain", policy.Domain)
+		opts.Images = Bool(false)
+	}
+
 	// Check if this is a direct image URL - create minimal HTML instead of fetching
 	var doc *html.Node
+	var contentLanguage, pageCharset string
+	var fetchInfo *models.FetchInfo
 	if isImageURL(targetURL) {
 		// Create a minimal HTML document with just the image tag
 		// This allows all existing image processing code to work as-is
@@ -175,28 +285,29 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 		}
 	} else {
 		// Fetch the page normally
-		req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
+		page, err := s.fetchPage(ctx, targetURL)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
+			return nil, err
 		}
-		req.Header.Set("User-Agent", UserAgent)
-
-		resp, err := s.httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch URL: %w", err)
+		if page.Truncated {
+			warnings = append(warnings, fmt.Sprintf("Page exceeds %d bytes, content truncated", s.config.MaxPageSizeBytes))
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+		if page.MediaType == "application/pdf" {
+			warnings = append(warnings, "PDF text extraction is not supported, content is empty")
+		}
+		if page.Info.Retries > 0 {
+			warnings = append(warnings, fmt.Sprintf("Page fetch succeeded after %d retries", page.Info.Retries))
 		}
+		contentLanguage = page.Header.Get("Content-Language")
+		fetchInfo = page.Info
 
-		// Parse HTML
-		doc, err = html.Parse(resp.Body)
+		// Transcode to UTF-8 and parse HTML
+		doc, pageCharset, err = page.document()
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse HTML: %w", err)
+			return nil, err
 		}
 	}
+	progress.emit(ProgressEvent{Stage: StageFetched, URL: targetURL})
 
 	// Extract title
 	title := extractTitle(doc)
@@ -206,11 +317,23 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 
 	// Extract text content
 	textContent := extractText(doc)
+	progress.emit(ProgressEvent{Stage: StageTextExtracted, URL: targetURL})
+
+	// Detect the page language so prompts can tell the model what it is reading
+	htmlLang, metaLang := extractDeclaredLanguage(doc)
+	if contentLanguage == "" {
+		contentLanguage = metaLang
+	}
+	detected := lang.Detect(htmlLang, contentLanguage, textContent)
+	ollamaClient = ollamaClient.WithLanguage(detected.Code)
 
 	// Use Ollama to extract meaningful content
 	content := textContent // Default to raw text
-	if err := s.acquireOllamaSlot(ctx); err == nil {
-		extractedContent, err := s.ollamaClient.ExtractContent(ctx, textContent)
+	warningCount := len(warnings)
+	if !enabled(opts.CleanContent, true) {
+		slog.Info("ai content cleanup disabled for request", "url", targetURL)
+	} else if err := s.acquireOllamaSlot(ctx); err == nil {
+		extractedContent, err := ollamaClient.ExtractContent(ctx, textContent)
 		s.releaseOllamaSlot()
 		if err != nil {
 			slog.Warn("ollama content extraction failed, using raw text", "url", targetURL, "error", err)
@@ -222,12 +345,34 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 		slog.Warn("context cancelled while waiting for ollama slot", "operation", "content_extraction", "error", err)
 		warnings = append(warnings, "Content extraction timed out, using raw text")
 	}
+	progress.emit(ProgressEvent{Stage: StageContentCleaned, URL: targetURL, Warning: lastWarning(warnings, warningCount)})
+
+	// Translate the content if a target language is configured and differs from the page
+	var translatedTo string
+	if target := s.translateTo(opts); target != "" && target != detected.Code && content != "" {
+		warningCount = len(warnings)
+		if err := s.acquireOllamaSlot(ctx); err == nil {
+			translated, err := ollamaClient.Translate(ctx, content, target)
+			s.releaseOllamaSlot()
+			if err != nil {
+				slog.Warn("ollama translation failed, keeping original content", "url", targetURL, "target", target, "error", err)
+				warnings = append(warnings, fmt.Sprintf("Translation to %s unavailable, keeping original content", lang.Name(target)))
+			} else {
+				content = translated
+				translatedTo = target
+			}
+		} else {
+			slog.Warn("context cancelled while waiting for ollama slot", "operation", "translation", "error", err)
+			warnings = append(warnings, "Translation timed out, keeping original content")
+		}
+		progress.emit(ProgressEvent{Stage: StageTranslated, URL: targetURL, Warning: lastWarning(warnings, warningCount)})
+	}
 
 	// Extract images
 	images := extractImages(doc, parsedURL)
 
 	// Process images (download and analyze if enabled)
-	images, existingImageRefs, imageWarnings := s.processImages(ctx, images)
+	images, existingImageRefs, imageWarnings := s.processImages(ctx, images, ollamaClient, opts)
 	warnings = append(warnings, imageWarnings...)
 
 	// For direct image URLs, use the image's AI-generated summary and tags
@@ -259,11 +404,29 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 	}
 
 	// Extract links with Ollama sanitization
-	links := s.extractLinksWithOllama(ctx, doc, parsedURL, title, content)
+	var links []string
+	if enabled(opts.FilterLinks, true) {
+		links = s.extractLinksWithOllama(ctx, ollamaClient, doc, parsedURL, title, content)
+	} else {
+		links = extractLinks(doc, parsedURL)
+		if links == nil {
+			links = []string{}
+		}
+	}
+	progress.emit(ProgressEvent{Stage: StageLinksFiltered, URL: targetURL, Count: len(links)})
 
 	// Extract metadata
 	metadata := extractMetadata(doc)
 
+	metadata.Charset = pageCharset
+	metadata.Language = detected.Code
+	metadata.LanguageSource = detected.Source
+	metadata.TranslatedTo = translatedTo
+	for _, link := range feed.Discover(doc, parsedURL) {
+		metadata.Feeds = append(metadata.Feeds, link.URL)
+	}
+	applyFeedEntry(&metadata, opts.FeedEntry)
+
 	// Add existing image references to metadata
 	if len(existingImageRefs) > 0 {
 		metadata.ExistingImageRefs = existingImageRefs
@@ -283,10 +446,15 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 	var categories []string
 	var maliciousIndicators []string
 	var aiUsed bool
+	warningCount = len(warnings)
+	scoringEnabled := enabled(opts.Score, true)
 
 	// Check for low-quality patterns first (before Ollama) to avoid unnecessary AI calls
-	shouldSkipAI, earlyScore, earlyReason, earlyCategories, earlyIndicators := checkForLowQualityPatterns(targetURL, title)
-	if shouldSkipAI {
+	rs := s.rulesFor(policy)
+	shouldSkipAI, earlyScore, earlyReason, earlyCategories, earlyIndicators := checkForLowQualityPatterns(rs, targetURL, title)
+	if !scoringEnabled {
+		slog.Info("scoring disabled for request", "url", targetURL)
+	} else if shouldSkipAI {
 		score = earlyScore
 		reason = earlyReason
 		categories = earlyCategories
@@ -294,12 +462,12 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 		aiUsed = false
 	} else if err := s.acquireOllamaSlot(ctx); err == nil {
 		var err error
-		score, reason, categories, maliciousIndicators, err = s.ollamaClient.ScoreContent(ctx, targetURL, title, content)
+		score, reason, categories, maliciousIndicators, err = ollamaClient.ScoreContent(ctx, targetURL, title, content)
 		s.releaseOllamaSlot()
 		if err != nil {
 			// Fallback to rule-based scoring when Ollama fails
 			slog.Warn("ollama scoring failed, using rule-based fallback", "url", targetURL, "error", err)
-			score, reason, categories, maliciousIndicators = scoreContentFallback(targetURL, title, content)
+			score, reason, categories, maliciousIndicators = scoreContentFallback(rs, targetURL, title, content)
 			aiUsed = false
 			warnings = append(warnings, "AI scoring unavailable, using rule-based scoring")
 		} else {
@@ -308,10 +476,13 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 	} else {
 		// Context cancelled, use rule-based fallback
 		slog.Warn("context cancelled while waiting for ollama slot", "operation", "scoring", "error", err)
-		score, reason, categories, maliciousIndicators = scoreContentFallback(targetURL, title, content)
+		score, reason, categories, maliciousIndicators = scoreContentFallback(rs, targetURL, title, content)
 		aiUsed = false
 		warnings = append(warnings, "Scoring timed out, using rule-based scoring")
 	}
+	if scoringEnabled {
+		progress.emit(ProgressEvent{Stage: StageScored, URL: targetURL, Score: score, Warning: lastWarning(warnings, warningCount)})
+	}
 
 	// For direct image URLs, add image tags to categories
 	if isDirectImageURL && len(images) > 0 {
@@ -334,14 +505,21 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 		}
 	}
 
-	linkScore := &models.LinkScore{
-		URL:                 targetURL,
-		Score:               score,
-		Reason:              reason,
-		Categories:          categories,
-		IsRecommended:       score >= s.config.LinkScoreThreshold,
-		MaliciousIndicators: maliciousIndicators,
-		AIUsed:              aiUsed,
+	var linkScore *models.LinkScore
+	if scoringEnabled {
+		linkScore = &models.LinkScore{
+			URL:                 targetURL,
+			Score:               score,
+			Reason:              reason,
+			Categories:          categories,
+			IsRecommended:       score >= s.config.LinkScoreThreshold,
+			MaliciousIndicators: maliciousIndicators,
+			AIUsed:              aiUsed,
+		}
+		if !shouldSkipAI {
+			s.applyScoreAdjustment(linkScore)
+		}
+		applyDomainPolicy(linkScore, policy)
 	}
 
 	// Score images for relevance (for thumbnail selection)
@@ -377,10 +555,12 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 		Score:          linkScore,
 		Warnings:       warnings,
 		Slug:           contentSlug,
+		Fetch:          fetchInfo,
 	}
 
-	// Save content to filesystem if storage is available
-	if s.storage != nil && content != "" {
+	// Save content to filesystem if storage is available; partial results are not saved, and
+	// would otherwise replace the full scrape's content under the same slug
+	if s.storage != nil && content != "" && !opts.Partial() {
 		// Create simple HTML wrapper for content
 		htmlContent := fmt.Sprintf(`<!DOCTYPE html>
 <html>
@@ -417,27 +597,21 @@ func (s *Scraper) ExtractLinks(ctx context.Context, targetURL string) ([]string,
 		return nil, fmt.Errorf("URL must be http or https")
 	}
 
-	// Fetch the page
-	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Enforce the domain policy before fetching anything
+	if policy := s.DomainPolicyFor(targetURL); policyBlocks(policy) {
+		return nil, &DomainBlockedError{URL: targetURL, Domain: policy.Domain}
 	}
-	req.Header.Set("User-Agent", UserAgent)
 
-	resp, err := s.httpClient.Do(req)
+	// Fetch the page
+	page, err := s.fetchPage(ctx, targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+		return nil, err
 	}
 
-	// Parse HTML
-	doc, err := html.Parse(resp.Body)
+	// Transcode to UTF-8 and parse HTML
+	doc, _, err := page.document()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, err
 	}
 
 	// Extract title
@@ -464,7 +638,7 @@ func (s *Scraper) ExtractLinks(ctx context.Context, targetURL string) ([]string,
 	}
 
 	// Extract links with Ollama sanitization and fallback
-	links := s.extractLinksWithOllama(ctx, doc, parsedURL, title, content)
+	links := s.extractLinksWithOllama(ctx, s.ollamaClient, doc, parsedURL, title, content)
 
 	return links, nil
 }
@@ -609,43 +783,40 @@ func extractText(n *html.Node) string {
 }
 
 // extractImages extracts image information from the HTML
+// Each <img> yields its highest-resolution rendition from src, srcset, an enclosing
+// <picture> and lazy-load attributes, with the others kept as alternates. Elements with a
+// CSS background image are included, and the page's og:image leads the list unless it
+// already appears in the page.
 func extractImages(n *html.Node, baseURL *url.URL) []models.ImageInfo {
 	var images []models.ImageInfo
+	seen := map[string]bool{}
+	add := func(candidates []imageCandidate, alt, caption string, width, height int) {
+		ranked := rankCandidates(candidates, baseURL, width)
+		if len(ranked) == 0 || seen[ranked[0].URL] {
+			return
+		}
+		for _, c := range ranked {
+			seen[c.URL] = true
+		}
+		images = append(images, newImageInfo(ranked, alt, caption, width, height))
+	}
+
 	var f func(*html.Node)
 	f = func(n *html.Node) {
 		if n.Type == html.ElementNode && n.Data == "img" {
-			var src, alt string
 			var width, height int
-			for _, attr := range n.Attr {
-				switch attr.Key {
-				case "src":
-					src = attr.Val
-				case "alt":
-					alt = attr.Val
-				case "width":
-					// Try to parse width attribute (may be in pixels or other units)
-					if w, err := strconv.Atoi(strings.TrimSpace(attr.Val)); err == nil && w > 0 {
-						width = w
-					}
-				case "height":
-					// Try to parse height attribute (may be in pixels or other units)
-					if h, err := strconv.Atoi(strings.TrimSpace(attr.Val)); err == nil && h > 0 {
-						height = h
-					}
-				}
+			// Try to parse width and height attributes (may be in pixels or other units)
+			if w, err := strconv.Atoi(strings.TrimSpace(attrValue(n, "width"))); err == nil && w > 0 {
+				width = w
 			}
-			if src != "" {
-				// Resolve relative URLs
-				if imgURL, err := resolveURL(baseURL, src); err == nil {
-					images = append(images, models.ImageInfo{
-						URL:     imgURL,
-						AltText: alt,
-						Width:   width,  // HTML attribute hint (0 if not specified)
-						Height:  height, // HTML attribute hint (0 if not specified)
-						Summary: "",
-						Tags:    []string{},
-					})
-				}
+			if h, err := strconv.Atoi(strings.TrimSpace(attrValue(n, "height"))); err == nil && h > 0 {
+				height = h
+			}
+			add(imgCandidates(n), attrValue(n, "alt"), figureCaption(n), width, height)
+		} else if n.Type == html.ElementNode {
+			if bg := backgroundImageURL(n); bg != "" {
+				alt := firstAttr(n, []string{"aria-label", "title"})
+				add([]imageCandidate{{URL: bg}}, alt, figureCaption(n), 0, 0)
 			}
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -653,11 +824,15 @@ func extractImages(n *html.Node, baseURL *url.URL) []models.ImageInfo {
 		}
 	}
 	f(n)
+
+	if og, ok := openGraphImage(n, baseURL); ok && !seen[og.URL] {
+		images = append([]models.ImageInfo{og}, images...)
+	}
 	return images
 }
 
 // extractLinksWithOllama extracts links from HTML and uses Ollama to sanitize them
-func (s *Scraper) extractLinksWithOllama(ctx context.Context, n *html.Node, baseURL *url.URL, pageTitle string, pageContent string) []string {
+func (s *Scraper) extractLinksWithOllama(ctx context.Context, client *ollama.Client, n *html.Node, baseURL *url.URL, pageTitle string, pageContent string) []string {
 	// First extract all links using the basic method
 	allLinks := extractLinks(n, baseURL)
 
@@ -671,7 +846,7 @@ func (s *Scraper) extractLinksWithOllama(ctx context.Context, n *html.Node, base
 	}
 
 	// Pre-filter links using pattern matching before AI
-	filteredLinks, filteredCount := filterLowQualityLinks(allLinks)
+	filteredLinks, filteredCount := s.filterLowQualityLinks(s.ruleEngine.Rules(), allLinks)
 	slog.Info("link extraction complete",
 		"total_links", len(allLinks),
 		"filtered_out", filteredCount,
@@ -728,7 +903,7 @@ EXCLUDE:
 IMPORTANT: If this is a homepage or news aggregator page, it will contain MANY article links - these should ALL be included as they are the primary content. Only filter out the navigation chrome around them.
 
 Page Title: %s
-
+%s
 Page Content: %s
 
 Links to filter:
@@ -737,13 +912,14 @@ Links to filter:
 Return ONLY a JSON array of the filtered URLs. Do not include any explanation or commentary.
 Format: ["url1", "url2", "url3"]`,
 		pageTitle,
+		pageLanguageLine(client.Language()),
 		pageContent,
 		string(linksJSON))
 
 	// Use Ollama with semaphore protection
 	sanitizedLinks := filteredLinks // Default to filtered links
 	if err := s.acquireOllamaSlot(ctx); err == nil {
-		response, err := s.ollamaClient.Generate(ctx, prompt)
+		response, err := client.Generate(ctx, prompt)
 		s.releaseOllamaSlot()
 		if err != nil {
 			// If Ollama fails, fall back to returning filtered links
@@ -806,6 +982,50 @@ func extractLinks(n *html.Node, baseURL *url.URL) []string {
 	return links
 }
 
+// pageLanguageLine returns the link filtering prompt line naming the page language, or "" if unknown
+func pageLanguageLine(code string) string {
+	if code == "" {
+		return ""
+	}
+	return fmt.Sprintf("Page Language: %s (link text may be in this language)\n", lang.Name(code))
+}
+
+// extractDeclaredLanguage returns the <html lang> attribute and any
+// <meta http-equiv="content-language"> value
+func extractDeclaredLanguage(n *html.Node) (htmlLang, metaLang string) {
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "html":
+				for _, attr := range n.Attr {
+					if (attr.Key == "lang" || attr.Key == "xml:lang") && htmlLang == "" {
+						htmlLang = attr.Val
+					}
+				}
+			case "meta":
+				var httpEquiv, content string
+				for _, attr := range n.Attr {
+					switch attr.Key {
+					case "http-equiv":
+						httpEquiv = strings.ToLower(attr.Val)
+					case "content":
+						content = attr.Val
+					}
+				}
+				if httpEquiv == "content-language" && metaLang == "" {
+					metaLang = content
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	return htmlLang, metaLang
+}
+
 // extractMetadata extracts page metadata from meta tags
 func extractMetadata(n *html.Node) models.PageMetadata {
 	metadata := models.PageMetadata{}
@@ -859,18 +1079,23 @@ func extractMetadata(n *html.Node) models.PageMetadata {
 }
 
 // downloadImage downloads an image from a URL with size and timeout limits
-func (s *Scraper) downloadImage(ctx context.Context, imageURL string) ([]byte, string, error) {
+func (s *Scraper) downloadImage(ctx context.Context, imageURL string, timeout time.Duration) ([]byte, string, error) {
 	// Create request with timeout context
-	ctx, cancel := context.WithTimeout(ctx, s.config.ImageTimeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+	// Images go out through the network profile of their own domain
+	profile := s.profileFor(imageURL)
+	req, err := profile.newRequest(ctx, imageURL)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create request: %w", err)
+		return nil, "", err
 	}
-	req.Header.Set("User-Agent", UserAgent)
 
-	resp, err := s.httpClient.Do(req)
+	if err := s.authorize(req); err != nil {
+		return nil, "", err
+	}
+
+	resp, err := profile.clients[profile.pick()].Do(req)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to fetch image: %w", err)
 	}
@@ -882,7 +1107,7 @@ func (s *Scraper) downloadImage(ctx context.Context, imageURL string) ([]byte, s
 
 	// Check content length if available
 	if resp.ContentLength > s.config.MaxImageSizeBytes {
-		return nil, "", fmt.Errorf("image too large: %d bytes (max: %d)", resp.ContentLength, s.config.MaxImageSizeBytes)
+		return nil, "", &ImageTooLargeError{URL: imageURL, Size: resp.ContentLength, Limit: s.config.MaxImageSizeBytes}
 	}
 
 	// Get content type from response
@@ -897,7 +1122,7 @@ func (s *Scraper) downloadImage(ctx context.Context, imageURL string) ([]byte, s
 
 	// Check if we exceeded the limit
 	if int64(len(imageData)) > s.config.MaxImageSizeBytes {
-		return nil, "", fmt.Errorf("image too large: exceeds %d bytes", s.config.MaxImageSizeBytes)
+		return nil, "", &ImageTooLargeError{URL: imageURL, Limit: s.config.MaxImageSizeBytes}
 	}
 
 	return imageData, contentType, nil
@@ -1010,54 +1235,10 @@ func extractEXIF(imageData []byte) *models.EXIFData {
 	return exifData
 }
 
-// shouldSkipImage determines if an image should be skipped based on URL patterns
+// shouldSkipImage determines if an image should be skipped according to the image rules
 // Returns true if the image appears to be a placeholder, temp file, UI component, or other junk data
-func shouldSkipImage(imageURL string) bool {
-	urlLower := strings.ToLower(imageURL)
-
-	// Skip images with placeholder or temp in the name
-	skipKeywords := []string{
-		"placeholder",
-		"temp",
-		"temporary",
-		// UI components
-		"icon",
-		"logo",
-		"button",
-		"sprite",
-		"avatar-default",
-		"default-avatar",
-		"generic-avatar",
-		// Tracking pixels and spacers
-		"1x1",
-		"pixel",
-		"tracking",
-		"spacer",
-		"blank",
-		"transparent",
-		// Social media buttons
-		"share-button",
-		"facebook-icon",
-		"twitter-icon",
-		"social-icon",
-		// Ads and promotional
-		"ad-banner",
-		"advertisement",
-		"promo",
-		// Common junk patterns
-		"spinner",
-		"loader",
-		"loading",
-		"thumbnail-placeholder",
-	}
-
-	for _, keyword := range skipKeywords {
-		if strings.Contains(urlLower, keyword) {
-			return true
-		}
-	}
-
-	return false
+func shouldSkipImage(rs *rules.RuleSet, imageURL string) bool {
+	return rs.Blocked(rules.TargetImages, imageURL)
 }
 
 // scoreImageRelevance calculates a relevance score for an image (0.0-1.0)
@@ -1207,11 +1388,12 @@ func scoreImageRelevance(img models.ImageInfo, position int, totalImages int, ar
 // processImages downloads and analyzes images if image analysis is enabled
 // Uses parallel processing with worker pool for better performance
 // Returns processed images, existing image references, and any warnings encountered
-func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo) ([]models.ImageInfo, []models.ExistingImageRef, []string) {
+func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo, client *ollama.Client, opts ScrapeOptions) ([]models.ImageInfo, []models.ExistingImageRef, []string) {
 	warnings := []string{}
 	existingRefs := []models.ExistingImageRef{}
+	progress := opts.Progress
 
-	if !s.config.EnableImageAnalysis {
+	if !s.imagesEnabled(opts) {
 		slog.Info("image analysis disabled", "image_count", len(images))
 		return images, existingRefs, warnings
 	}
@@ -1221,15 +1403,24 @@ func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo)
 	}
 
 	// Filter out placeholder, temp, UI component, and junk images
+	rs := s.ruleEngine.Rules()
 	filteredImages := make([]models.ImageInfo, 0, len(images))
 	skippedCount := 0
 	for _, img := range images {
-		if shouldSkipImage(img.URL) {
+		if shouldSkipImage(rs, img.URL) {
 			slog.Info("skipping junk image", "url", img.URL)
 			skippedCount++
-		} else {
-			filteredImages = append(filteredImages, img)
+			continue
 		}
+		// Placeholders among the smaller renditions are never a useful fallback
+		alternates := img.Alternates[:0:0]
+		for _, alt := range img.Alternates {
+			if !shouldSkipImage(rs, alt) {
+				alternates = append(alternates, alt)
+			}
+		}
+		img.Alternates = alternates
+		filteredImages = append(filteredImages, img)
 	}
 
 	if skippedCount > 0 {
@@ -1247,7 +1438,8 @@ func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo)
 	images = filteredImages
 
 	// Apply max images limit if configured
-	if s.config.MaxImages > 0 && len(images) > s.config.MaxImages {
+	maxImages := s.maxImages(opts)
+	if maxImages > 0 && len(images) > maxImages {
 		// Sort images by size (pixel area) in descending order to prioritize larger images
 		// Images without dimensions (0x0) will sort to the end
 		sort.Slice(images, func(i, j int) bool {
@@ -1268,11 +1460,13 @@ func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo)
 			return i < j
 		})
 
-		slog.Info("sorted and limited images", "total", len(images), "max_images", s.config.MaxImages)
-		warnings = append(warnings, fmt.Sprintf("Limited to %d largest images (found %d total)", s.config.MaxImages, len(images)))
-		images = images[:s.config.MaxImages]
+		slog.Info("sorted and limited images", "total", len(images), "max_images", maxImages)
+		warnings = append(warnings, fmt.Sprintf("Limited to %d largest images (found %d total)", maxImages, len(images)))
+		images = images[:maxImages]
 	}
 
+	progress.emit(ProgressEvent{Stage: StageImagesFound, Count: len(images)})
+
 	// Use a worker pool for parallel image processing
 	const maxWorkers = 5
 	numWorkers := min(maxWorkers, len(images))
@@ -1291,6 +1485,7 @@ func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo)
 
 	jobs := make(chan imageJob, len(images))
 	results := make(chan imageResult, len(images))
+	imageCtx, retries := withRetryCounter(ctx)
 
 	// Start worker goroutines
 	var wg sync.WaitGroup
@@ -1299,7 +1494,23 @@ func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo)
 		go func() {
 			defer wg.Done()
 			for job := range jobs {
-				img, existingRef, warning := s.processSingleImage(ctx, job.img)
+				index := job.index + 1
+				imageOpts := opts
+				if progress != nil {
+					imageOpts.Progress = func(event ProgressEvent) {
+						event.Index = index
+						event.Total = len(images)
+						progress(event)
+					}
+				}
+
+				img, existingRef, warning := s.processSingleImage(imageCtx, job.img, client, imageOpts)
+				imageOpts.Progress.emit(ProgressEvent{
+					Stage:    StageImageAnalyzed,
+					URL:      job.img.URL,
+					Warning:  warning,
+					Existing: existingRef != nil,
+				})
 				results <- imageResult{index: job.index, img: img, warning: warning, existingRef: existingRef}
 			}
 		}()
@@ -1344,6 +1555,9 @@ func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo)
 	if failedAnalysis > 0 {
 		warnings = append(warnings, fmt.Sprintf("AI analysis failed for %d/%d images", failedAnalysis, len(images)))
 	}
+	if n := retries.n.Load(); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("Retried %d image downloads after transient failures", n))
+	}
 
 	// Add info about deduplicated images
 	if len(existingRefs) > 0 {
@@ -1356,7 +1570,7 @@ func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo)
 // processSingleImage processes a single image (download and analyze)
 // Returns the processed image, existing image reference (if found), and a warning string (empty if no issues)
 // If existingRef is non-nil, the image already exists and img should be ignored
-func (s *Scraper) processSingleImage(ctx context.Context, img models.ImageInfo) (models.ImageInfo, *models.ExistingImageRef, string) {
+func (s *Scraper) processSingleImage(ctx context.Context, img models.ImageInfo, client *ollama.Client, opts ScrapeOptions) (models.ImageInfo, *models.ExistingImageRef, string) {
 
 	// Check if image already exists in database (if DB is available)
 	if s.db != nil {
@@ -1369,6 +1583,7 @@ func (s *Scraper) processSingleImage(ctx context.Context, img models.ImageInfo)
 			ref := &models.ExistingImageRef{
 				ImageID:  existingImage.ID,
 				ImageURL: existingImage.URL,
+				Match:    models.ImageMatchURL,
 			}
 			return models.ImageInfo{}, ref, ""
 		}
@@ -1377,41 +1592,88 @@ func (s *Scraper) processSingleImage(ctx context.Context, img models.ImageInfo)
 	// Generate UUID for the new image
 	img.ID = uuid.New().String()
 
-	// Download the image
-	imageData, contentType, err := s.downloadImage(ctx, img.URL)
+	// Download the highest-resolution rendition that fits within the size limit
+	var imageData []byte
+	var contentType string
+	var err error
+	for _, candidate := range imageDownloadOrder(img) {
+		imageData, contentType, err = s.downloadImage(ctx, candidate, s.imageTimeout(opts))
+		var tooLarge *ImageTooLargeError
+		if !errors.As(err, &tooLarge) {
+			if err == nil && candidate != img.URL {
+				slog.Info("downloaded smaller rendition", "url", img.URL, "rendition", candidate)
+				img.URL = candidate
+			}
+			break
+		}
+		slog.Info("image rendition too large, trying a smaller one", "url", candidate, "error", err)
+	}
 	if err != nil {
 		slog.Error("failed to download image", "url", img.URL, "error", err)
 		return img, nil, "download_failed"
 	}
 
 	slog.Info("downloaded image", "url", img.URL, "size_bytes", len(imageData))
+	opts.Progress.emit(ProgressEvent{Stage: StageImageDownloaded, URL: img.URL})
+
+	// The same picture is often served from other CDNs, sizes or query strings; reuse the
+	// stored copy and its analysis rather than storing and analyzing it again
+	img.SHA256, img.PerceptualHash = imageHashes(imageData)
+	if s.db != nil {
+		matchHash := img.PerceptualHash
+		if !distinctivePerceptualHash(matchHash) {
+			matchHash = "" // Only a byte-identical copy of a low-detail image is the same picture
+		}
+		ref, err := s.db.FindImageByHash(img.SHA256, matchHash, maxPerceptualDistance)
+		if err != nil {
+			slog.Error("failed to check for duplicate image", "url", img.URL, "error", err)
+		} else if ref != nil {
+			slog.Info("image matches an existing image", "url", img.URL, "existing_id", ref.ImageID, "match", ref.Match, "distance", ref.Distance)
+			ref.SourceURL = img.URL
+			return models.ImageInfo{}, ref, ""
+		}
+	}
 
 	// Generate slug from image info
-	img.Slug = slug.FromImageInfo(img.AltText, img.URL)
+	img.Slug = slug.FromImageInfo(firstNonEmpty(img.AltText, img.Caption), img.URL)
 	if img.Slug == "" {
 		img.Slug = img.ID // Fallback to UUID if slug generation fails
 	}
 
-	// Save to filesystem if storage is available
-	if s.storage != nil {
-		filePath, err := s.storage.SaveImage(imageData, img.Slug, contentType)
+	// Extract EXIF metadata before the metadata policy removes it from the stored bytes
+	if exifData := extractEXIF(imageData); exifData != nil {
+		img.EXIF = exifData
+		slog.Info("extracted EXIF data",
+			"url", img.URL,
+			"make", exifData.Make,
+			"model", exifData.Model,
+			"has_gps", exifData.GPS != nil)
+	}
+
+	// Populate file metadata
+	img.FileSizeBytes = int64(len(imageData))
+	img.ContentType = contentType
+
+	storedData, err := s.ScrubImageMetadata(&img, imageData)
+	if err != nil {
+		// Keep the analysis and the redacted EXIF but not the file, which would still carry the metadata
+		slog.Warn("failed to remove image metadata, not storing image", "url", img.URL, "error", err)
+	} else if s.storage != nil && !opts.Partial() {
+		// Save to filesystem if storage is available
+		filePath, err := s.storage.SaveImage(storedData, img.Slug, contentType)
 		if err != nil {
 			slog.Error("failed to save image to filesystem", "url", img.URL, "error", err)
 			// Fall back to base64 if filesystem storage fails
-			img.Base64Data = base64.StdEncoding.EncodeToString(imageData)
+			img.Base64Data = base64.StdEncoding.EncodeToString(storedData)
 		} else {
 			img.FilePath = filePath
 			slog.Info("saved image to filesystem", "url", img.URL, "path", filePath)
 		}
 	} else {
-		// No storage configured, use base64 for backward compatibility
-		img.Base64Data = base64.StdEncoding.EncodeToString(imageData)
+		// No storage configured, or a partial scrape that is not saved: use base64
+		img.Base64Data = base64.StdEncoding.EncodeToString(storedData)
 	}
 
-	// Populate file metadata
-	img.FileSizeBytes = int64(len(imageData))
-	img.ContentType = contentType
-
 	// Extract image dimensions
 	width, height, err := getImageDimensions(imageData)
 	if err != nil {
@@ -1423,68 +1685,60 @@ func (s *Scraper) processSingleImage(ctx context.Context, img models.ImageInfo)
 		slog.Info("extracted image dimensions", "url", img.URL, "width", width, "height", height)
 	}
 
-	// Extract EXIF metadata
-	if exifData := extractEXIF(imageData); exifData != nil {
-		img.EXIF = exifData
-		slog.Info("extracted EXIF data",
-			"url", img.URL,
-			"make", exifData.Make,
-			"model", exifData.Model,
-			"has_gps", exifData.GPS != nil)
+	// Classify the image for unsafe content first, so it is gated even if analysis fails
+	s.classifyImageSafety(ctx, client, &img, imageData)
+
+	// Analyze the image, then extract its text whether or not the analysis succeeded, so a
+	// dedicated OCR engine still reads images the vision model could not
+	warning := s.analyzeImage(ctx, client, &img, imageData)
+	if !enabled(opts.OCR, true) {
+		slog.Debug("ocr disabled for request", "url", img.URL)
+	} else {
+		s.ExtractImageText(ctx, client, &img, imageData)
 	}
+	return img, nil, warning
+}
 
+// analyzeImage adds the vision model's summary and tags to img, returning a warning if the
+// analysis failed
+func (s *Scraper) analyzeImage(ctx context.Context, client *ollama.Client, img *models.ImageInfo, imageData []byte) string {
 	// Analyze the image with Ollama (with semaphore protection)
-	if err := s.acquireOllamaSlot(ctx); err == nil {
-		summary, tags, err := s.ollamaClient.AnalyzeImage(ctx, imageData, img.AltText)
-		s.releaseOllamaSlot()
-		if err != nil {
-			slog.Error("failed to analyze image", "url", img.URL, "error", err)
-			return img, nil, "analysis_failed"
-		}
+	if err := s.acquireOllamaSlot(ctx); err != nil {
+		slog.Warn("context cancelled while waiting for ollama slot", "operation", "image_analysis", "url", img.URL, "error", err)
+		return "analysis_timeout"
+	}
+	summary, tags, err := client.AnalyzeImage(ctx, imageData, img.AltText, img.Caption)
+	s.releaseOllamaSlot()
+	if err != nil {
+		slog.Error("failed to analyze image", "url", img.URL, "error", err)
+		return "analysis_failed"
+	}
 
-		// Update image info with analysis results
-		img.Summary = summary
-		img.Tags = tags
+	// Update image info with analysis results
+	img.Summary = summary
+	img.Tags = tags
 
-		// Extract text from image using OCR (with semaphore protection)
-		if err := s.acquireOllamaSlot(ctx); err == nil {
-			extractedText, err := s.ollamaClient.ExtractTextFromImage(ctx, imageData)
-			s.releaseOllamaSlot()
-			if err != nil {
-				slog.Warn("failed to extract text from image", "url", img.URL, "error", err)
-				// Don't return error, OCR failure is not critical
-			} else if extractedText != "" {
-				img.ExtractedText = extractedText
-				slog.Info("extracted text from image", "url", img.URL, "text_length", len(extractedText))
+	// Check if image contains an infographic and add "banner" tag
+	if isInfographic(summary, tags) {
+		// Check if "banner" tag doesn't already exist
+		hasBanner := false
+		for _, tag := range img.Tags {
+			if strings.EqualFold(tag, "banner") {
+				hasBanner = true
+				break
 			}
 		}
-
-		// Check if image contains an infographic and add "banner" tag
-		if isInfographic(summary, tags) {
-			// Check if "banner" tag doesn't already exist
-			hasBanner := false
-			for _, tag := range img.Tags {
-				if strings.EqualFold(tag, "banner") {
-					hasBanner = true
-					break
-				}
-			}
-			if !hasBanner {
-				img.Tags = append(img.Tags, "banner")
-				slog.Info("detected infographic, added banner tag", "url", img.URL)
-			}
+		if !hasBanner {
+			img.Tags = append(img.Tags, "banner")
+			slog.Info("detected infographic, added banner tag", "url", img.URL)
 		}
-
-		slog.Info("successfully analyzed image",
-			"url", img.URL,
-			"summary_length", len(summary),
-			"tag_count", len(tags),
-			"extracted_text_length", len(img.ExtractedText))
-		return img, nil, ""
 	}
 
-	slog.Warn("context cancelled while waiting for ollama slot", "operation", "image_analysis", "url", img.URL, "error", err)
-	return img, nil, "analysis_timeout"
+	slog.Info("successfully analyzed image",
+		"url", img.URL,
+		"summary_length", len(summary),
+		"tag_count", len(tags))
+	return ""
 }
 
 // isInfographic checks if an image contains an infographic based on summary and tags
@@ -1557,9 +1811,15 @@ func (s *Scraper) ScoreLinkContent(ctx context.Context, targetURL string) (*mode
 		return nil, fmt.Errorf("URL must be http or https")
 	}
 
+	// Enforce the domain policy before fetching anything
+	policy := s.DomainPolicyFor(targetURL)
+	if policyBlocks(policy) {
+		return blockedLinkScore(targetURL, policy), nil
+	}
+
 	// Skip scoring for direct image URLs
 	if isImageURL(targetURL) {
-		return &models.LinkScore{
+		linkScore := &models.LinkScore{
 			URL:                 targetURL,
 			Score:               0.0,
 			Reason:              "Image file detected - skipping content scoring",
@@ -1567,30 +1827,21 @@ func (s *Scraper) ScoreLinkContent(ctx context.Context, targetURL string) (*mode
 			IsRecommended:       false,
 			MaliciousIndicators: []string{},
 			AIUsed:              false,
-		}, nil
+		}
+		applyDomainPolicy(linkScore, policy)
+		return linkScore, nil
 	}
 
 	// Fetch the page
-	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
+	page, err := s.fetchPage(ctx, targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	req.Header.Set("User-Agent", UserAgent)
 
-	resp, err := s.httpClient.Do(req)
+	// Transcode to UTF-8 and parse HTML
+	doc, _, err := page.document()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
-	}
-
-	// Parse HTML
-	doc, err := html.Parse(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, err
 	}
 
 	// Extract title
@@ -1610,7 +1861,8 @@ func (s *Scraper) ScoreLinkContent(ctx context.Context, targetURL string) (*mode
 	var aiUsed bool
 
 	// Check for low-quality patterns first (before Ollama) to avoid unnecessary AI calls
-	shouldSkipAI, earlyScore, earlyReason, earlyCategories, earlyIndicators := checkForLowQualityPatterns(targetURL, title)
+	rs := s.rulesFor(policy)
+	shouldSkipAI, earlyScore, earlyReason, earlyCategories, earlyIndicators := checkForLowQualityPatterns(rs, targetURL, title)
 	if shouldSkipAI {
 		score = earlyScore
 		reason = earlyReason
@@ -1624,7 +1876,7 @@ func (s *Scraper) ScoreLinkContent(ctx context.Context, targetURL string) (*mode
 		if err != nil {
 			// Fallback to rule-based scoring when Ollama fails
 			slog.Warn("ollama scoring failed, using rule-based fallback", "url", targetURL, "error", err)
-			score, reason, categories, maliciousIndicators = scoreContentFallback(targetURL, title, textContent)
+			score, reason, categories, maliciousIndicators = scoreContentFallback(rs, targetURL, title, textContent)
 			aiUsed = false
 		} else {
 			aiUsed = true
@@ -1632,7 +1884,7 @@ func (s *Scraper) ScoreLinkContent(ctx context.Context, targetURL string) (*mode
 	} else {
 		// Context cancelled, use rule-based fallback
 		slog.Warn("context cancelled while waiting for ollama slot", "operation", "scoring", "error", err)
-		score, reason, categories, maliciousIndicators = scoreContentFallback(targetURL, title, textContent)
+		score, reason, categories, maliciousIndicators = scoreContentFallback(rs, targetURL, title, textContent)
 		aiUsed = false
 	}
 
@@ -1648,16 +1900,18 @@ func (s *Scraper) ScoreLinkContent(ctx context.Context, targetURL string) (*mode
 		MaliciousIndicators: maliciousIndicators,
 		AIUsed:              aiUsed,
 	}
+	if !shouldSkipAI {
+		s.applyScoreAdjustment(linkScore) // Rule blocks are not adjusted
+	}
+	applyDomainPolicy(linkScore, policy)
 
 	return linkScore, nil
 }
 
 // checkForLowQualityPatterns performs early detection of low-quality URLs that don't require AI analysis
+// URLs are checked against the score rules; a blocking rule decides the score without calling Ollama
 // Returns (shouldSkipAI, score, reason, categories, maliciousIndicators)
-func checkForLowQualityPatterns(targetURL, title string) (bool, float64, string, []string, []string) {
-	urlLower := strings.ToLower(targetURL)
-	titleLower := strings.ToLower(title)
-
+func checkForLowQualityPatterns(rs *rules.RuleSet, targetURL, title string) (bool, float64, string, []string, []string) {
 	// Check for image content
 	if isImageURL(targetURL) {
 		categories := []string{"image", "media"}
@@ -1665,98 +1919,23 @@ func checkForLowQualityPatterns(targetURL, title string) (bool, float64, string,
 		return true, 0.0, "Image file detected - skipping content scoring", categories, maliciousIndicators
 	}
 
-	// Check for audio/video content
-	if isAudioVideoURL(targetURL) {
-		categories := []string{"media", "low-quality"}
-		maliciousIndicators := []string{}
-
-		if strings.Contains(urlLower, ".mp3") || strings.Contains(urlLower, ".wav") ||
-			strings.Contains(urlLower, ".ogg") || strings.Contains(urlLower, ".flac") ||
-			strings.Contains(urlLower, ".aac") || strings.Contains(urlLower, ".m4a") ||
-			strings.Contains(urlLower, ".mp4") || strings.Contains(urlLower, ".avi") ||
-			strings.Contains(urlLower, ".mkv") || strings.Contains(urlLower, ".mov") ||
-			strings.Contains(urlLower, ".webm") || strings.Contains(urlLower, ".wmv") ||
-			strings.Contains(urlLower, ".flv") || strings.Contains(urlLower, ".m4v") ||
-			strings.Contains(urlLower, ".mpeg") || strings.Contains(urlLower, ".mpg") ||
-			strings.Contains(urlLower, ".wma") || strings.Contains(urlLower, ".opus") ||
-			strings.Contains(urlLower, ".aiff") {
-			categories = append(categories, "audio-video")
-			maliciousIndicators = append(maliciousIndicators, "media-file")
-			return true, 0.15, "Audio/video file detected", categories, maliciousIndicators
-		}
-		categories = append(categories, "streaming")
-		maliciousIndicators = append(maliciousIndicators, "streaming-platform")
-		return true, 0.15, "Streaming platform detected", categories, maliciousIndicators
-	}
-
-	// Check for subscription/settings/preferences/account pages
-	undesirablePatterns := []struct {
-		patterns   []string
-		category   string
-		reason     string
-		indicator  string
-	}{
-		{
-			patterns:  []string{"/subscribe", "/subscription", "/subscriptions", "/pricing", "/plan", "/plans", "/premium", "/upgrade"},
-			category:  "subscription",
-			reason:    "Subscription/pricing page detected",
-			indicator: "subscription-page",
-		},
-		{
-			patterns:  []string{"/setting", "/settings", "/preference", "/preferences", "/config", "/configuration", "/configurations"},
-			category:  "settings",
-			reason:    "Settings/preferences page detected",
-			indicator: "settings-page",
-		},
-		{
-			patterns:  []string{"/account", "/accounts", "/profile", "/profiles", "/login", "/signin", "/signup", "/sign-up", "/register", "/auth"},
-			category:  "account",
-			reason:    "Account/login page detected",
-			indicator: "account-page",
-		},
-		{
-			patterns:  []string{"/checkout", "/cart", "/carts", "/basket", "/baskets", "/payment", "/payments"},
-			category:  "commerce",
-			reason:    "Shopping/payment page detected",
-			indicator: "commerce-page",
-		},
-		{
-			patterns:  []string{"/unsubscribe", "/opt-out", "/optout", "/opt_out"},
-			category:  "unsubscribe",
-			reason:    "Unsubscribe page detected",
-			indicator: "unsubscribe-page",
-		},
-		{
-			patterns:  []string{"/about", "/about-us", "/aboutus", "/who-we-are", "/our-story", "/our-team", "/team"},
-			category:  "about",
-			reason:    "About/team page detected",
-			indicator: "about-page",
-		},
-		{
-			patterns:  []string{"/contact", "/contact-us", "/contactus", "/get-in-touch", "/reach-us", "/support"},
-			category:  "contact",
-			reason:    "Contact page detected",
-			indicator: "contact-page",
-		},
-	}
-
-	for _, pattern := range undesirablePatterns {
-		for _, p := range pattern.patterns {
-			if strings.Contains(urlLower, p) || strings.Contains(titleLower, p) {
-				categories := []string{pattern.category, "low-quality", "utility-page"}
-				maliciousIndicators := []string{pattern.indicator}
-				return true, 0.1, pattern.reason, categories, maliciousIndicators
-			}
-		}
+	result := rs.Evaluate(rules.TargetScore, targetURL, title)
+	if !result.Blocked {
+		// No early pattern detected
+		return false, 0.0, "", nil, nil
 	}
 
-	// No early pattern detected
-	return false, 0.0, "", nil, nil
+	maliciousIndicators := result.Indicators
+	if maliciousIndicators == nil {
+		maliciousIndicators = []string{}
+	}
+	return true, result.Score, result.Reason, result.Categories, maliciousIndicators
 }
 
-// filterLowQualityLinks filters out low-quality URLs from a list using pattern matching
+// filterLowQualityLinks filters out URLs blocked by a domain policy or the link rules
+// Links to allowed domains are kept regardless of the rules
 // Returns the filtered list of URLs and the count of filtered links
-func filterLowQualityLinks(urls []string) ([]string, int) {
+func (s *Scraper) filterLowQualityLinks(rs *rules.RuleSet, urls []string) ([]string, int) {
 	if len(urls) == 0 {
 		return urls, 0
 	}
@@ -1764,92 +1943,12 @@ func filterLowQualityLinks(urls []string) ([]string, int) {
 	filtered := make([]string, 0, len(urls))
 	filteredCount := 0
 
-	// Define patterns to filter out
-	lowQualityPatterns := []string{
-		// Subscription and commerce
-		"/subscribe", "/subscription", "/subscriptions", "/pricing", "/plan", "/plans", "/premium", "/upgrade",
-		"/checkout", "/cart", "/carts", "/basket", "/baskets", "/payment", "/payments",
-
-		// Account and authentication
-		"/account", "/accounts", "/profile", "/profiles", "/login", "/signin", "/signup", "/sign-up", "/register", "/auth",
-		"/setting", "/settings", "/preference", "/preferences", "/config", "/configuration", "/configurations",
-
-		// Unsubscribe and opt-out
-		"/unsubscribe", "/opt-out", "/optout", "/opt_out",
-
-		// About and contact pages
-		"/about", "/about-us", "/aboutus", "/who-we-are", "/our-story", "/our-team", "/team",
-		"/contact", "/contact-us", "/contactus", "/get-in-touch", "/reach-us", "/support",
-
-		// Navigation and utility
-		"/privacy", "/terms", "/cookie", "/legal", "/disclaimer", "/faq", "/help",
-		"/sitemap", "/search", "/rss", "/feed", "/newsletter", "/jobs", "/careers",
-		"/press", "/media-kit", "/advertise", "/advertising",
-
-		// Social media and sharing
-		"/share", "/tweet", "/facebook", "/twitter", "/linkedin", "/pinterest",
-
-		// Fragments and anchors (often just navigation)
-		"#comments", "#respond", "#reply", "#share", "#footer", "#header", "#nav",
-	}
-
-	// Media file extensions
-	mediaExtensions := []string{
-		".mp3", ".wav", ".ogg", ".flac", ".aac", ".m4a", ".wma", ".opus", ".aiff",
-		".mp4", ".avi", ".mkv", ".mov", ".wmv", ".flv", ".webm", ".m4v", ".mpeg", ".mpg",
-		".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx", ".zip", ".rar", ".tar", ".gz",
-	}
-
-	// Streaming platforms to filter out
-	streamingPlatforms := []string{
-		"youtube.com", "youtu.be", "vimeo.com", "dailymotion.com",
-		"twitch.tv", "soundcloud.com", "spotify.com", "music.apple.com",
-		"tidal.com", "deezer.com", "pandora.com", "music.youtube.com",
-	}
-
 	for _, url := range urls {
-		urlLower := strings.ToLower(url)
-		shouldFilter := false
-
-		// Check for media files
-		for _, ext := range mediaExtensions {
-			if strings.HasSuffix(urlLower, ext) || strings.Contains(urlLower, ext+"?") {
-				shouldFilter = true
-				break
-			}
-		}
-
-		if !shouldFilter {
-			// Check for streaming platforms
-			for _, platform := range streamingPlatforms {
-				if strings.Contains(urlLower, platform) {
-					shouldFilter = true
-					break
-				}
-			}
-		}
-
-		if !shouldFilter {
-			// Check for low-quality URL patterns
-			for _, pattern := range lowQualityPatterns {
-				if strings.Contains(urlLower, pattern) {
-					shouldFilter = true
-					break
-				}
-			}
-		}
-
-		if !shouldFilter {
-			// Check if URL is a category/section page
-			if isCategoryPage(url) {
-				shouldFilter = true
-			}
-		}
-
-		if !shouldFilter {
-			filtered = append(filtered, url)
-		} else {
+		policy := s.DomainPolicyFor(url)
+		if policyBlocks(policy) || (!policyTrusts(policy) && rs.Blocked(rules.TargetLinks, url)) {
 			filteredCount++
+		} else {
+			filtered = append(filtered, url)
 		}
 	}
 
@@ -1875,235 +1974,6 @@ func deduplicateLinks(urls []string) []string {
 	return deduplicated
 }
 
-// isCategoryPage detects if a URL is likely a category/section landing page
-// These pages are useful for link extraction but shouldn't be in the final results
-func isCategoryPage(targetURL string) bool {
-	parsedURL, err := url.Parse(targetURL)
-	if err != nil {
-		return false
-	}
-
-	path := strings.Trim(parsedURL.Path, "/")
-
-	// Empty path or just the domain (homepage) - not a category
-	if path == "" {
-		return false
-	}
-
-	// Split path into segments
-	segments := strings.Split(path, "/")
-
-	// Common category/section indicators in the path
-	categoryIndicators := []string{
-		"section", "sections", "category", "categories", "topic", "topics",
-		"tag", "tags", "archive", "archives", "index",
-	}
-
-	// Check if any segment contains category indicators (strip file extensions first)
-	for _, segment := range segments {
-		segmentLower := strings.ToLower(segment)
-
-		// Strip common file extensions
-		segmentLower = strings.TrimSuffix(segmentLower, ".html")
-		segmentLower = strings.TrimSuffix(segmentLower, ".htm")
-		segmentLower = strings.TrimSuffix(segmentLower, ".php")
-		segmentLower = strings.TrimSuffix(segmentLower, ".asp")
-		segmentLower = strings.TrimSuffix(segmentLower, ".aspx")
-
-		for _, indicator := range categoryIndicators {
-			if segmentLower == indicator || strings.HasPrefix(segmentLower, indicator+"-") || strings.HasPrefix(segmentLower, indicator+"_") {
-				return true
-			}
-		}
-	}
-
-	// Common news section/category names
-	newsSections := []string{
-		// General news sections
-		"news", "world", "national", "local", "us", "uk", "international", "global",
-		"politics", "political", "government", "policy",
-		"business", "finance", "economy", "markets", "money",
-		"technology", "tech", "science", "innovation",
-		"technology", "tech", "science", "innovation",
-		"health", "medical", "wellness", "healthcare",
-		"sports", "sport", "football", "basketball", "baseball", "soccer",
-		"entertainment", "culture", "arts", "music", "movies", "film", "tv", "television",
-		"lifestyle", "life", "living", "fashion", "food", "travel", "style",
-		"opinion", "opinions", "editorial", "editorials", "commentary", "columnists",
-		"investigations", "analysis", "features", "special-reports",
-		"environment", "climate", "weather", "energy",
-		"education", "schools", "university", "college",
-		"crime", "law", "justice", "courts",
-		"religion", "faith", "beliefs",
-		"obituaries", "obits", "deaths",
-
-		// Regional/location-based sections
-		"asia", "europe", "africa", "americas", "middle-east", "middleeast",
-		"asia-pacific", "latin-america", "north-america", "south-america",
-		"england", "scotland", "wales", "northern-ireland",
-		"us-canada", "latin-america", "middle-east-asia",
-
-		// Media-specific sections
-		"video", "videos", "podcasts", "audio", "multimedia", "gallery", "galleries",
-		"photos", "pictures", "images",
-
-		// Time-based sections
-		"today", "latest", "breaking", "live", "now", "updates",
-	}
-
-	// Check if URL looks like an article (has numeric IDs or article patterns)
-	hasArticlePattern := false
-	for _, segment := range segments {
-		segmentLower := strings.ToLower(segment)
-
-		// Check for "articles" segment (common in modern news sites)
-		if segmentLower == "articles" || segmentLower == "article" || segmentLower == "story" {
-			hasArticlePattern = true
-			break
-		}
-
-		// Check for segments with 8+ digit numbers (common article IDs)
-		// e.g., "world-middle-east-12345678"
-		if len(segmentLower) >= 8 {
-			digitCount := 0
-			for _, char := range segmentLower {
-				if char >= '0' && char <= '9' {
-					digitCount++
-				}
-			}
-			if digitCount >= 8 {
-				hasArticlePattern = true
-				break
-			}
-		}
-	}
-
-	// If it has article patterns, it's not a category page
-	if hasArticlePattern {
-		return false
-	}
-
-	// Check all segments against known section names
-	// If URL has 1-4 path segments and consists primarily of section names, it's likely a category page
-	if len(segments) <= 4 {
-		sectionMatchCount := 0
-		for _, segment := range segments {
-			segmentLower := strings.ToLower(segment)
-
-			// Strip file extensions before checking
-			segmentLower = strings.TrimSuffix(segmentLower, ".html")
-			segmentLower = strings.TrimSuffix(segmentLower, ".htm")
-			segmentLower = strings.TrimSuffix(segmentLower, ".php")
-			segmentLower = strings.TrimSuffix(segmentLower, ".asp")
-			segmentLower = strings.TrimSuffix(segmentLower, ".aspx")
-
-			matched := false
-			for _, section := range newsSections {
-				// Exact match or starts with section name
-				if segmentLower == section || strings.HasPrefix(segmentLower, section+"-") || strings.HasPrefix(segmentLower, section+"_") {
-					matched = true
-					break
-				}
-				// Also check if segment starts with the section name (for compound names like "sciencetech")
-				if len(section) >= 4 && strings.HasPrefix(segmentLower, section) {
-					matched = true
-					break
-				}
-			}
-			if matched {
-				sectionMatchCount++
-			}
-		}
-
-		// If most segments are section names, it's a category page
-		// For 1-2 segments: all must match
-		// For 3 segments: at least 2 must match
-		// For 4 segments: at least 3 must match (to avoid false positives with article titles)
-		if len(segments) <= 2 && sectionMatchCount == len(segments) {
-			return true
-		}
-		if len(segments) == 3 && sectionMatchCount >= 2 {
-			return true
-		}
-		if len(segments) == 4 && sectionMatchCount >= 3 {
-			return true
-		}
-	}
-
-	// Check for patterns like "/section/name" or "/category/name" with no further depth
-	if len(segments) == 2 {
-		firstSegment := strings.ToLower(segments[0])
-		for _, indicator := range categoryIndicators {
-			if firstSegment == indicator {
-				return true
-			}
-		}
-	}
-
-	// Check for year-based archive pages (e.g., /2024/, /2024/01/)
-	if len(segments) >= 1 && len(segments) <= 3 {
-		// Check if first segment is a 4-digit year
-		if len(segments[0]) == 4 {
-			_, err := strconv.Atoi(segments[0])
-			if err == nil {
-				// If it's just /YYYY/ or /YYYY/MM/ or /YYYY/MM/DD/, likely an archive
-				if len(segments) <= 2 {
-					return true
-				}
-				// /YYYY/MM/DD/ is still a category if all are numbers
-				if len(segments) == 3 {
-					allNumbers := true
-					for _, seg := range segments {
-						if _, err := strconv.Atoi(seg); err != nil {
-							allNumbers = false
-							break
-						}
-					}
-					if allNumbers {
-						return true
-					}
-				}
-			}
-		}
-	}
-
-	return false
-}
-
-// isAudioVideoURL checks if a URL points to audio/video content or streaming platforms
-func isAudioVideoURL(targetURL string) bool {
-	urlLower := strings.ToLower(targetURL)
-
-	// Check for audio/video file extensions
-	audioVideoExtensions := []string{
-		// Audio
-		".mp3", ".wav", ".ogg", ".flac", ".aac", ".m4a", ".wma", ".opus", ".aiff",
-		// Video
-		".mp4", ".avi", ".mkv", ".mov", ".wmv", ".flv", ".webm", ".m4v", ".mpeg", ".mpg",
-	}
-
-	for _, ext := range audioVideoExtensions {
-		if strings.HasSuffix(urlLower, ext) || strings.Contains(urlLower, ext+"?") {
-			return true
-		}
-	}
-
-	// Check for streaming platforms
-	streamingPlatforms := []string{
-		"youtube.com", "youtu.be", "vimeo.com", "dailymotion.com",
-		"twitch.tv", "soundcloud.com", "spotify.com", "music.apple.com",
-		"tidal.com", "deezer.com", "pandora.com", "music.youtube.com",
-	}
-
-	for _, platform := range streamingPlatforms {
-		if strings.Contains(urlLower, platform) {
-			return true
-		}
-	}
-
-	return false
-}
-
 // isImageURL checks if a URL points directly to an image file
 func isImageURL(targetURL string) bool {
 	// Parse URL to extract components
@@ -2162,52 +2032,25 @@ func isImageURL(targetURL string) bool {
 }
 
 // scoreContentFallback provides rule-based content scoring when Ollama is unavailable
-// This function is only called after checkForLowQualityPatterns() has already passed,
-// so we don't need to re-check for audio/video, subscription pages, etc.
-func scoreContentFallback(targetURL, title, content string) (score float64, reason string, categories []string, maliciousIndicators []string) {
+// Blocking score rules are normally caught earlier by checkForLowQualityPatterns(),
+// adjust rules add their weights to the content heuristics below
+func scoreContentFallback(rs *rules.RuleSet, targetURL, title, content string) (score float64, reason string, categories []string, maliciousIndicators []string) {
 	score = 0.5 // Start with neutral score
 	categories = []string{}
 	maliciousIndicators = []string{}
 	reasons := []string{}
 
-	urlLower := strings.ToLower(targetURL)
 	titleLower := strings.ToLower(title)
 	contentLower := strings.ToLower(content)
 
-	// Check for blocked content types (social media, gambling, adult, drugs, etc.)
-	blockedDomains := map[string]string{
-		"facebook.com":    "social-media",
-		"twitter.com":     "social-media",
-		"x.com":           "social-media",
-		"instagram.com":   "social-media",
-		"tiktok.com":      "social-media",
-		"reddit.com":      "forum",
-		"linkedin.com":    "social-media",
-		"pinterest.com":   "social-media",
-		"snapchat.com":    "social-media",
-		"bet":             "gambling",
-		"casino":          "gambling",
-		"poker":           "gambling",
-		"betting":         "gambling",
-		"xxx":             "adult-content",
-		"porn":            "adult-content",
-		"adult":           "adult-content",
-		"cannabis":        "drugs",
-		"weed":            "drugs",
-		"ebay.com":        "marketplace",
-		"amazon.com":      "marketplace",
-		"craigslist.org":  "marketplace",
-	}
-
-	for domain, category := range blockedDomains {
-		if strings.Contains(urlLower, domain) {
-			score = 0.1
-			categories = append(categories, category, "low-quality")
-			reasons = append(reasons, "Blocked content type detected: "+category)
-			maliciousIndicators = append(maliciousIndicators, category)
-			reason = strings.Join(reasons, "; ")
-			return
-		}
+	// Check for blocked domains and content types (social media, gambling, adult, drugs, etc.)
+	result := rs.Evaluate(rules.TargetScore, targetURL, title)
+	if result.Blocked {
+		score = result.Score
+		reason = result.Reason
+		categories = normalizeTags(result.Categories)
+		maliciousIndicators = normalizeTags(result.Indicators)
+		return
 	}
 
 	// Content length checks
@@ -2290,16 +2133,10 @@ func scoreContentFallback(targetURL, title, content string) (score float64, reas
 		reasons = append(reasons, "Excessive punctuation")
 	}
 
-	// Check for quality indicators in URL
-	qualityDomains := []string{".edu", ".gov", ".org", "wikipedia", "arxiv", "github", "stackoverflow"}
-	for _, domain := range qualityDomains {
-		if strings.Contains(urlLower, domain) {
-			score += 0.3
-			reasons = append(reasons, "Quality domain detected")
-			categories = append(categories, "reference", "trusted_source")
-			break
-		}
-	}
+	// Apply rule weights (quality domains, etc.)
+	score += result.Adjustment
+	reasons = append(reasons, result.Reasons...)
+	categories = append(categories, result.Categories...)
 
 	// Check for technical/educational content indicators
 	technicalKeywords := []string{"documentation", "tutorial", "guide", "research", "study", "analysis", "technical"}
@@ -2340,19 +2177,22 @@ func scoreContentFallback(targetURL, title, content string) (score float64, reas
 		maliciousIndicators = []string{}
 	}
 
-	// Normalize all categories
-	for i, category := range categories {
-		categories[i] = normalizeTag(category)
-	}
-
-	// Normalize all malicious indicators
-	for i, indicator := range maliciousIndicators {
-		maliciousIndicators[i] = normalizeTag(indicator)
-	}
+	// Normalize all categories and malicious indicators
+	categories = normalizeTags(categories)
+	maliciousIndicators = normalizeTags(maliciousIndicators)
 
 	return score, reason, categories, maliciousIndicators
 }
 
+// normalizeTags normalizes each tag, always returning a non-nil slice
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, len(tags))
+	for i, tag := range tags {
+		normalized[i] = normalizeTag(tag)
+	}
+	return normalized
+}
+
 // normalizeTag normalizes a tag according to the tagging rules:
 // - Converts to lowercase
 // - Replaces spaces and underscores with hyphens
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/docutag/scraper/models"
+	"github.com/docutag/scraper/rules"
 )
 
 func TestNew(t *testing.T) {
@@ -905,6 +906,7 @@ func containsHelper(s, substr string) bool {
 // TestScoreContentFallbackSocialMedia tests fallback scoring for social media
 func TestScoreContentFallbackSocialMedia(t *testing.T) {
 	score, reason, categories, indicators := scoreContentFallback(
+		rules.Default(),
 		"https://www.facebook.com/profile",
 		"Facebook Profile",
 		"This is my Facebook profile with posts and photos.",
@@ -934,6 +936,7 @@ func TestScoreContentFallbackSocialMedia(t *testing.T) {
 // TestScoreContentFallbackQualityDomain tests fallback scoring for quality domains
 func TestScoreContentFallbackQualityDomain(t *testing.T) {
 	score, reason, categories, _ := scoreContentFallback(
+		rules.Default(),
 		"https://en.wikipedia.org/wiki/Artificial_Intelligence",
 		"Artificial Intelligence - Wikipedia",
 		strings.Repeat("This is a comprehensive article about artificial intelligence. ", 50),
@@ -955,6 +958,7 @@ func TestScoreContentFallbackQualityDomain(t *testing.T) {
 // TestScoreContentFallbackShortContent tests fallback scoring for short content
 func TestScoreContentFallbackShortContent(t *testing.T) {
 	score, reason, categories, _ := scoreContentFallback(
+		rules.Default(),
 		"https://example.com/short",
 		"Short Page",
 		"Very short content here.",
@@ -977,6 +981,7 @@ func TestScoreContentFallbackShortContent(t *testing.T) {
 func TestScoreContentFallbackSpam(t *testing.T) {
 	spamContent := "Click here! Click here! Click here! Buy now! Buy now! Limited offer!"
 	score, reason, categories, indicators := scoreContentFallback(
+		rules.Default(),
 		"https://example.com/spam",
 		"Amazing Offer",
 		spamContent,
@@ -1003,6 +1008,7 @@ func TestScoreContentFallbackSpam(t *testing.T) {
 func TestScoreContentFallbackTechnical(t *testing.T) {
 	technicalContent := strings.Repeat("This is a technical guide about software development and programming best practices. ", 20)
 	score, reason, categories, _ := scoreContentFallback(
+		rules.Default(),
 		"https://example.com/tutorial",
 		"Software Development Tutorial",
 		technicalContent,
@@ -1024,6 +1030,7 @@ func TestScoreContentFallbackTechnical(t *testing.T) {
 // TestScoreContentFallbackGambling tests fallback scoring for gambling sites
 func TestScoreContentFallbackGambling(t *testing.T) {
 	score, _, categories, indicators := scoreContentFallback(
+		rules.Default(),
 		"https://www.betcasino.com",
 		"Online Casino",
 		"Place your bets and win big!",
@@ -1084,6 +1091,7 @@ func TestCheckForLowQualityPatternsAudioVideo(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			shouldSkip, score, reason, categories, indicators := checkForLowQualityPatterns(
+				rules.Default(),
 				tt.url,
 				tt.title,
 			)
@@ -1181,6 +1189,7 @@ func TestCheckForLowQualityPatternsUtilityPages(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			shouldSkip, score, reason, categories, indicators := checkForLowQualityPatterns(
+				rules.Default(),
 				tt.url,
 				tt.title,
 			)
@@ -1216,7 +1225,7 @@ func TestCheckForLowQualityPatternsUtilityPages(t *testing.T) {
 	}
 }
 
-func TestIsAudioVideoURL(t *testing.T) {
+func TestAudioVideoRules(t *testing.T) {
 	tests := []struct {
 		name     string
 		url      string
@@ -1234,9 +1243,10 @@ func TestIsAudioVideoURL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isAudioVideoURL(tt.url)
+			_, _, _, categories, _ := checkForLowQualityPatterns(rules.Default(), tt.url, "")
+			result := containsString(categories, "media")
 			if result != tt.expected {
-				t.Errorf("isAudioVideoURL(%s) = %v, want %v", tt.url, result, tt.expected)
+				t.Errorf("audio/video detection for %s = %v, want %v", tt.url, result, tt.expected)
 			}
 		})
 	}
@@ -1590,7 +1600,7 @@ func TestShouldSkipImage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := shouldSkipImage(tt.imageURL)
+			result := shouldSkipImage(rules.Default(), tt.imageURL)
 			if result != tt.expected {
 				t.Errorf("shouldSkipImage(%q) = %v, expected %v", tt.imageURL, result, tt.expected)
 			}
@@ -1628,7 +1638,7 @@ func TestImageFiltering(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	processedImages, existingRefs, warnings := s.processImages(ctx, images)
+	processedImages, existingRefs, warnings := s.processImages(ctx, images, s.ollamaClient, ScrapeOptions{})
 
 	// Check that no existing refs were found (all new images)
 	if len(existingRefs) != 0 {
@@ -1770,172 +1780,6 @@ func TestWebPImageDimensions(t *testing.T) {
 	t.Logf("Successfully extracted WebP dimensions: %dx%d", width, height)
 }
 
-func TestIsCategoryPage(t *testing.T) {
-	tests := []struct {
-		name       string
-		url        string
-		isCategory bool
-	}{
-		// Should be detected as category pages
-		{
-			name:       "BBC Arts section",
-			url:        "https://www.bbc.com/arts",
-			isCategory: true,
-		},
-		{
-			name:       "Guardian World Asia",
-			url:        "https://www.theguardian.com/world/asia",
-			isCategory: true,
-		},
-		{
-			name:       "NYTimes Politics",
-			url:        "https://www.nytimes.com/section/politics",
-			isCategory: true,
-		},
-		{
-			name:       "CNN Business",
-			url:        "https://www.cnn.com/business",
-			isCategory: true,
-		},
-		{
-			name:       "Tech category",
-			url:        "https://example.com/technology",
-			isCategory: true,
-		},
-		{
-			name:       "Sports section",
-			url:        "https://example.com/sports",
-			isCategory: true,
-		},
-		{
-			name:       "Category with subcategory",
-			url:        "https://example.com/category/tech",
-			isCategory: true,
-		},
-		{
-			name:       "Year archive",
-			url:        "https://example.com/2024",
-			isCategory: true,
-		},
-		{
-			name:       "Year/Month archive",
-			url:        "https://example.com/2024/01",
-			isCategory: true,
-		},
-		{
-			name:       "Year/Month/Day archive (all numbers)",
-			url:        "https://example.com/2024/01/15",
-			isCategory: true,
-		},
-		{
-			name:       "Tag page",
-			url:        "https://example.com/tag/ai",
-			isCategory: true,
-		},
-		{
-			name:       "Topic page",
-			url:        "https://example.com/topic/climate",
-			isCategory: true,
-		},
-		{
-			name:       "Multiple sections",
-			url:        "https://example.com/world",
-			isCategory: true,
-		},
-		{
-			name:       "Opinion section",
-			url:        "https://example.com/opinion",
-			isCategory: true,
-		},
-		{
-			name:       "Guardian Science section",
-			url:        "https://www.theguardian.com/science",
-			isCategory: true,
-		},
-		{
-			name:       "DailyMail sciencetech index",
-			url:        "https://www.dailymail.co.uk/sciencetech/index.html",
-			isCategory: true,
-		},
-		{
-			name:       "BBC News section",
-			url:        "https://www.bbc.com/news",
-			isCategory: true,
-		},
-		{
-			name:       "BBC World/Asia section",
-			url:        "https://www.bbc.com/news/world/asia",
-			isCategory: true,
-		},
-		{
-			name:       "BBC World/Europe section",
-			url:        "https://www.bbc.com/news/world/europe",
-			isCategory: true,
-		},
-
-		// Should NOT be detected as category pages
-		{
-			name:       "Homepage",
-			url:        "https://www.bbc.com",
-			isCategory: false,
-		},
-		{
-			name:       "Article with world in path",
-			url:        "https://www.theguardian.com/world/asia/2024/jan/15/story-title",
-			isCategory: false,
-		},
-		{
-			name:       "Specific article",
-			url:        "https://www.bbc.com/news/article-title-12345",
-			isCategory: false,
-		},
-		{
-			name:       "BBC article with 8-digit ID",
-			url:        "https://www.bbc.com/news/world-middle-east-12345678",
-			isCategory: false,
-		},
-		{
-			name:       "BBC article in articles path",
-			url:        "https://www.bbc.com/news/articles/c1234567",
-			isCategory: false,
-		},
-		{
-			name:       "Article with date in path",
-			url:        "https://example.com/2024/01/15/article-title",
-			isCategory: false,
-		},
-		{
-			name:       "Deep nested article",
-			url:        "https://example.com/world/asia/china/article-name",
-			isCategory: false,
-		},
-		{
-			name:       "Article with ID",
-			url:        "https://example.com/article/12345",
-			isCategory: false,
-		},
-		{
-			name:       "Blog post",
-			url:        "https://example.com/blog/my-post-title",
-			isCategory: false,
-		},
-		{
-			name:       "Invalid URL",
-			url:        "not a url",
-			isCategory: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := isCategoryPage(tt.url)
-			if result != tt.isCategory {
-				t.Errorf("isCategoryPage(%q) = %v, want %v", tt.url, result, tt.isCategory)
-			}
-		})
-	}
-}
-
 func TestDeduplicateLinks(t *testing.T) {
 	tests := []struct {
 		name     string
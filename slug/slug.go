@@ -57,6 +57,9 @@ func GenerateWithFallback(s, fallback string) string {
 
 // transliterate converts unicode characters to ASCII equivalents
 func transliterate(s string) string {
+	// Romanize non-Latin scripts, then strip accents from what remains
+	s = romanize(s)
+
 	// Normalize unicode characters to NFD form (decomposed)
 	t := transform.Chain(norm.NFD, transform.RemoveFunc(isMn), norm.NFC)
 	result, _, _ := transform.String(t, s)
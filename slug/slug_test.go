@@ -68,7 +68,67 @@ func TestGenerate(t *testing.T) {
 		{
 			name:     "cyrillic characters",
 			input:    "Привет Мир",
-			expected: "", // Cyrillic chars are removed, not transliterated
+			expected: "privet-mir",
+		},
+		{
+			name:     "ukrainian letters",
+			input:    "Львів і Європа",
+			expected: "lviv-i-yevropa",
+		},
+		{
+			name:     "greek with accents",
+			input:    "Καλημέρα κόσμε",
+			expected: "kalimera-kosme",
+		},
+		{
+			name:     "arabic",
+			input:    "مرحبا بالعالم",
+			expected: "mrhba-balalm",
+		},
+		{
+			name:     "hebrew",
+			input:    "שלום עולם",
+			expected: "shlvm-vlm",
+		},
+		{
+			name:     "japanese kana",
+			input:    "きょうはいいてんき",
+			expected: "kyouhaiitenki",
+		},
+		{
+			name:     "katakana with small tsu and long vowel",
+			input:    "チョコレート・マッチ",
+			expected: "chokoreto-matchi",
+		},
+		{
+			name:     "korean",
+			input:    "안녕하세요 서울",
+			expected: "annyeonghaseyo-seoul",
+		},
+		{
+			name:     "devanagari",
+			input:    "नमस्ते भारत",
+			expected: "namaste-bharat",
+		},
+		{
+			name:     "latin letters without decomposition",
+			input:    "Straße Łódź Ærø",
+			expected: "strasse-lodz-aero",
+		},
+		{
+			name:     "han ideographs",
+			input:    "北京 News",
+			expected: "bei-jing-news",
+		},
+		{
+			name:     "chinese title",
+			input:    "中国经济发展报告",
+			expected: "zhong-guo-jing-ji-fa-zhan-bao-gao",
+		},
+		{
+			name:     "pinyin with umlaut",
+			input:    "绿色",
+			expected: "lv-se",
 		},
 		{
 			name:     "mixed case with numbers",
@@ -0,0 +1,253 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Transliteration of non-Latin scripts to ASCII
+//
+// Cyrillic and Greek follow common romanizations, Arabic and Hebrew are written as
+// their consonants (the scripts rarely mark vowels), kana use Hepburn, Hangul uses the
+// Revised Romanization of Korean without sound-change rules, and Devanagari adds the
+// inherent vowel except at the end of a word. Han ideographs are written as toneless
+// Mandarin pinyin, one word per character since word boundaries are not marked; kanji
+// in Japanese titles therefore get their Mandarin reading, and the rare ideographs
+// without a pinyin reading are dropped.
+//
+// Input is expected to be lowercased already.
+
+// hanArgs selects toneless pinyin with the most common reading of each character
+var hanArgs = pinyin.NewArgs()
+
+// letters maps single runes to their romanization
+var letters = map[rune]string{
+	// Latin letters that do not decompose into a base letter and a mark
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i", 'ŋ': "ng",
+
+	// Cyrillic (Russian, Ukrainian, Belarusian, Serbian, Macedonian)
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "u",
+	'ђ': "dj", 'ј': "j", 'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz", 'ѓ': "gj", 'ќ': "kj", 'ѕ': "dz",
+
+	// Greek; accented vowels are reduced to their base letter first
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i",
+	'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+
+	// Arabic, with the additional Persian and Urdu letters
+	'ا': "a", 'أ': "a", 'إ': "i", 'آ': "a", 'ٱ': "a", 'ب': "b", 'ت': "t", 'ث': "th", 'ج': "j",
+	'ح': "h", 'خ': "kh", 'د': "d", 'ذ': "dh", 'ر': "r", 'ز': "z", 'س': "s", 'ش': "sh", 'ص': "s",
+	'ض': "d", 'ط': "t", 'ظ': "z", 'ع': "", 'غ': "gh", 'ف': "f", 'ق': "q", 'ك': "k", 'ل': "l",
+	'م': "m", 'ن': "n", 'ه': "h", 'و': "w", 'ي': "y", 'ى': "a", 'ة': "h", 'ء': "", 'ئ': "", 'ؤ': "",
+	'پ': "p", 'چ': "ch", 'ژ': "zh", 'گ': "g", 'ک': "k", 'ی': "y",
+	'ٹ': "t", 'ڈ': "d", 'ڑ': "r", 'ں': "n", 'ے': "e", 'ہ': "h", 'ھ': "h",
+
+	// Hebrew, including final forms
+	'א': "", 'ב': "b", 'ג': "g", 'ד': "d", 'ה': "h", 'ו': "v", 'ז': "z", 'ח': "kh", 'ט': "t",
+	'י': "y", 'כ': "k", 'ך': "k", 'ל': "l", 'מ': "m", 'ם': "m", 'נ': "n", 'ן': "n", 'ס': "s",
+	'ע': "", 'פ': "p", 'ף': "p", 'צ': "ts", 'ץ': "ts", 'ק': "k", 'ר': "r", 'ש': "sh", 'ת': "t",
+
+	// Word separators used instead of spaces in CJK text
+	'　': " ", '、': " ", '。': " ", '・': " ", '「': " ", '」': " ", '『': " ", '』': " ", '，': " ",
+}
+
+// kana maps hiragana to Hepburn romanization; katakana are mapped through hiragana
+var kana = map[rune]string{
+	'ぁ': "a", 'あ': "a", 'ぃ': "i", 'い': "i", 'ぅ': "u", 'う': "u", 'ぇ': "e", 'え': "e", 'ぉ': "o", 'お': "o",
+	'か': "ka", 'が': "ga", 'き': "ki", 'ぎ': "gi", 'く': "ku", 'ぐ': "gu", 'け': "ke", 'げ': "ge", 'こ': "ko", 'ご': "go",
+	'さ': "sa", 'ざ': "za", 'し': "shi", 'じ': "ji", 'す': "su", 'ず': "zu", 'せ': "se", 'ぜ': "ze", 'そ': "so", 'ぞ': "zo",
+	'た': "ta", 'だ': "da", 'ち': "chi", 'ぢ': "ji", 'つ': "tsu", 'づ': "zu", 'て': "te", 'で': "de", 'と': "to", 'ど': "do",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ば': "ba", 'ぱ': "pa", 'ひ': "hi", 'び': "bi", 'ぴ': "pi", 'ふ': "fu", 'ぶ': "bu", 'ぷ': "pu",
+	'へ': "he", 'べ': "be", 'ぺ': "pe", 'ほ': "ho", 'ぼ': "bo", 'ぽ': "po",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo", 'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'ゎ': "wa", 'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n", 'ゔ': "vu",
+}
+
+// Kana that modify the syllable around them
+const (
+	smallTsu = 'っ' // Doubles the next consonant
+	smallYa  = 'ゃ'
+	smallYu  = 'ゅ'
+	smallYo  = 'ょ'
+)
+
+// Hangul syllable decomposition, per the Unicode standard
+const (
+	hangulBase   = 0xAC00
+	hangulLast   = 0xD7A3
+	hangulMedial = 21
+	hangulFinal  = 28
+)
+
+var (
+	hangulInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	hangulMedials  = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	hangulFinals   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+)
+
+// Devanagari consonants without their inherent vowel, independent vowels and vowel signs
+var (
+	devanagariConsonants = map[rune]string{
+		'क': "k", 'ख': "kh", 'ग': "g", 'घ': "gh", 'ङ': "n", 'च': "ch", 'छ': "chh", 'ज': "j", 'झ': "jh", 'ञ': "n",
+		'ट': "t", 'ठ': "th", 'ड': "d", 'ढ': "dh", 'ण': "n", 'त': "t", 'थ': "th", 'द': "d", 'ध': "dh", 'न': "n",
+		'प': "p", 'फ': "ph", 'ब': "b", 'भ': "bh", 'म': "m", 'य': "y", 'र': "r", 'ल': "l", 'व': "v",
+		'श': "sh", 'ष': "sh", 'स': "s", 'ह': "h",
+	}
+	devanagariVowels = map[rune]string{
+		'अ': "a", 'आ': "a", 'इ': "i", 'ई': "i", 'उ': "u", 'ऊ': "u", 'ऋ': "ri", 'ए': "e", 'ऐ': "ai", 'ओ': "o", 'औ': "au",
+		'ं': "n", 'ँ': "n", 'ः': "h",
+	}
+	devanagariSigns = map[rune]string{
+		'ा': "a", 'ि': "i", 'ी': "i", 'ु': "u", 'ू': "u", 'ृ': "ri", 'े': "e", 'ै': "ai", 'ो': "o", 'ौ': "au",
+		'्': "", // Virama: the consonant has no vowel
+	}
+)
+
+const devanagariNukta = '़'
+
+// romanize replaces characters of non-Latin scripts with ASCII, leaving Latin text and
+// unknown characters unchanged
+func romanize(s string) string {
+	runes := []rune(norm.NFC.String(s))
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r >= hangulBase && r <= hangulLast:
+			idx := int(r - hangulBase)
+			b.WriteString(hangulInitials[idx/(hangulMedial*hangulFinal)])
+			b.WriteString(hangulMedials[(idx%(hangulMedial*hangulFinal))/hangulFinal])
+			b.WriteString(hangulFinals[idx%hangulFinal])
+
+		case isKana(r):
+			i += writeKana(&b, runes, i)
+
+		case devanagariConsonants[r] != "":
+			b.WriteString(devanagariConsonants[r])
+			next := i + 1
+			for next < len(runes) && runes[next] == devanagariNukta {
+				next++
+			}
+			if next < len(runes) {
+				if sign, ok := devanagariSigns[runes[next]]; ok {
+					b.WriteString(sign)
+					i = next
+					continue
+				}
+			}
+			// The inherent vowel is silent at the end of a word
+			if next < len(runes) && isDevanagariLetter(runes[next]) {
+				b.WriteString("a")
+			}
+			i = next - 1
+
+		case devanagariVowels[r] != "":
+			b.WriteString(devanagariVowels[r])
+
+		case unicode.Is(unicode.Han, r):
+			if syllables := pinyin.SinglePinyin(r, hanArgs); len(syllables) > 0 {
+				b.WriteString(" " + syllables[0] + " ")
+			}
+
+		case r >= '०' && r <= '९':
+			b.WriteRune('0' + (r - '०'))
+		case r >= '٠' && r <= '٩':
+			b.WriteRune('0' + (r - '٠'))
+		case r >= '۰' && r <= '۹':
+			b.WriteRune('0' + (r - '۰'))
+
+		default:
+			if latin, ok := letters[r]; ok {
+				b.WriteString(latin)
+				continue
+			}
+			// Accented Greek and Cyrillic letters romanize like their base letter
+			if base := []rune(norm.NFD.String(string(r))); len(base) > 1 {
+				if latin, ok := letters[base[0]]; ok {
+					b.WriteString(latin)
+					continue
+				}
+			}
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// isKana reports whether r is a hiragana or katakana syllable handled by writeKana
+func isKana(r rune) bool {
+	return (r >= 'ぁ' && r <= 'ゖ') || (r >= 'ァ' && r <= 'ヶ') || r == 'ー'
+}
+
+// toHiragana maps katakana to the matching hiragana
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - ('ァ' - 'ぁ')
+	}
+	return r
+}
+
+// writeKana writes the syllable starting at runes[i] and returns how many extra runes it used
+func writeKana(b *strings.Builder, runes []rune, i int) int {
+	r := toHiragana(runes[i])
+
+	switch r {
+	case 'ー':
+		// Long vowel mark: Hepburn slugs leave the vowel short
+		return 0
+	case smallTsu:
+		// Double the first consonant of the next syllable: っち -> tchi
+		if i+1 < len(runes) && isKana(runes[i+1]) {
+			next := kana[toHiragana(runes[i+1])]
+			if strings.HasPrefix(next, "ch") {
+				b.WriteByte('t')
+			} else if next != "" && !strings.ContainsRune("aiueon", rune(next[0])) {
+				b.WriteByte(next[0])
+			}
+		}
+		return 0
+	}
+
+	syllable := kana[r]
+	if i+1 < len(runes) {
+		// Contracted sounds: きゃ -> kya, しゃ -> sha, じょ -> jo
+		var glide string
+		switch toHiragana(runes[i+1]) {
+		case smallYa:
+			glide = "a"
+		case smallYu:
+			glide = "u"
+		case smallYo:
+			glide = "o"
+		}
+		if glide != "" && len(syllable) > 1 && strings.HasSuffix(syllable, "i") {
+			stem := strings.TrimSuffix(syllable, "i")
+			if !strings.HasSuffix(stem, "sh") && !strings.HasSuffix(stem, "ch") && stem != "j" {
+				stem += "y"
+			}
+			b.WriteString(stem + glide)
+			return 1
+		}
+	}
+	b.WriteString(syllable)
+	return 0
+}
+
+// isDevanagariLetter reports whether r continues a Devanagari word
+func isDevanagariLetter(r rune) bool {
+	return r >= 0x0900 && r <= 0x097F && !(r >= '०' && r <= '९') && r != '।' && r != '॥'
+}
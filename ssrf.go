@@ -0,0 +1,187 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// blockedPrefixes are ranges IsPrivate and friends do not cover but which still reach
+// infrastructure rather than the public internet
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "This" network
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT, used for some cloud metadata services
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which can embed any IPv4 address
+}
+
+// BlockedAddressError is returned when a fetch would connect to a private, loopback or
+// link-local address and the host is not allowlisted
+type BlockedAddressError struct {
+	Host string // Host being dialled, as it appeared in the URL
+	IP   string // Address it resolved to
+}
+
+func (e *BlockedAddressError) Error() string {
+	return fmt.Sprintf("refusing to connect to %s (%s): private, loopback and link-local addresses are blocked", e.Host, e.IP)
+}
+
+// isBlockedAddr reports whether addr is outside the public internet
+func isBlockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostAllowlist holds the hosts and networks exempt from private-address blocking
+type hostAllowlist struct {
+	hosts    map[string]bool // Exact hostnames
+	suffixes []string        // ".example.internal" for "*.example.internal" entries
+	prefixes []netip.Prefix  // IPs and CIDR ranges
+}
+
+// parseHostAllowlist parses entries that are hostnames, "*.domain" wildcards, IPs or CIDRs
+// Invalid entries are reported in the error and left out of the returned allowlist.
+func parseHostAllowlist(entries []string) (*hostAllowlist, error) {
+	a := &hostAllowlist{hosts: make(map[string]bool)}
+	var errs []error
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case strings.Contains(entry, "/"):
+			p, err := netip.ParsePrefix(entry)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid allowed network %q: %w", entry, err))
+				continue
+			}
+			a.prefixes = append(a.prefixes, p.Masked())
+		case strings.HasPrefix(entry, "*."):
+			a.suffixes = append(a.suffixes, entry[1:])
+		default:
+			if addr, err := netip.ParseAddr(strings.Trim(entry, "[]")); err == nil {
+				a.prefixes = append(a.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+				continue
+			}
+			a.hosts[strings.TrimSuffix(entry, ".")] = true
+		}
+	}
+	return a, errors.Join(errs...)
+}
+
+// allowsHost reports whether a hostname is allowlisted
+func (a *hostAllowlist) allowsHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if a.hosts[host] {
+		return true
+	}
+	for _, suffix := range a.suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsAddr reports whether an address falls in an allowlisted network
+func (a *hostAllowlist) allowsAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range a.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// withHost returns a copy of the allowlist that also allows host
+func (a *hostAllowlist) withHost(host string) *hostAllowlist {
+	c := &hostAllowlist{hosts: make(map[string]bool, len(a.hosts)+1), suffixes: a.suffixes, prefixes: a.prefixes}
+	for h := range a.hosts {
+		c.hosts[h] = true
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		c.prefixes = append(append([]netip.Prefix(nil), a.prefixes...), netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	} else {
+		c.hosts[strings.TrimSuffix(strings.ToLower(host), ".")] = true
+	}
+	return c
+}
+
+// proxiedTargetGuard checks request targets before they are sent through a proxy
+// The proxy resolves and dials targets itself, out of reach of guardedDialContext, so
+// the target is resolved here and refused if any address is blocked. This cannot see
+// the proxy's own resolution, so it is weaker against DNS rebinding than the dialer guard.
+type proxiedTargetGuard struct {
+	base  http.RoundTripper
+	allow *hostAllowlist
+}
+
+func (g *proxiedTargetGuard) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	if !g.allow.allowsHost(host) {
+		addrs, err := lookupAddrs(req.Context(), host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			if isBlockedAddr(addr) && !g.allow.allowsAddr(addr) {
+				return nil, &BlockedAddressError{Host: host, IP: addr.Unmap().String()}
+			}
+		}
+	}
+	return g.base.RoundTrip(req)
+}
+
+// lookupAddrs resolves a host, returning IP literals as they are
+func lookupAddrs(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{addr}, nil
+	}
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+// guardedDialContext returns a dial function that refuses private, loopback and
+// link-local addresses unless the host or address is allowlisted
+//
+// The check runs on the address actually being connected to, after DNS resolution, so it
+// also covers redirects and hostnames that re-resolve to internal addresses between
+// lookups (DNS rebinding).
+func guardedDialContext(allow *hostAllowlist) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if !allow.allowsHost(host) {
+			dialer.Control = func(network, address string, _ syscall.RawConn) error {
+				ipPort, err := netip.ParseAddrPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := ipPort.Addr(); isBlockedAddr(ip) && !allow.allowsAddr(ip) {
+					return &BlockedAddressError{Host: host, IP: ip.Unmap().String()}
+				}
+				return nil
+			}
+		}
+		return dialer.DialContext(ctx, network, address)
+	}
+}
@@ -0,0 +1,111 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestIsBlockedAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"0.0.0.0", true},
+		{"100.100.100.200", true},
+		{"::ffff:127.0.0.1", true},
+		{"93.184.216.34", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", false},
+	}
+	for _, tt := range tests {
+		if got := isBlockedAddr(netip.MustParseAddr(tt.addr)); got != tt.blocked {
+			t.Errorf("isBlockedAddr(%s) = %v, want %v", tt.addr, got, tt.blocked)
+		}
+	}
+}
+
+func TestHostAllowlist(t *testing.T) {
+	allow, err := parseHostAllowlist([]string{"wiki.corp", "*.internal.example", "10.0.0.0/8", "192.168.1.5", "not/a/cidr"})
+	if err == nil {
+		t.Error("expected an error for the invalid CIDR")
+	}
+	for _, host := range []string{"wiki.corp", "WIKI.CORP.", "docs.internal.example"} {
+		if !allow.allowsHost(host) {
+			t.Errorf("allowsHost(%q) = false, want true", host)
+		}
+	}
+	for _, host := range []string{"corp", "internal.example", "evilwiki.corp"} {
+		if allow.allowsHost(host) {
+			t.Errorf("allowsHost(%q) = true, want false", host)
+		}
+	}
+	for _, addr := range []string{"10.9.8.7", "192.168.1.5", "::ffff:10.0.0.1"} {
+		if !allow.allowsAddr(netip.MustParseAddr(addr)) {
+			t.Errorf("allowsAddr(%s) = false, want true", addr)
+		}
+	}
+	if allow.allowsAddr(netip.MustParseAddr("192.168.1.6")) {
+		t.Error("allowsAddr(192.168.1.6) = true, want false")
+	}
+}
+
+func TestBlockPrivateNetworks(t *testing.T) {
+	var port string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			// Bounce an allowlisted hostname to the loopback address itself
+			http.Redirect(w, r, "http://127.0.0.1:"+port+"/page", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><title>Internal</title></head><body>secret</body></html>"))
+	}))
+	defer server.Close()
+	port = mustPort(t, server.URL)
+
+	fetch := func(allowed []string, target string) error {
+		config := DefaultConfig()
+		config.BlockPrivateNetworks = true
+		config.AllowedHosts = allowed
+		_, err := New(config, nil, nil).fetchPage(context.Background(), target)
+		return err
+	}
+
+	var blocked *BlockedAddressError
+	if err := fetch(nil, server.URL); !errors.As(err, &blocked) {
+		t.Errorf("loopback fetch error = %v, want BlockedAddressError", err)
+	} else if blocked.IP != "127.0.0.1" {
+		t.Errorf("blocked IP = %q, want 127.0.0.1", blocked.IP)
+	}
+
+	if err := fetch([]string{"127.0.0.0/8"}, server.URL); err != nil {
+		t.Errorf("allowlisted network fetch failed: %v", err)
+	}
+	if err := fetch([]string{"localhost"}, "http://localhost:"+port+"/page"); err != nil {
+		t.Errorf("allowlisted host fetch failed: %v", err)
+	}
+	if err := fetch([]string{"localhost"}, "http://localhost:"+port+"/redirect"); !errors.As(err, &blocked) {
+		t.Errorf("redirect to a blocked address error = %v, want BlockedAddressError", err)
+	}
+}
+
+func mustPort(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("invalid URL %q: %v", rawURL, err)
+	}
+	return u.Port()
+}
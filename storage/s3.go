@@ -107,6 +107,42 @@ func (s *S3Storage) SaveImage(imageData []byte, slug, contentType string) (strin
 	return key, nil
 }
 
+// SaveImageDerivative saves a resized or re-encoded copy of an image next to the original
+// Returns the S3 key, e.g. images/2024/03/slug_w320.webp for images/2024/03/slug.jpg.
+func (s *S3Storage) SaveImageDerivative(imageData []byte, originalKey, variant, contentType string) (string, error) {
+	key := DerivativeKey(originalKey, variant, contentType)
+
+	ctx := context.Background()
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(imageData),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image derivative to S3: %w", err)
+	}
+
+	return key, nil
+}
+
+// ReplaceImage overwrites the image stored at key, e.g. with a copy whose metadata has
+// been removed
+func (s *S3Storage) ReplaceImage(key string, imageData []byte, contentType string) error {
+	ctx := context.Background()
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(imageData),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace image in S3: %w", err)
+	}
+
+	return nil
+}
+
 // SaveContent saves scraped HTML content to S3
 // Returns the S3 key (path within bucket)
 func (s *S3Storage) SaveContent(content, slug string) (string, error) {
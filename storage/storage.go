@@ -2,12 +2,15 @@ package storage
 
 import (
 	"context"
+	"path"
 	"strings"
 )
 
 // StorageInterface defines the interface that all storage backends must implement
 type StorageInterface interface {
 	SaveImage(imageData []byte, slug, contentType string) (string, error)
+	SaveImageDerivative(imageData []byte, originalKey, variant, contentType string) (string, error)
+	ReplaceImage(key string, imageData []byte, contentType string) error
 	SaveContent(content, slug string) (string, error)
 	ReadImage(relPath string) ([]byte, error)
 	ReadContent(relPath string) (string, error)
@@ -21,6 +24,16 @@ func NewStorage(ctx context.Context, config S3Config) (StorageInterface, error)
 	return NewS3Storage(ctx, config)
 }
 
+// DerivativeKey returns the key of an image derivative: the original's key with the
+// variant appended to its name and the extension of contentType
+func DerivativeKey(originalKey, variant, contentType string) string {
+	ext := extensionFromContentType(contentType)
+	if ext == "" {
+		ext = ".jpg"
+	}
+	return strings.TrimSuffix(originalKey, path.Ext(originalKey)) + "_" + variant + ext
+}
+
 // extensionFromContentType returns the file extension for a content type
 func extensionFromContentType(contentType string) string {
 	// Normalize content type (remove charset, etc.)
@@ -110,3 +110,23 @@ func TestExtensionFromContentType(t *testing.T) {
 		})
 	}
 }
+
+// TestDerivativeKey tests that derivatives are stored next to the original
+func TestDerivativeKey(t *testing.T) {
+	tests := []struct {
+		originalKey string
+		variant     string
+		contentType string
+		want        string
+	}{
+		{"images/2024/03/cat.jpg", "w320", "image/webp", "images/2024/03/cat_w320.webp"},
+		{"images/2024/03/cat.png", "w640", "image/jpeg", "images/2024/03/cat_w640.jpg"},
+		{"images/2024/03/cat", "full", "image/webp", "images/2024/03/cat_full.webp"},
+	}
+
+	for _, tt := range tests {
+		if got := DerivativeKey(tt.originalKey, tt.variant, tt.contentType); got != tt.want {
+			t.Errorf("DerivativeKey(%q, %q, %q) = %q, want %q", tt.originalKey, tt.variant, tt.contentType, got, tt.want)
+		}
+	}
+}